@@ -0,0 +1,119 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+	"github.com/shopd/shopd/go/config"
+	"github.com/shopd/shopd/go/services"
+	"github.com/spf13/cobra"
+)
+
+// catalogCmd represents the catalog command
+var catalogCmd = &cobra.Command{
+	Use:   "catalog",
+	Short: "Import and export the catalog CSV",
+	Long:  ``,
+}
+
+// catalogImportCmd represents the catalog import command
+var catalogImportCmd = &cobra.Command{
+	Use:   "import FILE",
+	Short: "Creates or updates catalog items by sku from the CSV file",
+	Long: `Rows are validated first, nothing is imported if any row has errors.
+Use --dry-run to list errors and changes without importing`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		conf := cmd.Context().Value(config.Config{}).(*config.Config)
+
+		dryRun, err := cmd.Flags().GetBool("dry-run")
+		if err != nil {
+			log.Error().Stack().Err(errors.WithStack(err)).Msg("")
+			os.Exit(1)
+		}
+		file, err := os.Open(args[0])
+		if err != nil {
+			log.Error().Stack().Err(errors.WithStack(err)).Msg("")
+			os.Exit(1)
+		}
+		defer file.Close()
+
+		s, err := services.NewServices(conf)
+		if err != nil {
+			log.Error().Stack().Err(err).Msg("")
+			os.Exit(1)
+		}
+		defer s.Cleanup()
+
+		result, err := s.CatalogImport(cmd.Context(), file, dryRun, "")
+		if err != nil {
+			log.Error().Stack().Err(err).Msg("")
+			s.Cleanup()
+			os.Exit(1)
+		}
+		for _, rowErr := range result.Errors {
+			fmt.Printf("line %d %s %s: %s\n",
+				rowErr.Line, rowErr.Sku, rowErr.Col, rowErr.Error)
+		}
+		if len(result.Errors) > 0 {
+			fmt.Printf("%d errors in %d rows, nothing was imported\n",
+				len(result.Errors), result.Rows)
+			s.Cleanup()
+			os.Exit(1)
+		}
+		if dryRun {
+			fmt.Printf("dry run, %d to create, %d to update\n",
+				result.Created, result.Updated)
+			return
+		}
+		fmt.Printf("%d created, %d updated\n", result.Created, result.Updated)
+	},
+}
+
+// catalogExportCmd represents the catalog export command
+var catalogExportCmd = &cobra.Command{
+	Use:   "export [FILE]",
+	Short: "Writes the catalog CSV to the file, or stdout",
+	Long:  ``,
+	Args:  cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		conf := cmd.Context().Value(config.Config{}).(*config.Config)
+
+		s, err := services.NewServices(conf)
+		if err != nil {
+			log.Error().Stack().Err(err).Msg("")
+			os.Exit(1)
+		}
+		defer s.Cleanup()
+
+		var w io.Writer = os.Stdout
+		if len(args) > 0 {
+			file, err := os.Create(args[0])
+			if err != nil {
+				log.Error().Stack().Err(errors.WithStack(err)).Msg("")
+				s.Cleanup()
+				os.Exit(1)
+			}
+			defer file.Close()
+			w = file
+		}
+
+		err = s.Model.CatalogExport(cmd.Context(), w)
+		if err != nil {
+			log.Error().Stack().Err(err).Msg("")
+			s.Cleanup()
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(catalogCmd)
+	catalogCmd.AddCommand(catalogImportCmd)
+	catalogCmd.AddCommand(catalogExportCmd)
+
+	catalogImportCmd.Flags().Bool("dry-run", false, "Validate without importing")
+}
@@ -11,6 +11,7 @@ import (
 	"github.com/rs/zerolog/log"
 	"github.com/shopd/shopd/go/config"
 	"github.com/shopd/shopd/go/router"
+	"github.com/shopd/shopd/go/services"
 	"github.com/spf13/cobra"
 )
 
@@ -37,11 +38,17 @@ type NewServerParams struct {
 func NewServer(conf *config.Config, params NewServerParams) (
 	rh *RunHandler, err error) {
 
-	// TODO Services
-	// s := services.NewServices(conf)
+	rh = NewRunHandler()
+	s, err := services.NewServices(conf)
+	if err != nil {
+		return rh, err
+	}
 
 	if params.Stubs {
 		log.Info().Msg("stubs")
+		s.UseFakeProcessor()
+		s.UseFileMailer(conf.Dir())
+		s.UseFileSite(conf.Dir())
 		// TODO Refactor how stubs work
 		// - ApiServer considers using stubs if this mode is set
 		// - use stubs if route is not found
@@ -51,16 +58,14 @@ func NewServer(conf *config.Config, params NewServerParams) (
 	}
 
 	// Setup HTTP server
-	r := router.NewRouter(conf)
-	rh = NewRunHandler()
+	r := router.NewRouter(conf, s)
 	rh.Server = http.Server{}
 	rh.Handler = r.Handler()
 	rh.Addr = conf.PortApi()
-	// rh.cleanup = s.Cleanup
-	rh.cleanup = func() error {
-		log.Info().Msg("TODO Cleanup services")
-		return nil
-	}
+	rh.cleanup = s.Cleanup
+
+	// Background jobs
+	s.StartJobs()
 
 	return rh, nil
 }
@@ -3,22 +3,25 @@ module github.com/shopd/shopd
 go 1.23.2
 
 require (
+	github.com/Rhymond/go-money v1.0.14
 	github.com/a-h/templ v0.2.793
 	github.com/gin-gonic/gin v1.10.0
 	github.com/magefile/mage v1.15.0
 	github.com/matryer/is v1.4.1
+	github.com/mattn/go-sqlite3 v1.14.24
 	github.com/mozey/config v0.16.0
 	github.com/mozey/errors v0.1.0
 	github.com/mozey/ft v1.1.2
 	github.com/mozey/logutil v0.2.0
 	github.com/pkg/errors v0.9.1
 	github.com/rs/zerolog v1.33.0
+	github.com/segmentio/ksuid v1.0.4
 	github.com/shopd/shopd-proto v0.0.0-20241112054746-9a0251f4b6d7
 	github.com/spf13/cobra v1.8.1
+	golang.org/x/image v0.22.0
 )
 
 require (
-	github.com/Rhymond/go-money v1.0.14 // indirect
 	github.com/bytedance/sonic v1.11.6 // indirect
 	github.com/bytedance/sonic/loader v0.1.1 // indirect
 	github.com/cloudwego/base64x v0.1.4 // indirect
@@ -41,7 +44,6 @@ require (
 	github.com/modern-go/reflect2 v1.0.2 // indirect
 	github.com/pelletier/go-toml/v2 v2.2.2 // indirect
 	github.com/rogpeppe/go-internal v1.12.0 // indirect
-	github.com/segmentio/ksuid v1.0.4 // indirect
 	github.com/spf13/pflag v1.0.5 // indirect
 	github.com/twitchyliquid64/golang-asm v0.15.1 // indirect
 	github.com/ugorji/go/codec v1.2.12 // indirect
@@ -0,0 +1,43 @@
+-- AccountInsert creates a new account
+-- name: AccountInsert :exec
+insert into account (account_id, descr)
+values (?, ?);
+
+-- AccountByID fetches a single row
+-- name: AccountByID :one
+select account_id, descr from account
+where account_id = ? limit 1;
+
+-- AccountList lists all accounts
+-- name: AccountList :many
+select account_id, descr from account
+order by descr, account_id;
+
+-- AccountUserInsert links a user to an account
+-- name: AccountUserInsert :exec
+insert into account_x_user (account_id, user_id)
+values (?, ?);
+
+-- AccountUserDelete unlinks a user from an account
+-- name: AccountUserDelete :exec
+delete from account_x_user
+where account_id = ? and user_id = ?;
+
+-- AccountUsers lists users linked to an account
+-- name: AccountUsers :many
+select user_id from account_x_user
+where account_id = ?
+order by user_id;
+
+-- AccountTrans lists successful transactions for an account in order,
+-- with the type and linked order
+-- name: AccountTrans :many
+select tran.tran_id, tran.descr, tran.amount, tran.user_id, tran.mod,
+cast(ifnull(tran_config.val, '') as text) as type,
+cast(ifnull(order_tran.order_id, '') as text) as order_id
+from tran
+left join tran_config on tran_config.tran_id = tran.tran_id
+and tran_config.term = 'type'
+left join order_tran on order_tran.tran_id = tran.tran_id
+where tran.account_id = ? and tran.state = 'success'
+order by tran.mod;
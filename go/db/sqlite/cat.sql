@@ -0,0 +1,212 @@
+-- CatQtyBySKU lists available qty per depot
+-- name: CatQtyBySKU :many
+select sku, depot, qty from cat_qty
+where sku = ?
+order by depot;
+
+-- CatQtyAdd adds qty to a depot, use a negative value to subtract.
+-- Fails if qty would be less than zero
+-- name: CatQtyAdd :exec
+update cat_qty set qty = qty + ?
+where sku = ? and depot = ?;
+
+-- CatBySKU fetches a single row
+-- name: CatBySKU :one
+select sku, title, descr, state, mod, mod_id from cat
+where sku = ? limit 1;
+
+-- CatPriceBySKU fetches the exclusive price
+-- name: CatPriceBySKU :one
+select sku, price from cat_price
+where sku = ? limit 1;
+
+-- CatTagsByOrderID lists catalog tags for skus on the order
+-- name: CatTagsByOrderID :many
+select sku, tag from cat_tag
+where sku in (select sku from order_line where order_id = ?)
+order by sku, tag;
+
+-- CatPriceTiersBySKU lists quantity break prices, ordered by qty
+-- name: CatPriceTiersBySKU :many
+select sku, qty, price from cat_price_tier
+where sku = ?
+order by qty;
+
+-- CatPriceTierDelete removes all quantity break prices for a sku
+-- name: CatPriceTierDelete :exec
+delete from cat_price_tier
+where sku = ?;
+
+-- CatPriceTierInsert adds a quantity break price
+-- name: CatPriceTierInsert :exec
+insert into cat_price_tier (sku, qty, price)
+values (?, ?, ?);
+
+-- CatTagsBySKU lists catalog tags for a sku
+-- name: CatTagsBySKU :many
+select sku, tag from cat_tag
+where sku = ?
+order by tag;
+
+-- CatPriceUpsert sets the exclusive price
+-- name: CatPriceUpsert :exec
+insert into cat_price (sku, price)
+values (?, ?)
+on conflict (sku) do update set price = excluded.price;
+
+-- CatPriceHistoryInsert records a change to cat_price
+-- name: CatPriceHistoryInsert :exec
+insert into cat_price_history (
+sku, price, changed, mod, mod_id, cat_price_schedule_id)
+values (?, ?, ?, ?, ?, ?);
+
+-- CatPriceHistoryBySKU lists price changes, newest first
+-- name: CatPriceHistoryBySKU :many
+select sku, price, changed, mod, mod_id, cat_price_schedule_id
+from cat_price_history
+where sku = ?
+order by changed desc, mod desc;
+
+-- CatPriceScheduleInsert schedules a price change
+-- name: CatPriceScheduleInsert :exec
+insert into cat_price_schedule (
+cat_price_schedule_id, sku, price, start, end, state, mod, mod_id)
+values (?, ?, ?, ?, ?, ?, ?, ?);
+
+-- CatPriceScheduleByID fetches a single row
+-- name: CatPriceScheduleByID :one
+select cat_price_schedule_id, sku, price, start, end, prev, state, mod, mod_id
+from cat_price_schedule
+where cat_price_schedule_id = ? limit 1;
+
+-- CatPriceSchedulesBySKU lists scheduled changes for a sku, ordered by start
+-- name: CatPriceSchedulesBySKU :many
+select cat_price_schedule_id, sku, price, start, end, prev, state, mod, mod_id
+from cat_price_schedule
+where sku = ?
+order by start;
+
+-- CatPriceSchedulesStart lists pending changes that started before the date time
+-- name: CatPriceSchedulesStart :many
+select cat_price_schedule_id, sku, price, start, end, prev, state, mod, mod_id
+from cat_price_schedule
+where state = 'pending' and start <= ?
+order by start;
+
+-- CatPriceSchedulesEnd lists active changes that ended before the date time
+-- name: CatPriceSchedulesEnd :many
+select cat_price_schedule_id, sku, price, start, end, prev, state, mod, mod_id
+from cat_price_schedule
+where state = 'active' and end <> '' and end <= ?
+order by end;
+
+-- CatPriceScheduleUpdate sets the state and prev price
+-- name: CatPriceScheduleUpdate :exec
+update cat_price_schedule set state = ?, prev = ?, mod = ?, mod_id = ?
+where cat_price_schedule_id = ?;
+
+-- CatList lists catalog items by sku
+-- name: CatList :many
+select sku, title, descr, state, mod, mod_id from cat
+order by sku;
+
+-- CatUpsert creates or updates a catalog item
+-- name: CatUpsert :exec
+insert into cat (sku, title, descr, state, mod, mod_id)
+values (?, ?, ?, ?, ?, ?)
+on conflict (sku) do update set
+title = excluded.title, descr = excluded.descr, state = excluded.state,
+mod = excluded.mod, mod_id = excluded.mod_id;
+
+-- CatStateList lists valid catalog states
+-- name: CatStateList :many
+select state, custom from cat_state
+order by state;
+
+-- CatPriceList lists exclusive prices by sku
+-- name: CatPriceList :many
+select sku, price from cat_price
+order by sku;
+
+-- CatQtyList lists available qty by sku and depot
+-- name: CatQtyList :many
+select sku, depot, qty from cat_qty
+order by sku, depot;
+
+-- CatQtyUpsert sets the available qty for a depot
+-- name: CatQtyUpsert :exec
+insert into cat_qty (sku, depot, qty)
+values (?, ?, ?)
+on conflict (sku, depot) do update set qty = excluded.qty;
+
+-- CatTagList lists catalog tags by sku
+-- name: CatTagList :many
+select sku, tag from cat_tag
+order by sku, tag;
+
+-- CatTagDeleteBySKU removes all tags for a sku
+-- name: CatTagDeleteBySKU :exec
+delete from cat_tag where sku = ?;
+
+-- CatTagInsert tags a catalog item
+-- name: CatTagInsert :exec
+insert into cat_tag (sku, tag)
+values (?, ?);
+
+-- CatConfigList lists config for all catalog items
+-- name: CatConfigList :many
+select sku, term, val from cat_config
+order by sku, term;
+
+-- CatConfigBySKU lists config for a sku
+-- name: CatConfigBySKU :many
+select sku, term, val from cat_config
+where sku = ?
+order by term;
+
+-- CatConfigUpsert sets a config value for a sku
+-- name: CatConfigUpsert :exec
+insert into cat_config (sku, term, val)
+values (?, ?, ?)
+on conflict (sku, term) do update set val = excluded.val;
+
+-- CatConfigDelete removes a config value for a sku
+-- name: CatConfigDelete :exec
+delete from cat_config where sku = ? and term = ?;
+
+-- VariantList lists variant groups by sku
+-- name: VariantList :many
+select group_id, sku, idx from variant
+order by sku, group_id;
+
+-- VariantsBySKU lists the variant groups for a sku
+-- name: VariantsBySKU :many
+select group_id, sku, idx from variant
+where sku = ?
+order by group_id;
+
+-- VariantDeleteBySKU removes a sku from variant groups
+-- name: VariantDeleteBySKU :exec
+delete from variant where sku = ?;
+
+-- VariantInsert adds a sku to a variant group
+-- name: VariantInsert :exec
+insert into variant (group_id, sku, idx)
+values (?, ?, ?);
+
+-- VariantItemsByGroupID lists the variants in a group with the available qty
+-- name: VariantItemsByGroupID :many
+select v.group_id, v.sku, v.idx, c.title, c.state,
+coalesce((select sum(q.qty) from cat_qty q where q.sku = v.sku), 0) as qty
+from variant v
+join cat c on c.sku = v.sku
+where v.group_id = ?
+order by v.idx, v.sku;
+
+-- CatConfigByGroupID lists variant attribute config for the skus in a group
+-- name: CatConfigByGroupID :many
+select cc.sku, cc.term, cc.val
+from cat_config cc
+join variant v on v.sku = cc.sku
+where v.group_id = ? and cc.term glob 'variant_*'
+order by cc.sku, cc.term;
@@ -0,0 +1,17 @@
+-- ConfigByTerm fetches a global setting
+-- name: ConfigByTerm :one
+select term, val, mod from config
+where term = ? limit 1;
+
+-- FieldsByTaxonomy lists data capture fields for a taxonomy, e.g. address format
+-- name: FieldsByTaxonomy :many
+select field.term, field.idx
+from taxonomy_x_term join field on field.term = taxonomy_x_term.term
+where taxonomy_x_term.taxonomy = ?
+order by field.idx, field.term;
+
+-- ConfigUpsert sets a global setting
+-- name: ConfigUpsert :exec
+insert into config (term, val, mod)
+values (?, ?, ?)
+on conflict (term) do update set val = excluded.val, mod = excluded.mod;
@@ -0,0 +1,66 @@
+-- CouponByCode fetches a single row
+-- name: CouponByCode :one
+select code, discount_id, max_uses, max_user_uses, mod, mod_id from coupon
+where code = ? limit 1;
+
+-- CouponInsert creates a coupon code
+-- name: CouponInsert :exec
+insert into coupon (code, discount_id, max_uses, max_user_uses, mod, mod_id)
+values (?, ?, ?, ?, ?, ?);
+
+-- CouponUses counts orders that used the code, excluding the given order
+-- name: CouponUses :one
+select count(*) from coupon_use
+join orders on orders.order_id = coupon_use.order_id
+where coupon_use.code = ? and coupon_use.order_id <> ?
+and orders.state not in ('reversed', 'expired');
+
+-- CouponUserUses counts orders by the user that used the code, excluding the given order
+-- name: CouponUserUses :one
+select count(*) from coupon_use
+join orders on orders.order_id = coupon_use.order_id
+where coupon_use.code = ? and coupon_use.order_id <> ? and coupon_use.user_id = ?
+and orders.state not in ('reversed', 'expired');
+
+-- CouponUseInsert records the order that used the code
+-- name: CouponUseInsert :exec
+insert into coupon_use (code, order_id, user_id)
+values (?, ?, ?)
+on conflict (code, order_id) do nothing;
+
+-- CouponUseDelete removes coupon uses for an order
+-- name: CouponUseDelete :exec
+delete from coupon_use
+where order_id = ?;
+
+-- VoucherByCode fetches a single row
+-- name: VoucherByCode :one
+select code, amount, balance, mod, mod_id from voucher
+where code = ? limit 1;
+
+-- VoucherInsert creates a voucher with the balance set to amount
+-- name: VoucherInsert :exec
+insert into voucher (code, amount, balance, mod, mod_id)
+values (?, ?, ?, ?, ?);
+
+-- VoucherAdd adds to the balance, use a negative value to subtract.
+-- Fails if the balance would be less than zero
+-- name: VoucherAdd :exec
+update voucher set balance = balance + ?, mod = ?, mod_id = ?
+where code = ?;
+
+-- VoucherRedeemByOrderID lists amounts drawn from vouchers by an order
+-- name: VoucherRedeemByOrderID :many
+select code, order_id, amount, mod from voucher_redeem
+where order_id = ?
+order by mod;
+
+-- VoucherRedeemInsert records the amount drawn from a voucher
+-- name: VoucherRedeemInsert :exec
+insert into voucher_redeem (code, order_id, amount, mod)
+values (?, ?, ?, ?);
+
+-- VoucherRedeemDelete removes the redemptions for an order
+-- name: VoucherRedeemDelete :exec
+delete from voucher_redeem
+where order_id = ?;
@@ -0,0 +1,57 @@
+-- CreditNoteInsert creates a new credit note
+-- name: CreditNoteInsert :exec
+insert into credit_note (credit_note_id, order_id, credit_no, reason, amount, tax, refund, mod, mod_id)
+values (?, ?, ?, ?, ?, ?, ?, ?, ?);
+
+-- CreditNoteByID fetches a single row
+-- name: CreditNoteByID :one
+select credit_note_id, order_id, credit_no, reason, amount, tax, refund, mod, mod_id
+from credit_note where credit_note_id = ? limit 1;
+
+-- CreditNotesByOrderID lists credit notes for an order in order of creation
+-- name: CreditNotesByOrderID :many
+select credit_note_id, order_id, credit_no, reason, amount, tax, refund, mod, mod_id
+from credit_note where order_id = ?
+order by mod, credit_no;
+
+-- CreditNoteLineInsert appends a line to a credit note
+-- name: CreditNoteLineInsert :exec
+insert into credit_note_line (credit_note_id, order_line_id, sku, qty, amount, tax, restock)
+values (?, ?, ?, ?, ?, ?, ?);
+
+-- CreditNoteLinesByID lists the lines of a credit note
+-- name: CreditNoteLinesByID :many
+select credit_note_id, order_line_id, sku, qty, amount, tax, restock
+from credit_note_line where credit_note_id = ?
+order by order_line_id;
+
+-- CreditNoteLinesByOrderID lists credit note lines for all credit notes of an order
+-- name: CreditNoteLinesByOrderID :many
+select credit_note_line.credit_note_id, credit_note_line.order_line_id,
+credit_note_line.sku, credit_note_line.qty, credit_note_line.amount,
+credit_note_line.tax, credit_note_line.restock
+from credit_note_line
+join credit_note on credit_note.credit_note_id = credit_note_line.credit_note_id
+where credit_note.order_id = ?;
+
+-- TranIDsByConfig lists transactions with the config value, e.g. refunds for a credit note
+-- name: TranIDsByConfig :many
+select tran_id from tran_config
+where term = ? and val = ?
+order by tran_id;
+
+-- TranIDsByOrderConfig lists transactions for an order with the config value, e.g. refunds
+-- name: TranIDsByOrderConfig :many
+select tran_config.tran_id from tran_config
+join order_tran on order_tran.tran_id = tran_config.tran_id
+where order_tran.order_id = ? and tran_config.term = ? and tran_config.val = ?
+order by tran_config.tran_id;
+
+-- CreditNotesByMod lists credit notes created in the range, oldest first.
+-- Empty params are ignored
+-- name: CreditNotesByMod :many
+select credit_note_id, order_id, credit_no, reason, amount, tax, refund, mod, mod_id
+from credit_note
+where (sqlc.arg(mod_from) = '' or mod >= sqlc.arg(mod_from))
+and (sqlc.arg(mod_to) = '' or mod < sqlc.arg(mod_to))
+order by mod, credit_no;
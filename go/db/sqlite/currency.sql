@@ -0,0 +1,21 @@
+-- ExchangeRates lists rates ordered by currency
+-- name: ExchangeRates :many
+select currency, rate, mod, mod_id from exchange_rate
+order by currency;
+
+-- ExchangeRateByCurrency fetches a single row
+-- name: ExchangeRateByCurrency :one
+select currency, rate, mod, mod_id from exchange_rate
+where currency = ? limit 1;
+
+-- ExchangeRateUpsert sets the rate for a currency
+-- name: ExchangeRateUpsert :exec
+insert into exchange_rate (currency, rate, mod, mod_id)
+values (?, ?, ?, ?)
+on conflict (currency) do update set
+rate = excluded.rate, mod = excluded.mod, mod_id = excluded.mod_id;
+
+-- ExchangeRateDelete removes the rate for a currency
+-- name: ExchangeRateDelete :exec
+delete from exchange_rate
+where currency = ?;
@@ -0,0 +1,4 @@
+-- DepotList lists depots in order of preference
+-- name: DepotList :many
+select depot, descr, province, idx, mod, mod_id from depot
+order by idx, depot;
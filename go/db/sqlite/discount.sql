@@ -0,0 +1,43 @@
+-- Discounts lists discounts that are applied automatically,
+-- i.e. excluding discount_opt and coupons
+-- name: Discounts :many
+select discount_id, pct, discount, descr, mod, mod_id from discount
+where discount_id not in (select discount_id from discount_opt)
+and discount_id not in (select discount_id from coupon)
+order by discount_id;
+
+-- DiscountOpts lists discounts that may optionally be applied by admin users
+-- name: DiscountOpts :many
+select discount_id, pct, discount, descr, mod, mod_id from discount
+where discount_id in (select discount_id from discount_opt)
+order by discount_id;
+
+-- DiscountByID fetches a single row
+-- name: DiscountByID :one
+select discount_id, pct, discount, descr, mod, mod_id from discount
+where discount_id = ? limit 1;
+
+-- DiscountCountries lists country restrictions for all discounts
+-- name: DiscountCountries :many
+select discount_id, country from discount_country
+order by discount_id, country;
+
+-- DiscountTags lists tag restrictions for all discounts
+-- name: DiscountTags :many
+select discount_id, tag from discount_tag
+order by discount_id, tag;
+
+-- DiscountSkus lists sku restrictions for all discounts
+-- name: DiscountSkus :many
+select discount_id, sku from discount_sku
+order by discount_id, sku;
+
+-- DiscountUsers lists user restrictions for all discounts
+-- name: DiscountUsers :many
+select discount_id, user_id from discount_user
+order by discount_id, user_id;
+
+-- DiscountRanges lists date time ranges for all discounts
+-- name: DiscountRanges :many
+select discount_id, start, end from discount_range
+order by discount_id, start;
@@ -0,0 +1,35 @@
+-- ImgUpsert inserts an image by hash, the alt is updated if set
+-- name: ImgUpsert :exec
+insert into img (hash, ext, alt, mod)
+values (?, ?, ?, ?)
+on conflict (hash) do update set alt = excluded.alt, mod = excluded.mod
+where excluded.alt <> '';
+
+-- ImgByHash fetches a single row
+-- name: ImgByHash :one
+select hash, ext, alt, mod
+from img where hash = ? limit 1;
+
+-- CatImgsBySKU lists images for the sku, the first is the default image
+-- name: CatImgsBySKU :many
+select ci.sku, ci.hash, ci.descr, ci.idx, i.ext, i.alt
+from cat_img ci
+join img i on i.hash = ci.hash
+where ci.sku = ?
+order by ci.idx, i.mod, ci.hash;
+
+-- CatImgUpsert links an image to the sku, the idx is kept for linked images
+-- name: CatImgUpsert :exec
+insert into cat_img (sku, hash, descr, idx)
+values (?, ?, ?, ?)
+on conflict (sku, hash) do update set descr = excluded.descr;
+
+-- CatImgIdxUpdate sets the sort order of an image for the sku
+-- name: CatImgIdxUpdate :exec
+update cat_img set idx = ?
+where sku = ? and hash = ?;
+
+-- CatImgDelete unlinks an image from the sku
+-- name: CatImgDelete :exec
+delete from cat_img
+where sku = ? and hash = ?;
@@ -0,0 +1,32 @@
+-- JournalExportInsert creates a new export
+-- name: JournalExportInsert :exec
+insert into journal_export (export_id, period_from, period_to, mod, mod_id)
+values (?, ?, ?, ?, ?);
+
+-- JournalExportByID fetches a single row
+-- name: JournalExportByID :one
+select export_id, period_from, period_to, mod, mod_id
+from journal_export where export_id = ? limit 1;
+
+-- JournalExportList lists exports, most recent first
+-- name: JournalExportList :many
+select export_id, period_from, period_to, mod, mod_id
+from journal_export
+order by mod desc
+limit ?;
+
+-- JournalEntryInsert records a document as exported
+-- name: JournalEntryInsert :exec
+insert into journal_entry (source, source_id, export_id)
+values (?, ?, ?);
+
+-- JournalEntryBySource fetches the export for a document
+-- name: JournalEntryBySource :one
+select source, source_id, export_id
+from journal_entry where source = ? and source_id = ? limit 1;
+
+-- JournalEntriesByExportID lists the documents for an export
+-- name: JournalEntriesByExportID :many
+select source, source_id, export_id
+from journal_entry where export_id = ?
+order by source, source_id;
@@ -56,6 +56,33 @@ type CatPrice struct {
 	Price int64  `db:"price"`
 }
 
+type CatPriceHistory struct {
+	SKU                string `db:"sku"`
+	Price              int64  `db:"price"`
+	Changed            string `db:"changed"`
+	Mod                string `db:"mod"`
+	ModID              string `db:"mod_id"`
+	CatPriceScheduleID string `db:"cat_price_schedule_id"`
+}
+
+type CatPriceSchedule struct {
+	CatPriceScheduleID string `db:"cat_price_schedule_id"`
+	SKU                string `db:"sku"`
+	Price              int64  `db:"price"`
+	Start              string `db:"start"`
+	End                string `db:"end"`
+	Prev               int64  `db:"prev"`
+	State              string `db:"state"`
+	Mod                string `db:"mod"`
+	ModID              string `db:"mod_id"`
+}
+
+type CatPriceTier struct {
+	SKU   string `db:"sku"`
+	Qty   int64  `db:"qty"`
+	Price int64  `db:"price"`
+}
+
 type CatQty struct {
 	SKU   string `db:"sku"`
 	Depot string `db:"depot"`
@@ -78,6 +105,52 @@ type Config struct {
 	Mod  string `db:"mod"`
 }
 
+type Coupon struct {
+	Code        string `db:"code"`
+	DiscountID  string `db:"discount_id"`
+	MaxUses     int64  `db:"max_uses"`
+	MaxUserUses int64  `db:"max_user_uses"`
+	Mod         string `db:"mod"`
+	ModID       string `db:"mod_id"`
+}
+
+type CouponUse struct {
+	Code    string `db:"code"`
+	OrderID string `db:"order_id"`
+	UserID  string `db:"user_id"`
+}
+
+type CreditNote struct {
+	CreditNoteID string `db:"credit_note_id"`
+	OrderID      string `db:"order_id"`
+	CreditNo     string `db:"credit_no"`
+	Reason       string `db:"reason"`
+	Amount       int64  `db:"amount"`
+	Tax          int64  `db:"tax"`
+	Refund       int64  `db:"refund"`
+	Mod          string `db:"mod"`
+	ModID        string `db:"mod_id"`
+}
+
+type CreditNoteLine struct {
+	CreditNoteID string `db:"credit_note_id"`
+	OrderLineID  string `db:"order_line_id"`
+	SKU          string `db:"sku"`
+	Qty          int64  `db:"qty"`
+	Amount       int64  `db:"amount"`
+	Tax          int64  `db:"tax"`
+	Restock      int64  `db:"restock"`
+}
+
+type Depot struct {
+	Depot    string `db:"depot"`
+	Descr    string `db:"descr"`
+	Province string `db:"province"`
+	Idx      int64  `db:"idx"`
+	Mod      string `db:"mod"`
+	ModID    string `db:"mod_id"`
+}
+
 type Discount struct {
 	DiscountID string `db:"discount_id"`
 	Pct        int64  `db:"pct"`
@@ -125,6 +198,13 @@ type Eltype struct {
 	Eltype string `db:"eltype"`
 }
 
+type ExchangeRate struct {
+	Currency string `db:"currency"`
+	Rate     int64  `db:"rate"`
+	Mod      string `db:"mod"`
+	ModID    string `db:"mod_id"`
+}
+
 type Field struct {
 	Term   string `db:"term"`
 	Deflt  string `db:"deflt"`
@@ -155,6 +235,20 @@ type Img struct {
 	Mod  string `db:"mod"`
 }
 
+type JournalEntry struct {
+	Source   string `db:"source"`
+	SourceID string `db:"source_id"`
+	ExportID string `db:"export_id"`
+}
+
+type JournalExport struct {
+	ExportID   string `db:"export_id"`
+	PeriodFrom string `db:"period_from"`
+	PeriodTo   string `db:"period_to"`
+	Mod        string `db:"mod"`
+	ModID      string `db:"mod_id"`
+}
+
 type OrderAct struct {
 	OrderID     string `db:"order_id"`
 	OrderLineID string `db:"order_line_id"`
@@ -203,6 +297,8 @@ type OrderTax struct {
 	Fixed       int64   `db:"fixed"`
 	Tax         float64 `db:"tax"`
 	Pct         int64   `db:"pct"`
+	Vat         int64   `db:"vat"`
+	Descr       string  `db:"descr"`
 	Mod         string  `db:"mod"`
 }
 
@@ -222,6 +318,54 @@ type Orders struct {
 	ModID   string `db:"mod_id"`
 }
 
+type PaymentEvent struct {
+	Processor string `db:"processor"`
+	EventID   string `db:"event_id"`
+	Ref       string `db:"ref"`
+	State     string `db:"state"`
+	Amount    int64  `db:"amount"`
+	Body      string `db:"body"`
+	Handled   string `db:"handled"`
+	Error     string `db:"error"`
+	Attempts  int64  `db:"attempts"`
+	Mod       string `db:"mod"`
+	ModID     string `db:"mod_id"`
+}
+
+type PriceList struct {
+	PriceListID string `db:"price_list_id"`
+	Descr       string `db:"descr"`
+	Mod         string `db:"mod"`
+	ModID       string `db:"mod_id"`
+}
+
+type PriceListAccount struct {
+	PriceListID string `db:"price_list_id"`
+	AccountID   string `db:"account_id"`
+}
+
+type PriceListPrice struct {
+	PriceListID string `db:"price_list_id"`
+	SKU         string `db:"sku"`
+	Price       int64  `db:"price"`
+}
+
+type PriceListRange struct {
+	PriceListID string `db:"price_list_id"`
+	Start       string `db:"start"`
+	End         string `db:"end"`
+}
+
+type PriceListTag struct {
+	PriceListID string `db:"price_list_id"`
+	Tag         string `db:"tag"`
+}
+
+type PriceListUser struct {
+	PriceListID string `db:"price_list_id"`
+	UserID      string `db:"user_id"`
+}
+
 type Role struct {
 	Role string `db:"role"`
 }
@@ -347,3 +491,18 @@ type Vat struct {
 	Mod     string `db:"mod"`
 	ModID   string `db:"mod_id"`
 }
+
+type Voucher struct {
+	Code    string `db:"code"`
+	Amount  int64  `db:"amount"`
+	Balance int64  `db:"balance"`
+	Mod     string `db:"mod"`
+	ModID   string `db:"mod_id"`
+}
+
+type VoucherRedeem struct {
+	Code    string `db:"code"`
+	OrderID string `db:"order_id"`
+	Amount  int64  `db:"amount"`
+	Mod     string `db:"mod"`
+}
@@ -0,0 +1,216 @@
+-- OrderByID fetches a single row
+-- name: OrderByID :one
+select order_id, order_no, state, notes, user_id, paid, mod, mod_id from orders
+where order_id = ? limit 1;
+
+-- OrderLinesByOrderID lists lines in order of creation
+-- name: OrderLinesByOrderID :many
+select order_line_id, order_id, state, sku, price, qty from order_line
+where order_id = ?
+order by order_line_id;
+
+-- OrderLineInsert creates a new order line
+-- name: OrderLineInsert :exec
+insert into order_line (order_line_id, order_id, state, sku, price, qty)
+values (?, ?, ?, ?, ?, ?);
+
+-- OrderLineUpdateQty sets the qty for an order line
+-- name: OrderLineUpdateQty :exec
+update order_line set qty = ?
+where order_line_id = ?;
+
+-- OrderLineUpdatePrice sets the price snapshot for an order line
+-- name: OrderLineUpdatePrice :exec
+update order_line set price = ?
+where order_line_id = ?;
+
+-- OrderConfigByOrderID lists config for an order and its lines
+-- name: OrderConfigByOrderID :many
+select order_id, order_line_id, term, val from order_config
+where order_id = ?
+order by order_line_id, term;
+
+-- OrderConfigUpsert sets a config value for an order or order line
+-- name: OrderConfigUpsert :exec
+insert into order_config (order_id, order_line_id, term, val)
+values (?, ?, ?, ?)
+on conflict (order_id, order_line_id, term) do update set val = excluded.val;
+
+-- OrderConfigDelete removes a config value for an order or order line
+-- name: OrderConfigDelete :exec
+delete from order_config
+where order_id = ? and order_line_id = ? and term = ?;
+
+-- OrderConfigDeleteByOrderID removes all config for an order and its lines
+-- name: OrderConfigDeleteByOrderID :exec
+delete from order_config
+where order_id = ?;
+
+-- OrderActUpdateOrderID moves the activity entries to another order
+-- name: OrderActUpdateOrderID :exec
+update order_act set order_id = ?
+where order_id = ?;
+
+-- OrderActInsert appends an order activity entry
+-- name: OrderActInsert :exec
+insert into order_act (order_id, order_line_id, state, msg, user_id, admin, mod)
+values (?, ?, ?, ?, ?, ?, ?);
+
+-- OrderAddrByType fetches the order address of the given type
+-- name: OrderAddrByType :one
+select addr.hash, addr.taxonomy, addr.val
+from order_addr join addr on addr.hash = order_addr.hash
+where order_addr.order_id = ? and order_addr.type = ? limit 1;
+
+-- PickList lists order lines allocated to a depot,
+-- for orders in the given state
+-- name: PickList :many
+select orders.order_id, orders.order_no, order_line.order_line_id,
+order_line.sku, cat.title, order_line.qty
+from order_line
+join orders on orders.order_id = order_line.order_id
+join order_config on order_config.order_line_id = order_line.order_line_id
+join cat on cat.sku = order_line.sku
+where order_config.term = 'depot' and order_config.val = ?
+and orders.state = ?
+order by order_line.sku, orders.order_no;
+
+-- OrderTaxByOrderID lists tax lines for an order
+-- name: OrderTaxByOrderID :many
+select order_id, order_line_id, fixed, tax, pct, vat, descr, mod from order_tax
+where order_id = ?
+order by order_line_id, mod;
+
+-- OrderLinesWithTitle lists order lines with the catalog title,
+-- system skus are listed last
+-- name: OrderLinesWithTitle :many
+select order_line.order_line_id, order_line.state, order_line.sku,
+cast(ifnull(cat.title, '') as text) as title, order_line.price, order_line.qty
+from order_line left join cat on cat.sku = order_line.sku
+where order_line.order_id = ?
+order by ifnull(cat.state, '') = 'system', order_line.order_line_id;
+
+-- OrderUpdateOrderNo sets the order number
+-- name: OrderUpdateOrderNo :exec
+update orders set order_no = ?
+where order_id = ?;
+
+-- OrderInsert creates a new order
+-- name: OrderInsert :exec
+insert into orders (order_id, order_no, state, notes, user_id, paid, mod, mod_id)
+values (?, ?, ?, ?, ?, ?, ?, ?);
+
+-- OrderDelete removes an order, delete related rows first
+-- name: OrderDelete :exec
+delete from orders
+where order_id = ?;
+
+-- OrderTouch updates the mod cols, e.g. when order lines change
+-- name: OrderTouch :exec
+update orders set mod = ?, mod_id = ?
+where order_id = ?;
+
+-- OrderUpdateUserID assigns the order to a user
+-- name: OrderUpdateUserID :exec
+update orders set user_id = ?
+where order_id = ?;
+
+-- OrderByUserIDState fetches the most recently modified order in the given state
+-- name: OrderByUserIDState :one
+select order_id, order_no, state, notes, user_id, paid, mod, mod_id from orders
+where user_id = ? and state = ?
+order by mod desc limit 1;
+
+-- OrderLineDelete removes an order line
+-- name: OrderLineDelete :exec
+delete from order_line
+where order_line_id = ?;
+
+-- OrderLineUpdateOrderID moves an order line to another order
+-- name: OrderLineUpdateOrderID :exec
+update order_line set order_id = ?
+where order_line_id = ?;
+
+-- OrdersIdle lists orders in the given state that were not modified since mod
+-- name: OrdersIdle :many
+select order_id, order_no, state, notes, user_id, paid, mod, mod_id from orders
+where state = ? and mod < ?
+order by mod;
+
+-- OrdersIdleWithoutConfig lists idle orders for users with an email,
+-- excluding orders that have the order config term set
+-- name: OrdersIdleWithoutConfig :many
+select orders.order_id, orders.user_id, user.email from orders
+join user on user.user_id = orders.user_id
+where orders.state = ? and orders.mod < ? and user.email <> ''
+and not exists (
+	select 1 from order_config
+	where order_config.order_id = orders.order_id
+	and order_config.order_line_id = '' and order_config.term = ?
+)
+order by orders.mod;
+
+-- OrderUpdateState sets the order state
+-- name: OrderUpdateState :exec
+update orders set state = ?, mod = ?, mod_id = ?
+where order_id = ?;
+
+-- OrderList filters orders for the admin list, most recent first.
+-- Empty params are ignored, and paid is ignored if negative.
+-- Carts are excluded unless the state param is set
+-- name: OrderList :many
+select orders.order_id, orders.order_no, orders.state, orders.user_id, orders.paid, orders.mod,
+cast(ifnull(user.email, '') as text) as email
+from orders
+left join user on user.user_id = orders.user_id
+where ((sqlc.arg(state) = '' and orders.state not in ('cart', 'expired'))
+	or orders.state = sqlc.arg(state))
+and (sqlc.arg(paid) < 0 or orders.paid = sqlc.arg(paid))
+and (sqlc.arg(tag) = '' or exists (
+	select 1 from order_tag
+	where order_tag.order_id = orders.order_id and order_tag.tag = sqlc.arg(tag)
+))
+and (sqlc.arg(mod_from) = '' or orders.mod >= sqlc.arg(mod_from))
+and (sqlc.arg(mod_to) = '' or orders.mod < sqlc.arg(mod_to))
+and (sqlc.arg(user_id) = '' or orders.user_id = sqlc.arg(user_id))
+and (sqlc.arg(search) = ''
+	or orders.order_no = sqlc.arg(search)
+	or user.email like '%' || sqlc.arg(search) || '%')
+and (sqlc.arg(cursor) = '' or orders.mod < sqlc.arg(cursor))
+order by orders.mod desc
+limit sqlc.arg(limit);
+
+-- OrderTagsByOrderID lists tags for the order, excluding order line tags
+-- name: OrderTagsByOrderID :many
+select tag from order_tag
+where order_id = ? and order_line_id = ''
+order by tag;
+
+-- OrderTaxDelete removes tax lines before the order is priced again
+-- name: OrderTaxDelete :exec
+delete from order_tax
+where order_id = ?;
+
+-- OrderTaxInsert appends a tax line, tax lines can't be edited
+-- name: OrderTaxInsert :exec
+insert into order_tax (order_id, order_line_id, fixed, tax, pct, vat, descr, mod)
+values (?, ?, ?, ?, ?, ?, ?, ?);
+
+-- OrderUpdatePaid sets the paid flag for the order
+-- name: OrderUpdatePaid :exec
+update orders set paid = ?, mod = ?, mod_id = ?
+where order_id = ?;
+
+-- OrderLineUpdateState sets the state for an order line, empty to use the order state
+-- name: OrderLineUpdateState :exec
+update order_line set state = ?
+where order_line_id = ?;
+
+-- OrdersPaidByMod lists paid orders modified in the range, oldest first.
+-- Empty params are ignored
+-- name: OrdersPaidByMod :many
+select order_id, order_no, state, notes, user_id, paid, mod, mod_id from orders
+where paid = 1
+and (sqlc.arg(mod_from) = '' or mod >= sqlc.arg(mod_from))
+and (sqlc.arg(mod_to) = '' or mod < sqlc.arg(mod_to))
+order by mod;
@@ -0,0 +1,82 @@
+-- PriceListPricesBySKU lists price list prices for a sku
+-- name: PriceListPricesBySKU :many
+select price_list_id, sku, price from price_list_price
+where sku = ?
+order by price_list_id;
+
+-- PriceListUsers lists user restrictions for all price lists
+-- name: PriceListUsers :many
+select price_list_id, user_id from price_list_user
+order by price_list_id, user_id;
+
+-- PriceListTags lists user tag restrictions for all price lists
+-- name: PriceListTags :many
+select price_list_id, tag from price_list_tag
+order by price_list_id, tag;
+
+-- PriceListAccounts lists account restrictions for all price lists
+-- name: PriceListAccounts :many
+select price_list_id, account_id from price_list_account
+order by price_list_id, account_id;
+
+-- PriceListRanges lists date time ranges for all price lists
+-- name: PriceListRanges :many
+select price_list_id, start, end from price_list_range
+order by price_list_id, start;
+
+-- PriceListUpsert creates a price list, or updates the descr
+-- name: PriceListUpsert :exec
+insert into price_list (price_list_id, descr, mod, mod_id)
+values (?, ?, ?, ?)
+on conflict (price_list_id) do update set
+descr = excluded.descr, mod = excluded.mod, mod_id = excluded.mod_id;
+
+-- PriceListPriceDelete removes all prices from a price list
+-- name: PriceListPriceDelete :exec
+delete from price_list_price
+where price_list_id = ?;
+
+-- PriceListPriceInsert adds a price to a price list
+-- name: PriceListPriceInsert :exec
+insert into price_list_price (price_list_id, sku, price)
+values (?, ?, ?);
+
+-- PriceListUserDelete removes all user restrictions from a price list
+-- name: PriceListUserDelete :exec
+delete from price_list_user
+where price_list_id = ?;
+
+-- PriceListUserInsert adds a user restriction to a price list
+-- name: PriceListUserInsert :exec
+insert into price_list_user (price_list_id, user_id)
+values (?, ?);
+
+-- PriceListTagDelete removes all tag restrictions from a price list
+-- name: PriceListTagDelete :exec
+delete from price_list_tag
+where price_list_id = ?;
+
+-- PriceListTagInsert adds a tag restriction to a price list
+-- name: PriceListTagInsert :exec
+insert into price_list_tag (price_list_id, tag)
+values (?, ?);
+
+-- PriceListAccountDelete removes all account restrictions from a price list
+-- name: PriceListAccountDelete :exec
+delete from price_list_account
+where price_list_id = ?;
+
+-- PriceListAccountInsert adds a account restriction to a price list
+-- name: PriceListAccountInsert :exec
+insert into price_list_account (price_list_id, account_id)
+values (?, ?);
+
+-- PriceListRangeDelete removes all date time range restrictions from a price list
+-- name: PriceListRangeDelete :exec
+delete from price_list_range
+where price_list_id = ?;
+
+-- PriceListRangeInsert adds a date time range restriction to a price list
+-- name: PriceListRangeInsert :exec
+insert into price_list_range (price_list_id, start, end)
+values (?, ?, ?);
@@ -6,11 +6,352 @@ package sqlite
 
 import (
 	"context"
+
+	"github.com/mozey/ft"
 )
 
 type Querier interface {
+	// AccountByID fetches a single row
+	AccountByID(ctx context.Context, accountID string) (Account, error)
+	// AccountInsert creates a new account
+	AccountInsert(ctx context.Context, arg AccountInsertParams) error
+	// AccountList lists all accounts
+	AccountList(ctx context.Context) ([]Account, error)
+	// AccountTrans lists successful transactions for an account in order,
+	// with the type and linked order
+	AccountTrans(ctx context.Context, accountID string) ([]AccountTransRow, error)
+	// AccountUserDelete unlinks a user from an account
+	AccountUserDelete(ctx context.Context, arg AccountUserDeleteParams) error
+	// AccountUserInsert links a user to an account
+	AccountUserInsert(ctx context.Context, arg AccountUserInsertParams) error
+	// AccountUsers lists users linked to an account
+	AccountUsers(ctx context.Context, accountID string) ([]string, error)
+	// AccountsByUserID lists accounts linked to a user
+	AccountsByUserID(ctx context.Context, userID string) ([]string, error)
+	// CatBySKU fetches a single row
+	CatBySKU(ctx context.Context, sku string) (Cat, error)
+	// CatConfigByGroupID lists variant attribute config for the skus in a group
+	CatConfigByGroupID(ctx context.Context, groupID string) ([]CatConfig, error)
+	// CatConfigBySKU lists config for a sku
+	CatConfigBySKU(ctx context.Context, sku string) ([]CatConfig, error)
+	// CatConfigDelete removes a config value for a sku
+	CatConfigDelete(ctx context.Context, arg CatConfigDeleteParams) error
+	// CatConfigList lists config for all catalog items
+	CatConfigList(ctx context.Context) ([]CatConfig, error)
+	// CatConfigUpsert sets a config value for a sku
+	CatConfigUpsert(ctx context.Context, arg CatConfigUpsertParams) error
+	// CatImgDelete unlinks an image from the sku
+	CatImgDelete(ctx context.Context, arg CatImgDeleteParams) error
+	// CatImgIdxUpdate sets the sort order of an image for the sku
+	CatImgIdxUpdate(ctx context.Context, arg CatImgIdxUpdateParams) error
+	// CatImgUpsert links an image to the sku, the idx is kept for linked images
+	CatImgUpsert(ctx context.Context, arg CatImgUpsertParams) error
+	// CatImgsBySKU lists images for the sku, the first is the default image
+	CatImgsBySKU(ctx context.Context, sku string) ([]CatImgsBySKURow, error)
+	// CatList lists catalog items by sku
+	CatList(ctx context.Context) ([]Cat, error)
+	// CatPriceBySKU fetches the exclusive price
+	CatPriceBySKU(ctx context.Context, sku string) (CatPrice, error)
+	// CatPriceHistoryBySKU lists price changes, newest first
+	CatPriceHistoryBySKU(ctx context.Context, sku string) ([]CatPriceHistory, error)
+	// CatPriceHistoryInsert records a change to cat_price
+	CatPriceHistoryInsert(ctx context.Context, arg CatPriceHistoryInsertParams) error
+	// CatPriceList lists exclusive prices by sku
+	CatPriceList(ctx context.Context) ([]CatPrice, error)
+	// CatPriceScheduleByID fetches a single row
+	CatPriceScheduleByID(ctx context.Context, catPriceScheduleID string) (CatPriceSchedule, error)
+	// CatPriceScheduleInsert schedules a price change
+	CatPriceScheduleInsert(ctx context.Context, arg CatPriceScheduleInsertParams) error
+	// CatPriceScheduleUpdate sets the state and prev price
+	CatPriceScheduleUpdate(ctx context.Context, arg CatPriceScheduleUpdateParams) error
+	// CatPriceSchedulesBySKU lists scheduled changes for a sku, ordered by start
+	CatPriceSchedulesBySKU(ctx context.Context, sku string) ([]CatPriceSchedule, error)
+	// CatPriceSchedulesEnd lists active changes that ended before the date time
+	CatPriceSchedulesEnd(ctx context.Context, end string) ([]CatPriceSchedule, error)
+	// CatPriceSchedulesStart lists pending changes that started before the date time
+	CatPriceSchedulesStart(ctx context.Context, start string) ([]CatPriceSchedule, error)
+	// CatPriceTierDelete removes all quantity break prices for a sku
+	CatPriceTierDelete(ctx context.Context, sku string) error
+	// CatPriceTierInsert adds a quantity break price
+	CatPriceTierInsert(ctx context.Context, arg CatPriceTierInsertParams) error
+	// CatPriceTiersBySKU lists quantity break prices, ordered by qty
+	CatPriceTiersBySKU(ctx context.Context, sku string) ([]CatPriceTier, error)
+	// CatPriceUpsert sets the exclusive price
+	CatPriceUpsert(ctx context.Context, arg CatPriceUpsertParams) error
+	// CatQtyAdd adds qty to a depot, use a negative value to subtract.
+	// Fails if qty would be less than zero
+	CatQtyAdd(ctx context.Context, arg CatQtyAddParams) error
+	// CatQtyBySKU lists available qty per depot
+	CatQtyBySKU(ctx context.Context, sku string) ([]CatQty, error)
+	// CatQtyList lists available qty by sku and depot
+	CatQtyList(ctx context.Context) ([]CatQty, error)
+	// CatQtyUpsert sets the available qty for a depot
+	CatQtyUpsert(ctx context.Context, arg CatQtyUpsertParams) error
+	// CatStateList lists valid catalog states
+	CatStateList(ctx context.Context) ([]CatState, error)
+	// CatTagDeleteBySKU removes all tags for a sku
+	CatTagDeleteBySKU(ctx context.Context, sku string) error
+	// CatTagInsert tags a catalog item
+	CatTagInsert(ctx context.Context, arg CatTagInsertParams) error
+	// CatTagList lists catalog tags by sku
+	CatTagList(ctx context.Context) ([]CatTag, error)
+	// CatTagsByOrderID lists catalog tags for skus on the order
+	CatTagsByOrderID(ctx context.Context, orderID string) ([]CatTag, error)
+	// CatTagsBySKU lists catalog tags for a sku
+	CatTagsBySKU(ctx context.Context, sku string) ([]CatTag, error)
+	// CatUpsert creates or updates a catalog item
+	CatUpsert(ctx context.Context, arg CatUpsertParams) error
+	// ConfigByTerm fetches a global setting
+	ConfigByTerm(ctx context.Context, term string) (Config, error)
+	// ConfigUpsert sets a global setting
+	ConfigUpsert(ctx context.Context, arg ConfigUpsertParams) error
+	// CouponByCode fetches a single row
+	CouponByCode(ctx context.Context, code string) (Coupon, error)
+	// CouponInsert creates a coupon code
+	CouponInsert(ctx context.Context, arg CouponInsertParams) error
+	// CouponUseDelete removes coupon uses for an order
+	CouponUseDelete(ctx context.Context, orderID string) error
+	// CouponUseInsert records the order that used the code
+	CouponUseInsert(ctx context.Context, arg CouponUseInsertParams) error
+	// CouponUserUses counts orders by the user that used the code, excluding the given order
+	CouponUserUses(ctx context.Context, arg CouponUserUsesParams) (int64, error)
+	// CouponUses counts orders that used the code, excluding the given order
+	CouponUses(ctx context.Context, arg CouponUsesParams) (int64, error)
+	// CreditNoteByID fetches a single row
+	CreditNoteByID(ctx context.Context, creditNoteID string) (CreditNote, error)
+	// CreditNoteInsert creates a new credit note
+	CreditNoteInsert(ctx context.Context, arg CreditNoteInsertParams) error
+	// CreditNoteLineInsert appends a line to a credit note
+	CreditNoteLineInsert(ctx context.Context, arg CreditNoteLineInsertParams) error
+	// CreditNoteLinesByID lists the lines of a credit note
+	CreditNoteLinesByID(ctx context.Context, creditNoteID string) ([]CreditNoteLine, error)
+	// CreditNoteLinesByOrderID lists credit note lines for all credit notes of an order
+	CreditNoteLinesByOrderID(ctx context.Context, orderID string) ([]CreditNoteLine, error)
+	// CreditNotesByMod lists credit notes created in the range, oldest first.
+	// Empty params are ignored
+	CreditNotesByMod(ctx context.Context, arg CreditNotesByModParams) ([]CreditNote, error)
+	// CreditNotesByOrderID lists credit notes for an order in order of creation
+	CreditNotesByOrderID(ctx context.Context, orderID string) ([]CreditNote, error)
+	// DepotList lists depots in order of preference
+	DepotList(ctx context.Context) ([]Depot, error)
+	// DiscountByID fetches a single row
+	DiscountByID(ctx context.Context, discountID string) (Discount, error)
+	// DiscountCountries lists country restrictions for all discounts
+	DiscountCountries(ctx context.Context) ([]DiscountCountry, error)
+	// DiscountOpts lists discounts that may optionally be applied by admin users
+	DiscountOpts(ctx context.Context) ([]Discount, error)
+	// DiscountRanges lists date time ranges for all discounts
+	DiscountRanges(ctx context.Context) ([]DiscountRange, error)
+	// DiscountSkus lists sku restrictions for all discounts
+	DiscountSkus(ctx context.Context) ([]DiscountSku, error)
+	// DiscountTags lists tag restrictions for all discounts
+	DiscountTags(ctx context.Context) ([]DiscountTag, error)
+	// DiscountUsers lists user restrictions for all discounts
+	DiscountUsers(ctx context.Context) ([]DiscountUser, error)
+	// Discounts lists discounts that are applied automatically,
+	// i.e. excluding discount_opt and coupons
+	Discounts(ctx context.Context) ([]Discount, error)
+	// ExchangeRateByCurrency fetches a single row
+	ExchangeRateByCurrency(ctx context.Context, currency string) (ExchangeRate, error)
+	// ExchangeRateDelete removes the rate for a currency
+	ExchangeRateDelete(ctx context.Context, currency string) error
+	// ExchangeRateUpsert sets the rate for a currency
+	ExchangeRateUpsert(ctx context.Context, arg ExchangeRateUpsertParams) error
+	// ExchangeRates lists rates ordered by currency
+	ExchangeRates(ctx context.Context) ([]ExchangeRate, error)
+	// FieldsByTaxonomy lists data capture fields for a taxonomy, e.g. address format
+	FieldsByTaxonomy(ctx context.Context, taxonomy ft.NString) ([]FieldsByTaxonomyRow, error)
+	// ImgByHash fetches a single row
+	ImgByHash(ctx context.Context, hash string) (Img, error)
+	// ImgUpsert inserts an image by hash, the alt is updated if set
+	ImgUpsert(ctx context.Context, arg ImgUpsertParams) error
+	// JournalEntriesByExportID lists the documents for an export
+	JournalEntriesByExportID(ctx context.Context, exportID string) ([]JournalEntry, error)
+	// JournalEntryBySource fetches the export for a document
+	JournalEntryBySource(ctx context.Context, arg JournalEntryBySourceParams) (JournalEntry, error)
+	// JournalEntryInsert records a document as exported
+	JournalEntryInsert(ctx context.Context, arg JournalEntryInsertParams) error
+	// JournalExportByID fetches a single row
+	JournalExportByID(ctx context.Context, exportID string) (JournalExport, error)
+	// JournalExportInsert creates a new export
+	JournalExportInsert(ctx context.Context, arg JournalExportInsertParams) error
+	// JournalExportList lists exports, most recent first
+	JournalExportList(ctx context.Context, limit int64) ([]JournalExport, error)
+	// OrderActInsert appends an order activity entry
+	OrderActInsert(ctx context.Context, arg OrderActInsertParams) error
+	// OrderActUpdateOrderID moves the activity entries to another order
+	OrderActUpdateOrderID(ctx context.Context, arg OrderActUpdateOrderIDParams) error
+	// OrderAddrByType fetches the order address of the given type
+	OrderAddrByType(ctx context.Context, arg OrderAddrByTypeParams) (OrderAddrByTypeRow, error)
+	// OrderByID fetches a single row
+	OrderByID(ctx context.Context, orderID string) (Orders, error)
+	// OrderByUserIDState fetches the most recently modified order in the given state
+	OrderByUserIDState(ctx context.Context, arg OrderByUserIDStateParams) (Orders, error)
+	// OrderConfigByOrderID lists config for an order and its lines
+	OrderConfigByOrderID(ctx context.Context, orderID string) ([]OrderConfig, error)
+	// OrderConfigDelete removes a config value for an order or order line
+	OrderConfigDelete(ctx context.Context, arg OrderConfigDeleteParams) error
+	// OrderConfigDeleteByOrderID removes all config for an order and its lines
+	OrderConfigDeleteByOrderID(ctx context.Context, orderID string) error
+	// OrderConfigUpsert sets a config value for an order or order line
+	OrderConfigUpsert(ctx context.Context, arg OrderConfigUpsertParams) error
+	// OrderDelete removes an order, delete related rows first
+	OrderDelete(ctx context.Context, orderID string) error
+	// OrderInsert creates a new order
+	OrderInsert(ctx context.Context, arg OrderInsertParams) error
+	// OrderLineDelete removes an order line
+	OrderLineDelete(ctx context.Context, orderLineID string) error
+	// OrderLineInsert creates a new order line
+	OrderLineInsert(ctx context.Context, arg OrderLineInsertParams) error
+	// OrderLineUpdateOrderID moves an order line to another order
+	OrderLineUpdateOrderID(ctx context.Context, arg OrderLineUpdateOrderIDParams) error
+	// OrderLineUpdatePrice sets the price snapshot for an order line
+	OrderLineUpdatePrice(ctx context.Context, arg OrderLineUpdatePriceParams) error
+	// OrderLineUpdateQty sets the qty for an order line
+	OrderLineUpdateQty(ctx context.Context, arg OrderLineUpdateQtyParams) error
+	// OrderLineUpdateState sets the state for an order line, empty to use the order state
+	OrderLineUpdateState(ctx context.Context, arg OrderLineUpdateStateParams) error
+	// OrderLinesByOrderID lists lines in order of creation
+	OrderLinesByOrderID(ctx context.Context, orderID string) ([]OrderLine, error)
+	// OrderLinesWithTitle lists order lines with the catalog title,
+	// system skus are listed last
+	OrderLinesWithTitle(ctx context.Context, orderID string) ([]OrderLinesWithTitleRow, error)
+	// OrderList filters orders for the admin list, most recent first.
+	// Empty params are ignored, and paid is ignored if negative.
+	// Carts are excluded unless the state param is set
+	OrderList(ctx context.Context, arg OrderListParams) ([]OrderListRow, error)
+	// OrderTagsByOrderID lists tags for the order, excluding order line tags
+	OrderTagsByOrderID(ctx context.Context, orderID string) ([]string, error)
+	// OrderTaxByOrderID lists tax lines for an order
+	OrderTaxByOrderID(ctx context.Context, orderID string) ([]OrderTax, error)
+	// OrderTaxDelete removes tax lines before the order is priced again
+	OrderTaxDelete(ctx context.Context, orderID string) error
+	// OrderTaxInsert appends a tax line, tax lines can't be edited
+	OrderTaxInsert(ctx context.Context, arg OrderTaxInsertParams) error
+	// OrderTouch updates the mod cols, e.g. when order lines change
+	OrderTouch(ctx context.Context, arg OrderTouchParams) error
+	// OrderTranByTranID fetches the order link for a transaction
+	OrderTranByTranID(ctx context.Context, tranID string) (OrderTran, error)
+	// OrderTranInsert links a transaction to an order
+	OrderTranInsert(ctx context.Context, arg OrderTranInsertParams) error
+	// OrderUpdateOrderNo sets the order number
+	OrderUpdateOrderNo(ctx context.Context, arg OrderUpdateOrderNoParams) error
+	// OrderUpdatePaid sets the paid flag for the order
+	OrderUpdatePaid(ctx context.Context, arg OrderUpdatePaidParams) error
+	// OrderUpdateState sets the order state
+	OrderUpdateState(ctx context.Context, arg OrderUpdateStateParams) error
+	// OrderUpdateUserID assigns the order to a user
+	OrderUpdateUserID(ctx context.Context, arg OrderUpdateUserIDParams) error
+	// OrdersIdle lists orders in the given state that were not modified since mod
+	OrdersIdle(ctx context.Context, arg OrdersIdleParams) ([]Orders, error)
+	// OrdersIdleWithoutConfig lists idle orders for users with an email,
+	// excluding orders that have the order config term set
+	OrdersIdleWithoutConfig(ctx context.Context, arg OrdersIdleWithoutConfigParams) ([]OrdersIdleWithoutConfigRow, error)
+	// OrdersPaidByMod lists paid orders modified in the range, oldest first.
+	// Empty params are ignored
+	OrdersPaidByMod(ctx context.Context, arg OrdersPaidByModParams) ([]Orders, error)
+	// PaymentEventByID fetches a single row
+	PaymentEventByID(ctx context.Context, arg PaymentEventByIDParams) (PaymentEvent, error)
+	// PaymentEventInsert stores a webhook event
+	PaymentEventInsert(ctx context.Context, arg PaymentEventInsertParams) error
+	// PaymentEventUpdate records the outcome of handling an event
+	PaymentEventUpdate(ctx context.Context, arg PaymentEventUpdateParams) error
+	// PaymentEventsByHandled lists events, most recent first
+	PaymentEventsByHandled(ctx context.Context, arg PaymentEventsByHandledParams) ([]PaymentEvent, error)
+	// PickList lists order lines allocated to a depot,
+	// for orders in the given state
+	PickList(ctx context.Context, arg PickListParams) ([]PickListRow, error)
+	// PriceListAccountDelete removes all account restrictions from a price list
+	PriceListAccountDelete(ctx context.Context, priceListID string) error
+	// PriceListAccountInsert adds a account restriction to a price list
+	PriceListAccountInsert(ctx context.Context, arg PriceListAccountInsertParams) error
+	// PriceListAccounts lists account restrictions for all price lists
+	PriceListAccounts(ctx context.Context) ([]PriceListAccount, error)
+	// PriceListPriceDelete removes all prices from a price list
+	PriceListPriceDelete(ctx context.Context, priceListID string) error
+	// PriceListPriceInsert adds a price to a price list
+	PriceListPriceInsert(ctx context.Context, arg PriceListPriceInsertParams) error
+	// PriceListPricesBySKU lists price list prices for a sku
+	PriceListPricesBySKU(ctx context.Context, sku string) ([]PriceListPrice, error)
+	// PriceListRangeDelete removes all date time range restrictions from a price list
+	PriceListRangeDelete(ctx context.Context, priceListID string) error
+	// PriceListRangeInsert adds a date time range restriction to a price list
+	PriceListRangeInsert(ctx context.Context, arg PriceListRangeInsertParams) error
+	// PriceListRanges lists date time ranges for all price lists
+	PriceListRanges(ctx context.Context) ([]PriceListRange, error)
+	// PriceListTagDelete removes all tag restrictions from a price list
+	PriceListTagDelete(ctx context.Context, priceListID string) error
+	// PriceListTagInsert adds a tag restriction to a price list
+	PriceListTagInsert(ctx context.Context, arg PriceListTagInsertParams) error
+	// PriceListTags lists user tag restrictions for all price lists
+	PriceListTags(ctx context.Context) ([]PriceListTag, error)
+	// PriceListUpsert creates a price list, or updates the descr
+	PriceListUpsert(ctx context.Context, arg PriceListUpsertParams) error
+	// PriceListUserDelete removes all user restrictions from a price list
+	PriceListUserDelete(ctx context.Context, priceListID string) error
+	// PriceListUserInsert adds a user restriction to a price list
+	PriceListUserInsert(ctx context.Context, arg PriceListUserInsertParams) error
+	// PriceListUsers lists user restrictions for all price lists
+	PriceListUsers(ctx context.Context) ([]PriceListUser, error)
 	// SessionByUserID fetches a single row
 	SessionByUserID(ctx context.Context, userID string) (SessionByUserIDRow, error)
+	// SessionVerify verifies the session if the otp matches,
+	// the otp can only be used once
+	SessionVerify(ctx context.Context, arg SessionVerifyParams) (int64, error)
+	// TaxByCountry lists tax overrides and additional tax for a country
+	TaxByCountry(ctx context.Context, country string) ([]Tax, error)
+	// TranByID fetches a single row
+	TranByID(ctx context.Context, tranID string) (Tran, error)
+	// TranConfigByTranID lists config for a transaction
+	TranConfigByTranID(ctx context.Context, tranID string) ([]TranConfig, error)
+	// TranConfigUpsert sets a config value for a transaction
+	TranConfigUpsert(ctx context.Context, arg TranConfigUpsertParams) error
+	// TranIDByProcessorRef finds the transaction for a processor reference
+	TranIDByProcessorRef(ctx context.Context, arg TranIDByProcessorRefParams) (string, error)
+	// TranIDsByConfig lists transactions with the config value, e.g. refunds for a credit note
+	TranIDsByConfig(ctx context.Context, arg TranIDsByConfigParams) ([]string, error)
+	// TranIDsByOrderConfig lists transactions for an order with the config value, e.g. refunds
+	TranIDsByOrderConfig(ctx context.Context, arg TranIDsByOrderConfigParams) ([]string, error)
+	// TranInsert creates a new transaction
+	TranInsert(ctx context.Context, arg TranInsertParams) error
+	// TranReport lists transactions with the linked order and config,
+	// oldest first. Empty params are ignored
+	TranReport(ctx context.Context, arg TranReportParams) ([]TranReportRow, error)
+	// TranUpdateState sets the transaction state
+	TranUpdateState(ctx context.Context, arg TranUpdateStateParams) error
+	// TransByOrderID lists transactions linked to an order
+	TransByOrderID(ctx context.Context, orderID string) ([]Tran, error)
+	// UserByID fetches a single row
+	UserByID(ctx context.Context, userID string) (User, error)
+	// UserTagsByUserID lists tags for a user
+	UserTagsByUserID(ctx context.Context, userID string) ([]string, error)
+	// UserVerify sets the verified timestamp the first time a user verifies
+	UserVerify(ctx context.Context, arg UserVerifyParams) error
+	// VariantDeleteBySKU removes a sku from variant groups
+	VariantDeleteBySKU(ctx context.Context, sku string) error
+	// VariantInsert adds a sku to a variant group
+	VariantInsert(ctx context.Context, arg VariantInsertParams) error
+	// VariantItemsByGroupID lists the variants in a group with the available qty
+	VariantItemsByGroupID(ctx context.Context, groupID string) ([]VariantItemsByGroupIDRow, error)
+	// VariantList lists variant groups by sku
+	VariantList(ctx context.Context) ([]Variant, error)
+	// VariantsBySKU lists the variant groups for a sku
+	VariantsBySKU(ctx context.Context, sku string) ([]Variant, error)
+	// VatByCountry fetches the default vat rate
+	VatByCountry(ctx context.Context, country string) (Vat, error)
+	// VoucherAdd adds to the balance, use a negative value to subtract.
+	// Fails if the balance would be less than zero
+	VoucherAdd(ctx context.Context, arg VoucherAddParams) error
+	// VoucherByCode fetches a single row
+	VoucherByCode(ctx context.Context, code string) (Voucher, error)
+	// VoucherInsert creates a voucher with the balance set to amount
+	VoucherInsert(ctx context.Context, arg VoucherInsertParams) error
+	// VoucherRedeemByOrderID lists amounts drawn from vouchers by an order
+	VoucherRedeemByOrderID(ctx context.Context, orderID string) ([]VoucherRedeem, error)
+	// VoucherRedeemDelete removes the redemptions for an order
+	VoucherRedeemDelete(ctx context.Context, orderID string) error
+	// VoucherRedeemInsert records the amount drawn from a voucher
+	VoucherRedeemInsert(ctx context.Context, arg VoucherRedeemInsertParams) error
 }
 
 var _ Querier = (*Queries)(nil)
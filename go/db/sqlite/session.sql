@@ -4,3 +4,14 @@ select user.email, user.username, user.role, user.verified as user_verified,
 user.disabled, session.*
 from user join session on session.user_id = user.user_id
 where user.user_id = ? limit 1;
+
+-- SessionVerify verifies the session if the otp matches,
+-- the otp can only be used once
+-- name: SessionVerify :execrows
+update session set verified = ?, otp = '', attempts = 0, mod = ?
+where user_id = ? and otp = ? and otp <> '';
+
+-- UserVerify sets the verified timestamp the first time a user verifies
+-- name: UserVerify :exec
+update user set verified = ?, mod = ?
+where user_id = ? and verified = 0;
@@ -47,3 +47,42 @@ func (q *Queries) SessionByUserID(ctx context.Context, userID string) (SessionBy
 	)
 	return i, err
 }
+
+const sessionVerify = `-- name: SessionVerify :execrows
+update session set verified = ?, otp = '', attempts = 0, mod = ?
+where user_id = ? and otp = ? and otp <> ''
+`
+
+type SessionVerifyParams struct {
+	Verified int64  `db:"verified"`
+	Mod      string `db:"mod"`
+	UserID   string `db:"user_id"`
+	Otp      string `db:"otp"`
+}
+
+// SessionVerify verifies the session if the otp matches,
+// the otp can only be used once
+func (q *Queries) SessionVerify(ctx context.Context, arg SessionVerifyParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, sessionVerify, arg.Verified, arg.Mod, arg.UserID, arg.Otp)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const userVerify = `-- name: UserVerify :exec
+update user set verified = ?, mod = ?
+where user_id = ? and verified = 0
+`
+
+type UserVerifyParams struct {
+	Verified int64  `db:"verified"`
+	Mod      string `db:"mod"`
+	UserID   string `db:"user_id"`
+}
+
+// UserVerify sets the verified timestamp the first time a user verifies
+func (q *Queries) UserVerify(ctx context.Context, arg UserVerifyParams) error {
+	_, err := q.db.ExecContext(ctx, userVerify, arg.Verified, arg.Mod, arg.UserID)
+	return err
+}
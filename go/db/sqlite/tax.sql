@@ -0,0 +1,10 @@
+-- VatByCountry fetches the default vat rate
+-- name: VatByCountry :one
+select country, pct, mod, mod_id from vat
+where country = ? limit 1;
+
+-- TaxByCountry lists tax overrides and additional tax for a country
+-- name: TaxByCountry :many
+select country, tag, sku, vat, pct, tax, descr, mod, mod_id from tax
+where country = ?
+order by tag, sku;
@@ -0,0 +1,104 @@
+-- TransByOrderID lists transactions linked to an order
+-- name: TransByOrderID :many
+select tran.tran_id, tran.account_id, tran.state, tran.descr,
+tran.amount, tran.currency, tran.user_id, tran.mod
+from tran join order_tran on order_tran.tran_id = tran.tran_id
+where order_tran.order_id = ?
+order by tran.mod;
+
+-- TranByID fetches a single row
+-- name: TranByID :one
+select tran_id, account_id, state, descr, amount, currency, user_id, mod
+from tran where tran_id = ? limit 1;
+
+-- TranInsert creates a new transaction
+-- name: TranInsert :exec
+insert into tran (tran_id, account_id, state, descr, amount, currency, user_id, mod)
+values (?, ?, ?, ?, ?, ?, ?, ?);
+
+-- TranUpdateState sets the transaction state
+-- name: TranUpdateState :exec
+update tran set state = ?, mod = ?
+where tran_id = ?;
+
+-- TranConfigByTranID lists config for a transaction
+-- name: TranConfigByTranID :many
+select tran_id, term, val, user_id from tran_config
+where tran_id = ?
+order by term;
+
+-- TranConfigUpsert sets a config value for a transaction
+-- name: TranConfigUpsert :exec
+insert into tran_config (tran_id, term, val, user_id)
+values (?, ?, ?, ?)
+on conflict (tran_id, term) do update set
+val = excluded.val, user_id = excluded.user_id;
+
+-- OrderTranInsert links a transaction to an order
+-- name: OrderTranInsert :exec
+insert into order_tran (order_id, tran_id)
+values (?, ?);
+
+-- OrderTranByTranID fetches the order link for a transaction
+-- name: OrderTranByTranID :one
+select order_id, tran_id from order_tran
+where tran_id = ? limit 1;
+
+-- TranIDByProcessorRef finds the transaction for a processor reference
+-- name: TranIDByProcessorRef :one
+select ref.tran_id from tran_config ref
+join tran_config processor on processor.tran_id = ref.tran_id
+and processor.term = 'processor'
+where ref.term = 'processor_ref' and ref.val = sqlc.arg(ref)
+and processor.val = sqlc.arg(processor)
+limit 1;
+
+-- PaymentEventByID fetches a single row
+-- name: PaymentEventByID :one
+select processor, event_id, ref, state, amount, body, handled, error, attempts, mod, mod_id
+from payment_event where processor = ? and event_id = ? limit 1;
+
+-- PaymentEventInsert stores a webhook event
+-- name: PaymentEventInsert :exec
+insert into payment_event (processor, event_id, ref, state, amount, body, handled, error, attempts, mod, mod_id)
+values (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?);
+
+-- PaymentEventUpdate records the outcome of handling an event
+-- name: PaymentEventUpdate :exec
+update payment_event set handled = ?, error = ?, attempts = attempts + 1,
+mod = ?, mod_id = ?
+where processor = ? and event_id = ?;
+
+-- PaymentEventsByHandled lists events, most recent first
+-- name: PaymentEventsByHandled :many
+select processor, event_id, ref, state, amount, body, handled, error, attempts, mod, mod_id
+from payment_event where handled = ?
+order by mod desc
+limit ?;
+
+-- TranReport lists transactions with the linked order and config,
+-- oldest first. Empty params are ignored
+-- name: TranReport :many
+select tran.tran_id, tran.state, tran.descr, tran.amount, tran.currency, tran.mod,
+cast(ifnull(order_tran.order_id, '') as text) as order_id,
+cast(ifnull(orders.order_no, '') as text) as order_no,
+cast(ifnull(method.val, '') as text) as method,
+cast(ifnull(processor.val, '') as text) as processor,
+cast(ifnull(ref.val, '') as text) as ref,
+cast(ifnull(tran_type.val, '') as text) as type
+from tran
+left join order_tran on order_tran.tran_id = tran.tran_id
+left join orders on orders.order_id = order_tran.order_id
+left join tran_config method on method.tran_id = tran.tran_id
+and method.term = 'method'
+left join tran_config processor on processor.tran_id = tran.tran_id
+and processor.term = 'processor'
+left join tran_config ref on ref.tran_id = tran.tran_id
+and ref.term = 'processor_ref'
+left join tran_config tran_type on tran_type.tran_id = tran.tran_id
+and tran_type.term = 'type'
+where (sqlc.arg(processor) = '' or processor.val = sqlc.arg(processor))
+and (sqlc.arg(state) = '' or tran.state = sqlc.arg(state))
+and (sqlc.arg(mod_from) = '' or tran.mod >= sqlc.arg(mod_from))
+and (sqlc.arg(mod_to) = '' or tran.mod < sqlc.arg(mod_to))
+order by tran.mod;
@@ -0,0 +1,16 @@
+-- UserByID fetches a single row
+-- name: UserByID :one
+select user_id, email, username, descr, role, verified, disabled, mod from user
+where user_id = ? limit 1;
+
+-- UserTagsByUserID lists tags for a user
+-- name: UserTagsByUserID :many
+select tag from user_tag
+where user_id = ?
+order by tag;
+
+-- AccountsByUserID lists accounts linked to a user
+-- name: AccountsByUserID :many
+select account_id from account_x_user
+where user_id = ?
+order by account_id;
@@ -0,0 +1,359 @@
+package model
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/shopd/shopd/go/db/sqlite"
+	"github.com/shopd/shopd/go/share"
+)
+
+// Accounts are customer ledgers. The balance is derived from successful
+// tran rows with the account_id, credits and refunds increase the balance,
+// debits and payments decrease it. Store credit is issued on refunds,
+// see CreditNoteCreate, and spent as a payment method at checkout.
+// Users linked to the same account share the balance.
+// An account is created for the user of the order on the first refund
+// to store credit, if the user is not linked to an account yet
+
+// PaymentCredit pays for the order with store credit,
+// up to the balance of the account for the order user.
+// The accountID is required if the user is linked to more than one account.
+// Guest orders are claimed by the session user, userID.
+// Nothing is applied if the user doesn't have store credit
+func (m *Model) PaymentCredit(
+	ctx context.Context, orderID, accountID, userID string) (
+	pay share.Payment, err error) {
+
+	err = m.tx(ctx, func(q *sqlite.Queries) error {
+		order, err := orderByID(ctx, q, orderID)
+		if err != nil {
+			return err
+		}
+		if order.Paid == 1 {
+			return errors.WithStack(ErrOrderPaid(orderID))
+		}
+		if order.State != share.OrderStatePending {
+			return errors.WithStack(ErrOrderState(orderID, order.State))
+		}
+		if order.UserID == "" && userID != "" {
+			// Items were added to a guest cart after the user logged in
+			err = q.OrderUpdateUserID(ctx, sqlite.OrderUpdateUserIDParams{
+				UserID:  userID,
+				OrderID: orderID,
+			})
+			if err != nil {
+				return errors.WithStack(err)
+			}
+			order.UserID = userID
+		}
+		accountID, err = orderAccount(ctx, q, order, accountID, false)
+		if err != nil || accountID == "" {
+			return err
+		}
+		statement, err := accountStatement(ctx, q, accountID)
+		if err != nil {
+			return err
+		}
+		inv, err := invoice(ctx, q, order)
+		if err != nil {
+			return err
+		}
+		amount := min(statement.Balance, inv.Due)
+		if amount <= 0 {
+			return nil
+		}
+
+		pay = share.Payment{
+			TranID:    NewID(),
+			OrderID:   orderID,
+			State:     share.TranStateSuccess,
+			Descr:     tranDescr[share.TranMethodCredit],
+			Amount:    amount,
+			Currency:  inv.Currency,
+			Method:    share.TranMethodCredit,
+			AccountID: accountID,
+		}
+		err = tranInsert(ctx, q, pay, userID)
+		if err != nil {
+			return err
+		}
+		err = orderAct(ctx, q, order, userID, false,
+			fmt.Sprintf("%s applied %s", pay.Descr, pay.TranID))
+		if err != nil {
+			return err
+		}
+		return orderPaid(ctx, q, order, userID)
+	})
+	if err != nil {
+		return pay, err
+	}
+	return pay, nil
+}
+
+// AccountAdjust credits or debits the account, e.g. goodwill credit.
+// Debits may not exceed the balance
+func (m *Model) AccountAdjust(
+	ctx context.Context, params share.ParamsAccountCreditPost, userID string) (
+	statement share.AccountStatement, err error) {
+
+	if params.Amount == 0 {
+		return statement, errors.WithStack(ErrInvalidParam("Amount"))
+	}
+	err = m.tx(ctx, func(q *sqlite.Queries) error {
+		statement, err = accountStatement(ctx, q, params.AccountID)
+		if err != nil {
+			return err
+		}
+		pay := share.Payment{
+			TranID:    NewID(),
+			State:     share.TranStateSuccess,
+			Descr:     "Store credit issued",
+			Amount:    params.Amount,
+			Currency:  statement.Currency,
+			Message:   strings.TrimSpace(params.Message),
+			Type:      share.TranTypeCredit,
+			AccountID: params.AccountID,
+		}
+		if params.Amount < 0 {
+			if -params.Amount > statement.Balance {
+				return errors.WithStack(ErrInvalidParam("Amount"))
+			}
+			pay.Descr = "Store credit withdrawn"
+			pay.Amount = -params.Amount
+			pay.Type = share.TranTypeDebit
+		}
+		if pay.Message != "" {
+			pay.Descr = fmt.Sprintf("%s, %s", pay.Descr, pay.Message)
+		}
+		err = tranInsert(ctx, q, pay, userID)
+		if err != nil {
+			return err
+		}
+		statement, err = accountStatement(ctx, q, params.AccountID)
+		return err
+	})
+	if err != nil {
+		return statement, err
+	}
+	return statement, nil
+}
+
+// AccountUser links the user to the account, or unlinks the user.
+// Linked users share the account balance
+func (m *Model) AccountUser(
+	ctx context.Context, params share.ParamsAccountUserPost) (
+	statement share.AccountStatement, err error) {
+
+	params.UserID = strings.TrimSpace(params.UserID)
+	err = m.tx(ctx, func(q *sqlite.Queries) error {
+		statement, err = accountStatement(ctx, q, params.AccountID)
+		if err != nil {
+			return err
+		}
+		linked := slices.Contains(statement.Users, params.UserID)
+		if params.Unlink {
+			if !linked {
+				return errors.WithStack(ErrNotFound(params.UserID))
+			}
+			err = q.AccountUserDelete(ctx, sqlite.AccountUserDeleteParams{
+				AccountID: params.AccountID,
+				UserID:    params.UserID,
+			})
+			if err != nil {
+				return errors.WithStack(err)
+			}
+			statement, err = accountStatement(ctx, q, params.AccountID)
+			return err
+		}
+
+		if linked {
+			return nil
+		}
+		_, err = q.UserByID(ctx, params.UserID)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return errors.WithStack(ErrNotFound(params.UserID))
+			}
+			return errors.WithStack(err)
+		}
+		err = q.AccountUserInsert(ctx, sqlite.AccountUserInsertParams{
+			AccountID: params.AccountID,
+			UserID:    params.UserID,
+		})
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		statement, err = accountStatement(ctx, q, params.AccountID)
+		return err
+	})
+	if err != nil {
+		return statement, err
+	}
+	return statement, nil
+}
+
+// AccountStatement lists the transactions for the account
+func (m *Model) AccountStatement(
+	ctx context.Context, accountID string) (
+	statement share.AccountStatement, err error) {
+
+	return accountStatement(ctx, m.q, accountID)
+}
+
+// UserStatements lists statements for the accounts linked to the user
+func (m *Model) UserStatements(
+	ctx context.Context, userID string) (
+	statements []share.AccountStatement, err error) {
+
+	if userID == "" {
+		return statements, errors.WithStack(ErrSessionVerify(userID))
+	}
+	accounts, err := m.q.AccountsByUserID(ctx, userID)
+	if err != nil {
+		return statements, errors.WithStack(err)
+	}
+	for _, accountID := range accounts {
+		statement, err := accountStatement(ctx, m.q, accountID)
+		if err != nil {
+			return statements, err
+		}
+		statements = append(statements, statement)
+	}
+	return statements, nil
+}
+
+// Accounts lists accounts with balances
+func (m *Model) Accounts(ctx context.Context) (list share.Accounts, err error) {
+	list.Currency, err = configVal(ctx, m.q, TermCurrency, CurrencyDefault)
+	if err != nil {
+		return list, err
+	}
+	rows, err := m.q.AccountList(ctx)
+	if err != nil {
+		return list, errors.WithStack(err)
+	}
+	list.Accounts = make([]share.Account, 0, len(rows))
+	for _, row := range rows {
+		statement, err := accountStatement(ctx, m.q, row.AccountID)
+		if err != nil {
+			return list, err
+		}
+		list.Accounts = append(list.Accounts, statement.Account)
+	}
+	return list, nil
+}
+
+// accountStatement lists the transactions with a running balance
+func accountStatement(
+	ctx context.Context, q *sqlite.Queries, accountID string) (
+	statement share.AccountStatement, err error) {
+
+	account, err := q.AccountByID(ctx, accountID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return statement, errors.WithStack(ErrNotFound(accountID))
+		}
+		return statement, errors.WithStack(err)
+	}
+	statement.AccountID = account.AccountID
+	statement.Descr = account.Descr
+	statement.Users, err = q.AccountUsers(ctx, accountID)
+	if err != nil {
+		return statement, errors.WithStack(err)
+	}
+	statement.Currency, err = configVal(ctx, q, TermCurrency, CurrencyDefault)
+	if err != nil {
+		return statement, err
+	}
+
+	trans, err := q.AccountTrans(ctx, accountID)
+	if err != nil {
+		return statement, errors.WithStack(err)
+	}
+	statement.Lines = make([]share.AccountLine, 0, len(trans))
+	for _, tran := range trans {
+		line := share.AccountLine{
+			TranID:  tran.TranID,
+			OrderID: tran.OrderID,
+			Date:    modTime(tran.Mod),
+			Descr:   tran.Descr,
+		}
+		switch tran.Type {
+		case share.TranTypeCredit, share.TranTypeRefund:
+			line.Credit = tran.Amount
+			statement.Balance += tran.Amount
+		default:
+			line.Debit = tran.Amount
+			statement.Balance -= tran.Amount
+		}
+		line.Balance = statement.Balance
+		statement.Lines = append(statement.Lines, line)
+	}
+	return statement, nil
+}
+
+// orderAccount returns the account for the order user,
+// empty if the user is not linked to an account, unless create is set.
+// If accountID is set the user must be linked to it,
+// otherwise the first account linked to the user is used.
+// Guest orders don't have an account
+func orderAccount(
+	ctx context.Context, q *sqlite.Queries, order sqlite.Orders,
+	accountID string, create bool) (string, error) {
+
+	if order.UserID == "" {
+		if create {
+			return accountID, errors.WithStack(ErrInvalidParam("Method"))
+		}
+		return accountID, nil
+	}
+	accounts, err := q.AccountsByUserID(ctx, order.UserID)
+	if err != nil {
+		return accountID, errors.WithStack(err)
+	}
+	if accountID != "" {
+		if !slices.Contains(accounts, accountID) {
+			return accountID, errors.WithStack(ErrInvalidParam("AccountID"))
+		}
+		return accountID, nil
+	}
+	if len(accounts) > 0 {
+		return accounts[0], nil
+	}
+	if !create {
+		return accountID, nil
+	}
+
+	user, err := q.UserByID(ctx, order.UserID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return accountID, errors.WithStack(ErrNotFound(order.UserID))
+		}
+		return accountID, errors.WithStack(err)
+	}
+	descr := user.Descr
+	if descr == "" {
+		descr = user.Email
+	}
+	accountID = NewID()
+	err = q.AccountInsert(ctx, sqlite.AccountInsertParams{
+		AccountID: accountID,
+		Descr:     descr,
+	})
+	if err != nil {
+		return accountID, errors.WithStack(err)
+	}
+	err = q.AccountUserInsert(ctx, sqlite.AccountUserInsertParams{
+		AccountID: accountID,
+		UserID:    order.UserID,
+	})
+	if err != nil {
+		return accountID, errors.WithStack(err)
+	}
+	return accountID, nil
+}
@@ -0,0 +1,143 @@
+package model_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/matryer/is"
+	"github.com/pkg/errors"
+	"github.com/shopd/shopd/go/model"
+	"github.com/shopd/shopd/go/share"
+)
+
+func TestPaymentCredit(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+	m, db := newTestModel(t)
+	exec(t, db,
+		`insert into cat(sku, title, descr, state, mod, mod_id)
+		values ('apple', 'Apple', '', 'stock', 'm', 's')`,
+		`insert into cat_price values ('apple', 1000)`,
+		`insert into user(user_id, email, descr, mod)
+		values ('u1', 'u1@example.com', 'Jane', 'm')`,
+		`insert into account values ('a1', 'Jane')`,
+		`insert into account_x_user values ('a1', 'u1')`,
+	)
+	_, err := m.AccountAdjust(ctx, share.ParamsAccountCreditPost{
+		AccountID: "a1",
+		Amount:    1000,
+	}, "admin")
+	is.NoErr(err)
+
+	// Items added to a guest cart, the user logs in before checkout
+	cart, err := m.CartAdd(ctx, "", "", share.ParamsCartPost{Sku: "apple", Qty: 2})
+	is.NoErr(err)
+	is.NoErr(m.SetOrderState(ctx, cart.OrderID, share.OrderStatePending, "u1"))
+
+	pay, err := m.PaymentCredit(ctx, cart.OrderID, "", "u1")
+	is.NoErr(err)
+	is.Equal(pay.AccountID, "a1")
+	is.Equal(pay.Amount, int64(1000))
+
+	payments, err := m.OrderPayments(ctx, cart.OrderID)
+	is.NoErr(err)
+	is.True(!payments.Paid)
+	is.Equal(payments.Received, int64(1000))
+	is.Equal(payments.Due, payments.Total-1000)
+
+	statement, err := m.AccountStatement(ctx, "a1")
+	is.NoErr(err)
+	is.Equal(statement.Balance, int64(0))
+
+	// Nothing is applied without a balance
+	pay, err = m.PaymentCredit(ctx, cart.OrderID, "", "u1")
+	is.NoErr(err)
+	is.Equal(pay.Amount, int64(0))
+
+	// The order is paid when the credit covers the total
+	_, err = m.AccountAdjust(ctx, share.ParamsAccountCreditPost{
+		AccountID: "a1",
+		Amount:    payments.Due,
+	}, "admin")
+	is.NoErr(err)
+	pay, err = m.PaymentCredit(ctx, cart.OrderID, "", "u1")
+	is.NoErr(err)
+	is.Equal(pay.Amount, payments.Due)
+	summary, err := m.OrderSummary(ctx, cart.OrderID)
+	is.NoErr(err)
+	is.True(summary.Paid)
+	is.Equal(summary.UserID, "u1")
+	is.Equal(summary.State, share.OrderStateConfirmed)
+	is.True(summary.OrderNo != "")
+}
+
+func TestAccountUser(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+	m, db := newTestModel(t)
+	exec(t, db,
+		`insert into cat(sku, title, descr, state, mod, mod_id)
+		values ('apple', 'Apple', '', 'stock', 'm', 's')`,
+		`insert into cat_price values ('apple', 1000)`,
+		`insert into user(user_id, email, descr, mod)
+		values ('u1', 'u1@example.com', 'Jane', 'm')`,
+		`insert into user(user_id, email, descr, mod)
+		values ('u2', 'u2@example.com', 'Acme', 'm')`,
+		`insert into account values ('a1', 'Jane')`,
+		`insert into account values ('a2', 'Acme')`,
+		`insert into account_x_user values ('a1', 'u1')`,
+	)
+
+	// Link a user to a shared account, linking twice is a no-op
+	statement, err := m.AccountUser(ctx, share.ParamsAccountUserPost{
+		AccountID: "a2", UserID: "u1"})
+	is.NoErr(err)
+	is.Equal(statement.Users, []string{"u1"})
+	statement, err = m.AccountUser(ctx, share.ParamsAccountUserPost{
+		AccountID: "a2", UserID: " u2 "})
+	is.NoErr(err)
+	is.Equal(statement.Users, []string{"u1", "u2"})
+	statement, err = m.AccountUser(ctx, share.ParamsAccountUserPost{
+		AccountID: "a2", UserID: "u2"})
+	is.NoErr(err)
+	is.Equal(statement.Users, []string{"u1", "u2"})
+	statements, err := m.UserStatements(ctx, "u1")
+	is.NoErr(err)
+	is.Equal(len(statements), 2)
+
+	// Invalid params
+	_, err = m.AccountUser(ctx, share.ParamsAccountUserPost{
+		AccountID: "a2", UserID: "u3"})
+	is.True(errors.Is(err, model.ErrNotFound("")))
+	_, err = m.AccountUser(ctx, share.ParamsAccountUserPost{
+		AccountID: "a3", UserID: "u1"})
+	is.True(errors.Is(err, model.ErrNotFound("")))
+	_, err = m.AccountUser(ctx, share.ParamsAccountUserPost{
+		AccountID: "a1", UserID: "u2", Unlink: true})
+	is.True(errors.Is(err, model.ErrNotFound("")))
+
+	// The buyer selects the account to pay from
+	_, err = m.AccountAdjust(ctx, share.ParamsAccountCreditPost{
+		AccountID: "a2",
+		Amount:    500,
+	}, "admin")
+	is.NoErr(err)
+	cart, err := m.CartAdd(ctx, "", "u1", share.ParamsCartPost{Sku: "apple", Qty: 1})
+	is.NoErr(err)
+	is.NoErr(m.SetOrderState(ctx, cart.OrderID, share.OrderStatePending, "u1"))
+	pay, err := m.PaymentCredit(ctx, cart.OrderID, "a1", "u1")
+	is.NoErr(err)
+	is.Equal(pay.Amount, int64(0)) // no balance
+	pay, err = m.PaymentCredit(ctx, cart.OrderID, "a2", "u1")
+	is.NoErr(err)
+	is.Equal(pay.AccountID, "a2")
+	is.Equal(pay.Amount, int64(500))
+
+	// Users can't pay from accounts they are not linked to
+	statement, err = m.AccountUser(ctx, share.ParamsAccountUserPost{
+		AccountID: "a2", UserID: "u1", Unlink: true})
+	is.NoErr(err)
+	is.Equal(statement.Users, []string{"u2"})
+	_, err = m.PaymentCredit(ctx, cart.OrderID, "a2", "u1")
+	is.True(errors.Is(err, model.ErrInvalidParam("")))
+}
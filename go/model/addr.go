@@ -0,0 +1,52 @@
+package model
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+
+	"github.com/mozey/ft"
+	"github.com/pkg/errors"
+	"github.com/shopd/shopd/go/db/sqlite"
+	"github.com/shopd/shopd/go/share"
+)
+
+// addrField returns the address line for the field term ending with suffix.
+// Address lines are ordered as per the fields for the address taxonomy,
+// see comments for the addr table
+func addrField(
+	ctx context.Context, q *sqlite.Queries, taxonomy, val, suffix string) (
+	field string, err error) {
+
+	fields, err := q.FieldsByTaxonomy(ctx, ft.NStringFrom(taxonomy))
+	if err != nil {
+		return field, errors.WithStack(err)
+	}
+	lines := strings.Split(val, "\n")
+	for i, f := range fields {
+		if strings.HasSuffix(f.Term, suffix) && i < len(lines) {
+			return strings.TrimSpace(lines[i]), nil
+		}
+	}
+	return field, nil
+}
+
+// orderProvince returns the province of the delivery address,
+// or empty string if the order does not have a delivery address,
+// or the address format does not have a province field
+func orderProvince(
+	ctx context.Context, q *sqlite.Queries, orderID string) (
+	province string, err error) {
+
+	addr, err := q.OrderAddrByType(ctx, sqlite.OrderAddrByTypeParams{
+		OrderID: orderID,
+		Type:    share.AddrTypeDelivery,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return province, nil
+		}
+		return province, errors.WithStack(err)
+	}
+	return addrField(ctx, q, addr.Taxonomy, addr.Val, "_province")
+}
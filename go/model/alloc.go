@@ -0,0 +1,668 @@
+package model
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/shopd/shopd/go/db/sqlite"
+	"github.com/shopd/shopd/go/money"
+	"github.com/shopd/shopd/go/share"
+)
+
+// Allocation strategies, set with the alloc_strategy config term
+const (
+	// AllocPrefer allocates from the preferred depot (alloc_depot term),
+	// falling back to the other depots in order of preference
+	AllocPrefer = "prefer"
+	// AllocFewest allocates from the fewest possible number of depots
+	AllocFewest = "fewest"
+	// AllocNearest allocates from depots in the same province as
+	// the delivery address first
+	AllocNearest = "nearest"
+)
+
+// Config terms used for allocation
+const (
+	TermAllocStrategy = "alloc_strategy"
+	TermAllocDepot    = "alloc_depot"
+	// TermDepot is the order_config term for the allocated depot
+	TermDepot = "depot"
+	// TermBackorder is the order_config term set on paid orders
+	// that could not be allocated, see AllocateOrder
+	TermBackorder = "backorder"
+)
+
+// AllocLine is an order line to allocate
+type AllocLine struct {
+	OrderLineID string
+	SKU         string
+	Qty         int64
+}
+
+// Allocation of qty for an order line to a depot.
+// Lines may be split over multiple allocations
+type Allocation struct {
+	OrderLineID string
+	SKU         string
+	Depot       string
+	Qty         int64
+}
+
+type AllocParams struct {
+	Strategy string
+	// Depots in order of preference
+	Depots []string
+	// Preferred depot for the AllocPrefer strategy
+	Preferred string
+	// Provinces maps depot to province for the AllocNearest strategy
+	Provinces map[string]string
+	// Province of the delivery address
+	Province string
+	// Stock maps sku to the qty available per depot.
+	// Skus that are not listed are not stock items, e.g. services
+	Stock map[string]map[string]int64
+	Lines []AllocLine
+}
+
+// Allocate order lines to depots as per strategy.
+// Lines are only split if no single depot has enough stock
+func Allocate(params AllocParams) (allocs []Allocation, err error) {
+	// Stock is decremented while allocating, don't modify params
+	stock := make(map[string]map[string]int64)
+	for sku, depots := range params.Stock {
+		stock[sku] = make(map[string]int64)
+		for depot, qty := range depots {
+			stock[sku][depot] = qty
+		}
+	}
+	depots := allocDepots(params.Depots, stock)
+
+	lines := make([]AllocLine, 0, len(params.Lines))
+	for _, line := range params.Lines {
+		if _, ok := stock[line.SKU]; ok {
+			lines = append(lines, line)
+		}
+	}
+
+	switch params.Strategy {
+	case AllocPrefer, "":
+		return allocateRanked(
+			rankPreferred(depots, params.Preferred), stock, lines)
+	case AllocNearest:
+		return allocateRanked(
+			rankNearest(depots, params.Provinces, params.Province), stock, lines)
+	case AllocFewest:
+		return allocateFewest(depots, stock, lines)
+	}
+	return allocs, errors.WithStack(ErrAllocStrategy(params.Strategy))
+}
+
+// allocDepots appends depots listed on the stock,
+// but not in the depot table, e.g. the empty default depot
+func allocDepots(depots []string, stock map[string]map[string]int64) []string {
+	listed := make(map[string]bool)
+	for _, depot := range depots {
+		listed[depot] = true
+	}
+	unlisted := []string{}
+	for _, qty := range stock {
+		for depot := range qty {
+			if !listed[depot] {
+				listed[depot] = true
+				unlisted = append(unlisted, depot)
+			}
+		}
+	}
+	sort.Strings(unlisted)
+	return append(append([]string{}, depots...), unlisted...)
+}
+
+func rankPreferred(depots []string, preferred string) []string {
+	ranked := []string{}
+	for _, depot := range depots {
+		if depot == preferred {
+			ranked = append(ranked, depot)
+		}
+	}
+	for _, depot := range depots {
+		if depot != preferred {
+			ranked = append(ranked, depot)
+		}
+	}
+	return ranked
+}
+
+func rankNearest(
+	depots []string, provinces map[string]string, province string) []string {
+
+	ranked := append([]string{}, depots...)
+	if province == "" {
+		return ranked
+	}
+	sort.SliceStable(ranked, func(i, j int) bool {
+		return strings.EqualFold(provinces[ranked[i]], province) &&
+			!strings.EqualFold(provinces[ranked[j]], province)
+	})
+	return ranked
+}
+
+// allocateLine from the first depot with enough stock,
+// otherwise split the line over depots in the given order
+func allocateLine(
+	depots []string, stock map[string]map[string]int64, line AllocLine) (
+	allocs []Allocation, err error) {
+
+	for _, depot := range depots {
+		if stock[line.SKU][depot] >= line.Qty {
+			stock[line.SKU][depot] -= line.Qty
+			return []Allocation{{
+				OrderLineID: line.OrderLineID,
+				SKU:         line.SKU,
+				Depot:       depot,
+				Qty:         line.Qty,
+			}}, nil
+		}
+	}
+
+	remain := line.Qty
+	for _, depot := range depots {
+		qty := min(stock[line.SKU][depot], remain)
+		if qty <= 0 {
+			continue
+		}
+		stock[line.SKU][depot] -= qty
+		remain -= qty
+		allocs = append(allocs, Allocation{
+			OrderLineID: line.OrderLineID,
+			SKU:         line.SKU,
+			Depot:       depot,
+			Qty:         qty,
+		})
+		if remain == 0 {
+			return allocs, nil
+		}
+	}
+	return allocs, errors.WithStack(ErrInsufficientStock(line.SKU))
+}
+
+func allocateRanked(
+	depots []string, stock map[string]map[string]int64, lines []AllocLine) (
+	allocs []Allocation, err error) {
+
+	for _, line := range lines {
+		a, err := allocateLine(depots, stock, line)
+		if err != nil {
+			return allocs, err
+		}
+		allocs = append(allocs, a...)
+	}
+	return allocs, nil
+}
+
+// allocateFewest repeatedly picks the depot that can fulfil
+// the most remaining lines. Lines that can't be fulfilled by a single depot
+// are split, starting with depots already used, and then by most stock
+func allocateFewest(
+	depots []string, stock map[string]map[string]int64, lines []AllocLine) (
+	allocs []Allocation, err error) {
+
+	byLine := make(map[string][]Allocation)
+	pending := lines
+	for len(pending) > 0 {
+		best, count := "", 0
+		for _, depot := range depots {
+			n := 0
+			for _, line := range pending {
+				if stock[line.SKU][depot] >= line.Qty {
+					n++
+				}
+			}
+			if n > count {
+				best, count = depot, n
+			}
+		}
+		if count == 0 {
+			break
+		}
+
+		remain := []AllocLine{}
+		for _, line := range pending {
+			// Check again, lines for the same sku share the depot stock
+			if stock[line.SKU][best] < line.Qty {
+				remain = append(remain, line)
+				continue
+			}
+			stock[line.SKU][best] -= line.Qty
+			byLine[line.OrderLineID] = []Allocation{{
+				OrderLineID: line.OrderLineID,
+				SKU:         line.SKU,
+				Depot:       best,
+				Qty:         line.Qty,
+			}}
+		}
+		pending = remain
+	}
+
+	used := make(map[string]bool)
+	for _, a := range byLine {
+		used[a[0].Depot] = true
+	}
+	for _, line := range pending {
+		ranked := append([]string{}, depots...)
+		sort.SliceStable(ranked, func(i, j int) bool {
+			if used[ranked[i]] != used[ranked[j]] {
+				return used[ranked[i]]
+			}
+			return stock[line.SKU][ranked[i]] > stock[line.SKU][ranked[j]]
+		})
+		a, err := allocateLine(ranked, stock, line)
+		if err != nil {
+			return allocs, err
+		}
+		byLine[line.OrderLineID] = a
+		for _, split := range a {
+			used[split.Depot] = true
+		}
+	}
+
+	// Return allocations in the same order as the lines
+	for _, line := range lines {
+		allocs = append(allocs, byLine[line.OrderLineID]...)
+	}
+	return allocs, nil
+}
+
+// .............................................................................
+
+// AllocateOrder allocates order lines to depots, and reserves stock.
+// Lines that are already allocated are skipped, and the backorder flag
+// is removed once all lines are allocated.
+// If a line is split, new order lines are created for the extra depots
+func (m *Model) AllocateOrder(
+	ctx context.Context, orderID, userID string) (
+	allocs []Allocation, err error) {
+
+	err = m.tx(ctx, func(q *sqlite.Queries) error {
+		allocs, err = allocateOrder(ctx, q, orderID, userID)
+		return err
+	})
+	if err != nil {
+		return allocs, err
+	}
+
+	return allocs, nil
+}
+
+// allocateOrder must be called in a transaction
+func allocateOrder(
+	ctx context.Context, q *sqlite.Queries, orderID, userID string) (
+	allocs []Allocation, err error) {
+
+	order, err := orderByID(ctx, q, orderID)
+	if err != nil {
+		return allocs, err
+	}
+	lines, err := q.OrderLinesByOrderID(ctx, orderID)
+	if err != nil {
+		return allocs, errors.WithStack(err)
+	}
+	allocated, err := allocatedLines(ctx, q, orderID)
+	if err != nil {
+		return allocs, err
+	}
+
+	params := AllocParams{
+		Provinces: make(map[string]string),
+		Stock:     make(map[string]map[string]int64),
+	}
+	for _, line := range lines {
+		if _, ok := allocated[line.OrderLineID]; ok {
+			continue
+		}
+		if _, ok := params.Stock[line.SKU]; !ok {
+			qtys, err := q.CatQtyBySKU(ctx, line.SKU)
+			if err != nil {
+				return allocs, errors.WithStack(err)
+			}
+			if len(qtys) == 0 {
+				continue
+			}
+			params.Stock[line.SKU] = make(map[string]int64)
+			for _, qty := range qtys {
+				params.Stock[line.SKU][qty.Depot] = qty.Qty
+			}
+		}
+		params.Lines = append(params.Lines, AllocLine{
+			OrderLineID: line.OrderLineID,
+			SKU:         line.SKU,
+			Qty:         line.Qty,
+		})
+	}
+	if len(params.Lines) == 0 {
+		return allocs, nil
+	}
+
+	params.Strategy, err = configVal(ctx, q, TermAllocStrategy, AllocPrefer)
+	if err != nil {
+		return allocs, err
+	}
+	params.Preferred, err = configVal(ctx, q, TermAllocDepot, "")
+	if err != nil {
+		return allocs, err
+	}
+	depots, err := q.DepotList(ctx)
+	if err != nil {
+		return allocs, errors.WithStack(err)
+	}
+	for _, depot := range depots {
+		params.Depots = append(params.Depots, depot.Depot)
+		params.Provinces[depot.Depot] = depot.Province
+	}
+	if params.Strategy == AllocNearest {
+		params.Province, err = orderProvince(ctx, q, orderID)
+		if err != nil {
+			return allocs, err
+		}
+	}
+
+	allocs, err = Allocate(params)
+	if err != nil {
+		return allocs, err
+	}
+	err = saveAllocations(ctx, q, order, lines, allocs, userID)
+	if err != nil {
+		return allocs, err
+	}
+	err = q.OrderConfigDelete(ctx, sqlite.OrderConfigDeleteParams{
+		OrderID: orderID,
+		Term:    TermBackorder,
+	})
+	if err != nil {
+		return allocs, errors.WithStack(err)
+	}
+	return allocs, nil
+}
+
+// backorder flags the order for manual allocation,
+// stock may run out between checkout and payment
+func backorder(
+	ctx context.Context, q *sqlite.Queries, order sqlite.Orders,
+	userID string, cause error) (err error) {
+
+	err = q.OrderConfigUpsert(ctx, sqlite.OrderConfigUpsertParams{
+		OrderID: order.OrderID,
+		Term:    TermBackorder,
+		Val:     "1",
+	})
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	return orderAct(ctx, q, order, userID, true,
+		fmt.Sprintf("Backorder, allocate manually: %s", cause))
+}
+
+func allocatedLines(
+	ctx context.Context, q *sqlite.Queries, orderID string) (
+	depots map[string]string, err error) {
+
+	config, err := q.OrderConfigByOrderID(ctx, orderID)
+	if err != nil {
+		return depots, errors.WithStack(err)
+	}
+	depots = make(map[string]string)
+	for _, c := range config {
+		if c.Term == TermDepot && c.OrderLineID != "" {
+			depots[c.OrderLineID] = c.Val
+		}
+	}
+	return depots, nil
+}
+
+func saveAllocations(
+	ctx context.Context, q *sqlite.Queries, order sqlite.Orders,
+	lines []sqlite.OrderLine, allocs []Allocation, userID string) (err error) {
+
+	byID := make(map[string]sqlite.OrderLine)
+	for _, line := range lines {
+		byID[line.OrderLineID] = line
+	}
+	seen := make(map[string]bool)
+	depots := []string{}
+	splits := make(map[string][]lineQty)
+	for _, a := range allocs {
+		orderLineID := a.OrderLineID
+		line := byID[a.OrderLineID]
+		if !seen[a.OrderLineID] {
+			seen[a.OrderLineID] = true
+			if a.Qty != line.Qty {
+				err = q.OrderLineUpdateQty(ctx, sqlite.OrderLineUpdateQtyParams{
+					Qty:         a.Qty,
+					OrderLineID: orderLineID,
+				})
+				if err != nil {
+					return errors.WithStack(err)
+				}
+			}
+		} else {
+			// Remaining qty is allocated to another depot on a new line
+			orderLineID = NewID()
+			err = q.OrderLineInsert(ctx, sqlite.OrderLineInsertParams{
+				OrderLineID: orderLineID,
+				OrderID:     line.OrderID,
+				State:       line.State,
+				SKU:         line.SKU,
+				Price:       line.Price,
+				Qty:         a.Qty,
+			})
+			if err != nil {
+				return errors.WithStack(err)
+			}
+		}
+
+		err = q.OrderConfigUpsert(ctx, sqlite.OrderConfigUpsertParams{
+			OrderID:     order.OrderID,
+			OrderLineID: orderLineID,
+			Term:        TermDepot,
+			Val:         a.Depot,
+		})
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		err = q.CatQtyAdd(ctx, sqlite.CatQtyAddParams{
+			Qty:   -a.Qty,
+			SKU:   a.SKU,
+			Depot: a.Depot,
+		})
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		if !slices.Contains(depots, a.Depot) {
+			depots = append(depots, a.Depot)
+		}
+		splits[a.OrderLineID] = append(splits[a.OrderLineID],
+			lineQty{orderLineID: orderLineID, qty: a.Qty})
+	}
+
+	err = splitTax(ctx, q, order.OrderID, splits)
+	if err != nil {
+		return err
+	}
+
+	return orderAct(ctx, q, order, userID, true,
+		fmt.Sprintf("Allocated to depot %s", strings.Join(depots, ", ")))
+}
+
+// lineQty is the qty on an order line after splitting
+type lineQty struct {
+	orderLineID string
+	qty         int64
+}
+
+// splitTax allocates the order_tax rows of split lines to the new lines
+// by qty. Tax was calculated on the discounted line amount,
+// and discounts are allocated to lines by amount when the invoice is made,
+// so the tax and discount for each part is in proportion to the qty
+func splitTax(
+	ctx context.Context, q *sqlite.Queries, orderID string,
+	splits map[string][]lineQty) (err error) {
+
+	split := false
+	for _, parts := range splits {
+		if len(parts) > 1 {
+			split = true
+		}
+	}
+	if !split {
+		return nil
+	}
+	taxes, err := q.OrderTaxByOrderID(ctx, orderID)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	rows := make([]TaxRow, 0, len(taxes))
+	for _, tax := range taxes {
+		row := TaxRow{
+			OrderLineID: tax.OrderLineID,
+			Fixed:       tax.Fixed == 1,
+			Pct:         tax.Pct,
+			Tax:         money.ExactFromFloat(tax.Tax),
+			Vat:         tax.Vat == 1,
+			Descr:       tax.Descr,
+		}
+		parts := splits[tax.OrderLineID]
+		if len(parts) < 2 {
+			rows = append(rows, row)
+			continue
+		}
+		weights := make([]int64, len(parts))
+		for i, part := range parts {
+			weights[i] = part.qty
+		}
+		for i, exact := range row.Tax.Allocate(weights...) {
+			rows = append(rows, TaxRow{
+				OrderLineID: parts[i].orderLineID,
+				Fixed:       row.Fixed,
+				Pct:         row.Pct,
+				Tax:         exact,
+				Vat:         row.Vat,
+				Descr:       row.Descr,
+			})
+		}
+	}
+	return saveTax(ctx, q, orderID, rows)
+}
+
+// ReleaseOrder returns reserved stock to the allocated depots,
+// and removes the allocations from the order lines
+func (m *Model) ReleaseOrder(
+	ctx context.Context, orderID, userID string) (err error) {
+
+	return m.tx(ctx, func(q *sqlite.Queries) error {
+		return releaseOrder(ctx, q, orderID, userID)
+	})
+}
+
+func releaseOrder(
+	ctx context.Context, q *sqlite.Queries, orderID, userID string) (err error) {
+
+	order, err := orderByID(ctx, q, orderID)
+	if err != nil {
+		return err
+	}
+	allocated, err := allocatedLines(ctx, q, orderID)
+	if err != nil {
+		return err
+	}
+	if len(allocated) == 0 {
+		return nil
+	}
+	lines, err := q.OrderLinesByOrderID(ctx, orderID)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	// Stock may have been returned by credit notes
+	restocked, err := restockedLines(ctx, q, orderID)
+	if err != nil {
+		return err
+	}
+	for _, line := range lines {
+		depot, ok := allocated[line.OrderLineID]
+		if !ok {
+			continue
+		}
+		err = q.CatQtyAdd(ctx, sqlite.CatQtyAddParams{
+			Qty:   line.Qty - restocked[line.OrderLineID],
+			SKU:   line.SKU,
+			Depot: depot,
+		})
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		err = q.OrderConfigDelete(ctx, sqlite.OrderConfigDeleteParams{
+			OrderID:     orderID,
+			OrderLineID: line.OrderLineID,
+			Term:        TermDepot,
+		})
+		if err != nil {
+			return errors.WithStack(err)
+		}
+	}
+
+	return orderAct(ctx, q, order, userID, true, "Released stock allocation")
+}
+
+// Depots lists depots in order of preference
+func (m *Model) Depots(ctx context.Context) (depots []share.Depot, err error) {
+	rows, err := m.q.DepotList(ctx)
+	if err != nil {
+		return depots, errors.WithStack(err)
+	}
+	depots = make([]share.Depot, 0, len(rows))
+	for _, row := range rows {
+		depots = append(depots, share.Depot{
+			Depot:    row.Depot,
+			Descr:    row.Descr,
+			Province: row.Province,
+		})
+	}
+	return depots, nil
+}
+
+// PickList lists the items to collect at a depot for confirmed orders
+func (m *Model) PickList(
+	ctx context.Context, depot string) (list share.PickList, err error) {
+
+	rows, err := m.q.PickList(ctx, sqlite.PickListParams{
+		Val:   depot,
+		State: share.OrderStateConfirmed,
+	})
+	if err != nil {
+		return list, errors.WithStack(err)
+	}
+
+	list.Depot = depot
+	list.Items = []share.PickItem{}
+	for _, row := range rows {
+		orderNo := row.OrderNo
+		if orderNo == "" {
+			orderNo = row.OrderID
+		}
+		i := len(list.Items) - 1
+		if i < 0 || list.Items[i].Sku != row.SKU {
+			list.Items = append(list.Items, share.PickItem{
+				Sku:   row.SKU,
+				Title: row.Title,
+			})
+			i++
+		}
+		list.Items[i].Qty += row.Qty
+		if !slices.Contains(list.Items[i].OrderNo, orderNo) {
+			list.Items[i].OrderNo = append(list.Items[i].OrderNo, orderNo)
+		}
+	}
+	return list, nil
+}
@@ -0,0 +1,226 @@
+package model_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/matryer/is"
+	"github.com/pkg/errors"
+	"github.com/shopd/shopd/go/model"
+)
+
+func TestAllocate(t *testing.T) {
+	depots := []string{"jhb", "cpt"}
+	provinces := map[string]string{"jhb": "Gauteng", "cpt": "Western Cape"}
+	line := func(id, sku string, qty int64) model.AllocLine {
+		return model.AllocLine{OrderLineID: id, SKU: sku, Qty: qty}
+	}
+	alloc := func(id, sku, depot string, qty int64) model.Allocation {
+		return model.Allocation{OrderLineID: id, SKU: sku, Depot: depot, Qty: qty}
+	}
+
+	for _, tc := range []struct {
+		name      string
+		strategy  string
+		preferred string
+		province  string
+		stock     map[string]map[string]int64
+		lines     []model.AllocLine
+		want      []model.Allocation
+		err       error
+	}{
+		{
+			name:      "preferred depot",
+			preferred: "cpt",
+			stock:     map[string]map[string]int64{"a": {"jhb": 5, "cpt": 10}},
+			lines:     []model.AllocLine{line("l1", "a", 3)},
+			want:      []model.Allocation{alloc("l1", "a", "cpt", 3)},
+		},
+		{
+			name:      "preferred depot without enough stock",
+			preferred: "cpt",
+			stock:     map[string]map[string]int64{"a": {"jhb": 5, "cpt": 1}},
+			lines:     []model.AllocLine{line("l1", "a", 3)},
+			want:      []model.Allocation{alloc("l1", "a", "jhb", 3)},
+		},
+		{
+			name:      "split shipment",
+			preferred: "jhb",
+			stock:     map[string]map[string]int64{"a": {"jhb": 2, "cpt": 2}},
+			lines:     []model.AllocLine{line("l1", "a", 3)},
+			want: []model.Allocation{
+				alloc("l1", "a", "jhb", 2), alloc("l1", "a", "cpt", 1)},
+		},
+		{
+			name:      "lines for the same sku share stock",
+			preferred: "jhb",
+			stock:     map[string]map[string]int64{"a": {"jhb": 4, "cpt": 10}},
+			lines:     []model.AllocLine{line("l1", "a", 3), line("l2", "a", 3)},
+			want: []model.Allocation{
+				alloc("l1", "a", "jhb", 3), alloc("l2", "a", "cpt", 3)},
+		},
+		{
+			name:  "insufficient stock",
+			stock: map[string]map[string]int64{"a": {"jhb": 1, "cpt": 1}},
+			lines: []model.AllocLine{line("l1", "a", 3)},
+			err:   model.ErrInsufficientStock(""),
+		},
+		{
+			name:  "items without stock are not allocated",
+			stock: map[string]map[string]int64{"a": {"jhb": 1}},
+			lines: []model.AllocLine{line("l1", "svc", 1), line("l2", "a", 1)},
+			want:  []model.Allocation{alloc("l2", "a", "jhb", 1)},
+		},
+		{
+			name:  "default depot",
+			stock: map[string]map[string]int64{"a": {"": 5}},
+			lines: []model.AllocLine{line("l1", "a", 3)},
+			want:  []model.Allocation{alloc("l1", "a", "", 3)},
+		},
+		{
+			name:     "nearest depot",
+			strategy: model.AllocNearest,
+			province: "western cape",
+			stock:    map[string]map[string]int64{"a": {"jhb": 5, "cpt": 5}},
+			lines:    []model.AllocLine{line("l1", "a", 3)},
+			want:     []model.Allocation{alloc("l1", "a", "cpt", 3)},
+		},
+		{
+			name:     "fewest depots",
+			strategy: model.AllocFewest,
+			stock: map[string]map[string]int64{
+				"a": {"jhb": 5, "cpt": 5},
+				"b": {"cpt": 5},
+			},
+			lines: []model.AllocLine{line("l1", "a", 2), line("l2", "b", 2)},
+			want: []model.Allocation{
+				alloc("l1", "a", "cpt", 2), alloc("l2", "b", "cpt", 2)},
+		},
+		{
+			name:     "fewest depots split",
+			strategy: model.AllocFewest,
+			stock: map[string]map[string]int64{
+				"a": {"jhb": 1, "cpt": 2},
+				"b": {"cpt": 5},
+			},
+			lines: []model.AllocLine{line("l1", "a", 3), line("l2", "b", 2)},
+			want: []model.Allocation{
+				alloc("l1", "a", "cpt", 2),
+				alloc("l1", "a", "jhb", 1),
+				alloc("l2", "b", "cpt", 2),
+			},
+		},
+		{
+			name:     "invalid strategy",
+			strategy: "random",
+			err:      model.ErrAllocStrategy(""),
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			is := is.New(t)
+			allocs, err := model.Allocate(model.AllocParams{
+				Strategy:  tc.strategy,
+				Depots:    depots,
+				Preferred: tc.preferred,
+				Provinces: provinces,
+				Province:  tc.province,
+				Stock:     tc.stock,
+				Lines:     tc.lines,
+			})
+			if tc.err != nil {
+				is.True(errors.Is(err, tc.err))
+				return
+			}
+			is.NoErr(err)
+			is.Equal(allocs, tc.want)
+		})
+	}
+}
+
+func TestAllocateOrder(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+	m, db := newTestModel(t)
+	exec(t, db,
+		`insert into depot(depot, descr, province, idx, mod, mod_id) values
+		('jhb', 'Johannesburg', 'Gauteng', 0, 'm', 's'),
+		('cpt', 'Cape Town', 'Western Cape', 1, 'm', 's')`,
+		`insert into cat(sku, title, descr, state, mod, mod_id)
+		values ('a', 'Apple', '', 'stock', 'm', 's')`,
+		`insert into cat_qty values ('a', 'jhb', 2), ('a', 'cpt', 3)`,
+		`insert into config(term, val, mod) values ('alloc_depot', 'jhb', 'm')`,
+		`insert into orders values ('o1', '', 'pending', '', 'u1', 0, 'm', 's')`,
+		`insert into order_line values ('l1', 'o1', '', 'a', 100, 4)`,
+		`insert into order_tax(order_id, order_line_id, tax, pct, vat, mod)
+		values ('o1', 'l1', 60, 1500, 1, 't1')`,
+	)
+
+	allocs, err := m.AllocateOrder(ctx, "o1", "admin")
+	is.NoErr(err)
+	is.Equal(len(allocs), 2)
+
+	// The line is split, the remaining qty is on a new line
+	rows, err := db.Query(`select l.order_line_id, l.qty, c.val from order_line l
+		join order_config c on c.order_line_id = l.order_line_id
+		and c.term = 'depot' where l.order_id = 'o1' order by c.val desc`)
+	is.NoErr(err)
+	defer rows.Close()
+	type split struct {
+		qty   int64
+		depot string
+	}
+	splits := []split{}
+	lineIDs := []string{}
+	for rows.Next() {
+		var lineID string
+		s := split{}
+		is.NoErr(rows.Scan(&lineID, &s.qty, &s.depot))
+		splits = append(splits, s)
+		lineIDs = append(lineIDs, lineID)
+	}
+	is.NoErr(rows.Err())
+	is.Equal(splits, []split{{2, "jhb"}, {2, "cpt"}})
+	is.Equal(lineIDs[0], "l1")
+
+	// Tax is split with the line
+	for _, lineID := range lineIDs {
+		var tax float64
+		is.NoErr(db.QueryRow(`select tax from order_tax where order_line_id = ?`,
+			lineID).Scan(&tax))
+		is.Equal(tax, float64(30))
+	}
+
+	// Stock is reserved
+	var jhb, cpt int64
+	is.NoErr(db.QueryRow(
+		`select qty from cat_qty where sku = 'a' and depot = 'jhb'`).Scan(&jhb))
+	is.NoErr(db.QueryRow(
+		`select qty from cat_qty where sku = 'a' and depot = 'cpt'`).Scan(&cpt))
+	is.Equal(jhb, int64(0))
+	is.Equal(cpt, int64(1))
+
+	// Allocated lines are skipped
+	allocs, err = m.AllocateOrder(ctx, "o1", "admin")
+	is.NoErr(err)
+	is.Equal(len(allocs), 0)
+}
+
+func TestAllocateOrderInsufficientStock(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+	m, db := newTestModel(t)
+	exec(t, db,
+		`insert into cat(sku, title, descr, state, mod, mod_id)
+		values ('a', 'Apple', '', 'stock', 'm', 's')`,
+		`insert into cat_qty values ('a', '', 3)`,
+		`insert into orders values ('o1', '', 'pending', '', 'u1', 0, 'm', 's')`,
+		`insert into order_line values ('l1', 'o1', '', 'a', 100, 4)`,
+	)
+	_, err := m.AllocateOrder(ctx, "o1", "admin")
+	is.True(errors.Is(err, model.ErrInsufficientStock("")))
+
+	// Nothing is reserved
+	var qty int64
+	is.NoErr(db.QueryRow(`select qty from cat_qty where sku = 'a'`).Scan(&qty))
+	is.Equal(qty, int64(3))
+}
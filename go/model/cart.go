@@ -0,0 +1,649 @@
+package model
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/shopd/shopd/go/db/sqlite"
+	"github.com/shopd/shopd/go/money"
+	"github.com/shopd/shopd/go/share"
+)
+
+// Carts are orders in the cart state.
+// Guest carts have an empty user_id, they are identified by a signed cookie,
+// and converted to the user's cart when the session is verified.
+// The price is a snapshot taken when the sku is added to the cart,
+// order_line_id is used to sort lines by snapshot time.
+// Lines for skus with quantity breaks are priced again when the qty changes.
+// Discount lines are replaced on every change, see discount.go
+
+// Cart fetches the cart, returns ErrNotFound if the order is not a cart,
+// or the cart belongs to another user
+func (m *Model) Cart(
+	ctx context.Context, orderID, userID string) (cart share.Cart, err error) {
+
+	order, err := userCartByID(ctx, m.q, orderID, userID)
+	if err != nil {
+		return cart, err
+	}
+	return cartLines(ctx, m.q, order)
+}
+
+// CartAdd adds qty of sku to the cart. A new cart is created
+// if orderID is empty, or the order is no longer a cart.
+// Set userID to empty for guest carts
+func (m *Model) CartAdd(
+	ctx context.Context, orderID, userID string, params share.ParamsCartPost) (
+	cart share.Cart, err error) {
+
+	if params.Qty <= 0 {
+		return cart, errors.WithStack(ErrInvalidQty(params.Qty))
+	}
+	err = m.tx(ctx, func(q *sqlite.Queries) error {
+		order, err := cartForUser(ctx, q, orderID, userID)
+		if err != nil {
+			return err
+		}
+		orderID = order.OrderID
+
+		item, err := q.CatBySKU(ctx, params.Sku)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return errors.WithStack(ErrNotFound(params.Sku))
+			}
+			return errors.WithStack(err)
+		}
+		if item.State != share.CatStateStock {
+			return errors.WithStack(ErrUnavailable(params.Sku))
+		}
+		lines, err := q.OrderLinesByOrderID(ctx, order.OrderID)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		for _, line := range lines {
+			if line.SKU == params.Sku {
+				err = cartLineQty(ctx, q, order, line, line.Qty+params.Qty)
+				if err != nil {
+					return err
+				}
+				return cartTouch(ctx, q, order, userID)
+			}
+		}
+
+		price, err := skuPrice(
+			ctx, q, params.Sku, order.UserID, params.Qty, time.Now())
+		if err != nil {
+			return err
+		}
+		err = q.OrderLineInsert(ctx, sqlite.OrderLineInsertParams{
+			OrderLineID: NewID(),
+			OrderID:     order.OrderID,
+			SKU:         params.Sku,
+			Price:       price,
+			Qty:         params.Qty,
+		})
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		return cartTouch(ctx, q, order, userID)
+	})
+	if err != nil {
+		return cart, err
+	}
+	return m.Cart(ctx, orderID, userID)
+}
+
+// CartUpdate sets the qty for a cart line, zero removes the line
+func (m *Model) CartUpdate(
+	ctx context.Context, orderID, userID string, params share.ParamsCartPatch) (
+	cart share.Cart, err error) {
+
+	if params.Qty < 0 {
+		return cart, errors.WithStack(ErrInvalidQty(params.Qty))
+	}
+	err = m.tx(ctx, func(q *sqlite.Queries) error {
+		order, err := userCartByID(ctx, q, orderID, userID)
+		if err != nil {
+			return err
+		}
+		lines, err := q.OrderLinesByOrderID(ctx, order.OrderID)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		i := slices.IndexFunc(lines, func(line sqlite.OrderLine) bool {
+			// System lines can't be updated
+			return line.OrderLineID == params.OrderLineID && !systemSku(line.SKU)
+		})
+		if i < 0 {
+			return errors.WithStack(ErrNotFound(params.OrderLineID))
+		}
+
+		if params.Qty == 0 {
+			err = q.OrderLineDelete(ctx, params.OrderLineID)
+			if err != nil {
+				return errors.WithStack(err)
+			}
+		} else {
+			err = cartLineQty(ctx, q, order, lines[i], params.Qty)
+			if err != nil {
+				return err
+			}
+		}
+		return cartTouch(ctx, q, order, userID)
+	})
+	if err != nil {
+		return cart, err
+	}
+	return m.Cart(ctx, orderID, userID)
+}
+
+// CartMerge converts the guest cart to the user's cart,
+// it must be called when the user's session is verified.
+// If the user already has a cart the guest cart lines are moved to it,
+// duplicate skus are combined. Lines are priced again for the user,
+// e.g. with the user's price lists. The guest's coupon and voucher codes are kept if the user's cart
+// doesn't have codes. Returns the user's cart, orderID may be empty
+func (m *Model) CartMerge(
+	ctx context.Context, orderID, userID string) (cart share.Cart, err error) {
+
+	err = m.tx(ctx, func(q *sqlite.Queries) error {
+		guest, err := cartByID(ctx, q, orderID)
+		if err != nil {
+			if !errors.Is(err, ErrNotFound("")) {
+				return err
+			}
+			// Guest cart expired or checked out
+			guest = sqlite.Orders{}
+		}
+		if guest.UserID != "" && guest.UserID != userID {
+			// Don't claim carts that belong to another user
+			guest = sqlite.Orders{}
+		}
+
+		order, err := q.OrderByUserIDState(ctx, sqlite.OrderByUserIDStateParams{
+			UserID: userID,
+			State:  share.OrderStateCart,
+		})
+		if err != nil {
+			if !errors.Is(err, sql.ErrNoRows) {
+				return errors.WithStack(err)
+			}
+			if guest.OrderID == "" {
+				// Neither the guest nor the user has a cart
+				return nil
+			}
+			// Convert the guest cart
+			err = q.OrderUpdateUserID(ctx, sqlite.OrderUpdateUserIDParams{
+				UserID:  userID,
+				OrderID: guest.OrderID,
+			})
+			if err != nil {
+				return errors.WithStack(err)
+			}
+			orderID = guest.OrderID
+			guest.UserID = userID
+			err = cartReprice(ctx, q, guest)
+			if err != nil {
+				return err
+			}
+			return cartTouch(ctx, q, guest, userID)
+		}
+
+		orderID = order.OrderID
+		if guest.OrderID == "" || guest.OrderID == order.OrderID {
+			return nil
+		}
+		err = cartMergeLines(ctx, q, guest, order)
+		if err != nil {
+			return err
+		}
+		err = cartMergeCodes(ctx, q, guest, order)
+		if err != nil {
+			return err
+		}
+		err = cartDelete(ctx, q, guest, order)
+		if err != nil {
+			return err
+		}
+		err = cartReprice(ctx, q, order)
+		if err != nil {
+			return err
+		}
+		err = orderAct(ctx, q, order, userID, false,
+			fmt.Sprintf("Merged guest cart %s", guest.OrderID))
+		if err != nil {
+			return err
+		}
+		return cartTouch(ctx, q, order, userID)
+	})
+	if err != nil {
+		return cart, err
+	}
+	if orderID == "" {
+		return cart, nil
+	}
+	return m.Cart(ctx, orderID, userID)
+}
+
+// cartMergeLines moves lines from the guest cart to the user's cart
+func cartMergeLines(
+	ctx context.Context, q *sqlite.Queries, guest, order sqlite.Orders) (
+	err error) {
+
+	// Discounts are applied again to the user's cart
+	err = discountDelete(ctx, q, guest.OrderID)
+	if err != nil {
+		return err
+	}
+	lines, err := q.OrderLinesByOrderID(ctx, order.OrderID)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	existing := make(map[string]sqlite.OrderLine)
+	for _, line := range lines {
+		existing[line.SKU] = line
+	}
+
+	guestLines, err := q.OrderLinesByOrderID(ctx, guest.OrderID)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	for _, line := range guestLines {
+		keep, drop := line, sqlite.OrderLine{}
+		if prev, ok := existing[line.SKU]; ok {
+			qty := prev.Qty + line.Qty
+			// KSUIDs sort by creation time
+			if prev.OrderLineID > line.OrderLineID {
+				keep, drop = prev, line
+			} else {
+				drop = prev
+			}
+			keep.Qty = qty
+		}
+		if drop.OrderLineID != "" {
+			err = q.OrderLineDelete(ctx, drop.OrderLineID)
+			if err != nil {
+				return errors.WithStack(err)
+			}
+			err = cartLineQty(ctx, q, order, keep, keep.Qty)
+			if err != nil {
+				return err
+			}
+		}
+		if keep.OrderID != order.OrderID {
+			err = q.OrderLineUpdateOrderID(ctx, sqlite.OrderLineUpdateOrderIDParams{
+				OrderID:     order.OrderID,
+				OrderLineID: keep.OrderLineID,
+			})
+			if err != nil {
+				return errors.WithStack(err)
+			}
+			keep.OrderID = order.OrderID
+		}
+		existing[line.SKU] = keep
+	}
+	return nil
+}
+
+// cartMergeCodes copies the coupon and voucher codes from the guest cart,
+// unless the user's cart has a code of the same type.
+// Usage limits are checked again when the order is priced
+func cartMergeCodes(
+	ctx context.Context, q *sqlite.Queries, guest, order sqlite.Orders) (
+	err error) {
+
+	for _, term := range []string{TermCoupon, TermVoucher} {
+		code, err := orderConfigVal(ctx, q, guest.OrderID, term)
+		if err != nil {
+			return err
+		}
+		if code == "" {
+			continue
+		}
+		existing, err := orderConfigVal(ctx, q, order.OrderID, term)
+		if err != nil {
+			return err
+		}
+		if existing != "" {
+			continue
+		}
+		err = q.OrderConfigUpsert(ctx, sqlite.OrderConfigUpsertParams{
+			OrderID: order.OrderID,
+			Term:    term,
+			Val:     code,
+		})
+		if err != nil {
+			return errors.WithStack(err)
+		}
+	}
+	return nil
+}
+
+// cartDelete removes the guest cart after the lines were merged,
+// activity entries are moved to the user's cart
+func cartDelete(
+	ctx context.Context, q *sqlite.Queries, guest, order sqlite.Orders) (
+	err error) {
+
+	err = q.OrderConfigDeleteByOrderID(ctx, guest.OrderID)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	err = q.CouponUseDelete(ctx, guest.OrderID)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	err = q.OrderActUpdateOrderID(ctx, sqlite.OrderActUpdateOrderIDParams{
+		OrderID:   order.OrderID,
+		OrderID_2: guest.OrderID,
+	})
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	err = q.OrderDelete(ctx, guest.OrderID)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	return nil
+}
+
+// cartLineQty sets the qty for the line,
+// lines for skus with quantity breaks are priced again
+func cartLineQty(
+	ctx context.Context, q *sqlite.Queries, order sqlite.Orders,
+	line sqlite.OrderLine, qty int64) (err error) {
+
+	err = q.OrderLineUpdateQty(ctx, sqlite.OrderLineUpdateQtyParams{
+		Qty:         qty,
+		OrderLineID: line.OrderLineID,
+	})
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	tiers, err := q.CatPriceTiersBySKU(ctx, line.SKU)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	if len(tiers) == 0 {
+		// Keep the price snapshot
+		return nil
+	}
+	price, err := skuPrice(ctx, q, line.SKU, order.UserID, qty, time.Now())
+	if err != nil {
+		return err
+	}
+	err = q.OrderLineUpdatePrice(ctx, sqlite.OrderLineUpdatePriceParams{
+		Price:       price,
+		OrderLineID: line.OrderLineID,
+	})
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	return nil
+}
+
+// cartReprice sets the price snapshot of each line to the price for the
+// cart owner, e.g. guest lines get the user's price list prices.
+// Lines for skus that are no longer priced keep the snapshot
+func cartReprice(
+	ctx context.Context, q *sqlite.Queries, order sqlite.Orders) (err error) {
+
+	lines, err := q.OrderLinesByOrderID(ctx, order.OrderID)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	now := time.Now()
+	for _, line := range lines {
+		if systemSku(line.SKU) {
+			continue
+		}
+		price, err := skuPrice(ctx, q, line.SKU, order.UserID, line.Qty, now)
+		if errors.Is(err, ErrUnavailable("")) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if price == line.Price {
+			continue
+		}
+		err = q.OrderLineUpdatePrice(ctx, sqlite.OrderLineUpdatePriceParams{
+			Price:       price,
+			OrderLineID: line.OrderLineID,
+		})
+		if err != nil {
+			return errors.WithStack(err)
+		}
+	}
+	return nil
+}
+
+// cartByID fetches the order, or returns ErrNotFound if it's not a cart
+func cartByID(
+	ctx context.Context, q *sqlite.Queries, orderID string) (
+	order sqlite.Orders, err error) {
+
+	order, err = orderByID(ctx, q, orderID)
+	if err != nil {
+		return order, err
+	}
+	if order.State != share.OrderStateCart {
+		return order, errors.WithStack(ErrNotFound(orderID))
+	}
+	return order, nil
+}
+
+// userCartByID fetches the cart for the session user,
+// returns ErrNotFound if the cart belongs to another user.
+// Guest carts are identified by the signed cart cookie only
+func userCartByID(
+	ctx context.Context, q *sqlite.Queries, orderID, userID string) (
+	order sqlite.Orders, err error) {
+
+	order, err = cartByID(ctx, q, orderID)
+	if err != nil {
+		return order, err
+	}
+	if order.UserID != "" && order.UserID != userID {
+		return order, errors.WithStack(ErrNotFound(orderID))
+	}
+	return order, nil
+}
+
+// cartForUser returns the cart to add items to,
+// a new cart is created if required
+func cartForUser(
+	ctx context.Context, q *sqlite.Queries, orderID, userID string) (
+	order sqlite.Orders, err error) {
+
+	order, err = cartByID(ctx, q, orderID)
+	if err == nil && (order.UserID == "" || order.UserID == userID) {
+		return order, nil
+	}
+	if err != nil && !errors.Is(err, ErrNotFound("")) {
+		return order, err
+	}
+
+	if userID != "" {
+		order, err = q.OrderByUserIDState(ctx, sqlite.OrderByUserIDStateParams{
+			UserID: userID,
+			State:  share.OrderStateCart,
+		})
+		if err == nil {
+			return order, nil
+		}
+		if !errors.Is(err, sql.ErrNoRows) {
+			return order, errors.WithStack(err)
+		}
+	}
+
+	order = sqlite.Orders{
+		OrderID: NewID(),
+		State:   share.OrderStateCart,
+		UserID:  userID,
+		Mod:     NewID(),
+		ModID:   modID(userID),
+	}
+	err = q.OrderInsert(ctx, sqlite.OrderInsertParams{
+		OrderID: order.OrderID,
+		State:   order.State,
+		UserID:  order.UserID,
+		Mod:     order.Mod,
+		ModID:   order.ModID,
+	})
+	if err != nil {
+		return order, errors.WithStack(err)
+	}
+	return order, nil
+}
+
+// cartTouch updates the mod cols used to expire idle carts,
+// resets the reminder, and applies discounts
+func cartTouch(
+	ctx context.Context, q *sqlite.Queries, order sqlite.Orders, userID string) (
+	err error) {
+
+	err = q.OrderTouch(ctx, sqlite.OrderTouchParams{
+		Mod:     NewID(),
+		ModID:   modID(userID),
+		OrderID: order.OrderID,
+	})
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	err = q.OrderConfigDelete(ctx, sqlite.OrderConfigDeleteParams{
+		OrderID: order.OrderID,
+		Term:    TermCartReminded,
+	})
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	_, err = discountOrder(ctx, q, order, time.Now())
+	return err
+}
+
+func cartLines(
+	ctx context.Context, q *sqlite.Queries, order sqlite.Orders) (
+	cart share.Cart, err error) {
+
+	cart.OrderID = order.OrderID
+	cart.UserID = order.UserID
+	cart.Currency, err = configVal(ctx, q, TermCurrency, CurrencyDefault)
+	if err != nil {
+		return cart, err
+	}
+	lines, err := q.OrderLinesWithTitle(ctx, order.OrderID)
+	if err != nil {
+		return cart, errors.WithStack(err)
+	}
+	descr, err := systemDescr(ctx, q, order.OrderID)
+	if err != nil {
+		return cart, err
+	}
+	subtotal := money.New(0, cart.Currency)
+	cart.Lines = make([]share.CartLine, 0, len(lines))
+	for _, line := range lines {
+		amount := money.New(line.Price, cart.Currency).Multiply(line.Qty)
+		subtotal, err = subtotal.Add(amount)
+		if err != nil {
+			return cart, err
+		}
+		cart.Lines = append(cart.Lines, share.CartLine{
+			OrderLineID: line.OrderLineID,
+			Sku:         line.SKU,
+			Title:       line.Title,
+			Price:       line.Price,
+			Qty:         line.Qty,
+			Amount:      amount.Amount(),
+			System:      systemSku(line.SKU),
+			Descr:       descr[line.OrderLineID],
+		})
+	}
+	cart.Subtotal = subtotal.Amount()
+	err = cartTax(ctx, q, order, &cart)
+	if err != nil {
+		return cart, err
+	}
+
+	cart.Coupon, err = orderConfigVal(ctx, q, order.OrderID, TermCoupon)
+	if err != nil {
+		return cart, err
+	}
+	cart.Voucher, err = orderConfigVal(ctx, q, order.OrderID, TermVoucher)
+	if err != nil {
+		return cart, err
+	}
+	if cart.Voucher != "" {
+		voucher, err := q.VoucherByCode(ctx, cart.Voucher)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return cart, errors.WithStack(err)
+		}
+		cart.VoucherBalance = voucher.Balance
+	}
+	return cart, nil
+}
+
+// cartTax sets the tax and total for the cart,
+// line amounts and the subtotal include tax if the display is inclusive
+func cartTax(
+	ctx context.Context, q *sqlite.Queries, order sqlite.Orders,
+	cart *share.Cart) (err error) {
+
+	cart.TaxDisplay, err = priceDisplay(ctx, q)
+	if err != nil {
+		return err
+	}
+	params, err := discountParams(ctx, q, order, time.Now())
+	if err != nil {
+		return err
+	}
+	totals, _, err := orderTotals(ctx, q, order, params)
+	if err != nil {
+		return err
+	}
+	cart.Tax = totals.Tax
+	cart.Total = money.Sum(cart.Currency, cart.Subtotal, cart.Tax).Amount()
+	if cart.TaxDisplay == share.PriceDisplayExclusive {
+		return nil
+	}
+
+	lines, err := taxLines(ctx, q, order.OrderID)
+	if err != nil {
+		return err
+	}
+	amounts, err := inclusiveLines(ctx, q, order.OrderID, lines, cart.Tax)
+	if err != nil {
+		return err
+	}
+	mode, _, err := rounding(ctx, q)
+	if err != nil {
+		return err
+	}
+	cart.Subtotal = 0
+	for i, line := range cart.Lines {
+		if amount, ok := amounts[line.OrderLineID]; ok {
+			cart.Lines[i].Amount = amount
+			cart.Lines[i].Price = unitPrice(amount, line.Qty, mode)
+		}
+		cart.Subtotal += cart.Lines[i].Amount
+	}
+	return nil
+}
+
+// systemSku returns true for skus that are added to orders by the system
+func systemSku(sku string) bool {
+	return sku == SkuDiscount || sku == SkuVoucher
+}
+
+// modID returns the mod_id for changes made by userID,
+// guest changes are recorded as system changes
+func modID(userID string) string {
+	if userID == "" {
+		return ModIDSystem
+	}
+	return userID
+}
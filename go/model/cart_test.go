@@ -0,0 +1,103 @@
+package model_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/matryer/is"
+	"github.com/pkg/errors"
+	"github.com/shopd/shopd/go/model"
+	"github.com/shopd/shopd/go/share"
+)
+
+func TestCartOwnership(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+	m, db := newTestModel(t)
+	exec(t, db,
+		`insert into cat(sku, title, descr, state, mod, mod_id)
+		values ('a', 'Apple', '', 'stock', 'm', 's')`,
+		`insert into cat_price values ('a', 100)`,
+		`insert into voucher values ('GIFT', 500, 500, 'm', 's')`,
+	)
+	cart, err := m.CartAdd(ctx, "", "u1", share.ParamsCartPost{Sku: "a", Qty: 1})
+	is.NoErr(err)
+	orderID := cart.OrderID
+	lineID := cart.Lines[0].OrderLineID
+
+	// Guests and other users can't see or change the cart
+	for _, userID := range []string{"", "u2"} {
+		_, err = m.Cart(ctx, orderID, userID)
+		is.True(errors.Is(err, model.ErrNotFound("")))
+		_, err = m.CartUpdate(ctx, orderID, userID,
+			share.ParamsCartPatch{OrderLineID: lineID, Qty: 5})
+		is.True(errors.Is(err, model.ErrNotFound("")))
+		_, err = m.CartCode(ctx, orderID, userID, "gift")
+		is.True(errors.Is(err, model.ErrNotFound("")))
+		_, err = m.CartCodeRemove(ctx, orderID, userID, "gift")
+		is.True(errors.Is(err, model.ErrNotFound("")))
+	}
+
+	// Adding to another user's cart creates a new cart
+	other, err := m.CartAdd(ctx, orderID, "u2", share.ParamsCartPost{Sku: "a", Qty: 1})
+	is.NoErr(err)
+	is.True(other.OrderID != orderID)
+
+	cart, err = m.CartCode(ctx, orderID, "u1", "gift")
+	is.NoErr(err)
+	is.Equal(cart.Voucher, "GIFT")
+	cart, err = m.CartUpdate(ctx, orderID, "u1",
+		share.ParamsCartPatch{OrderLineID: lineID, Qty: 2})
+	is.NoErr(err)
+	is.Equal(cart.Lines[0].Qty, int64(2))
+
+	// Guest carts are identified by the cart cookie
+	guest, err := m.CartAdd(ctx, "", "", share.ParamsCartPost{Sku: "a", Qty: 1})
+	is.NoErr(err)
+	_, err = m.Cart(ctx, guest.OrderID, "")
+	is.NoErr(err)
+}
+
+func TestCartMerge(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+	m, db := newTestModel(t)
+	exec(t, db,
+		`insert into cat(sku, title, descr, state, mod, mod_id) values
+		('a', 'Apple', '', 'stock', 'm', 's'),
+		('b', 'Banana', '', 'stock', 'm', 's')`,
+		`insert into cat_price values ('a', 100), ('b', 50)`,
+		`insert into voucher values ('GIFT', 500, 500, 'm', 's')`,
+	)
+	guest, err := m.CartAdd(ctx, "", "", share.ParamsCartPost{Sku: "a", Qty: 1})
+	is.NoErr(err)
+	_, err = m.CartAdd(ctx, guest.OrderID, "", share.ParamsCartPost{Sku: "b", Qty: 1})
+	is.NoErr(err)
+	_, err = m.CartCode(ctx, guest.OrderID, "", "gift")
+	is.NoErr(err)
+	user, err := m.CartAdd(ctx, "", "u1", share.ParamsCartPost{Sku: "a", Qty: 2})
+	is.NoErr(err)
+
+	cart, err := m.CartMerge(ctx, guest.OrderID, "u1")
+	is.NoErr(err)
+	is.Equal(cart.OrderID, user.OrderID)
+	qty := make(map[string]int64)
+	for _, line := range cart.Lines {
+		qty[line.Sku] = line.Qty
+	}
+	is.Equal(qty, map[string]int64{"a": 3, "b": 1})
+	// The voucher code is kept
+	is.Equal(cart.Voucher, "GIFT")
+
+	// The guest cart is deleted with its config
+	var n int
+	is.NoErr(db.QueryRow(`select count(*) from orders where order_id = ?`,
+		guest.OrderID).Scan(&n))
+	is.Equal(n, 0)
+	is.NoErr(db.QueryRow(`select count(*) from order_config where order_id = ?`,
+		guest.OrderID).Scan(&n))
+	is.Equal(n, 0)
+	is.NoErr(db.QueryRow(`select count(*) from order_act where order_id = ?`,
+		guest.OrderID).Scan(&n))
+	is.Equal(n, 0)
+}
@@ -0,0 +1,604 @@
+package model
+
+import (
+	"context"
+	"database/sql"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/shopd/shopd/go/db/sqlite"
+	"github.com/shopd/shopd/go/share"
+)
+
+// The catalog CSV has one row per sku, headers are case-insensitive.
+// Columns are sku, title, descr, state, price, tags, and group for the
+// variant group. The qty column is stock without a depot, stock per depot
+// is in columns with the depot prefix, e.g. qty:jhb.
+// Other columns are cat_config terms.
+// Columns that are not in the file are not changed. Empty cells don't
+// change the title, descr, state, price, or qty. Tags, group, and config
+// are optional, an empty cell removes the value.
+// The export lists all columns, so it may be edited and imported again
+
+// catalogQtyPrefix for qty columns per depot
+const catalogQtyPrefix = "qty:"
+
+// catalogTagSep separates tags in the tags column
+const catalogTagSep = ";"
+
+// catalogCols are the columns that are not config terms, in export order
+var catalogCols = []string{"sku", "title", "descr", "state", "price", "qty"}
+
+// CatalogImport creates or updates catalog items by sku.
+// Rows are validated first, and nothing is imported if there are errors.
+// A dry run reports the errors and changes without importing
+func (m *Model) CatalogImport(
+	ctx context.Context, r io.Reader, dryRun bool, userID string) (
+	result share.CatalogImport, err error) {
+
+	result.DryRun = dryRun
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+	record, err := reader.Read()
+	if err != nil {
+		return result, errors.WithStack(ErrInvalidParam("header"))
+	}
+
+	err = m.tx(ctx, func(q *sqlite.Queries) error {
+		depots, err := catalogDepots(ctx, q)
+		if err != nil {
+			return err
+		}
+		header, err := catalogHeader(record, depots)
+		if err != nil {
+			return err
+		}
+		states, err := q.CatStateList(ctx)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+
+		rows := []catalogRow{}
+		seen := make(map[string]bool)
+		for {
+			record, err := reader.Read()
+			if err == io.EOF {
+				break
+			}
+			var parseErr *csv.ParseError
+			if errors.As(err, &parseErr) {
+				result.Rows++
+				result.Errors = append(result.Errors, share.CatalogRowError{
+					Line:  parseErr.StartLine,
+					Error: parseErr.Err.Error(),
+				})
+				continue
+			}
+			if err != nil {
+				return errors.WithStack(err)
+			}
+			result.Rows++
+			line, _ := reader.FieldPos(0)
+			row, rowErrs, err := catalogValidate(ctx, q, header, states, line, record)
+			if err != nil {
+				return err
+			}
+			if seen[row.cat.SKU] {
+				rowErrs = append(rowErrs, catalogErr(row, "sku", "duplicate sku"))
+			}
+			seen[row.cat.SKU] = true
+			if len(rowErrs) > 0 {
+				result.Errors = append(result.Errors, rowErrs...)
+				continue
+			}
+			if !row.changes() {
+				continue
+			}
+			if row.exists {
+				result.Updated++
+			} else {
+				result.Created++
+			}
+			result.Skus = append(result.Skus, row.cat.SKU)
+			rows = append(rows, row)
+		}
+		if dryRun || len(result.Errors) > 0 {
+			return nil
+		}
+
+		for _, row := range rows {
+			err = catalogUpsert(ctx, q, row, userID)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return result, err
+	}
+	return result, nil
+}
+
+// CatalogExport writes the catalog CSV, system items are not exported
+func (m *Model) CatalogExport(ctx context.Context, w io.Writer) (err error) {
+	items, err := m.q.CatList(ctx)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	prices := make(map[string]int64)
+	priceRows, err := m.q.CatPriceList(ctx)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	for _, row := range priceRows {
+		prices[row.SKU] = row.Price
+	}
+	depots, err := catalogDepots(ctx, m.q)
+	if err != nil {
+		return err
+	}
+	qty := make(map[string]map[string]int64)
+	qtyRows, err := m.q.CatQtyList(ctx)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	for _, row := range qtyRows {
+		if qty[row.SKU] == nil {
+			qty[row.SKU] = make(map[string]int64)
+		}
+		qty[row.SKU][row.Depot] = row.Qty
+	}
+	tags := make(map[string][]string)
+	tagRows, err := m.q.CatTagList(ctx)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	for _, row := range tagRows {
+		tags[row.SKU] = append(tags[row.SKU], row.Tag)
+	}
+	groups := make(map[string]string)
+	variantRows, err := m.q.VariantList(ctx)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	for _, row := range variantRows {
+		if _, ok := groups[row.SKU]; !ok {
+			groups[row.SKU] = row.GroupID
+		}
+	}
+	config := make(map[string]map[string]string)
+	terms := []string{}
+	configRows, err := m.q.CatConfigList(ctx)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	for _, row := range configRows {
+		if config[row.SKU] == nil {
+			config[row.SKU] = make(map[string]string)
+		}
+		config[row.SKU][row.Term] = row.Val
+		if !slices.Contains(terms, row.Term) {
+			terms = append(terms, row.Term)
+		}
+	}
+	sort.Strings(terms)
+
+	writer := csv.NewWriter(w)
+	header := slices.Clone(catalogCols)
+	for _, depot := range depots {
+		if depot != "" {
+			header = append(header, catalogQtyPrefix+depot)
+		}
+	}
+	header = append(header, "tags", "group")
+	header = append(header, terms...)
+	err = writer.Write(header)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	for _, item := range items {
+		if item.State == share.CatStateSystem || systemSku(item.SKU) {
+			continue
+		}
+		record := []string{
+			item.SKU,
+			item.Title,
+			item.Descr,
+			item.State,
+			strconv.FormatInt(prices[item.SKU], 10),
+		}
+		for _, depot := range depots {
+			record = append(record, strconv.FormatInt(qty[item.SKU][depot], 10))
+		}
+		record = append(record,
+			strings.Join(tags[item.SKU], catalogTagSep+" "), groups[item.SKU])
+		for _, term := range terms {
+			record = append(record, config[item.SKU][term])
+		}
+		err = writer.Write(record)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+	}
+	writer.Flush()
+	return errors.WithStack(writer.Error())
+}
+
+// catalogDepots lists depots in order of preference, and depots that
+// are only used by cat_qty. The first depot is empty, for the qty column
+func catalogDepots(
+	ctx context.Context, q *sqlite.Queries) (depots []string, err error) {
+
+	depots = []string{""}
+	rows, err := q.DepotList(ctx)
+	if err != nil {
+		return depots, errors.WithStack(err)
+	}
+	for _, row := range rows {
+		depots = append(depots, row.Depot)
+	}
+	qtyRows, err := q.CatQtyList(ctx)
+	if err != nil {
+		return depots, errors.WithStack(err)
+	}
+	for _, row := range qtyRows {
+		if !slices.Contains(depots, row.Depot) {
+			depots = append(depots, row.Depot)
+		}
+	}
+	return depots, nil
+}
+
+// catalogCSV maps catalog columns to the index in the header
+type catalogCSV struct {
+	cols map[string]int
+	// qty by depot, the qty column is the empty depot
+	qty    map[string]int
+	depots []string
+	// config by term
+	config map[string]int
+	terms  []string
+}
+
+// catalogHeader reads the columns, qty columns must be for known depots
+func catalogHeader(
+	record []string, depots []string) (header catalogCSV, err error) {
+
+	header = catalogCSV{
+		cols:   make(map[string]int),
+		qty:    make(map[string]int),
+		config: make(map[string]int),
+	}
+	seen := make(map[string]bool)
+	for i, col := range record {
+		col = strings.ToLower(strings.TrimSpace(col))
+		if col == "" || seen[col] {
+			return header, errors.WithStack(
+				ErrInvalidParam(fmt.Sprintf("header %d", i+1)))
+		}
+		seen[col] = true
+		switch {
+		case col == "qty":
+			header.qty[""] = i
+			header.depots = append(header.depots, "")
+		case strings.HasPrefix(col, catalogQtyPrefix):
+			depot := strings.TrimPrefix(col, catalogQtyPrefix)
+			if depot == "" || !slices.Contains(depots, depot) {
+				return header, errors.WithStack(
+					ErrInvalidParam(fmt.Sprintf("header %s", col)))
+			}
+			header.qty[depot] = i
+			header.depots = append(header.depots, depot)
+		case slices.Contains(catalogCols, col) || col == "tags" || col == "group":
+			header.cols[col] = i
+		default:
+			header.config[col] = i
+			header.terms = append(header.terms, col)
+		}
+	}
+	if _, ok := header.cols["sku"]; !ok {
+		return header, errors.WithStack(ErrInvalidParam("header"))
+	}
+	return header, nil
+}
+
+// catalogRow is a validated row, only changes are set
+type catalogRow struct {
+	line int
+	// cat is the item with changes from the row
+	cat          sqlite.Cat
+	exists       bool
+	catChanged   bool
+	price        int64
+	priceChanged bool
+	// qty by depot
+	qty         map[string]int64
+	tags        []string
+	tagsChanged bool
+	group       string
+	// groupChanged removes the sku from other groups
+	groupChanged bool
+	// config by term, empty values are removed
+	config map[string]string
+}
+
+func (row catalogRow) changes() bool {
+	return row.catChanged || row.priceChanged || len(row.qty) > 0 ||
+		row.tagsChanged || row.groupChanged || len(row.config) > 0
+}
+
+func catalogErr(row catalogRow, col, msg string) share.CatalogRowError {
+	return share.CatalogRowError{
+		Line:  row.line,
+		Sku:   row.cat.SKU,
+		Col:   col,
+		Error: msg,
+	}
+}
+
+// catalogValidate compares the record to the item in the DB.
+// Discontinued items can't be changed to another state
+func catalogValidate(
+	ctx context.Context, q *sqlite.Queries, header catalogCSV,
+	states []sqlite.CatState, line int, record []string) (
+	row catalogRow, rowErrs []share.CatalogRowError, err error) {
+
+	val := func(col string) (string, bool) {
+		i, ok := header.cols[col]
+		if !ok {
+			return "", false
+		}
+		return strings.TrimSpace(record[i]), true
+	}
+	row = catalogRow{
+		line:   line,
+		qty:    make(map[string]int64),
+		config: make(map[string]string),
+	}
+	row.cat.SKU, _ = val("sku")
+	if row.cat.SKU == "" {
+		return row, append(rowErrs, catalogErr(row, "sku", "required")), nil
+	}
+	if systemSku(row.cat.SKU) {
+		return row, append(rowErrs, catalogErr(row, "sku", "system sku")), nil
+	}
+
+	current, err := q.CatBySKU(ctx, row.cat.SKU)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return row, rowErrs, errors.WithStack(err)
+	}
+	row.exists = err == nil
+	if row.exists {
+		row.cat = current
+		if current.State == share.CatStateSystem {
+			return row, append(rowErrs, catalogErr(row, "sku", "system sku")), nil
+		}
+	} else {
+		row.cat.State = share.CatStateStock
+		row.catChanged = true
+	}
+
+	if title, ok := val("title"); ok && title != "" {
+		row.catChanged = row.catChanged || title != row.cat.Title
+		row.cat.Title = title
+	}
+	if row.cat.Title == "" {
+		rowErrs = append(rowErrs, catalogErr(row, "title", "required"))
+	}
+	if descr, ok := val("descr"); ok && descr != "" {
+		row.catChanged = row.catChanged || descr != row.cat.Descr
+		row.cat.Descr = descr
+	}
+	if state, ok := val("state"); ok && state != "" {
+		state = strings.ToLower(state)
+		valid := slices.ContainsFunc(states, func(s sqlite.CatState) bool {
+			return s.State == state
+		})
+		switch {
+		case !valid:
+			rowErrs = append(rowErrs, catalogErr(row, "state",
+				fmt.Sprintf("invalid state %s", state)))
+		case row.exists && current.State == share.CatStateDiscontinued &&
+			state != share.CatStateDiscontinued:
+			rowErrs = append(rowErrs, catalogErr(row, "state",
+				"discontinued items can't be reactivated"))
+		default:
+			row.catChanged = row.catChanged || state != row.cat.State
+			row.cat.State = state
+		}
+	}
+
+	if price, ok := val("price"); ok && price != "" {
+		row.price, err = strconv.ParseInt(price, 10, 64)
+		if err != nil || row.price < 0 {
+			rowErrs = append(rowErrs, catalogErr(row, "price", "invalid price"))
+		} else {
+			current, err := q.CatPriceBySKU(ctx, row.cat.SKU)
+			if err != nil && !errors.Is(err, sql.ErrNoRows) {
+				return row, rowErrs, errors.WithStack(err)
+			}
+			// Items without a price are exported with price 0
+			row.priceChanged = current.Price != row.price
+		}
+	}
+
+	if len(header.depots) > 0 {
+		current, err := q.CatQtyBySKU(ctx, row.cat.SKU)
+		if err != nil {
+			return row, rowErrs, errors.WithStack(err)
+		}
+		for _, depot := range header.depots {
+			val := strings.TrimSpace(record[header.qty[depot]])
+			if val == "" {
+				continue
+			}
+			qty, err := strconv.ParseInt(val, 10, 64)
+			if err != nil || qty < 0 {
+				col := "qty"
+				if depot != "" {
+					col = catalogQtyPrefix + depot
+				}
+				rowErrs = append(rowErrs, catalogErr(row, col, "invalid qty"))
+				continue
+			}
+			// Depots without stock are exported with qty 0
+			var currentQty int64
+			i := slices.IndexFunc(current, func(c sqlite.CatQty) bool {
+				return c.Depot == depot
+			})
+			if i >= 0 {
+				currentQty = current[i].Qty
+			}
+			if qty != currentQty {
+				row.qty[depot] = qty
+			}
+		}
+	}
+
+	if tags, ok := val("tags"); ok {
+		for _, tag := range strings.Split(tags, catalogTagSep) {
+			tag = strings.TrimSpace(tag)
+			if tag != "" && !slices.Contains(row.tags, tag) {
+				row.tags = append(row.tags, tag)
+			}
+		}
+		sort.Strings(row.tags)
+		current, err := q.CatTagsBySKU(ctx, row.cat.SKU)
+		if err != nil {
+			return row, rowErrs, errors.WithStack(err)
+		}
+		currentTags := make([]string, 0, len(current))
+		for _, c := range current {
+			currentTags = append(currentTags, c.Tag)
+		}
+		row.tagsChanged = !slices.Equal(row.tags, currentTags)
+	}
+
+	if group, ok := val("group"); ok {
+		row.group = group
+		current, err := q.VariantsBySKU(ctx, row.cat.SKU)
+		if err != nil {
+			return row, rowErrs, errors.WithStack(err)
+		}
+		if group == "" {
+			row.groupChanged = len(current) > 0
+		} else {
+			row.groupChanged = len(current) != 1 || current[0].GroupID != group
+		}
+	}
+
+	if len(header.terms) > 0 {
+		current, err := q.CatConfigBySKU(ctx, row.cat.SKU)
+		if err != nil {
+			return row, rowErrs, errors.WithStack(err)
+		}
+		for _, term := range header.terms {
+			val := strings.TrimSpace(record[header.config[term]])
+			i := slices.IndexFunc(current, func(c sqlite.CatConfig) bool {
+				return c.Term == term
+			})
+			if (i < 0 && val != "") || (i >= 0 && current[i].Val != val) {
+				row.config[term] = val
+			}
+		}
+	}
+
+	return row, rowErrs, nil
+}
+
+// catalogUpsert applies the changes for a validated row
+func catalogUpsert(
+	ctx context.Context, q *sqlite.Queries, row catalogRow,
+	userID string) (err error) {
+
+	sku := row.cat.SKU
+	if row.catChanged {
+		err = q.CatUpsert(ctx, sqlite.CatUpsertParams{
+			SKU:   sku,
+			Title: row.cat.Title,
+			Descr: row.cat.Descr,
+			State: row.cat.State,
+			Mod:   NewID(),
+			ModID: modID(userID),
+		})
+		if err != nil {
+			return errors.WithStack(err)
+		}
+	}
+	if row.priceChanged {
+		err = catPriceSet(ctx, q, catPriceChange{
+			sku:     sku,
+			price:   row.price,
+			changed: time.Now().UTC().Format(DateTimeFormat),
+		}, userID)
+		if err != nil {
+			return err
+		}
+	}
+	for depot, qty := range row.qty {
+		err = q.CatQtyUpsert(ctx, sqlite.CatQtyUpsertParams{
+			SKU:   sku,
+			Depot: depot,
+			Qty:   qty,
+		})
+		if err != nil {
+			return errors.WithStack(err)
+		}
+	}
+	if row.tagsChanged {
+		err = q.CatTagDeleteBySKU(ctx, sku)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		for _, tag := range row.tags {
+			err = q.CatTagInsert(ctx, sqlite.CatTagInsertParams{
+				SKU: sku,
+				Tag: tag,
+			})
+			if err != nil {
+				return errors.WithStack(err)
+			}
+		}
+	}
+	if row.groupChanged {
+		err = q.VariantDeleteBySKU(ctx, sku)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		if row.group != "" {
+			err = q.VariantInsert(ctx, sqlite.VariantInsertParams{
+				GroupID: row.group,
+				SKU:     sku,
+			})
+			if err != nil {
+				return errors.WithStack(err)
+			}
+		}
+	}
+	for term, val := range row.config {
+		if val == "" {
+			err = q.CatConfigDelete(ctx, sqlite.CatConfigDeleteParams{
+				SKU:  sku,
+				Term: term,
+			})
+		} else {
+			err = q.CatConfigUpsert(ctx, sqlite.CatConfigUpsertParams{
+				SKU:  sku,
+				Term: term,
+				Val:  val,
+			})
+		}
+		if err != nil {
+			return errors.WithStack(err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,130 @@
+package model_test
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/matryer/is"
+	"github.com/pkg/errors"
+	"github.com/shopd/shopd/go/model"
+	"github.com/shopd/shopd/go/share"
+)
+
+func TestCatalogImportErrors(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+	m, db := newTestModel(t)
+	exec(t, db,
+		`insert into depot(depot, descr, province, idx, mod, mod_id)
+		values ('jhb', 'Johannesburg', 'Gauteng', 0, 'm', 's')`,
+		`insert into cat(sku, title, descr, state, mod, mod_id)
+		values ('pear', 'Pear', '', 'discontinued', 'm', 's')`,
+	)
+	items := func() (n int) {
+		is.NoErr(db.QueryRow(`select count(*) from cat`).Scan(&n))
+		return n
+	}
+	before := items()
+
+	csv := strings.Join([]string{
+		"sku, title, state, price, qty:jhb, tags, colour",
+		"apple, Apple, stock, 1000, 5, fruit; red, red",
+		", No sku, stock, 1, 1, , ",
+		"banana, , stock, 1, 1, , ",
+		"cherry, Cherry, frozen, abc, -1, , ",
+		"pear, , stock, , , , ",
+		"apple, Apple, stock, 1000, 5, , ",
+		model.SkuDiscount + ", Discount, , , , , ",
+		"fig, Fig",
+	}, "\n")
+	// Errors are reported for dry runs, and nothing is imported otherwise
+	for _, dryRun := range []bool{true, false} {
+		result, err := m.CatalogImport(ctx, strings.NewReader(csv), dryRun, "admin")
+		is.NoErr(err)
+		is.Equal(result.DryRun, dryRun)
+		is.Equal(result.Rows, 8)
+		is.Equal(result.Created, 1)
+		is.Equal(result.Skus, []string{"apple"})
+		is.Equal(result.Errors, []share.CatalogRowError{
+			{Line: 3, Col: "sku", Error: "required"},
+			{Line: 4, Sku: "banana", Col: "title", Error: "required"},
+			{Line: 5, Sku: "cherry", Col: "state", Error: "invalid state frozen"},
+			{Line: 5, Sku: "cherry", Col: "price", Error: "invalid price"},
+			{Line: 5, Sku: "cherry", Col: "qty:jhb", Error: "invalid qty"},
+			{Line: 6, Sku: "pear", Col: "state",
+				Error: "discontinued items can't be reactivated"},
+			{Line: 7, Sku: "apple", Col: "sku", Error: "duplicate sku"},
+			{Line: 8, Sku: model.SkuDiscount, Col: "sku", Error: "system sku"},
+			{Line: 9, Error: "wrong number of fields"},
+		})
+		is.Equal(items(), before)
+	}
+
+	// Invalid headers
+	for _, header := range []string{
+		"",
+		"title, price",
+		"sku, title, title",
+		"sku, , price",
+		"sku, qty:cpt",
+		"sku, qty:",
+	} {
+		_, err := m.CatalogImport(ctx, strings.NewReader(header+"\n"), true, "admin")
+		is.True(errors.Is(err, model.ErrInvalidParam("")))
+	}
+}
+
+func TestCatalogImport(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+	m, db := newTestModel(t)
+	exec(t, db,
+		`insert into depot(depot, descr, province, idx, mod, mod_id)
+		values ('jhb', 'Johannesburg', 'Gauteng', 0, 'm', 's')`,
+		`insert into cat(sku, title, descr, state, mod, mod_id)
+		values ('pear', 'Pear', '', 'stock', 'm', 's')`,
+		`insert into cat_price values ('pear', 500)`,
+	)
+	csv := strings.Join([]string{
+		"sku, title, price, qty:jhb, tags, colour",
+		"apple, Apple, 1000, 5, fruit; red, red",
+		"pear, , 600, , fruit, ",
+	}, "\n")
+
+	// A dry run counts the changes
+	result, err := m.CatalogImport(ctx, strings.NewReader(csv), true, "admin")
+	is.NoErr(err)
+	is.Equal(len(result.Errors), 0)
+	is.Equal(result.Created, 1)
+	is.Equal(result.Updated, 1)
+	is.Equal(result.Skus, []string{"apple", "pear"})
+	var n int
+	is.NoErr(db.QueryRow(`select count(*) from cat where sku = 'apple'`).Scan(&n))
+	is.Equal(n, 0)
+
+	result, err = m.CatalogImport(ctx, strings.NewReader(csv), false, "admin")
+	is.NoErr(err)
+	is.Equal(result.Created, 1)
+	is.Equal(result.Updated, 1)
+	var price int64
+	is.NoErr(db.QueryRow(
+		`select price from cat_price where sku = 'pear'`).Scan(&price))
+	is.Equal(price, int64(600))
+	var qty int64
+	is.NoErr(db.QueryRow(
+		`select qty from cat_qty where sku = 'apple' and depot = 'jhb'`).Scan(&qty))
+	is.Equal(qty, int64(5))
+
+	// The export may be imported again without changes
+	var buf bytes.Buffer
+	is.NoErr(m.CatalogExport(ctx, &buf))
+	is.True(strings.HasPrefix(buf.String(),
+		"sku,title,descr,state,price,qty,qty:jhb,tags,group,colour\n"))
+	result, err = m.CatalogImport(ctx, &buf, true, "admin")
+	is.NoErr(err)
+	is.Equal(len(result.Errors), 0)
+	is.Equal(result.Rows, 2)
+	is.Equal(result.Created+result.Updated, 0)
+}
@@ -0,0 +1,290 @@
+package model
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"math/big"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/shopd/shopd/go/db/sqlite"
+	"github.com/shopd/shopd/go/share"
+)
+
+// Coupon codes grant a discount, the coupon discount is added to the
+// discount rules for the order, see discountParams.
+// Usage limits are checked when the code is entered,
+// and again when the order is priced, the use is recorded then.
+// Vouchers are a monetary substitute, see voucher.go
+
+// TermCoupon is the order config term for the coupon code
+const TermCoupon = "coupon"
+
+// codeAlphabet excludes characters that are easily confused, e.g. 0 and O
+const codeAlphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+
+// codeLen is the number of random characters in generated codes
+const codeLen = 10
+
+// CodesMax is the max number of codes generated per batch
+const CodesMax = 1000
+
+// CartCode applies a coupon or voucher code to the cart.
+// A cart may have one coupon and one voucher,
+// entering another code of the same type replaces it
+func (m *Model) CartCode(
+	ctx context.Context, orderID, userID, code string) (
+	cart share.Cart, err error) {
+
+	code = normCode(code)
+	err = m.tx(ctx, func(q *sqlite.Queries) error {
+		order, err := userCartByID(ctx, q, orderID, userID)
+		if err != nil {
+			return err
+		}
+		term, err := codeTerm(ctx, q, order, code)
+		if err != nil {
+			return err
+		}
+		err = q.OrderConfigUpsert(ctx, sqlite.OrderConfigUpsertParams{
+			OrderID: orderID,
+			Term:    term,
+			Val:     code,
+		})
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		return cartTouch(ctx, q, order, userID)
+	})
+	if err != nil {
+		return cart, err
+	}
+	return m.Cart(ctx, orderID, userID)
+}
+
+// CartCodeRemove removes the coupon or voucher code from the cart
+func (m *Model) CartCodeRemove(
+	ctx context.Context, orderID, userID, code string) (
+	cart share.Cart, err error) {
+
+	code = normCode(code)
+	err = m.tx(ctx, func(q *sqlite.Queries) error {
+		order, err := userCartByID(ctx, q, orderID, userID)
+		if err != nil {
+			return err
+		}
+		for _, term := range []string{TermCoupon, TermVoucher} {
+			val, err := orderConfigVal(ctx, q, orderID, term)
+			if err != nil {
+				return err
+			}
+			if val != code {
+				continue
+			}
+			err = q.OrderConfigDelete(ctx, sqlite.OrderConfigDeleteParams{
+				OrderID: orderID,
+				Term:    term,
+			})
+			if err != nil {
+				return errors.WithStack(err)
+			}
+		}
+		return cartTouch(ctx, q, order, userID)
+	})
+	if err != nil {
+		return cart, err
+	}
+	return m.Cart(ctx, orderID, userID)
+}
+
+// codeTerm returns the order config term for a valid code
+func codeTerm(
+	ctx context.Context, q *sqlite.Queries, order sqlite.Orders, code string) (
+	term string, err error) {
+
+	coupon, err := q.CouponByCode(ctx, code)
+	if err == nil {
+		return TermCoupon, couponCheck(ctx, q, coupon, order)
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return term, errors.WithStack(err)
+	}
+
+	voucher, err := q.VoucherByCode(ctx, code)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return term, errors.WithStack(ErrInvalidCode(code))
+		}
+		return term, errors.WithStack(err)
+	}
+	if voucher.Balance <= 0 {
+		return term, errors.WithStack(ErrInvalidCode(code))
+	}
+	return TermVoucher, nil
+}
+
+// GenerateCoupons creates a batch of coupon codes for the discount
+func (m *Model) GenerateCoupons(
+	ctx context.Context, params share.ParamsCouponsPost, userID string) (
+	codes []string, err error) {
+
+	if params.Count <= 0 || params.Count > CodesMax {
+		return codes, errors.WithStack(ErrInvalidParam("Count"))
+	}
+	if params.MaxUses < 0 || params.MaxUserUses < 0 {
+		return codes, errors.WithStack(ErrInvalidParam("MaxUses"))
+	}
+	err = m.tx(ctx, func(q *sqlite.Queries) error {
+		_, err := q.DiscountByID(ctx, params.DiscountID)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return errors.WithStack(ErrNotFound(params.DiscountID))
+			}
+			return errors.WithStack(err)
+		}
+		for range params.Count {
+			code, err := newCode(params.Prefix)
+			if err != nil {
+				return err
+			}
+			err = q.CouponInsert(ctx, sqlite.CouponInsertParams{
+				Code:        code,
+				DiscountID:  params.DiscountID,
+				MaxUses:     params.MaxUses,
+				MaxUserUses: params.MaxUserUses,
+				Mod:         NewID(),
+				ModID:       modID(userID),
+			})
+			if err != nil {
+				return errors.WithStack(err)
+			}
+			codes = append(codes, code)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// couponCheck returns an error if the coupon usage limits are reached.
+// Per user limits are checked when the order has a user
+func couponCheck(
+	ctx context.Context, q *sqlite.Queries, coupon sqlite.Coupon,
+	order sqlite.Orders) (err error) {
+
+	if coupon.MaxUses > 0 {
+		uses, err := q.CouponUses(ctx, sqlite.CouponUsesParams{
+			Code:    coupon.Code,
+			OrderID: order.OrderID,
+		})
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		if uses >= coupon.MaxUses {
+			return errors.WithStack(ErrCouponLimit(coupon.Code))
+		}
+	}
+	if coupon.MaxUserUses > 0 && order.UserID != "" {
+		uses, err := q.CouponUserUses(ctx, sqlite.CouponUserUsesParams{
+			Code:    coupon.Code,
+			OrderID: order.OrderID,
+			UserID:  order.UserID,
+		})
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		if uses >= coupon.MaxUserUses {
+			return errors.WithStack(ErrCouponLimit(coupon.Code))
+		}
+	}
+	return nil
+}
+
+// couponOrder checks the usage limits for the coupon on the order,
+// and records the use. Must be called in a transaction
+func couponOrder(
+	ctx context.Context, q *sqlite.Queries, order sqlite.Orders) (err error) {
+
+	err = q.CouponUseDelete(ctx, order.OrderID)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	code, err := orderConfigVal(ctx, q, order.OrderID, TermCoupon)
+	if err != nil || code == "" {
+		return err
+	}
+	coupon, err := q.CouponByCode(ctx, code)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return errors.WithStack(ErrInvalidCode(code))
+		}
+		return errors.WithStack(err)
+	}
+	err = couponCheck(ctx, q, coupon, order)
+	if err != nil {
+		return err
+	}
+	err = q.CouponUseInsert(ctx, sqlite.CouponUseInsertParams{
+		Code:    code,
+		OrderID: order.OrderID,
+		UserID:  order.UserID,
+	})
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	return nil
+}
+
+// couponRule returns the discount rule for the coupon on the order,
+// DiscountID is empty if the order doesn't have a valid coupon
+func couponRule(
+	ctx context.Context, q *sqlite.Queries, orderID string) (
+	rule DiscountRule, err error) {
+
+	code, err := orderConfigVal(ctx, q, orderID, TermCoupon)
+	if err != nil || code == "" {
+		return rule, err
+	}
+	coupon, err := q.CouponByCode(ctx, code)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return rule, nil
+		}
+		return rule, errors.WithStack(err)
+	}
+	discount, err := q.DiscountByID(ctx, coupon.DiscountID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return rule, nil
+		}
+		return rule, errors.WithStack(err)
+	}
+	rules, err := discountRestrictions(ctx, q, []sqlite.Discount{discount})
+	if err != nil {
+		return rule, err
+	}
+	return rules[0], nil
+}
+
+// normCode for comparison, codes are stored upper case
+func normCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+// newCode returns a random code with the prefix
+func newCode(prefix string) (code string, err error) {
+	var b strings.Builder
+	b.WriteString(normCode(prefix))
+	max := big.NewInt(int64(len(codeAlphabet)))
+	for range codeLen {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return code, errors.WithStack(err)
+		}
+		b.WriteByte(codeAlphabet[n.Int64()])
+	}
+	return b.String(), nil
+}
@@ -0,0 +1,677 @@
+package model
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/shopd/shopd/go/db/sqlite"
+	"github.com/shopd/shopd/go/money"
+	"github.com/shopd/shopd/go/share"
+)
+
+// Credit notes reverse all or part of the invoice for a paid order,
+// by line qty, or by amount. Credit notes have their own numbering,
+// see the credit_no_seq config term.
+//
+// Amounts credited include tax, the tax is split off in proportion to the
+// invoice. Line amounts are after discounts, so crediting all lines credits
+// the invoice total. The order is reversed when the total is credited,
+// and voucher amounts are restored. Money refunded is recorded as refund
+// transactions linked to the credit note, processor refunds are made by
+// go/services. Stock is returned to the allocated depot on request
+
+// Config terms for credit note numbers
+const (
+	TermCreditNoPrefix = "credit_no_prefix"
+	// TermCreditNoSeq is the last allocated credit note number
+	TermCreditNoSeq = "credit_no_seq"
+)
+
+// CreditNoteCreate issues a credit note for the order.
+// Refunds by EFT, cash, or store credit are recorded with the credit note,
+// for RefundMethodProcessor the caller must refund note.Refund
+// with the processor, and call RefundRecord
+func (m *Model) CreditNoteCreate(
+	ctx context.Context, params share.ParamsOrdersRefundPost, userID string) (
+	note share.CreditNote, err error) {
+
+	switch params.Method {
+	case share.RefundMethodNone, share.RefundMethodProcessor,
+		share.TranMethodEFT, share.TranMethodCash, share.TranMethodCredit:
+	default:
+		return note, errors.WithStack(ErrInvalidParam("Method"))
+	}
+	if len(params.OrderLineID) != len(params.Qty) {
+		return note, errors.WithStack(ErrInvalidParam("Qty"))
+	}
+	if params.Amount < 0 {
+		return note, errors.WithStack(ErrInvalidParam("Amount"))
+	}
+
+	err = m.tx(ctx, func(q *sqlite.Queries) error {
+		order, err := orderByID(ctx, q, params.OrderID)
+		if err != nil {
+			return err
+		}
+		if order.Paid == 0 {
+			return errors.WithStack(ErrOrderNotPaid(order.OrderID))
+		}
+		if order.State != share.OrderStateConfirmed &&
+			order.State != share.OrderStateComplete {
+			return errors.WithStack(ErrOrderState(order.OrderID, order.State))
+		}
+		inv, err := invoice(ctx, q, order)
+		if err != nil {
+			return err
+		}
+		credit, err := orderCredit(ctx, q, order.OrderID, inv)
+		if err != nil {
+			return err
+		}
+
+		note = share.CreditNote{
+			CreditNoteID: NewID(),
+			OrderID:      order.OrderID,
+			Reason:       strings.TrimSpace(params.Reason),
+			Currency:     inv.Currency,
+		}
+		gross := []int64{}
+		seen := make(map[string]bool)
+		for i, orderLineID := range params.OrderLineID {
+			qty := params.Qty[i]
+			if qty == 0 {
+				continue
+			}
+			line, ok := credit.lines[orderLineID]
+			if !ok || seen[orderLineID] {
+				return errors.WithStack(ErrInvalidParam("OrderLineID"))
+			}
+			seen[orderLineID] = true
+			if qty < 0 || line.credited+qty > line.qty {
+				return errors.WithStack(ErrInvalidQty(qty))
+			}
+			g := money.Allocate(line.gross, qty, line.qty-qty)[0]
+			if line.credited+qty == line.qty {
+				g = line.gross - line.creditedGross
+			}
+			gross = append(gross, g)
+			note.Lines = append(note.Lines, share.CreditNoteLine{
+				OrderLineID: orderLineID,
+				Sku:         line.sku,
+				Qty:         qty,
+			})
+		}
+		// Credits by amount are not linked to lines, when the last lines are
+		// credited their amounts are reduced to the remaining total
+		if len(gross) > 0 && credit.complete(note.Lines) {
+			remaining := inv.Total - credit.gross - params.Amount
+			if remaining < 0 {
+				return errors.WithStack(ErrInvalidParam("Amount"))
+			}
+			gross = money.Allocate(remaining, gross...)
+		}
+		if params.Amount > 0 {
+			gross = append(gross, params.Amount)
+			note.Lines = append(note.Lines, share.CreditNoteLine{Qty: 1})
+		}
+		for _, g := range gross {
+			note.Total += g
+		}
+		if note.Total <= 0 {
+			return errors.WithStack(ErrInvalidParam("Qty"))
+		}
+		if credit.gross+note.Total > inv.Total {
+			return errors.WithStack(ErrInvalidParam("Amount"))
+		}
+
+		// The remaining tax is credited with the remaining total
+		full := credit.gross+note.Total == inv.Total
+		note.Tax = money.Allocate(inv.Tax, note.Total, inv.Total-note.Total)[0]
+		if full || note.Tax > inv.Tax-credit.tax {
+			note.Tax = inv.Tax - credit.tax
+		}
+		note.Amount = note.Total - note.Tax
+		for i, tax := range money.Allocate(note.Tax, gross...) {
+			note.Lines[i].Tax = tax
+			note.Lines[i].Amount = gross[i] - tax
+		}
+
+		// Vouchers are restored when the order is reversed,
+		// only money paid may be refunded
+		note.Refund = note.Total
+		if full {
+			note.Refund = min(note.Refund, credit.paid-credit.refunded)
+		}
+		if params.Method == share.RefundMethodNone {
+			note.Refund = 0
+		}
+		// Processor refunds that failed are still due
+		refundable := credit.paid - credit.refunded - credit.due
+		if params.Method == share.RefundMethodProcessor {
+			refundable = credit.processor - credit.due
+		}
+		if note.Refund > refundable {
+			return errors.WithStack(ErrInvalidParam("Amount"))
+		}
+
+		note.CreditNo, err = nextNo(ctx, q, TermCreditNoPrefix, TermCreditNoSeq)
+		if err != nil {
+			return err
+		}
+		err = q.CreditNoteInsert(ctx, sqlite.CreditNoteInsertParams{
+			CreditNoteID: note.CreditNoteID,
+			OrderID:      note.OrderID,
+			CreditNo:     note.CreditNo,
+			Reason:       note.Reason,
+			Amount:       note.Amount,
+			Tax:          note.Tax,
+			Refund:       note.Refund,
+			Mod:          NewID(),
+			ModID:        modID(userID),
+		})
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		allocated, err := allocatedLines(ctx, q, order.OrderID)
+		if err != nil {
+			return err
+		}
+		for i, line := range note.Lines {
+			if depot, ok := allocated[line.OrderLineID]; ok && params.Restock {
+				err = q.CatQtyAdd(ctx, sqlite.CatQtyAddParams{
+					Qty:   line.Qty,
+					SKU:   line.Sku,
+					Depot: depot,
+				})
+				if err != nil {
+					return errors.WithStack(err)
+				}
+				note.Lines[i].Restock = line.Qty
+			}
+			err = q.CreditNoteLineInsert(ctx, sqlite.CreditNoteLineInsertParams{
+				CreditNoteID: note.CreditNoteID,
+				OrderLineID:  line.OrderLineID,
+				SKU:          line.Sku,
+				Qty:          line.Qty,
+				Amount:       line.Amount,
+				Tax:          line.Tax,
+				Restock:      note.Lines[i].Restock,
+			})
+			if err != nil {
+				return errors.WithStack(err)
+			}
+			if line.OrderLineID == "" {
+				continue
+			}
+			c := credit.lines[line.OrderLineID]
+			if c.credited+line.Qty == c.qty {
+				err = q.OrderLineUpdateState(ctx, sqlite.OrderLineUpdateStateParams{
+					State:       share.OrderStateReversed,
+					OrderLineID: line.OrderLineID,
+				})
+				if err != nil {
+					return errors.WithStack(err)
+				}
+			}
+		}
+		err = orderAct(ctx, q, order, userID, false,
+			fmt.Sprintf("Credit note %s issued", note.CreditNo))
+		if err != nil {
+			return err
+		}
+
+		if note.Refund > 0 && params.Method != share.RefundMethodProcessor {
+			pay := share.Payment{
+				Method:  params.Method,
+				Message: strings.TrimSpace(params.Message),
+				Amount:  note.Refund,
+			}
+			// Store credit is issued to the account for the order user
+			if params.Method == share.TranMethodCredit {
+				pay.AccountID, err = orderAccount(ctx, q, order, "", true)
+				if err != nil {
+					return err
+				}
+			}
+			_, err = refundRecord(ctx, q, note.CreditNoteID, pay, userID)
+			if err != nil {
+				return err
+			}
+		}
+		if full {
+			return setOrderState(ctx, q, order, share.OrderStateReversed, userID)
+		}
+		return nil
+	})
+	if err != nil {
+		return note, err
+	}
+	return note, nil
+}
+
+// RefundRecord records money refunded for the credit note,
+// e.g. after the processor refunded a payment
+func (m *Model) RefundRecord(
+	ctx context.Context, creditNoteID string, pay share.Payment,
+	userID string) (refund share.Payment, err error) {
+
+	err = m.tx(ctx, func(q *sqlite.Queries) error {
+		refund, err = refundRecord(ctx, q, creditNoteID, pay, userID)
+		return err
+	})
+	if err != nil {
+		return refund, err
+	}
+	return refund, nil
+}
+
+// refundRecord inserts a successful refund transaction for the credit note
+func refundRecord(
+	ctx context.Context, q *sqlite.Queries, creditNoteID string,
+	pay share.Payment, userID string) (refund share.Payment, err error) {
+
+	note, err := q.CreditNoteByID(ctx, creditNoteID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return refund, errors.WithStack(ErrNotFound(creditNoteID))
+		}
+		return refund, errors.WithStack(err)
+	}
+	if pay.Amount <= 0 {
+		return refund, errors.WithStack(ErrInvalidParam("Amount"))
+	}
+	order, err := orderByID(ctx, q, note.OrderID)
+	if err != nil {
+		return refund, err
+	}
+	currency, err := configVal(ctx, q, TermCurrency, CurrencyDefault)
+	if err != nil {
+		return refund, err
+	}
+	refund = share.Payment{
+		TranID:    NewID(),
+		OrderID:   note.OrderID,
+		State:     share.TranStateSuccess,
+		Descr:     fmt.Sprintf("Refund for credit note %s", note.CreditNo),
+		Amount:    pay.Amount,
+		Currency:  currency,
+		Method:    pay.Method,
+		Processor: pay.Processor,
+		// Ref is not set, processor refs identify payments,
+		// see TranIDByProcessorRef
+		Message:      pay.Message,
+		Type:         share.TranTypeRefund,
+		CreditNoteID: note.CreditNoteID,
+		AccountID:    pay.AccountID,
+	}
+	err = tranInsert(ctx, q, refund, userID)
+	if err != nil {
+		return refund, err
+	}
+	err = orderAct(ctx, q, order, userID, true,
+		fmt.Sprintf("%s refunded %s", refund.Descr, refund.TranID))
+	if err != nil {
+		return refund, err
+	}
+	return refund, nil
+}
+
+// OrderRefunds lists the credit notes for the order,
+// and the lines that may be credited
+func (m *Model) OrderRefunds(
+	ctx context.Context, orderID string) (list share.OrderRefunds, err error) {
+
+	order, err := orderByID(ctx, m.q, orderID)
+	if err != nil {
+		return list, err
+	}
+	inv, err := invoice(ctx, m.q, order)
+	if err != nil {
+		return list, err
+	}
+	credit, err := orderCredit(ctx, m.q, orderID, inv)
+	if err != nil {
+		return list, err
+	}
+	list = share.OrderRefunds{
+		OrderID:    order.OrderID,
+		State:      order.State,
+		Paid:       order.Paid == 1,
+		Currency:   inv.Currency,
+		Total:      inv.Total,
+		Credited:   credit.gross,
+		Refundable: credit.paid - credit.refunded - credit.due,
+	}
+
+	lines, err := m.q.OrderLinesWithTitle(ctx, orderID)
+	if err != nil {
+		return list, errors.WithStack(err)
+	}
+	for _, line := range lines {
+		c, ok := credit.lines[line.OrderLineID]
+		if !ok {
+			continue
+		}
+		list.Lines = append(list.Lines, share.OrderRefundLine{
+			OrderLineID: line.OrderLineID,
+			Sku:         line.SKU,
+			Title:       line.Title,
+			Qty:         c.qty,
+			Credited:    c.credited,
+		})
+	}
+
+	notes, err := m.q.CreditNotesByOrderID(ctx, orderID)
+	if err != nil {
+		return list, errors.WithStack(err)
+	}
+	list.CreditNotes = make([]share.CreditNote, 0, len(notes))
+	for _, n := range notes {
+		list.CreditNotes = append(list.CreditNotes, share.CreditNote{
+			CreditNoteID: n.CreditNoteID,
+			OrderID:      n.OrderID,
+			CreditNo:     n.CreditNo,
+			Reason:       n.Reason,
+			Currency:     inv.Currency,
+			Amount:       n.Amount,
+			Tax:          n.Tax,
+			Total:        n.Amount + n.Tax,
+			Refund:       n.Refund,
+			RefundDue:    max(0, n.Refund-credit.noteRefunded[n.CreditNoteID]),
+		})
+	}
+	return list, nil
+}
+
+// CreditNote builds the credit note document for the order
+func (m *Model) CreditNote(
+	ctx context.Context, orderID, creditNoteID string) (
+	doc share.Invoice, err error) {
+
+	note, err := m.q.CreditNoteByID(ctx, creditNoteID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return doc, errors.WithStack(err)
+	}
+	if note.OrderID == "" || note.OrderID != orderID {
+		return doc, errors.WithStack(ErrNotFound(creditNoteID))
+	}
+	order, err := orderByID(ctx, m.q, orderID)
+	if err != nil {
+		return doc, err
+	}
+	inv, err := invoice(ctx, m.q, order)
+	if err != nil {
+		return doc, err
+	}
+	doc = share.Invoice{
+		OrderID:    inv.OrderID,
+		OrderNo:    inv.OrderNo,
+		Date:       modTime(note.Mod),
+		Currency:   inv.Currency,
+		Seller:     inv.Seller,
+		Buyer:      inv.Buyer,
+		TaxDisplay: inv.TaxDisplay,
+		Tax:        note.Tax,
+		Total:      note.Amount + note.Tax,
+		CreditNo:   note.CreditNo,
+		Reason:     note.Reason,
+	}
+
+	orderLines, err := m.q.OrderLinesWithTitle(ctx, orderID)
+	if err != nil {
+		return doc, errors.WithStack(err)
+	}
+	titles := make(map[string]string, len(orderLines))
+	for _, line := range orderLines {
+		titles[line.OrderLineID] = line.Title
+	}
+	mode, _, err := rounding(ctx, m.q)
+	if err != nil {
+		return doc, err
+	}
+	lines, err := m.q.CreditNoteLinesByID(ctx, creditNoteID)
+	if err != nil {
+		return doc, errors.WithStack(err)
+	}
+	for _, line := range lines {
+		amount := line.Amount
+		if doc.TaxDisplay == share.PriceDisplayInclusive {
+			amount += line.Tax
+		}
+		title := titles[line.OrderLineID]
+		if line.OrderLineID == "" {
+			title = "Credit"
+		}
+		doc.Lines = append(doc.Lines, share.InvoiceLine{
+			Sku:    line.SKU,
+			Title:  title,
+			Qty:    line.Qty,
+			Price:  unitPrice(amount, line.Qty, mode),
+			Amount: amount,
+		})
+		doc.Subtotal += amount
+	}
+
+	// Tax is split by the invoice tax breakdown
+	taxWeights := make([]int64, len(inv.Taxes))
+	taxableWeights := make([]int64, len(inv.Taxes))
+	for i, tax := range inv.Taxes {
+		taxWeights[i] = tax.Tax
+		taxableWeights[i] = tax.Taxable
+	}
+	taxes := money.Allocate(note.Tax, taxWeights...)
+	taxable := money.Allocate(note.Amount, taxableWeights...)
+	for i, tax := range inv.Taxes {
+		tax.Tax = taxes[i]
+		tax.Taxable = taxable[i]
+		doc.Taxes = append(doc.Taxes, tax)
+	}
+
+	tranIDs, err := m.q.TranIDsByConfig(ctx, sqlite.TranIDsByConfigParams{
+		Term: TermCreditNote,
+		Val:  creditNoteID,
+	})
+	if err != nil {
+		return doc, errors.WithStack(err)
+	}
+	for _, tranID := range tranIDs {
+		pay, err := payment(ctx, m.q, tranID)
+		if err != nil {
+			return doc, err
+		}
+		if pay.State != share.TranStateSuccess {
+			continue
+		}
+		// The refund date is when the state was last changed
+		tran, err := m.q.TranByID(ctx, tranID)
+		if err != nil {
+			return doc, errors.WithStack(err)
+		}
+		doc.Paid += pay.Amount
+		doc.Payments = append(doc.Payments, share.InvoicePayment{
+			TranID: pay.TranID,
+			Descr:  pay.Descr,
+			Date:   modTime(tran.Mod),
+			Amount: pay.Amount,
+		})
+	}
+	return doc, nil
+}
+
+// creditLine is a product line that may be credited,
+// gross is the line amount including tax and discounts
+type creditLine struct {
+	sku           string
+	qty           int64
+	gross         int64
+	credited      int64
+	creditedGross int64
+}
+
+// creditState is what was credited and paid for an order
+type creditState struct {
+	lines map[string]creditLine
+	// gross and tax credited on previous credit notes
+	gross int64
+	tax   int64
+	// paid is money received excluding vouchers, refunded is money refunded
+	paid     int64
+	refunded int64
+	// processor is the amount of processor payments not refunded yet
+	processor int64
+	// noteRefunded is money refunded by credit_note_id,
+	// due is the sum of refunds for credit notes that were not made yet
+	noteRefunded map[string]int64
+	due          int64
+}
+
+// complete returns true if all lines are fully credited with the note lines
+func (credit creditState) complete(lines []share.CreditNoteLine) bool {
+	qty := make(map[string]int64, len(lines))
+	for _, line := range lines {
+		qty[line.OrderLineID] = line.Qty
+	}
+	for orderLineID, line := range credit.lines {
+		if line.credited+qty[orderLineID] < line.qty {
+			return false
+		}
+	}
+	return true
+}
+
+// orderCredit returns the credit state for the order,
+// inv is the invoice for the order
+func orderCredit(
+	ctx context.Context, q *sqlite.Queries, orderID string,
+	inv share.Invoice) (credit creditState, err error) {
+
+	tl, err := taxLines(ctx, q, orderID)
+	if err != nil {
+		return credit, err
+	}
+	amounts, err := inclusiveLines(ctx, q, orderID, tl, inv.Tax)
+	if err != nil {
+		return credit, err
+	}
+	products := []TaxLine{}
+	var discount int64
+	for _, line := range tl {
+		if line.Sku == SkuDiscount {
+			discount += amounts[line.OrderLineID]
+		} else if !systemSku(line.Sku) {
+			products = append(products, line)
+		}
+	}
+	weights := make([]int64, len(products))
+	for i, line := range products {
+		weights[i] = amounts[line.OrderLineID]
+	}
+	discounts := money.Allocate(discount, weights...)
+	credit.lines = make(map[string]creditLine, len(products))
+	for i, line := range products {
+		credit.lines[line.OrderLineID] = creditLine{
+			sku:   line.Sku,
+			qty:   line.Qty,
+			gross: weights[i] + discounts[i],
+		}
+	}
+
+	notes, err := q.CreditNotesByOrderID(ctx, orderID)
+	if err != nil {
+		return credit, errors.WithStack(err)
+	}
+	for _, note := range notes {
+		credit.gross += note.Amount + note.Tax
+		credit.tax += note.Tax
+	}
+	noteLines, err := q.CreditNoteLinesByOrderID(ctx, orderID)
+	if err != nil {
+		return credit, errors.WithStack(err)
+	}
+	for _, line := range noteLines {
+		c, ok := credit.lines[line.OrderLineID]
+		if !ok {
+			continue
+		}
+		c.credited += line.Qty
+		c.creditedGross += line.Amount + line.Tax
+		credit.lines[line.OrderLineID] = c
+	}
+
+	trans, err := q.TransByOrderID(ctx, orderID)
+	if err != nil {
+		return credit, errors.WithStack(err)
+	}
+	credit.noteRefunded = make(map[string]int64, len(notes))
+	for _, tran := range trans {
+		if tran.State != share.TranStateSuccess {
+			continue
+		}
+		pay, err := payment(ctx, q, tran.TranID)
+		if err != nil {
+			return credit, err
+		}
+		if pay.Type == share.TranTypeRefund {
+			credit.refunded += pay.Amount
+			credit.noteRefunded[pay.CreditNoteID] += pay.Amount
+			continue
+		}
+		credit.paid += pay.Amount
+		if pay.Processor != "" {
+			credit.processor += pay.Amount - pay.Refunded
+		}
+	}
+	for _, note := range notes {
+		credit.due += max(0, note.Refund-credit.noteRefunded[note.CreditNoteID])
+	}
+	return credit, nil
+}
+
+// CreditNoteRefundDue returns the refund for the credit note that was not
+// made yet, e.g. because the processor refund failed
+func (m *Model) CreditNoteRefundDue(
+	ctx context.Context, orderID, creditNoteID string) (due int64, err error) {
+
+	note, err := m.q.CreditNoteByID(ctx, creditNoteID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return due, errors.WithStack(err)
+	}
+	if note.OrderID == "" || note.OrderID != orderID {
+		return due, errors.WithStack(ErrNotFound(creditNoteID))
+	}
+	order, err := orderByID(ctx, m.q, orderID)
+	if err != nil {
+		return due, err
+	}
+	inv, err := invoice(ctx, m.q, order)
+	if err != nil {
+		return due, err
+	}
+	credit, err := orderCredit(ctx, m.q, orderID, inv)
+	if err != nil {
+		return due, err
+	}
+	return max(0, note.Refund-credit.noteRefunded[creditNoteID]), nil
+}
+
+// restockedLines returns the qty returned to stock by credit notes,
+// by order_line_id
+func restockedLines(
+	ctx context.Context, q *sqlite.Queries, orderID string) (
+	restocked map[string]int64, err error) {
+
+	lines, err := q.CreditNoteLinesByOrderID(ctx, orderID)
+	if err != nil {
+		return restocked, errors.WithStack(err)
+	}
+	restocked = make(map[string]int64)
+	for _, line := range lines {
+		if line.OrderLineID != "" && line.Restock > 0 {
+			restocked[line.OrderLineID] += line.Restock
+		}
+	}
+	return restocked, nil
+}
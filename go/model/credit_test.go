@@ -0,0 +1,187 @@
+package model_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+	"github.com/pkg/errors"
+	"github.com/segmentio/ksuid"
+	"github.com/shopd/shopd/go/model"
+	"github.com/shopd/shopd/go/share"
+)
+
+func TestCreditNoteCreate(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+	m, db := newTestModel(t)
+	exec(t, db,
+		`insert into depot(depot, descr, province, idx, mod, mod_id)
+		values ('jhb', 'Johannesburg', 'Gauteng', 0, 'm', 's')`,
+		`insert into cat(sku, title, descr, state, mod, mod_id)
+		values ('apple', 'Apple', '', 'stock', 'm', 's')`,
+		`insert into cat_price values ('apple', 1000)`,
+		`insert into cat_qty values ('apple', 'jhb', 5)`,
+	)
+	stock := func() (qty int64) {
+		is.NoErr(db.QueryRow(
+			`select qty from cat_qty where sku = 'apple'`).Scan(&qty))
+		return qty
+	}
+
+	cart, err := m.CartAdd(ctx, "", "", share.ParamsCartPost{Sku: "apple", Qty: 3})
+	is.NoErr(err)
+	orderID := cart.OrderID
+	is.NoErr(m.SetOrderState(ctx, orderID, share.OrderStatePending, ""))
+
+	// Credit notes are only issued for paid orders
+	_, err = m.CreditNoteCreate(ctx, share.ParamsOrdersRefundPost{
+		OrderID: orderID, Amount: 100, Method: share.TranMethodEFT}, "admin")
+	is.True(errors.Is(err, model.ErrOrderNotPaid("")))
+
+	payments, err := m.OrderPayments(ctx, orderID)
+	is.NoErr(err)
+	is.Equal(payments.Total, int64(3450))
+	_, err = m.PaymentRecord(ctx, share.ParamsOrdersPaymentPost{
+		OrderID: orderID,
+		Method:  share.TranMethodEFT,
+		Amount:  payments.Total,
+	}, "admin")
+	is.NoErr(err)
+	is.Equal(stock(), int64(2))
+	refunds, err := m.OrderRefunds(ctx, orderID)
+	is.NoErr(err)
+	lineID := refunds.Lines[0].OrderLineID
+
+	// Partial credit with restock
+	note, err := m.CreditNoteCreate(ctx, share.ParamsOrdersRefundPost{
+		OrderID:     orderID,
+		OrderLineID: []string{lineID},
+		Qty:         []int64{1},
+		Method:      share.TranMethodEFT,
+		Reason:      "Bruised",
+		Restock:     true,
+	}, "admin")
+	is.NoErr(err)
+	is.True(note.CreditNo != "")
+	is.Equal(note.Amount, int64(1000))
+	is.Equal(note.Tax, int64(150))
+	is.Equal(note.Refund, int64(1150))
+	is.Equal(stock(), int64(3))
+	summary, err := m.OrderSummary(ctx, orderID)
+	is.NoErr(err)
+	is.Equal(summary.State, share.OrderStateConfirmed)
+
+	// Invalid params
+	_, err = m.CreditNoteCreate(ctx, share.ParamsOrdersRefundPost{
+		OrderID:     orderID,
+		OrderLineID: []string{lineID},
+		Qty:         []int64{3},
+		Method:      share.TranMethodEFT,
+	}, "admin")
+	is.True(err != nil) // more than the qty remaining
+	_, err = m.CreditNoteCreate(ctx, share.ParamsOrdersRefundPost{
+		OrderID: orderID, Amount: -1, Method: share.TranMethodEFT}, "admin")
+	is.True(errors.Is(err, model.ErrInvalidParam("")))
+	_, err = m.CreditNoteCreate(ctx, share.ParamsOrdersRefundPost{
+		OrderID: orderID, Amount: 100, Method: "cheque"}, "admin")
+	is.True(errors.Is(err, model.ErrInvalidParam("")))
+
+	// Refund dates are taken from the transaction
+	doc, err := m.CreditNote(ctx, orderID, note.CreditNoteID)
+	is.NoErr(err)
+	is.Equal(doc.CreditNo, note.CreditNo)
+	is.Equal(doc.Paid, int64(1150))
+	is.Equal(len(doc.Payments), 1)
+	mod, err := ksuid.NewRandomWithTime(time.Now().AddDate(0, 0, -2))
+	is.NoErr(err)
+	_, err = db.Exec(`update tran set mod = ? where tran_id = ?`,
+		mod.String(), doc.Payments[0].TranID)
+	is.NoErr(err)
+	doc, err = m.CreditNote(ctx, orderID, note.CreditNoteID)
+	is.NoErr(err)
+	is.True(doc.Payments[0].Date.Equal(mod.Time()))
+
+	// Crediting the rest reverses the order
+	_, err = m.CreditNoteCreate(ctx, share.ParamsOrdersRefundPost{
+		OrderID:     orderID,
+		OrderLineID: []string{lineID},
+		Qty:         []int64{2},
+		Method:      share.TranMethodCash,
+	}, "admin")
+	is.NoErr(err)
+	summary, err = m.OrderSummary(ctx, orderID)
+	is.NoErr(err)
+	is.Equal(summary.State, share.OrderStateReversed)
+	payments, err = m.OrderPayments(ctx, orderID)
+	is.NoErr(err)
+	is.Equal(payments.Refunded, payments.Total)
+	// The order wasn't shipped, stock is released when it's reversed
+	is.Equal(stock(), int64(5))
+}
+
+func TestCreditNoteRefundDue(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+	m, db := newTestModel(t)
+	exec(t, db,
+		`insert into depot(depot, descr, province, idx, mod, mod_id)
+		values ('jhb', 'Johannesburg', 'Gauteng', 0, 'm', 's')`,
+		`insert into cat(sku, title, descr, state, mod, mod_id)
+		values ('apple', 'Apple', '', 'stock', 'm', 's')`,
+		`insert into cat_price values ('apple', 1000)`,
+		`insert into cat_qty values ('apple', 'jhb', 5)`,
+	)
+
+	cart, err := m.CartAdd(ctx, "", "", share.ParamsCartPost{Sku: "apple", Qty: 2})
+	is.NoErr(err)
+	orderID := cart.OrderID
+	is.NoErr(m.SetOrderState(ctx, orderID, share.OrderStatePending, ""))
+	pay, err := m.PaymentCreate(ctx, orderID, "fake", "")
+	is.NoErr(err)
+	_, err = m.PaymentUpdate(ctx, pay.TranID, share.TranStateSuccess, "")
+	is.NoErr(err)
+
+	// The caller refunds processor credit notes,
+	// the refund is due until it's recorded
+	note, err := m.CreditNoteCreate(ctx, share.ParamsOrdersRefundPost{
+		OrderID: orderID, Amount: 1000, Method: share.RefundMethodProcessor,
+	}, "admin")
+	is.NoErr(err)
+	is.Equal(note.Refund, int64(1000))
+	due, err := m.CreditNoteRefundDue(ctx, orderID, note.CreditNoteID)
+	is.NoErr(err)
+	is.Equal(due, int64(1000))
+	refunds, err := m.OrderRefunds(ctx, orderID)
+	is.NoErr(err)
+	is.Equal(refunds.CreditNotes[0].RefundDue, int64(1000))
+	is.Equal(refunds.Refundable, int64(1300)) // 2300 paid
+
+	_, err = m.RefundRecord(ctx, note.CreditNoteID, share.Payment{
+		Method:    pay.Method,
+		Processor: pay.Processor,
+		Amount:    600,
+	}, "admin")
+	is.NoErr(err)
+	due, err = m.CreditNoteRefundDue(ctx, orderID, note.CreditNoteID)
+	is.NoErr(err)
+	is.Equal(due, int64(400))
+	_, err = m.RefundRecord(ctx, note.CreditNoteID, share.Payment{
+		Method:    pay.Method,
+		Processor: pay.Processor,
+		Amount:    400,
+	}, "admin")
+	is.NoErr(err)
+	due, err = m.CreditNoteRefundDue(ctx, orderID, note.CreditNoteID)
+	is.NoErr(err)
+	is.Equal(due, int64(0))
+	refunds, err = m.OrderRefunds(ctx, orderID)
+	is.NoErr(err)
+	is.Equal(refunds.CreditNotes[0].RefundDue, int64(0))
+	is.Equal(refunds.Refundable, int64(1300))
+
+	// The credit note must belong to the order
+	_, err = m.CreditNoteRefundDue(ctx, "other", note.CreditNoteID)
+	is.True(errors.Is(err, model.ErrNotFound("")))
+}
@@ -0,0 +1,112 @@
+package model
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/shopd/shopd/go/db/sqlite"
+	"github.com/shopd/shopd/go/money"
+	"github.com/shopd/shopd/go/share"
+)
+
+// Prices in the DB are for the default currency, see TermCurrency.
+// Buyers may choose a display currency, prices are converted with the
+// exchange rates maintained by admin users and rounded with the domain
+// rounding mode. Orders are always charged in the default currency
+
+// ExchangeRates lists the display currencies
+func (m *Model) ExchangeRates(
+	ctx context.Context) (rates share.ExchangeRates, err error) {
+
+	rates.Currency, err = configVal(ctx, m.q, TermCurrency, CurrencyDefault)
+	if err != nil {
+		return rates, err
+	}
+	rows, err := m.q.ExchangeRates(ctx)
+	if err != nil {
+		return rates, errors.WithStack(err)
+	}
+	rates.Rates = make([]share.ExchangeRate, 0, len(rows))
+	for _, row := range rows {
+		rates.Rates = append(rates.Rates, share.ExchangeRate{
+			Currency: row.Currency,
+			Rate:     money.FormatRate(row.Rate),
+		})
+	}
+	return rates, nil
+}
+
+// ExchangeRateSet sets the rate for a display currency,
+// an empty or zero rate removes the currency
+func (m *Model) ExchangeRateSet(
+	ctx context.Context, params share.ParamsExchangeRatePost, userID string) (
+	rates share.ExchangeRates, err error) {
+
+	currency := strings.ToUpper(strings.TrimSpace(params.Currency))
+	if !money.ValidCurrency(currency) {
+		return rates, errors.WithStack(ErrInvalidParam("Currency"))
+	}
+	deflt, err := configVal(ctx, m.q, TermCurrency, CurrencyDefault)
+	if err != nil {
+		return rates, err
+	}
+	if currency == deflt {
+		return rates, errors.WithStack(ErrInvalidParam("Currency"))
+	}
+
+	rate := strings.TrimSpace(params.Rate)
+	if rate == "" || strings.Trim(rate, "0.") == "" {
+		err = m.q.ExchangeRateDelete(ctx, currency)
+		if err != nil {
+			return rates, errors.WithStack(err)
+		}
+		return m.ExchangeRates(ctx)
+	}
+	r, err := money.ParseRate(rate)
+	if err != nil {
+		return rates, errors.WithStack(ErrInvalidParam("Rate"))
+	}
+	err = m.q.ExchangeRateUpsert(ctx, sqlite.ExchangeRateUpsertParams{
+		Currency: currency,
+		Rate:     r,
+		Mod:      NewID(),
+		ModID:    modID(userID),
+	})
+	if err != nil {
+		return rates, errors.WithStack(err)
+	}
+	return m.ExchangeRates(ctx)
+}
+
+// Converter for display prices in the currency.
+// Prices are not converted if currency is empty, the default currency,
+// or doesn't have an exchange rate
+func (m *Model) Converter(
+	ctx context.Context, currency string) (c money.Converter, err error) {
+
+	c.From, err = configVal(ctx, m.q, TermCurrency, CurrencyDefault)
+	if err != nil {
+		return c, err
+	}
+	c.To = c.From
+	currency = strings.ToUpper(strings.TrimSpace(currency))
+	if currency == "" || currency == c.From {
+		return c, nil
+	}
+	rate, err := m.q.ExchangeRateByCurrency(ctx, currency)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return c, nil
+		}
+		return c, errors.WithStack(err)
+	}
+	c.Mode, _, err = rounding(ctx, m.q)
+	if err != nil {
+		return c, err
+	}
+	c.To = rate.Currency
+	c.Rate = rate.Rate
+	return c, nil
+}
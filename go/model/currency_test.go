@@ -0,0 +1,53 @@
+package model_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/matryer/is"
+	"github.com/pkg/errors"
+	"github.com/shopd/shopd/go/model"
+	"github.com/shopd/shopd/go/share"
+)
+
+func TestExchangeRateSet(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+	m, db := newTestModel(t)
+	exec(t, db, `update config set val = 'ZAR' where term = 'currency'`)
+
+	for _, params := range []share.ParamsExchangeRatePost{
+		{Currency: "XXXX", Rate: "0.055"},
+		{Currency: "ZAR", Rate: "1"}, // the default currency
+		{Currency: "USD", Rate: "-0.055"},
+		{Currency: "USD", Rate: "abc"},
+	} {
+		_, err := m.ExchangeRateSet(ctx, params, "")
+		is.True(errors.Is(err, model.ErrInvalidParam("")))
+	}
+
+	rates, err := m.ExchangeRateSet(ctx, share.ParamsExchangeRatePost{
+		Currency: " usd ", Rate: "0.055",
+	}, "")
+	is.NoErr(err)
+	is.Equal(rates.Currency, "ZAR")
+	is.Equal(rates.Rates, []share.ExchangeRate{{Currency: "USD", Rate: "0.055"}})
+
+	c, err := m.Converter(ctx, "usd")
+	is.NoErr(err)
+	is.True(c.Converted())
+	is.Equal(c.Convert(10000), int64(550))
+
+	// Currencies without a rate are not converted
+	c, err = m.Converter(ctx, "EUR")
+	is.NoErr(err)
+	is.True(!c.Converted())
+	is.Equal(c.Currency(), "ZAR")
+
+	// A zero rate removes the currency
+	rates, err = m.ExchangeRateSet(ctx, share.ParamsExchangeRatePost{
+		Currency: "USD", Rate: "0",
+	}, "")
+	is.NoErr(err)
+	is.Equal(len(rates.Rates), 0)
+}
@@ -0,0 +1,475 @@
+package model
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/shopd/shopd/go/db/sqlite"
+	"github.com/shopd/shopd/go/money"
+)
+
+// Discounts are applied to the order as order lines for the SkuDiscount
+// system sku, with a negative price and qty of one.
+// The discount_id is recorded on the line with the TermDiscount order_config.
+// Discount lines are replaced whenever the cart changes,
+// and when the order is priced.
+// Optional discounts are only used if applied by an admin user,
+// see discountopt.go
+//
+// Precedence and stacking rules:
+//  1. A discount with a non-zero discount col is a fixed amount,
+//     otherwise pct is used.
+//  2. Discounts without tag or sku restrictions apply to all lines,
+//     otherwise to lines with a listed sku, or a listed tag.
+//  3. At most one pct discount applies per line, the largest pct is used.
+//     Matching discounts with the same pct are used in discount_id order.
+//  4. Fixed discounts stack, they are applied after pct discounts,
+//     in discount_id order, and never reduce a line below zero.
+//     The discount is split between matching lines by the remaining amount
+
+// SkuDiscount is the system sku for discount lines
+const SkuDiscount = "discount"
+
+// Order config terms for discounts
+const (
+	// TermDiscount is the discount_id on a discount line
+	TermDiscount = "discount"
+	// TermDiscountOpt is the optional discount_id applied to the order
+	TermDiscountOpt = "discount_opt"
+)
+
+// DiscountRule is a row from the discount table with restrictions.
+// Empty restrictions are not applicable
+type DiscountRule struct {
+	DiscountID string
+	Descr      string
+	// Pct in basis points, e.g. 1000 for 10%
+	Pct int64
+	// Discount is a fixed amount, used instead of Pct if non-zero
+	Discount  int64
+	Countries []string
+	Tags      []string
+	Skus      []string
+	Users     []string
+	Ranges    []DateRange
+}
+
+// active returns true if the discount applies to the order
+func (r DiscountRule) active(params DiscountParams) bool {
+	if len(r.Countries) > 0 && !slices.Contains(r.Countries, params.Country) {
+		return false
+	}
+	if len(r.Users) > 0 && !slices.Contains(r.Users, params.UserID) {
+		return false
+	}
+	return inRanges(r.Ranges, params.Now)
+}
+
+// matches returns true if the discount applies to the line
+func (r DiscountRule) matches(line TaxLine) bool {
+	if len(r.Tags) == 0 && len(r.Skus) == 0 {
+		return true
+	}
+	if slices.Contains(r.Skus, line.Sku) {
+		return true
+	}
+	for _, tag := range line.Tags {
+		if slices.Contains(r.Tags, tag) {
+			return true
+		}
+	}
+	return false
+}
+
+// DiscountParams for the Discount func
+type DiscountParams struct {
+	Now     time.Time
+	Country string
+	UserID  string
+	Mode    money.Rounding
+	Rules   []DiscountRule
+	// Lines to discount, system skus must not be included
+	Lines []TaxLine
+}
+
+// DiscountLine is a discount to apply to the order
+type DiscountLine struct {
+	DiscountID string
+	Descr      string
+	// Amount is the positive discount in the smallest unit
+	Amount int64
+	// Lines maps order_line_id to the part of the amount
+	// that was allocated to the line
+	Lines map[string]int64
+}
+
+// Discount calculates the discounts for the order lines,
+// see comments at the top of this file for the stacking rules
+func Discount(params DiscountParams) (discounts []DiscountLine) {
+	remain := make([]int64, len(params.Lines))
+	for i, line := range params.Lines {
+		remain[i] = line.Amount
+	}
+
+	rules := []DiscountRule{}
+	for _, rule := range params.Rules {
+		if rule.active(params) {
+			rules = append(rules, rule)
+		}
+	}
+
+	// Largest pct per line
+	best := make([]int, len(params.Lines))
+	for i, line := range params.Lines {
+		best[i] = -1
+		for j, rule := range rules {
+			if rule.Discount > 0 || rule.Pct <= 0 || !rule.matches(line) {
+				continue
+			}
+			if best[i] < 0 || rule.Pct > rules[best[i]].Pct {
+				best[i] = j
+			}
+		}
+	}
+	for j, rule := range rules {
+		if rule.Discount > 0 {
+			continue
+		}
+		lines := []int{}
+		weights := []int64{}
+		var exact money.Exact
+		for i, line := range params.Lines {
+			if best[i] == j {
+				lines = append(lines, i)
+				weights = append(weights, line.Amount)
+				exact += money.Pct(line.Amount, min(rule.Pct, money.Scale))
+			}
+		}
+		amount := exact.Round(params.Mode)
+		if amount <= 0 {
+			continue
+		}
+		discounts = append(discounts,
+			discountLine(rule, amount, params.Lines, lines, weights, remain))
+	}
+
+	for _, rule := range rules {
+		if rule.Discount <= 0 {
+			continue
+		}
+		lines := []int{}
+		weights := []int64{}
+		var total int64
+		for i, line := range params.Lines {
+			if remain[i] > 0 && rule.matches(line) {
+				lines = append(lines, i)
+				weights = append(weights, remain[i])
+				total += remain[i]
+			}
+		}
+		amount := min(rule.Discount, total)
+		if amount <= 0 {
+			continue
+		}
+		discounts = append(discounts,
+			discountLine(rule, amount, params.Lines, lines, weights, remain))
+	}
+
+	return discounts
+}
+
+// discountLine allocates the amount to the lines by weight,
+// and subtracts the parts from the remaining line amounts
+func discountLine(
+	rule DiscountRule, amount int64, lines []TaxLine,
+	indexes []int, weights []int64, remain []int64) DiscountLine {
+
+	discount := DiscountLine{
+		DiscountID: rule.DiscountID,
+		Descr:      rule.Descr,
+		Amount:     amount,
+		Lines:      make(map[string]int64),
+	}
+	for k, part := range money.Allocate(amount, weights...) {
+		i := indexes[k]
+		remain[i] -= part
+		discount.Lines[lines[i].OrderLineID] += part
+	}
+	return discount
+}
+
+// discountOrder replaces the discount lines on the order,
+// must be called in a transaction.
+// Note that tax is calculated on the discounted line amounts,
+// see the discountedLines func
+func discountOrder(
+	ctx context.Context, q *sqlite.Queries, order sqlite.Orders, now time.Time) (
+	discounts []DiscountLine, err error) {
+
+	params, err := discountParams(ctx, q, order, now)
+	if err != nil {
+		return discounts, err
+	}
+	discounts = Discount(params)
+
+	err = discountDelete(ctx, q, order.OrderID)
+	if err != nil {
+		return discounts, err
+	}
+	for _, discount := range discounts {
+		orderLineID := NewID()
+		err = q.OrderLineInsert(ctx, sqlite.OrderLineInsertParams{
+			OrderLineID: orderLineID,
+			OrderID:     order.OrderID,
+			SKU:         SkuDiscount,
+			Price:       -discount.Amount,
+			Qty:         1,
+		})
+		if err != nil {
+			return discounts, errors.WithStack(err)
+		}
+		err = q.OrderConfigUpsert(ctx, sqlite.OrderConfigUpsertParams{
+			OrderID:     order.OrderID,
+			OrderLineID: orderLineID,
+			Term:        TermDiscount,
+			Val:         discount.DiscountID,
+		})
+		if err != nil {
+			return discounts, errors.WithStack(err)
+		}
+	}
+	return discounts, nil
+}
+
+// discountParams for the order, rules include the optional discount
+// applied by an admin user, see ApplyDiscount,
+// and the discount for the coupon code entered by the customer
+func discountParams(
+	ctx context.Context, q *sqlite.Queries, order sqlite.Orders, now time.Time) (
+	params DiscountParams, err error) {
+
+	params = DiscountParams{
+		Now:    now.UTC(),
+		UserID: order.UserID,
+	}
+	params.Rules, err = discountRules(ctx, q, false)
+	if err != nil {
+		return params, err
+	}
+	applied, err := orderConfigVal(ctx, q, order.OrderID, TermDiscountOpt)
+	if err != nil {
+		return params, err
+	}
+	if applied != "" {
+		opts, err := discountRules(ctx, q, true)
+		if err != nil {
+			return params, err
+		}
+		for _, rule := range opts {
+			if rule.DiscountID == applied {
+				params.Rules = append(params.Rules, rule)
+			}
+		}
+	}
+	coupon, err := couponRule(ctx, q, order.OrderID)
+	if err != nil {
+		return params, err
+	}
+	if coupon.DiscountID != "" {
+		params.Rules = append(params.Rules, coupon)
+	}
+
+	params.Country, err = orderCountry(ctx, q, order.OrderID)
+	if err != nil {
+		return params, err
+	}
+	params.Mode, _, err = rounding(ctx, q)
+	if err != nil {
+		return params, err
+	}
+	lines, err := taxLines(ctx, q, order.OrderID)
+	if err != nil {
+		return params, err
+	}
+	params.Lines = discountedLines(lines, nil)
+	return params, nil
+}
+
+// discountedLines subtracts the discounts from the line amounts,
+// system lines are removed
+func discountedLines(lines []TaxLine, discounts []DiscountLine) []TaxLine {
+	discounted := make([]TaxLine, 0, len(lines))
+	for _, line := range lines {
+		if systemSku(line.Sku) {
+			continue
+		}
+		for _, discount := range discounts {
+			line.Amount -= discount.Lines[line.OrderLineID]
+		}
+		discounted = append(discounted, line)
+	}
+	return discounted
+}
+
+// discountDelete removes the discount lines from the order
+func discountDelete(
+	ctx context.Context, q *sqlite.Queries, orderID string) (err error) {
+
+	lines, err := q.OrderLinesByOrderID(ctx, orderID)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	for _, line := range lines {
+		if line.SKU != SkuDiscount {
+			continue
+		}
+		err = q.OrderConfigDelete(ctx, sqlite.OrderConfigDeleteParams{
+			OrderID:     orderID,
+			OrderLineID: line.OrderLineID,
+			Term:        TermDiscount,
+		})
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		err = q.OrderLineDelete(ctx, line.OrderLineID)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+	}
+	return nil
+}
+
+// discountRules lists the discounts with restrictions.
+// Set opt to list the discounts that may be applied by admin users instead
+func discountRules(
+	ctx context.Context, q *sqlite.Queries, opt bool) (
+	rules []DiscountRule, err error) {
+
+	var discounts []sqlite.Discount
+	if opt {
+		discounts, err = q.DiscountOpts(ctx)
+	} else {
+		discounts, err = q.Discounts(ctx)
+	}
+	if err != nil {
+		return rules, errors.WithStack(err)
+	}
+	return discountRestrictions(ctx, q, discounts)
+}
+
+// discountRestrictions lists the discounts with restrictions
+func discountRestrictions(
+	ctx context.Context, q *sqlite.Queries, discounts []sqlite.Discount) (
+	rules []DiscountRule, err error) {
+
+	if len(discounts) == 0 {
+		return rules, nil
+	}
+	index := make(map[string]int)
+	for _, d := range discounts {
+		index[d.DiscountID] = len(rules)
+		rules = append(rules, DiscountRule{
+			DiscountID: d.DiscountID,
+			Descr:      d.Descr,
+			Pct:        d.Pct,
+			Discount:   d.Discount,
+		})
+	}
+
+	countries, err := q.DiscountCountries(ctx)
+	if err != nil {
+		return rules, errors.WithStack(err)
+	}
+	for _, row := range countries {
+		if i, ok := index[row.DiscountID]; ok {
+			rules[i].Countries = append(rules[i].Countries, row.Country)
+		}
+	}
+	tags, err := q.DiscountTags(ctx)
+	if err != nil {
+		return rules, errors.WithStack(err)
+	}
+	for _, row := range tags {
+		if i, ok := index[row.DiscountID]; ok {
+			rules[i].Tags = append(rules[i].Tags, row.Tag)
+		}
+	}
+	skus, err := q.DiscountSkus(ctx)
+	if err != nil {
+		return rules, errors.WithStack(err)
+	}
+	for _, row := range skus {
+		if i, ok := index[row.DiscountID]; ok {
+			rules[i].Skus = append(rules[i].Skus, row.SKU)
+		}
+	}
+	users, err := q.DiscountUsers(ctx)
+	if err != nil {
+		return rules, errors.WithStack(err)
+	}
+	for _, row := range users {
+		if i, ok := index[row.DiscountID]; ok {
+			rules[i].Users = append(rules[i].Users, row.UserID)
+		}
+	}
+	ranges, err := q.DiscountRanges(ctx)
+	if err != nil {
+		return rules, errors.WithStack(err)
+	}
+	for _, row := range ranges {
+		i, ok := index[row.DiscountID]
+		if !ok {
+			continue
+		}
+		start, err := time.Parse(DateTimeFormat, row.Start)
+		if err != nil {
+			return rules, errors.WithStack(ErrInvalidParam(row.DiscountID))
+		}
+		end, err := time.Parse(DateTimeFormat, row.End)
+		if err != nil {
+			return rules, errors.WithStack(ErrInvalidParam(row.DiscountID))
+		}
+		rules[i].Ranges = append(rules[i].Ranges, DateRange{
+			Start: start, End: end,
+		})
+	}
+
+	return rules, nil
+}
+
+// systemDescr maps system order_line_id to a description,
+// e.g. the discount that was applied
+func systemDescr(
+	ctx context.Context, q *sqlite.Queries, orderID string) (
+	descr map[string]string, err error) {
+
+	descr = make(map[string]string)
+	config, err := q.OrderConfigByOrderID(ctx, orderID)
+	if err != nil {
+		return descr, errors.WithStack(err)
+	}
+	for _, c := range config {
+		if c.OrderLineID == "" {
+			continue
+		}
+		switch c.Term {
+		case TermDiscount:
+			discount, err := q.DiscountByID(ctx, c.Val)
+			if err != nil {
+				if errors.Is(err, sql.ErrNoRows) {
+					// Discount was deleted after it was applied
+					continue
+				}
+				return descr, errors.WithStack(err)
+			}
+			descr[c.OrderLineID] = discount.Descr
+		case TermVoucher:
+			descr[c.OrderLineID] = fmt.Sprintf("Voucher %s", c.Val)
+		}
+	}
+	return descr, nil
+}
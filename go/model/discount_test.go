@@ -0,0 +1,152 @@
+package model_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+	"github.com/shopd/shopd/go/model"
+	"github.com/shopd/shopd/go/money"
+)
+
+func TestDiscount(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	bread := model.TaxLine{
+		OrderLineID: "l1", Sku: "bread", Tags: []string{"food"}, Qty: 1, Amount: 1000}
+	wine := model.TaxLine{
+		OrderLineID: "l2", Sku: "wine", Tags: []string{"alcohol"}, Qty: 1, Amount: 3000}
+	discount := func(id string, amount int64, lines map[string]int64) model.DiscountLine {
+		return model.DiscountLine{DiscountID: id, Amount: amount, Lines: lines}
+	}
+
+	for _, tc := range []struct {
+		name  string
+		mode  money.Rounding
+		rules []model.DiscountRule
+		lines []model.TaxLine
+		want  []model.DiscountLine
+	}{
+		{
+			name:  "pct for all lines",
+			rules: []model.DiscountRule{{DiscountID: "d1", Pct: 1000}},
+			lines: []model.TaxLine{bread, wine},
+			want: []model.DiscountLine{
+				discount("d1", 400, map[string]int64{"l1": 100, "l2": 300})},
+		},
+		{
+			name: "largest pct per line",
+			rules: []model.DiscountRule{
+				{DiscountID: "d1", Pct: 1000},
+				{DiscountID: "d2", Pct: 2000, Tags: []string{"food"}},
+			},
+			lines: []model.TaxLine{bread, wine},
+			want: []model.DiscountLine{
+				discount("d1", 300, map[string]int64{"l2": 300}),
+				discount("d2", 200, map[string]int64{"l1": 200}),
+			},
+		},
+		{
+			name: "same pct in discount_id order",
+			rules: []model.DiscountRule{
+				{DiscountID: "d1", Pct: 1000, Skus: []string{"wine"}},
+				{DiscountID: "d2", Pct: 1000},
+			},
+			lines: []model.TaxLine{bread, wine},
+			want: []model.DiscountLine{
+				discount("d1", 300, map[string]int64{"l2": 300}),
+				discount("d2", 100, map[string]int64{"l1": 100}),
+			},
+		},
+		{
+			name: "fixed stacks after pct",
+			rules: []model.DiscountRule{
+				{DiscountID: "d1", Discount: 500},
+				{DiscountID: "d2", Pct: 1000},
+			},
+			lines: []model.TaxLine{bread, wine},
+			want: []model.DiscountLine{
+				discount("d2", 400, map[string]int64{"l1": 100, "l2": 300}),
+				// Split by the remaining amounts, 900 and 2700
+				discount("d1", 500, map[string]int64{"l1": 125, "l2": 375}),
+			},
+		},
+		{
+			name: "fixed never below zero",
+			rules: []model.DiscountRule{
+				{DiscountID: "d1", Discount: 800, Skus: []string{"bread"}},
+				{DiscountID: "d2", Discount: 500, Skus: []string{"bread"}},
+				{DiscountID: "d3", Discount: 500, Skus: []string{"bread"}},
+			},
+			lines: []model.TaxLine{bread, wine},
+			want: []model.DiscountLine{
+				discount("d1", 800, map[string]int64{"l1": 800}),
+				discount("d2", 200, map[string]int64{"l1": 200}),
+			},
+		},
+		{
+			name:  "pct is capped",
+			rules: []model.DiscountRule{{DiscountID: "d1", Pct: 20000}},
+			lines: []model.TaxLine{bread},
+			want: []model.DiscountLine{
+				discount("d1", 1000, map[string]int64{"l1": 1000})},
+		},
+		{
+			name:  "rounding half even",
+			mode:  money.RoundHalfEven,
+			rules: []model.DiscountRule{{DiscountID: "d1", Pct: 1500}},
+			// 49.95
+			lines: []model.TaxLine{{OrderLineID: "l1", Qty: 1, Amount: 333}},
+			want: []model.DiscountLine{
+				discount("d1", 50, map[string]int64{"l1": 50})},
+		},
+		{
+			name:  "rounding down",
+			mode:  money.RoundDown,
+			rules: []model.DiscountRule{{DiscountID: "d1", Pct: 1500}},
+			lines: []model.TaxLine{{OrderLineID: "l1", Qty: 1, Amount: 333}},
+			want: []model.DiscountLine{
+				discount("d1", 49, map[string]int64{"l1": 49})},
+		},
+		{
+			name: "restrictions",
+			rules: []model.DiscountRule{
+				{DiscountID: "d1", Pct: 1000, Countries: []string{"ITA"}},
+				{DiscountID: "d2", Pct: 1000, Users: []string{"u2"}},
+				{DiscountID: "d3", Pct: 1000, Ranges: []model.DateRange{
+					{Start: now.AddDate(0, 0, -2), End: now.AddDate(0, 0, -1)}}},
+				{DiscountID: "d4", Pct: 1000, Skus: []string{"cheese"}},
+			},
+			lines: []model.TaxLine{bread, wine},
+		},
+		{
+			name: "date range",
+			rules: []model.DiscountRule{
+				{DiscountID: "d1", Pct: 1000, Ranges: []model.DateRange{
+					{Start: now, End: now.AddDate(0, 0, 1)}}},
+			},
+			lines: []model.TaxLine{bread},
+			want: []model.DiscountLine{
+				discount("d1", 100, map[string]int64{"l1": 100})},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			is := is.New(t)
+			if tc.mode == "" {
+				tc.mode = money.RoundHalfEven
+			}
+			discounts := model.Discount(model.DiscountParams{
+				Now:     now,
+				Country: "ZAF",
+				UserID:  "u1",
+				Mode:    tc.mode,
+				Rules:   tc.rules,
+				Lines:   tc.lines,
+			})
+			if len(tc.want) == 0 {
+				is.Equal(len(discounts), 0)
+				return
+			}
+			is.Equal(discounts, tc.want)
+		})
+	}
+}
@@ -0,0 +1,188 @@
+package model
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/shopd/shopd/go/db/sqlite"
+	"github.com/shopd/shopd/go/money"
+	"github.com/shopd/shopd/go/share"
+)
+
+// Optional discounts are listed in discount_opt,
+// admin users may apply one to a pending order before it's paid.
+// The discount_id is set on the TermDiscountOpt order config,
+// and the order is priced again
+
+// OrderDiscounts lists optional discounts that apply to the pending order,
+// with a preview of the new totals
+func (m *Model) OrderDiscounts(
+	ctx context.Context, orderID string) (list share.OrderDiscounts, err error) {
+
+	order, err := orderByID(ctx, m.q, orderID)
+	if err != nil {
+		return list, err
+	}
+	list.OrderID = order.OrderID
+	list.State = order.State
+	list.Paid = order.Paid == 1
+	list.Currency, err = configVal(ctx, m.q, TermCurrency, CurrencyDefault)
+	if err != nil {
+		return list, err
+	}
+	list.Applied, err = orderConfigVal(ctx, m.q, orderID, TermDiscountOpt)
+	if err != nil {
+		return list, err
+	}
+
+	now := time.Now()
+	params, err := discountParams(ctx, m.q, order, now)
+	if err != nil {
+		return list, err
+	}
+	list.Totals, _, err = orderTotals(ctx, m.q, order, params)
+	if err != nil {
+		return list, err
+	}
+	list.Discounts = []share.OrderDiscount{}
+	if order.State != share.OrderStatePending || order.Paid == 1 {
+		return list, nil
+	}
+
+	opts, err := discountRules(ctx, m.q, true)
+	if err != nil {
+		return list, err
+	}
+	auto, err := discountRules(ctx, m.q, false)
+	if err != nil {
+		return list, err
+	}
+	for _, opt := range opts {
+		params.Rules = append(slices.Clone(auto), opt)
+		totals, discounts, err := orderTotals(ctx, m.q, order, params)
+		if err != nil {
+			return list, err
+		}
+		for _, discount := range discounts {
+			if discount.DiscountID != opt.DiscountID {
+				continue
+			}
+			list.Discounts = append(list.Discounts, share.OrderDiscount{
+				DiscountID: opt.DiscountID,
+				Descr:      opt.Descr,
+				Amount:     discount.Amount,
+				Totals:     totals,
+			})
+		}
+	}
+	return list, nil
+}
+
+// ApplyDiscount applies the optional discount to the pending order,
+// replacing the optional discount that was applied before.
+// Discounts and tax are calculated again
+func (m *Model) ApplyDiscount(
+	ctx context.Context, orderID, discountID, userID string) (err error) {
+
+	return m.tx(ctx, func(q *sqlite.Queries) error {
+		order, err := orderByID(ctx, q, orderID)
+		if err != nil {
+			return err
+		}
+		if order.Paid == 1 {
+			return errors.WithStack(ErrOrderPaid(orderID))
+		}
+		if order.State != share.OrderStatePending {
+			return errors.WithStack(ErrOrderState(orderID, order.State))
+		}
+
+		opts, err := discountRules(ctx, q, true)
+		if err != nil {
+			return err
+		}
+		i := slices.IndexFunc(opts, func(rule DiscountRule) bool {
+			return rule.DiscountID == discountID
+		})
+		if i < 0 {
+			return errors.WithStack(ErrNotFound(discountID))
+		}
+
+		err = q.OrderConfigUpsert(ctx, sqlite.OrderConfigUpsertParams{
+			OrderID: orderID,
+			Term:    TermDiscountOpt,
+			Val:     discountID,
+		})
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		params, err := discountParams(ctx, q, order, time.Now())
+		if err != nil {
+			return err
+		}
+		if !slices.ContainsFunc(Discount(params), func(d DiscountLine) bool {
+			return d.DiscountID == discountID
+		}) {
+			return errors.WithStack(ErrDiscountNotApplicable(discountID))
+		}
+		err = priceOrder(ctx, q, order)
+		if err != nil {
+			return err
+		}
+
+		err = orderAct(ctx, q, order, userID, true,
+			fmt.Sprintf("Applied discount %s: %s", discountID, opts[i].Descr))
+		if err != nil {
+			return err
+		}
+		// The voucher may cover the discounted total
+		return orderPaid(ctx, q, order, userID)
+	})
+}
+
+// orderTotals calculates the discounts and totals for the order,
+// without changing the order
+func orderTotals(
+	ctx context.Context, q *sqlite.Queries, order sqlite.Orders,
+	params DiscountParams) (
+	totals share.OrderTotals, discounts []DiscountLine, err error) {
+
+	discounts = Discount(params)
+	tax, err := taxParams(ctx, q, order.OrderID)
+	if err != nil {
+		return totals, discounts, err
+	}
+	tax.Lines = discountedLines(params.Lines, discounts)
+	rows, err := Tax(tax)
+	if err != nil {
+		return totals, discounts, err
+	}
+
+	currency, err := configVal(ctx, q, TermCurrency, CurrencyDefault)
+	if err != nil {
+		return totals, discounts, err
+	}
+	amounts := make([]int64, 0, len(params.Lines))
+	for _, line := range params.Lines {
+		amounts = append(amounts, line.Amount)
+	}
+	totals.Subtotal = money.Sum(currency, amounts...).Amount()
+	amounts = make([]int64, 0, len(discounts))
+	for _, discount := range discounts {
+		amounts = append(amounts, discount.Amount)
+	}
+	totals.Discount = money.Sum(currency, amounts...).Amount()
+	totals.Tax, err = taxTotal(ctx, q, rows, params.Lines)
+	if err != nil {
+		return totals, discounts, err
+	}
+	total, err := money.Sum(currency, totals.Subtotal, totals.Tax).Subtract(
+		money.New(totals.Discount, currency))
+	if err != nil {
+		return totals, discounts, err
+	}
+	totals.Total = total.Amount()
+	return totals, discounts, nil
+}
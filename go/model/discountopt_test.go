@@ -0,0 +1,40 @@
+package model_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/matryer/is"
+	"github.com/shopd/shopd/go/share"
+)
+
+func TestApplyDiscountFailsStalePayments(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+	m, db := newTestModel(t)
+	exec(t, db,
+		`insert into cat(sku, title, descr, state, mod, mod_id)
+		values ('a', 'Apple', '', 'stock', 'm', 's')`,
+		`insert into cat_price values ('a', 1000)`,
+		`insert into discount values ('d1', 1000, 0, 'Staff', 'm', 's')`,
+		`insert into discount_opt values ('d1')`,
+	)
+	cart, err := m.CartAdd(ctx, "", "", share.ParamsCartPost{Sku: "a", Qty: 1})
+	is.NoErr(err)
+	orderID := cart.OrderID
+	is.NoErr(m.SetOrderState(ctx, orderID, share.OrderStatePending, ""))
+	stale, err := m.PaymentCreate(ctx, orderID, "fake", "")
+	is.NoErr(err)
+	is.Equal(stale.Amount, int64(1150))
+
+	is.NoErr(m.ApplyDiscount(ctx, orderID, "d1", "admin"))
+	stale, err = m.Payment(ctx, stale.TranID)
+	is.NoErr(err)
+	is.Equal(stale.State, share.TranStateFailed)
+
+	// Checkout creates a payment for the discounted amount
+	pay, err := m.PaymentCreate(ctx, orderID, "fake", "")
+	is.NoErr(err)
+	is.True(pay.TranID != stale.TranID)
+	is.Equal(pay.Amount, int64(1035))
+}
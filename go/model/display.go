@@ -0,0 +1,148 @@
+package model
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	"github.com/shopd/shopd/go/db/sqlite"
+	"github.com/shopd/shopd/go/money"
+	"github.com/shopd/shopd/go/share"
+)
+
+// Prices in the DB exclude tax, see comments for cat_price.
+// The price_display term decides if buyers see prices inclusive or
+// exclusive of tax. Inclusive prices are calculated with the tax engine,
+// for the country of the order, or the domain country.
+//
+// Inclusive line amounts must sum to the order total. Product lines include
+// the tax before discounts, allocated from the rounded total, and discount
+// lines include the difference with the tax that is charged
+
+// TermPriceDisplay is inclusive or exclusive
+const TermPriceDisplay = "price_display"
+
+// priceDisplay returns the price display mode for the domain
+func priceDisplay(
+	ctx context.Context, q *sqlite.Queries) (display string, err error) {
+
+	display, err = configVal(ctx, q, TermPriceDisplay, share.PriceDisplayExclusive)
+	if err != nil {
+		return display, err
+	}
+	if display != share.PriceDisplayInclusive &&
+		display != share.PriceDisplayExclusive {
+		return display, errors.WithStack(ErrConfig(TermPriceDisplay))
+	}
+	return display, nil
+}
+
+// inclusivePrice returns the unit price including tax for the sku,
+// params are the tax params for the order without lines
+func inclusivePrice(
+	ctx context.Context, q *sqlite.Queries, params TaxParams, sku string,
+	price int64) (incl int64, err error) {
+
+	tags, err := q.CatTagsBySKU(ctx, sku)
+	if err != nil {
+		return incl, errors.WithStack(err)
+	}
+	line := TaxLine{Sku: sku, Qty: 1, Amount: price}
+	for _, tag := range tags {
+		line.Tags = append(line.Tags, tag.Tag)
+	}
+	params.Method = TaxMethodLine
+	params.Lines = []TaxLine{line}
+	rows, err := Tax(params)
+	if err != nil {
+		return incl, err
+	}
+	mode, _, err := rounding(ctx, q)
+	if err != nil {
+		return incl, err
+	}
+	var tax money.Exact
+	for _, row := range rows {
+		tax += row.Tax
+	}
+	return price + tax.Round(mode), nil
+}
+
+// inclusiveLines returns the line amounts including tax by order_line_id.
+// Lines are the order lines before discounts, including system lines,
+// and tax is the rounded tax total for the order
+func inclusiveLines(
+	ctx context.Context, q *sqlite.Queries, orderID string, lines []TaxLine,
+	tax int64) (amounts map[string]int64, err error) {
+
+	products := []TaxLine{}
+	discounts := []TaxLine{}
+	amounts = make(map[string]int64, len(lines))
+	for _, line := range lines {
+		amounts[line.OrderLineID] = line.Amount
+		if line.Sku == SkuDiscount {
+			discounts = append(discounts, line)
+		} else if !systemSku(line.Sku) {
+			products = append(products, line)
+		}
+	}
+	if len(products) == 0 {
+		return amounts, nil
+	}
+
+	params, err := taxParams(ctx, q, orderID)
+	if err != nil {
+		return amounts, err
+	}
+	params.Method = TaxMethodLine
+	params.Lines = products
+	rows, err := Tax(params)
+	if err != nil {
+		return amounts, err
+	}
+	exact := make(map[string]money.Exact)
+	for _, row := range rows {
+		exact[row.OrderLineID] += row.Tax
+	}
+	mode, _, err := rounding(ctx, q)
+	if err != nil {
+		return amounts, err
+	}
+	var sum money.Exact
+	weights := make([]int64, len(products))
+	for i, line := range products {
+		weights[i] = int64(exact[line.OrderLineID])
+		sum += exact[line.OrderLineID]
+	}
+	before := sum.Round(mode)
+	for i, part := range money.Allocate(before, weights...) {
+		amounts[products[i].OrderLineID] += part
+	}
+
+	// The difference is due to discounts, or rounding per invoice
+	diff := tax - before
+	if diff == 0 {
+		return amounts, nil
+	}
+	if len(discounts) == 0 {
+		for i, part := range money.Allocate(diff, weights...) {
+			amounts[products[i].OrderLineID] += part
+		}
+		return amounts, nil
+	}
+	weights = make([]int64, len(discounts))
+	for i, line := range discounts {
+		weights[i] = -line.Amount
+	}
+	for i, part := range money.Allocate(diff, weights...) {
+		amounts[discounts[i].OrderLineID] += part
+	}
+	return amounts, nil
+}
+
+// unitPrice for the line amount, rounded
+func unitPrice(amount, qty int64, mode money.Rounding) int64 {
+	if qty == 0 {
+		return amount
+	}
+	return money.Exact(money.NewExact(amount) / money.Exact(qty)).Round(mode)
+}
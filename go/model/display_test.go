@@ -0,0 +1,56 @@
+package model_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/matryer/is"
+	"github.com/shopd/shopd/go/share"
+)
+
+func TestPriceDisplay(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+	m, db := newTestModel(t)
+	exec(t, db,
+		`insert into cat(sku, title, descr, state, mod, mod_id) values
+		('a', 'Apple', '', 'stock', 'm', 's'),
+		('b', 'Banana', '', 'stock', 'm', 's'),
+		('c', 'Cherry', '', 'stock', 'm', 's')`,
+		`insert into cat_price values ('a', 333), ('b', 1001), ('c', 77)`,
+		`insert into discount values ('d1', 1000, 0, 'Sale', 'm', 's')`,
+		`update config set val = 'inclusive' where term = 'price_display'`,
+	)
+
+	price, err := m.SkuPrice(ctx, "b", "", "")
+	is.NoErr(err)
+	is.Equal(price.TaxDisplay, share.PriceDisplayInclusive)
+	is.Equal(price.Price, int64(1151)) // 1001 + 150.15 rounded
+
+	cart, err := m.CartAdd(ctx, "", "", share.ParamsCartPost{Sku: "a", Qty: 3})
+	is.NoErr(err)
+	_, err = m.CartAdd(ctx, cart.OrderID, "", share.ParamsCartPost{Sku: "b", Qty: 1})
+	is.NoErr(err)
+	cart, err = m.CartAdd(ctx, cart.OrderID, "", share.ParamsCartPost{Sku: "c", Qty: 7})
+	is.NoErr(err)
+
+	// Inclusive line amounts sum to the total, including discount lines
+	is.Equal(cart.TaxDisplay, share.PriceDisplayInclusive)
+	var sum int64
+	for _, line := range cart.Lines {
+		sum += line.Amount
+	}
+	is.Equal(sum, cart.Subtotal)
+	is.Equal(cart.Subtotal, cart.Total)
+
+	is.NoErr(m.SetOrderState(ctx, cart.OrderID, share.OrderStatePending, ""))
+	inv, err := m.Receipt(ctx, cart.OrderID)
+	is.NoErr(err)
+	is.Equal(inv.Total, cart.Total)
+
+	// Exclusive prices are shown as stored
+	exec(t, db, `update config set val = 'exclusive' where term = 'price_display'`)
+	price, err = m.SkuPrice(ctx, "b", "", "")
+	is.NoErr(err)
+	is.Equal(price.Price, int64(1001))
+}
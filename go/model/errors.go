@@ -0,0 +1,76 @@
+package model
+
+import (
+	"github.com/mozey/errors"
+)
+
+var ErrModel = errors.NewCause("model")
+
+var ErrAllocStrategy = func(strategy string) error {
+	return errors.NewWithCausef(ErrModel, "invalid allocation strategy %s", strategy)
+}
+
+var ErrInsufficientStock = func(sku string) error {
+	return errors.NewWithCausef(ErrModel, "insufficient stock %s", sku)
+}
+
+var ErrOrderNotPaid = func(orderID string) error {
+	return errors.NewWithCausef(ErrModel, "order not paid %s", orderID)
+}
+
+var ErrOrderPaid = func(orderID string) error {
+	return errors.NewWithCausef(ErrModel, "order already paid %s", orderID)
+}
+
+var ErrNotFound = func(msg string) error {
+	return errors.NewWithCausef(ErrModel, "not found %s", msg)
+}
+
+var ErrInvalidQty = func(qty int64) error {
+	return errors.NewWithCausef(ErrModel, "invalid qty %d", qty)
+}
+
+var ErrUnavailable = func(sku string) error {
+	return errors.NewWithCausef(ErrModel, "unavailable %s", sku)
+}
+
+var ErrSessionVerify = func(userID string) error {
+	return errors.NewWithCausef(ErrModel, "session not verified %s", userID)
+}
+
+var ErrConfig = func(term string) error {
+	return errors.NewWithCausef(ErrModel, "invalid config %s", term)
+}
+
+var ErrStateTransition = func(from, to string) error {
+	return errors.NewWithCausef(ErrModel, "invalid state transition %s to %s", from, to)
+}
+
+var ErrInvalidParam = func(param string) error {
+	return errors.NewWithCausef(ErrModel, "invalid param %s", param)
+}
+
+var ErrOrderState = func(orderID, state string) error {
+	return errors.NewWithCausef(ErrModel, "order %s is %s", orderID, state)
+}
+
+var ErrOrderPayments = func(orderID string) error {
+	return errors.NewWithCausef(ErrModel,
+		"order %s has payments, issue a credit note to reverse it", orderID)
+}
+
+var ErrInvalidCode = func(code string) error {
+	return errors.NewWithCausef(ErrModel, "invalid code %s", code)
+}
+
+var ErrCouponLimit = func(code string) error {
+	return errors.NewWithCausef(ErrModel, "coupon %s usage limit reached", code)
+}
+
+var ErrDiscountNotApplicable = func(discountID string) error {
+	return errors.NewWithCausef(ErrModel, "discount %s does not apply", discountID)
+}
+
+var ErrSignature = func(processor string) error {
+	return errors.NewWithCausef(ErrModel, "invalid signature %s", processor)
+}
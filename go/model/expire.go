@@ -0,0 +1,211 @@
+package model
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/shopd/shopd/go/db/sqlite"
+	"github.com/shopd/shopd/go/share"
+)
+
+// Config terms for idle carts, values are parsed with time.ParseDuration
+const (
+	TermCartExpire = "cart_expire"
+	// TermCartRemind is disabled if empty
+	TermCartRemind = "cart_remind"
+	// TermCartReminded is the order_config term set when a reminder was sent,
+	// it's removed when the cart changes
+	TermCartReminded = "cart_reminded"
+)
+
+// CartExpireDefault is used if the cart_expire term is not set
+const CartExpireDefault = "720h"
+
+// TermOrderExpire is the idle period after which unpaid pending orders
+// are expired, parsed with time.ParseDuration
+const TermOrderExpire = "order_expire"
+
+// OrderExpireDefault is used if the order_expire term is not set
+const OrderExpireDefault = "168h"
+
+// ExpireCarts expires carts that were idle since before now minus the
+// cart_expire period, and releases stock reserved for them
+func (m *Model) ExpireCarts(ctx context.Context, now time.Time) (n int, err error) {
+	idle, err := configDuration(ctx, m.q, TermCartExpire, CartExpireDefault)
+	if err != nil {
+		return n, err
+	}
+	if idle == 0 {
+		return n, nil
+	}
+
+	orders, err := m.q.OrdersIdle(ctx, sqlite.OrdersIdleParams{
+		State: share.OrderStateCart,
+		Mod:   modBefore(now.Add(-idle)),
+	})
+	if err != nil {
+		return n, errors.WithStack(err)
+	}
+	for _, order := range orders {
+		err = m.tx(ctx, func(q *sqlite.Queries) error {
+			err := releaseOrder(ctx, q, order.OrderID, ModIDSystem)
+			if err != nil {
+				return err
+			}
+			err = q.OrderUpdateState(ctx, sqlite.OrderUpdateStateParams{
+				State:   share.OrderStateExpired,
+				Mod:     NewID(),
+				ModID:   ModIDSystem,
+				OrderID: order.OrderID,
+			})
+			if err != nil {
+				return errors.WithStack(err)
+			}
+			order.State = share.OrderStateExpired
+			return orderAct(ctx, q, order, ModIDSystem, false,
+				fmt.Sprintf("Cart expired after %s idle", idle))
+		})
+		if err != nil {
+			return n, err
+		}
+		n++
+	}
+	return n, nil
+}
+
+// ExpireOrders expires unpaid pending orders that were idle since before
+// now minus the order_expire period. Vouchers drawn at checkout are refunded,
+// orders with payments are left for a credit note
+func (m *Model) ExpireOrders(ctx context.Context, now time.Time) (n int, err error) {
+	idle, err := configDuration(ctx, m.q, TermOrderExpire, OrderExpireDefault)
+	if err != nil {
+		return n, err
+	}
+	if idle == 0 {
+		return n, nil
+	}
+
+	orders, err := m.q.OrdersIdle(ctx, sqlite.OrdersIdleParams{
+		State: share.OrderStatePending,
+		Mod:   modBefore(now.Add(-idle)),
+	})
+	if err != nil {
+		return n, errors.WithStack(err)
+	}
+	for _, order := range orders {
+		expired := false
+		err = m.tx(ctx, func(q *sqlite.Queries) error {
+			if order.Paid == 1 {
+				return nil
+			}
+			paid, err := orderHasPayments(ctx, q, order.OrderID)
+			if err != nil || paid {
+				return err
+			}
+			err = voucherRefund(ctx, q, order.OrderID, ModIDSystem)
+			if err != nil {
+				return err
+			}
+			err = q.OrderUpdateState(ctx, sqlite.OrderUpdateStateParams{
+				State:   share.OrderStateExpired,
+				Mod:     NewID(),
+				ModID:   ModIDSystem,
+				OrderID: order.OrderID,
+			})
+			if err != nil {
+				return errors.WithStack(err)
+			}
+			expired = true
+			order.State = share.OrderStateExpired
+			return orderAct(ctx, q, order, ModIDSystem, false,
+				fmt.Sprintf("Order expired after %s idle", idle))
+		})
+		if err != nil {
+			return n, err
+		}
+		if expired {
+			n++
+		}
+	}
+	return n, nil
+}
+
+// CartReminders lists carts that were idle since before now minus the
+// cart_remind period. Only users with an email are reminded, once per change
+func (m *Model) CartReminders(
+	ctx context.Context, now time.Time) (reminders []share.CartReminder, err error) {
+
+	idle, err := configDuration(ctx, m.q, TermCartRemind, "")
+	if err != nil {
+		return reminders, err
+	}
+	if idle == 0 {
+		return reminders, nil
+	}
+
+	rows, err := m.q.OrdersIdleWithoutConfig(ctx, sqlite.OrdersIdleWithoutConfigParams{
+		State: share.OrderStateCart,
+		Mod:   modBefore(now.Add(-idle)),
+		Term:  TermCartReminded,
+	})
+	if err != nil {
+		return reminders, errors.WithStack(err)
+	}
+	reminders = make([]share.CartReminder, 0, len(rows))
+	for _, row := range rows {
+		cart, err := m.Cart(ctx, row.OrderID, row.UserID)
+		if err != nil {
+			return reminders, err
+		}
+		if len(cart.Lines) == 0 {
+			continue
+		}
+		reminders = append(reminders, share.CartReminder{
+			Cart:  cart,
+			Email: row.Email,
+		})
+	}
+	return reminders, nil
+}
+
+// CartReminded records that a reminder was sent,
+// it does not change the mod cols used to determine idle time
+func (m *Model) CartReminded(ctx context.Context, orderID string) (err error) {
+	return m.tx(ctx, func(q *sqlite.Queries) error {
+		order, err := cartByID(ctx, q, orderID)
+		if err != nil {
+			return err
+		}
+		err = q.OrderConfigUpsert(ctx, sqlite.OrderConfigUpsertParams{
+			OrderID: orderID,
+			Term:    TermCartReminded,
+			Val:     NewID(),
+		})
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		return orderAct(ctx, q, order, ModIDSystem, false, "Cart reminder sent")
+	})
+}
+
+// configDuration returns the global setting for term as a duration,
+// zero if the term is not set and deflt is empty
+func configDuration(
+	ctx context.Context, q *sqlite.Queries, term, deflt string) (
+	d time.Duration, err error) {
+
+	val, err := configVal(ctx, q, term, deflt)
+	if err != nil {
+		return d, err
+	}
+	if val == "" {
+		return d, nil
+	}
+	d, err = time.ParseDuration(val)
+	if err != nil {
+		return d, errors.WithStack(ErrConfig(term))
+	}
+	return d, nil
+}
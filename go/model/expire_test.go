@@ -0,0 +1,130 @@
+package model_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+	"github.com/pkg/errors"
+	"github.com/shopd/shopd/go/model"
+	"github.com/shopd/shopd/go/share"
+)
+
+func TestCartReminders(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+	m, db := newTestModel(t)
+	exec(t, db,
+		`insert into cat(sku, title, descr, state, mod, mod_id)
+		values ('a', 'Apple', '', 'stock', 'm', 's')`,
+		`insert into cat_price values ('a', 100)`,
+		`insert into user(user_id, email, descr, mod)
+		values ('u1', 'u1@example.com', '', 'm')`,
+		`insert into config(term, val, mod) values ('cart_remind', '1h', 'm')`,
+	)
+	cart, err := m.CartAdd(ctx, "", "u1", share.ParamsCartPost{Sku: "a", Qty: 1})
+	is.NoErr(err)
+	now := time.Now()
+
+	reminders, err := m.CartReminders(ctx, now)
+	is.NoErr(err)
+	is.Equal(len(reminders), 0) // not idle yet
+
+	reminders, err = m.CartReminders(ctx, now.Add(2*time.Hour))
+	is.NoErr(err)
+	is.Equal(len(reminders), 1)
+	is.Equal(reminders[0].Email, "u1@example.com")
+	is.Equal(reminders[0].Cart.OrderID, cart.OrderID)
+
+	// Only one reminder is sent until the cart changes
+	is.NoErr(m.CartReminded(ctx, cart.OrderID))
+	reminders, err = m.CartReminders(ctx, now.Add(2*time.Hour))
+	is.NoErr(err)
+	is.Equal(len(reminders), 0)
+}
+
+func TestExpireCarts(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+	m, db := newTestModel(t)
+	exec(t, db,
+		`insert into cat(sku, title, descr, state, mod, mod_id)
+		values ('a', 'Apple', '', 'stock', 'm', 's')`,
+		`insert into cat_price values ('a', 100)`,
+	)
+	cart, err := m.CartAdd(ctx, "", "", share.ParamsCartPost{Sku: "a", Qty: 1})
+	is.NoErr(err)
+	now := time.Now()
+
+	n, err := m.ExpireCarts(ctx, now)
+	is.NoErr(err)
+	is.Equal(n, 0)
+
+	n, err = m.ExpireCarts(ctx, now.Add(31*24*time.Hour))
+	is.NoErr(err)
+	is.Equal(n, 1)
+	var state string
+	is.NoErr(db.QueryRow(`select state from orders where order_id = ?`,
+		cart.OrderID).Scan(&state))
+	is.Equal(state, share.OrderStateExpired)
+
+	// Expired carts can't be changed
+	_, err = m.Cart(ctx, cart.OrderID, "")
+	is.True(errors.Is(err, model.ErrNotFound("")))
+}
+
+func TestExpireOrders(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+	m, db := newTestModel(t)
+	exec(t, db,
+		`insert into cat(sku, title, descr, state, mod, mod_id)
+		values ('a', 'Apple', '', 'stock', 'm', 's')`,
+		`insert into cat_price values ('a', 1000)`,
+		`insert into voucher values ('GIFT', 500, 500, 'm', 's')`,
+	)
+	cart, err := m.CartAdd(ctx, "", "", share.ParamsCartPost{Sku: "a", Qty: 1})
+	is.NoErr(err)
+	_, err = m.CartCode(ctx, cart.OrderID, "", "gift")
+	is.NoErr(err)
+	is.NoErr(m.SetOrderState(ctx, cart.OrderID, share.OrderStatePending, ""))
+	var balance int64
+	is.NoErr(db.QueryRow(`select balance from voucher where code = 'GIFT'`).
+		Scan(&balance))
+	is.Equal(balance, int64(0))
+	now := time.Now()
+
+	n, err := m.ExpireOrders(ctx, now)
+	is.NoErr(err)
+	is.Equal(n, 0)
+
+	// The abandoned order releases the voucher balance
+	n, err = m.ExpireOrders(ctx, now.Add(8*24*time.Hour))
+	is.NoErr(err)
+	is.Equal(n, 1)
+	var state string
+	is.NoErr(db.QueryRow(`select state from orders where order_id = ?`,
+		cart.OrderID).Scan(&state))
+	is.Equal(state, share.OrderStateExpired)
+	is.NoErr(db.QueryRow(`select balance from voucher where code = 'GIFT'`).
+		Scan(&balance))
+	is.Equal(balance, int64(500))
+}
+
+func TestSMTPConfig(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+	m, db := newTestModel(t)
+
+	_, err := m.SMTPConfig(ctx)
+	is.True(errors.Is(err, model.ErrConfig(model.TermSmtpAddr)))
+
+	exec(t, db, `insert into config(term, val, mod) values
+		('smtp_addr', 'smtp.example.com:587', 'm'),
+		('business_email', 'shop@example.com', 'm')`)
+	conf, err := m.SMTPConfig(ctx)
+	is.NoErr(err)
+	is.Equal(conf, share.SMTPConfig{
+		Addr: "smtp.example.com:587", From: "shop@example.com"})
+}
@@ -0,0 +1,177 @@
+package model
+
+import (
+	"context"
+	"database/sql"
+	"slices"
+
+	"github.com/pkg/errors"
+	"github.com/shopd/shopd/go/db/sqlite"
+	"github.com/shopd/shopd/go/share"
+)
+
+// CatImgs lists the images for the sku, the first is the default image
+func (m *Model) CatImgs(
+	ctx context.Context, sku string) (imgs share.CatImgs, err error) {
+
+	return catImgs(ctx, m.q, sku)
+}
+
+// CatImgAdd links the image to the sku, the image is added last.
+// Images are de-duplicated by hash, linking an image again updates the descr,
+// and the alt if it's set
+func (m *Model) CatImgAdd(
+	ctx context.Context, sku string, img share.Img) (
+	imgs share.CatImgs, err error) {
+
+	err = m.tx(ctx, func(q *sqlite.Queries) error {
+		imgs, err = catImgs(ctx, q, sku)
+		if err != nil {
+			return err
+		}
+		err = q.ImgUpsert(ctx, sqlite.ImgUpsertParams{
+			Hash: img.Hash,
+			Ext:  img.Ext,
+			Alt:  img.Alt,
+			Mod:  NewID(),
+		})
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		err = q.CatImgUpsert(ctx, sqlite.CatImgUpsertParams{
+			SKU:   sku,
+			Hash:  img.Hash,
+			Descr: img.Descr,
+			Idx:   int64(len(imgs.Imgs)),
+		})
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		imgs, err = catImgs(ctx, q, sku)
+		return err
+	})
+	if err != nil {
+		return imgs, err
+	}
+	return imgs, nil
+}
+
+// CatImgOrder sorts the images for the sku,
+// hashes must list all linked images and the first is the default image
+func (m *Model) CatImgOrder(
+	ctx context.Context, params share.ParamsCatImgOrderPost) (
+	imgs share.CatImgs, err error) {
+
+	err = m.tx(ctx, func(q *sqlite.Queries) error {
+		imgs, err = catImgs(ctx, q, params.Sku)
+		if err != nil {
+			return err
+		}
+		if len(params.Hash) != len(imgs.Imgs) {
+			return errors.WithStack(ErrInvalidParam("Hash"))
+		}
+		for _, img := range imgs.Imgs {
+			if !slices.Contains(params.Hash, img.Hash) {
+				return errors.WithStack(ErrInvalidParam("Hash"))
+			}
+		}
+		err = catImgIdx(ctx, q, params.Sku, params.Hash)
+		if err != nil {
+			return err
+		}
+		imgs, err = catImgs(ctx, q, params.Sku)
+		return err
+	})
+	if err != nil {
+		return imgs, err
+	}
+	return imgs, nil
+}
+
+// CatImgDelete unlinks the image from the sku,
+// the remaining images are renumbered so the first is the default image.
+// The image is kept, it may be linked to other skus
+func (m *Model) CatImgDelete(
+	ctx context.Context, params share.ParamsCatImgDelete) (
+	imgs share.CatImgs, err error) {
+
+	err = m.tx(ctx, func(q *sqlite.Queries) error {
+		imgs, err = catImgs(ctx, q, params.Sku)
+		if err != nil {
+			return err
+		}
+		hashes := []string{}
+		for _, img := range imgs.Imgs {
+			if img.Hash != params.Hash {
+				hashes = append(hashes, img.Hash)
+			}
+		}
+		if len(hashes) == len(imgs.Imgs) {
+			return errors.WithStack(ErrNotFound(params.Hash))
+		}
+		err = q.CatImgDelete(ctx, sqlite.CatImgDeleteParams{
+			SKU:  params.Sku,
+			Hash: params.Hash,
+		})
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		err = catImgIdx(ctx, q, params.Sku, hashes)
+		if err != nil {
+			return err
+		}
+		imgs, err = catImgs(ctx, q, params.Sku)
+		return err
+	})
+	if err != nil {
+		return imgs, err
+	}
+	return imgs, nil
+}
+
+func catImgs(
+	ctx context.Context, q *sqlite.Queries, sku string) (
+	imgs share.CatImgs, err error) {
+
+	imgs.Sku = sku
+	_, err = q.CatBySKU(ctx, sku)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return imgs, errors.WithStack(ErrNotFound(sku))
+		}
+		return imgs, errors.WithStack(err)
+	}
+	rows, err := q.CatImgsBySKU(ctx, sku)
+	if err != nil {
+		return imgs, errors.WithStack(err)
+	}
+	imgs.Imgs = make([]share.Img, 0, len(rows))
+	for _, row := range rows {
+		imgs.Imgs = append(imgs.Imgs, share.Img{
+			Hash:  row.Hash,
+			Ext:   row.Ext,
+			Alt:   row.Alt,
+			Descr: row.Descr,
+			Idx:   row.Idx,
+		})
+	}
+	return imgs, nil
+}
+
+// catImgIdx sets idx in the order of hashes, starting from 0
+func catImgIdx(
+	ctx context.Context, q *sqlite.Queries, sku string, hashes []string) (
+	err error) {
+
+	for i, hash := range hashes {
+		err = q.CatImgIdxUpdate(ctx, sqlite.CatImgIdxUpdateParams{
+			Idx:  int64(i),
+			SKU:  sku,
+			Hash: hash,
+		})
+		if err != nil {
+			return errors.WithStack(err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,89 @@
+package model_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/matryer/is"
+	"github.com/pkg/errors"
+	"github.com/shopd/shopd/go/model"
+	"github.com/shopd/shopd/go/share"
+)
+
+func TestCatImg(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+	m, db := newTestModel(t)
+	exec(t, db,
+		`insert into cat(sku, title, descr, state, mod, mod_id) values
+		('apple', 'Apple', '', 'stock', 'm', 's'),
+		('pear', 'Pear', '', 'stock', 'm', 's')`,
+	)
+	hashes := func(imgs share.CatImgs) (list []string) {
+		for i, img := range imgs.Imgs {
+			is.Equal(img.Idx, int64(i))
+			list = append(list, img.Hash)
+		}
+		return list
+	}
+
+	// Images are added last
+	for _, hash := range []string{"h1", "h2", "h3"} {
+		_, err := m.CatImgAdd(ctx, "apple", share.Img{
+			Hash: hash, Ext: share.ImgExtJPEG, Alt: "Apple " + hash})
+		is.NoErr(err)
+	}
+	imgs, err := m.CatImgs(ctx, "apple")
+	is.NoErr(err)
+	is.Equal(hashes(imgs), []string{"h1", "h2", "h3"})
+	is.Equal(imgs.Imgs[0].Alt, "Apple h1")
+
+	// Images are de-duplicated by hash, and may be linked to other skus
+	imgs, err = m.CatImgAdd(ctx, "pear", share.Img{
+		Hash: "h1", Ext: share.ImgExtJPEG, Alt: "Fruit", Descr: "Side"})
+	is.NoErr(err)
+	is.Equal(hashes(imgs), []string{"h1"})
+	is.Equal(imgs.Imgs[0].Descr, "Side")
+	var n int
+	is.NoErr(db.QueryRow(`select count(*) from img`).Scan(&n))
+	is.Equal(n, 3)
+	imgs, err = m.CatImgs(ctx, "apple")
+	is.NoErr(err)
+	is.Equal(imgs.Imgs[0].Alt, "Fruit")
+
+	// The first image is the default
+	imgs, err = m.CatImgOrder(ctx, share.ParamsCatImgOrderPost{
+		Sku: "apple", Hash: []string{"h3", "h1", "h2"}})
+	is.NoErr(err)
+	is.Equal(hashes(imgs), []string{"h3", "h1", "h2"})
+	for _, hash := range [][]string{
+		{"h3", "h1"},
+		{"h3", "h1", "h2", "h4"},
+		{"h3", "h1", "h4"},
+	} {
+		_, err = m.CatImgOrder(ctx, share.ParamsCatImgOrderPost{
+			Sku: "apple", Hash: hash})
+		is.True(errors.Is(err, model.ErrInvalidParam("")))
+	}
+
+	// Remaining images are renumbered, the image is kept for other skus
+	imgs, err = m.CatImgDelete(ctx, share.ParamsCatImgDelete{
+		Sku: "apple", Hash: "h3"})
+	is.NoErr(err)
+	is.Equal(hashes(imgs), []string{"h1", "h2"})
+	imgs, err = m.CatImgDelete(ctx, share.ParamsCatImgDelete{
+		Sku: "apple", Hash: "h1"})
+	is.NoErr(err)
+	is.Equal(hashes(imgs), []string{"h2"})
+	imgs, err = m.CatImgs(ctx, "pear")
+	is.NoErr(err)
+	is.Equal(hashes(imgs), []string{"h1"})
+	_, err = m.CatImgDelete(ctx, share.ParamsCatImgDelete{
+		Sku: "apple", Hash: "h1"})
+	is.True(errors.Is(err, model.ErrNotFound("")))
+
+	_, err = m.CatImgs(ctx, "banana")
+	is.True(errors.Is(err, model.ErrNotFound("")))
+	_, err = m.CatImgAdd(ctx, "banana", share.Img{Hash: "h1", Ext: share.ImgExtJPEG})
+	is.True(errors.Is(err, model.ErrNotFound("")))
+}
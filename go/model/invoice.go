@@ -0,0 +1,389 @@
+package model
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/shopd/shopd/go/db/sqlite"
+	"github.com/shopd/shopd/go/money"
+	"github.com/shopd/shopd/go/share"
+)
+
+// Config terms for the business details printed on invoices.
+// South African tax invoices must include the name, address,
+// and VAT registration number of the supplier
+const (
+	TermBusinessName  = "business_name"
+	TermBusinessAddr  = "business_addr"
+	TermBusinessVatNo = "business_vat_no"
+	TermBusinessRegNo = "business_reg_no"
+	TermBusinessEmail = "business_email"
+	TermBusinessPhone = "business_phone"
+)
+
+// TermCurrency is the config term for the default currency
+const TermCurrency = "currency"
+
+// CurrencyDefault is used if the currency term is not set
+const CurrencyDefault = "ZAR"
+
+// TermVatNo is the order_config term for the customer VAT number
+const TermVatNo = "vat_no"
+
+// Invoice builds the tax invoice for a paid order,
+// the order number is allocated when the order is paid
+func (m *Model) Invoice(
+	ctx context.Context, orderID string) (inv share.Invoice, err error) {
+
+	order, err := orderByID(ctx, m.q, orderID)
+	if err != nil {
+		return inv, err
+	}
+	if order.Paid == 0 {
+		return inv, errors.WithStack(ErrOrderNotPaid(orderID))
+	}
+	return invoice(ctx, m.q, order)
+}
+
+// Receipt lists the payments received for an order,
+// it may be used for orders that are not paid in full
+func (m *Model) Receipt(
+	ctx context.Context, orderID string) (inv share.Invoice, err error) {
+
+	order, err := orderByID(ctx, m.q, orderID)
+	if err != nil {
+		return inv, err
+	}
+	return invoice(ctx, m.q, order)
+}
+
+func invoice(
+	ctx context.Context, q *sqlite.Queries, order sqlite.Orders) (
+	inv share.Invoice, err error) {
+
+	inv.OrderID = order.OrderID
+	inv.OrderNo = order.OrderNo
+	inv.Currency, err = configVal(ctx, q, TermCurrency, CurrencyDefault)
+	if err != nil {
+		return inv, err
+	}
+	inv.Seller, err = invoiceSeller(ctx, q)
+	if err != nil {
+		return inv, err
+	}
+	inv.Buyer, err = invoiceBuyer(ctx, q, order)
+	if err != nil {
+		return inv, err
+	}
+
+	lines, err := q.OrderLinesWithTitle(ctx, order.OrderID)
+	if err != nil {
+		return inv, errors.WithStack(err)
+	}
+	descr, err := systemDescr(ctx, q, order.OrderID)
+	if err != nil {
+		return inv, err
+	}
+	amounts := make([]lineAmount, 0, len(lines))
+	subtotal := money.New(0, inv.Currency)
+	inv.Lines = make([]share.InvoiceLine, 0, len(lines))
+	// lineIDs are the order_line_id for each invoice line
+	lineIDs := make([]string, 0, len(lines))
+	// Vouchers are a monetary substitute, listed with the payments
+	vouchers := []share.InvoicePayment{}
+	for _, line := range lines {
+		amount := money.New(line.Price, inv.Currency).Multiply(line.Qty)
+		if line.SKU == SkuVoucher {
+			vouchers = append(vouchers, share.InvoicePayment{
+				Descr:  descr[line.OrderLineID],
+				Date:   modTime(line.OrderLineID),
+				Amount: -amount.Amount(),
+			})
+			continue
+		}
+		if !systemSku(line.SKU) {
+			amounts = append(amounts, lineAmount{line.OrderLineID, amount.Amount()})
+		}
+		subtotal, err = subtotal.Add(amount)
+		if err != nil {
+			return inv, err
+		}
+		lineIDs = append(lineIDs, line.OrderLineID)
+		inv.Lines = append(inv.Lines, share.InvoiceLine{
+			Sku:    line.SKU,
+			Title:  line.Title,
+			Qty:    line.Qty,
+			Price:  line.Price,
+			Amount: amount.Amount(),
+		})
+	}
+	inv.Subtotal = subtotal.Amount()
+
+	taxes, err := q.OrderTaxByOrderID(ctx, order.OrderID)
+	if err != nil {
+		return inv, errors.WithStack(err)
+	}
+	mode, scope, err := rounding(ctx, q)
+	if err != nil {
+		return inv, err
+	}
+	inv.Taxes = invoiceTaxes(taxes, amounts, mode, scope)
+	for _, tax := range inv.Taxes {
+		inv.Tax += tax.Tax
+	}
+	inv.Total = money.Sum(inv.Currency, inv.Subtotal, inv.Tax).Amount()
+	err = invoiceInclusive(ctx, q, order.OrderID, &inv, lineIDs)
+	if err != nil {
+		return inv, err
+	}
+
+	trans, err := q.TransByOrderID(ctx, order.OrderID)
+	if err != nil {
+		return inv, errors.WithStack(err)
+	}
+	// Refunds are listed on credit notes
+	refunds, err := q.TranIDsByOrderConfig(ctx, sqlite.TranIDsByOrderConfigParams{
+		OrderID: order.OrderID,
+		Term:    TermTranType,
+		Val:     share.TranTypeRefund,
+	})
+	if err != nil {
+		return inv, errors.WithStack(err)
+	}
+	inv.Payments = vouchers
+	for _, payment := range vouchers {
+		inv.Paid += payment.Amount
+	}
+	for _, tran := range trans {
+		if tran.State != share.TranStateSuccess ||
+			slices.Contains(refunds, tran.TranID) {
+			continue
+		}
+		inv.Paid += tran.Amount
+		inv.Payments = append(inv.Payments, share.InvoicePayment{
+			TranID: tran.TranID,
+			Descr:  tran.Descr,
+			Date:   modTime(tran.Mod),
+			Amount: tran.Amount,
+		})
+	}
+	inv.Due = max(inv.Total-inv.Paid, 0)
+
+	// Date of issue is when the order was paid in full
+	inv.Date = modTime(order.Mod)
+	if len(inv.Payments) > 0 {
+		inv.Date = inv.Payments[len(inv.Payments)-1].Date
+	}
+	if inv.Date.IsZero() {
+		inv.Date = time.Now()
+	}
+
+	return inv, nil
+}
+
+// invoiceInclusive sets line amounts and the subtotal including tax,
+// if the display is inclusive. The total doesn't change
+func invoiceInclusive(
+	ctx context.Context, q *sqlite.Queries, orderID string,
+	inv *share.Invoice, lineIDs []string) (err error) {
+
+	inv.TaxDisplay, err = priceDisplay(ctx, q)
+	if err != nil {
+		return err
+	}
+	if inv.TaxDisplay == share.PriceDisplayExclusive {
+		return nil
+	}
+	lines, err := taxLines(ctx, q, orderID)
+	if err != nil {
+		return err
+	}
+	amounts, err := inclusiveLines(ctx, q, orderID, lines, inv.Tax)
+	if err != nil {
+		return err
+	}
+	mode, _, err := rounding(ctx, q)
+	if err != nil {
+		return err
+	}
+	inv.Subtotal = 0
+	for i, line := range inv.Lines {
+		if amount, ok := amounts[lineIDs[i]]; ok {
+			inv.Lines[i].Amount = amount
+			inv.Lines[i].Price = unitPrice(amount, line.Qty, mode)
+		}
+		inv.Subtotal += inv.Lines[i].Amount
+	}
+	return nil
+}
+
+// lineAmount is the amount for an order line before tax,
+// excluding system lines
+type lineAmount struct {
+	orderLineID string
+	amount      int64
+}
+
+// invoiceTaxes groups order tax lines by tax and pct, i.e. VAT and
+// additional taxes are listed separately, and fixed amounts are grouped
+// separately. Rows without order_line_id are from the basket tax method,
+// for line scope rounding they are allocated to the lines by amount
+func invoiceTaxes(
+	rows []sqlite.OrderTax, lines []lineAmount,
+	mode money.Rounding, scope money.Scope) (taxes []share.InvoiceTax) {
+
+	type key struct {
+		vat   bool
+		descr string
+		pct   int64
+		fixed bool
+	}
+	exact := make(map[key]map[string]money.Exact)
+	taxable := make(map[key]map[string]bool)
+	// derived is the taxable amount for pct rows,
+	// calculated from the exact tax, i.e. after discounts
+	derived := make(map[key]int64)
+	keys := []key{}
+	weights := make([]int64, len(lines))
+	for i, line := range lines {
+		weights[i] = line.amount
+	}
+	for _, row := range rows {
+		k := key{
+			vat:   row.Vat == 1,
+			descr: row.Descr,
+			pct:   row.Pct,
+			fixed: row.Fixed == 1,
+		}
+		if _, ok := exact[k]; !ok {
+			exact[k] = make(map[string]money.Exact)
+			taxable[k] = make(map[string]bool)
+			keys = append(keys, k)
+		}
+		tax := money.ExactFromFloat(row.Tax)
+		if !k.fixed && k.pct > 0 {
+			derived[k] += int64(tax) / k.pct
+		} else {
+			taxable[k][row.OrderLineID] = true
+		}
+		if row.OrderLineID == "" && scope == money.ScopeLine && len(lines) > 0 {
+			for i, part := range tax.Allocate(weights...) {
+				exact[k][lines[i].orderLineID] += part
+			}
+			continue
+		}
+		exact[k][row.OrderLineID] += tax
+	}
+	sort.SliceStable(keys, func(i, j int) bool {
+		if keys[i].vat != keys[j].vat {
+			return keys[i].vat
+		}
+		if keys[i].fixed != keys[j].fixed {
+			return !keys[i].fixed
+		}
+		return keys[i].pct > keys[j].pct
+	})
+
+	taxes = make([]share.InvoiceTax, 0, len(keys))
+	for _, k := range keys {
+		tax := share.InvoiceTax{
+			Descr: taxDescr(k.vat, k.descr, k.pct, k.fixed),
+			Pct:   k.pct,
+			Fixed: k.fixed,
+			Vat:   k.vat,
+		}
+		var sum money.Exact
+		for _, e := range exact[k] {
+			sum += e
+			if scope == money.ScopeLine {
+				tax.Tax += e.Round(mode)
+			}
+		}
+		if scope == money.ScopeInvoice {
+			tax.Tax = sum.Round(mode)
+		}
+		tax.Taxable = derived[k]
+		for _, line := range lines {
+			if taxable[k][""] || taxable[k][line.orderLineID] {
+				tax.Taxable += line.amount
+			}
+		}
+		taxes = append(taxes, tax)
+	}
+	return taxes
+}
+
+// taxDescr labels the invoice tax line, e.g. "VAT 15%" or "Levy 5%".
+// The description defaults to "VAT" for VAT, otherwise "Tax"
+func taxDescr(vat bool, descr string, pct int64, fixed bool) string {
+	if descr == "" {
+		descr = "Tax"
+		if vat {
+			descr = "VAT"
+		}
+	}
+	if fixed {
+		return descr
+	}
+	return fmt.Sprintf("%s %s", descr, share.FormatPct(pct))
+}
+
+func invoiceSeller(
+	ctx context.Context, q *sqlite.Queries) (seller share.InvoiceParty, err error) {
+
+	fields := []struct {
+		term string
+		val  *string
+	}{
+		{TermBusinessName, &seller.Name},
+		{TermBusinessAddr, &seller.Addr},
+		{TermBusinessVatNo, &seller.VatNo},
+		{TermBusinessRegNo, &seller.RegNo},
+		{TermBusinessEmail, &seller.Email},
+		{TermBusinessPhone, &seller.Phone},
+	}
+	for _, field := range fields {
+		*field.val, err = configVal(ctx, q, field.term, "")
+		if err != nil {
+			return seller, err
+		}
+	}
+	return seller, nil
+}
+
+func invoiceBuyer(
+	ctx context.Context, q *sqlite.Queries, order sqlite.Orders) (
+	buyer share.InvoiceParty, err error) {
+
+	user, err := q.UserByID(ctx, order.UserID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return buyer, errors.WithStack(err)
+	}
+	buyer.Name = user.Descr
+	buyer.Email = user.Email
+
+	addr, err := q.OrderAddrByType(ctx, sqlite.OrderAddrByTypeParams{
+		OrderID: order.OrderID,
+		Type:    share.AddrTypeBilling,
+	})
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return buyer, errors.WithStack(err)
+	}
+	buyer.Addr = addr.Val
+
+	config, err := q.OrderConfigByOrderID(ctx, order.OrderID)
+	if err != nil {
+		return buyer, errors.WithStack(err)
+	}
+	for _, c := range config {
+		if c.OrderLineID == "" && c.Term == TermVatNo {
+			buyer.VatNo = c.Val
+		}
+	}
+	return buyer, nil
+}
@@ -0,0 +1,468 @@
+package model
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/shopd/shopd/go/db/sqlite"
+	"github.com/shopd/shopd/go/money"
+	"github.com/shopd/shopd/go/share"
+)
+
+// Journal exports turn invoices, payments, refunds, and credit notes into
+// double-entry rows for the bookkeeper. Invoices debit receivables and
+// credit sales, VAT output, and other taxes, payments debit the bank and
+// credit receivables. Store credit adjustments are booked against expenses. Documents are recorded in journal_entry when exported,
+// and skipped by later exports so nothing is booked twice
+
+// Config terms for the account codes used by journal exports
+const (
+	TermJournalSales      = "journal_sales"
+	TermJournalVat        = "journal_vat"
+	TermJournalReceivable = "journal_receivable"
+	TermJournalBank       = "journal_bank"
+	// TermJournalCredit is the liability for store credit and vouchers
+	TermJournalCredit = "journal_credit"
+	// TermJournalTax is for taxes other than VAT, e.g. a levy
+	TermJournalTax = "journal_tax"
+	// TermJournalExpense is for store credit issued or withdrawn on accounts,
+	// e.g. goodwill credit
+	TermJournalExpense = "journal_expense"
+)
+
+// JournalExportsLimit is the number of previous exports listed
+const JournalExportsLimit = 20
+
+// JournalExportCreate exports documents dated in the period that were not
+// exported before. Invoices are dated when the order was paid in full.
+// Nothing is recorded if there are no new documents
+func (m *Model) JournalExportCreate(
+	ctx context.Context, params share.ParamsJournalPost, userID string) (
+	export share.JournalExport, err error) {
+
+	if params.From == "" {
+		return export, errors.WithStack(ErrInvalidParam("From"))
+	}
+	if params.To == "" {
+		return export, errors.WithStack(ErrInvalidParam("To"))
+	}
+	modFrom, modTo, err := modRange(params.From, params.To)
+	if err != nil {
+		return export, err
+	}
+	if modTo <= modFrom {
+		return export, errors.WithStack(ErrInvalidParam("To"))
+	}
+	from, _ := time.Parse(DateFormat, params.From)
+	to, _ := time.Parse(DateFormat, params.To)
+	to = to.AddDate(0, 0, 1)
+
+	err = m.tx(ctx, func(q *sqlite.Queries) error {
+		codes, err := journalAccounts(ctx, q)
+		if err != nil {
+			return err
+		}
+		mod := NewID()
+		export = share.JournalExport{
+			ExportID: NewID(),
+			From:     params.From,
+			To:       params.To,
+			Date:     modTime(mod),
+		}
+		export.Currency, err = configVal(ctx, q, TermCurrency, CurrencyDefault)
+		if err != nil {
+			return err
+		}
+
+		type source struct{ source, sourceID string }
+		sources := []source{}
+		// Orders are modified when paid, so orders paid in the
+		// period were last modified after the start of the period
+		orders, err := q.OrdersPaidByMod(ctx, sqlite.OrdersPaidByModParams{
+			ModFrom: modFrom,
+		})
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		for _, order := range orders {
+			sources = append(sources, source{share.JournalSourceInvoice, order.OrderID})
+		}
+		trans, err := reconTrans(ctx, q, sqlite.TranReportParams{
+			State:   share.TranStateSuccess,
+			ModFrom: modFrom,
+			ModTo:   modTo,
+		})
+		if err != nil {
+			return err
+		}
+		for _, tran := range trans {
+			sources = append(sources, source{share.JournalSourceTran, tran.TranID})
+		}
+		notes, err := q.CreditNotesByMod(ctx, sqlite.CreditNotesByModParams{
+			ModFrom: modFrom,
+			ModTo:   modTo,
+		})
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		for _, note := range notes {
+			sources = append(sources, source{share.JournalSourceCredit, note.CreditNoteID})
+		}
+
+		exported := []source{}
+		for _, s := range sources {
+			_, err := q.JournalEntryBySource(ctx, sqlite.JournalEntryBySourceParams{
+				Source:   s.source,
+				SourceID: s.sourceID,
+			})
+			if err == nil {
+				continue
+			}
+			if !errors.Is(err, sql.ErrNoRows) {
+				return errors.WithStack(err)
+			}
+			date, rows, err := journalEntry(ctx, q, codes, s.source, s.sourceID)
+			if err != nil {
+				return err
+			}
+			if len(rows) == 0 || date.Before(from) || !date.Before(to) {
+				continue
+			}
+			exported = append(exported, s)
+			export.Rows = append(export.Rows, rows...)
+		}
+		if len(exported) == 0 {
+			return nil
+		}
+
+		err = q.JournalExportInsert(ctx, sqlite.JournalExportInsertParams{
+			ExportID:   export.ExportID,
+			PeriodFrom: export.From,
+			PeriodTo:   export.To,
+			Mod:        mod,
+			ModID:      modID(userID),
+		})
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		for _, s := range exported {
+			err = q.JournalEntryInsert(ctx, sqlite.JournalEntryInsertParams{
+				Source:   s.source,
+				SourceID: s.sourceID,
+				ExportID: export.ExportID,
+			})
+			if err != nil {
+				return errors.WithStack(err)
+			}
+		}
+		export.Count = len(exported)
+		return nil
+	})
+	if err != nil {
+		return export, err
+	}
+	sortJournal(export.Rows)
+	return export, nil
+}
+
+// JournalExport rebuilds the rows for a previous export,
+// e.g. to download it again in another format
+func (m *Model) JournalExport(
+	ctx context.Context, exportID string) (export share.JournalExport, err error) {
+
+	err = m.tx(ctx, func(q *sqlite.Queries) error {
+		codes, err := journalAccounts(ctx, q)
+		if err != nil {
+			return err
+		}
+		export, err = journalExport(ctx, q, exportID)
+		if err != nil {
+			return err
+		}
+		entries, err := q.JournalEntriesByExportID(ctx, exportID)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		for _, entry := range entries {
+			_, rows, err := journalEntry(ctx, q, codes, entry.Source, entry.SourceID)
+			if err != nil {
+				return err
+			}
+			export.Rows = append(export.Rows, rows...)
+		}
+		export.Count = len(entries)
+		return nil
+	})
+	if err != nil {
+		return export, err
+	}
+	sortJournal(export.Rows)
+	return export, nil
+}
+
+// JournalExports lists previous exports, most recent first
+func (m *Model) JournalExports(
+	ctx context.Context) (list share.JournalExports, err error) {
+
+	rows, err := m.q.JournalExportList(ctx, JournalExportsLimit)
+	if err != nil {
+		return list, errors.WithStack(err)
+	}
+	list.Exports = make([]share.JournalExport, 0, len(rows))
+	for _, row := range rows {
+		export, err := journalExport(ctx, m.q, row.ExportID)
+		if err != nil {
+			return list, err
+		}
+		entries, err := m.q.JournalEntriesByExportID(ctx, row.ExportID)
+		if err != nil {
+			return list, errors.WithStack(err)
+		}
+		export.Count = len(entries)
+		list.Exports = append(list.Exports, export)
+	}
+	return list, nil
+}
+
+func journalExport(
+	ctx context.Context, q *sqlite.Queries, exportID string) (
+	export share.JournalExport, err error) {
+
+	row, err := q.JournalExportByID(ctx, exportID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return export, errors.WithStack(ErrNotFound(exportID))
+		}
+		return export, errors.WithStack(err)
+	}
+	export = share.JournalExport{
+		ExportID: row.ExportID,
+		From:     row.PeriodFrom,
+		To:       row.PeriodTo,
+		Date:     modTime(row.Mod),
+	}
+	export.Currency, err = configVal(ctx, q, TermCurrency, CurrencyDefault)
+	if err != nil {
+		return export, err
+	}
+	return export, nil
+}
+
+// journalCodes are the account codes for journal rows
+type journalCodes struct {
+	sales      string
+	vat        string
+	receivable string
+	bank       string
+	credit     string
+	tax        string
+	expense    string
+}
+
+func journalAccounts(
+	ctx context.Context, q *sqlite.Queries) (codes journalCodes, err error) {
+
+	fields := []struct {
+		term string
+		val  *string
+	}{
+		{TermJournalSales, &codes.sales},
+		{TermJournalVat, &codes.vat},
+		{TermJournalReceivable, &codes.receivable},
+		{TermJournalBank, &codes.bank},
+		{TermJournalCredit, &codes.credit},
+		{TermJournalTax, &codes.tax},
+		{TermJournalExpense, &codes.expense},
+	}
+	for _, field := range fields {
+		*field.val, err = configVal(ctx, q, field.term, "")
+		if err != nil {
+			return codes, err
+		}
+		if *field.val == "" {
+			return codes, errors.WithStack(ErrConfig(field.term))
+		}
+	}
+	return codes, nil
+}
+
+// taxAccount returns the account code for VAT or other taxes
+func (codes journalCodes) taxAccount(tax share.InvoiceTax) string {
+	if tax.Vat {
+		return codes.vat
+	}
+	return codes.tax
+}
+
+// journalEntry builds the rows for a document, and returns the date
+func journalEntry(
+	ctx context.Context, q *sqlite.Queries, codes journalCodes,
+	source, sourceID string) (
+	date time.Time, rows []share.JournalRow, err error) {
+
+	entry := journalRows{source: source, sourceID: sourceID}
+	switch source {
+	case share.JournalSourceInvoice:
+		order, err := orderByID(ctx, q, sourceID)
+		if err != nil {
+			return date, rows, err
+		}
+		inv, err := invoice(ctx, q, order)
+		if err != nil {
+			return date, rows, err
+		}
+		entry.date = inv.Date
+		entry.journal = fmt.Sprintf("Invoice %s", order.OrderNo)
+		entry.ref = order.OrderNo
+		entry.add(codes.receivable, "Invoice", inv.Total)
+		entry.add(codes.sales, "Sales", -(inv.Total - inv.Tax))
+		for _, tax := range inv.Taxes {
+			entry.add(codes.taxAccount(tax), tax.Descr, -tax.Tax)
+		}
+		// Vouchers are listed with the payments, without a TranID
+		for _, pay := range inv.Payments {
+			if pay.TranID == "" {
+				entry.add(codes.credit, pay.Descr, pay.Amount)
+				entry.add(codes.receivable, pay.Descr, -pay.Amount)
+			}
+		}
+
+	case share.JournalSourceTran:
+		pay, err := payment(ctx, q, sourceID)
+		if err != nil {
+			return date, rows, err
+		}
+		tran, err := q.TranByID(ctx, sourceID)
+		if err != nil {
+			return date, rows, errors.WithStack(err)
+		}
+		entry.date = modTime(tran.Mod)
+		entry.ref = pay.TranID
+		if pay.OrderID != "" {
+			order, err := orderByID(ctx, q, pay.OrderID)
+			if err != nil {
+				return date, rows, err
+			}
+			entry.ref = order.OrderID
+			if order.OrderNo != "" {
+				entry.ref = order.OrderNo
+			}
+		}
+		account := codes.bank
+		if pay.Method == share.TranMethodCredit {
+			account = codes.credit
+		}
+		switch pay.Type {
+		case "":
+			entry.journal = fmt.Sprintf("Payment %s", entry.ref)
+			entry.add(account, pay.Descr, pay.Amount)
+			entry.add(codes.receivable, pay.Descr, -pay.Amount)
+		case share.TranTypeRefund:
+			entry.journal = fmt.Sprintf("Refund %s", entry.ref)
+			entry.add(codes.receivable, pay.Descr, pay.Amount)
+			entry.add(account, pay.Descr, -pay.Amount)
+		case share.TranTypeCredit:
+			entry.journal = fmt.Sprintf("Store credit %s", entry.ref)
+			entry.add(codes.expense, pay.Descr, pay.Amount)
+			entry.add(codes.credit, pay.Descr, -pay.Amount)
+		case share.TranTypeDebit:
+			entry.journal = fmt.Sprintf("Store credit %s", entry.ref)
+			entry.add(codes.credit, pay.Descr, pay.Amount)
+			entry.add(codes.expense, pay.Descr, -pay.Amount)
+		}
+
+	case share.JournalSourceCredit:
+		note, err := q.CreditNoteByID(ctx, sourceID)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return date, rows, errors.WithStack(ErrNotFound(sourceID))
+			}
+			return date, rows, errors.WithStack(err)
+		}
+		entry.date = modTime(note.Mod)
+		entry.journal = fmt.Sprintf("Credit note %s", note.CreditNo)
+		entry.ref = note.CreditNo
+		entry.add(codes.sales, "Sales returned", note.Amount)
+		// Tax is split over the invoice taxes, like the credit note total
+		order, err := orderByID(ctx, q, note.OrderID)
+		if err != nil {
+			return date, rows, err
+		}
+		inv, err := invoice(ctx, q, order)
+		if err != nil {
+			return date, rows, err
+		}
+		if note.Tax != 0 && inv.Tax != 0 {
+			weights := make([]int64, len(inv.Taxes))
+			for i, tax := range inv.Taxes {
+				weights[i] = tax.Tax
+			}
+			for i, tax := range money.Allocate(note.Tax, weights...) {
+				entry.add(codes.taxAccount(inv.Taxes[i]), inv.Taxes[i].Descr, tax)
+			}
+		}
+		entry.add(codes.receivable, "Credit note", -(note.Amount + note.Tax))
+
+	default:
+		return date, rows, errors.WithStack(ErrInvalidParam("source"))
+	}
+	return entry.date, entry.rows, nil
+}
+
+// journalRows accumulates the rows for a document
+type journalRows struct {
+	source   string
+	sourceID string
+	date     time.Time
+	journal  string
+	ref      string
+	rows     []share.JournalRow
+}
+
+// add a debit for a positive amount, or a credit for a negative amount
+func (e *journalRows) add(account, descr string, amount int64) {
+	if amount == 0 {
+		return
+	}
+	row := share.JournalRow{
+		Date:     e.date,
+		Journal:  e.journal,
+		Ref:      e.ref,
+		Source:   e.source,
+		SourceID: e.sourceID,
+		Account:  account,
+		Descr:    descr,
+	}
+	if amount > 0 {
+		row.Debit = amount
+	} else {
+		row.Credit = -amount
+	}
+	e.rows = append(e.rows, row)
+}
+
+// journalSources orders documents with the same date
+var journalSources = map[string]int{
+	share.JournalSourceInvoice: 0,
+	share.JournalSourceTran:    1,
+	share.JournalSourceCredit:  2,
+}
+
+// sortJournal by date and document, so exports downloaded again
+// list the rows in the same order. Rows for a document stay together
+func sortJournal(rows []share.JournalRow) {
+	sort.SliceStable(rows, func(i, j int) bool {
+		a, b := rows[i], rows[j]
+		if !a.Date.Equal(b.Date) {
+			return a.Date.Before(b.Date)
+		}
+		if a.Source != b.Source {
+			return journalSources[a.Source] < journalSources[b.Source]
+		}
+		return a.SourceID < b.SourceID
+	})
+}
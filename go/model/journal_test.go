@@ -0,0 +1,114 @@
+package model_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+	"github.com/pkg/errors"
+	"github.com/shopd/shopd/go/model"
+	"github.com/shopd/shopd/go/share"
+)
+
+func TestJournalExport(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+	m, db := newTestModel(t)
+	exec(t, db,
+		`insert into cat(sku, title, descr, state, mod, mod_id)
+		values ('wine', 'Wine', '', 'stock', 'm', 's')`,
+		`insert into cat_price values ('wine', 1000)`,
+		`insert into cat_tag values ('wine', 'alcohol')`,
+		`insert into tax(country, tag, sku, vat, pct, tax, descr, mod, mod_id)
+		values ('ZAF', 'alcohol', '', 0, 500, 0, 'Levy', 'm', 's')`,
+		`insert into user(user_id, email, descr, mod)
+		values ('u1', 'u1@example.com', 'Jane', 'm')`,
+		`insert into account values ('a1', 'Jane')`,
+		`insert into account_x_user values ('a1', 'u1')`,
+	)
+	today := time.Now().Format(model.DateFormat)
+
+	cart, err := m.CartAdd(ctx, "", "", share.ParamsCartPost{Sku: "wine", Qty: 1})
+	is.NoErr(err)
+	orderID := cart.OrderID
+	is.NoErr(m.SetOrderState(ctx, orderID, share.OrderStatePending, ""))
+	_, err = m.PaymentRecord(ctx, share.ParamsOrdersPaymentPost{
+		OrderID: orderID,
+		Method:  share.TranMethodEFT,
+		Amount:  1200,
+	}, "admin")
+	is.NoErr(err)
+	_, err = m.CreditNoteCreate(ctx, share.ParamsOrdersRefundPost{
+		OrderID: orderID, Amount: 600, Method: share.TranMethodEFT}, "admin")
+	is.NoErr(err)
+	_, err = m.AccountAdjust(ctx, share.ParamsAccountCreditPost{
+		AccountID: "a1", Amount: 500, Message: "Goodwill"}, "admin")
+	is.NoErr(err)
+	_, err = m.AccountAdjust(ctx, share.ParamsAccountCreditPost{
+		AccountID: "a1", Amount: -200}, "admin")
+	is.NoErr(err)
+
+	export, err := m.JournalExportCreate(ctx, share.ParamsJournalPost{
+		From: today, To: today}, "admin")
+	is.NoErr(err)
+	// Invoice, payment, refund, two adjustments, and the credit note
+	is.Equal(export.Count, 6)
+
+	// Every document balances
+	type balance struct{ debit, credit int64 }
+	docs := make(map[string]balance)
+	accounts := make(map[string]int64)
+	for _, row := range export.Rows {
+		b := docs[row.SourceID]
+		b.debit += row.Debit
+		b.credit += row.Credit
+		docs[row.SourceID] = b
+		accounts[row.Account] += row.Debit - row.Credit
+	}
+	is.Equal(len(docs), 6)
+	for _, b := range docs {
+		is.True(b.debit > 0)
+		is.Equal(b.debit, b.credit)
+	}
+
+	// VAT and the levy are booked separately,
+	// the credit note reverses half of each
+	is.Equal(accounts["2200"], int64(-75))
+	is.Equal(accounts["2210"], int64(-25))
+	is.Equal(accounts["4000"], int64(-500))
+	// Received 1200, refunded 600
+	is.Equal(accounts["1000"], int64(600))
+	is.Equal(accounts["1200"], int64(0))
+	// Store credit adjustments are booked against expenses
+	is.Equal(accounts["6100"], int64(300))
+	is.Equal(accounts["2300"], int64(-300))
+
+	// Documents are exported once, rows are the same when downloaded again
+	next, err := m.JournalExportCreate(ctx, share.ParamsJournalPost{
+		From: today, To: today}, "admin")
+	is.NoErr(err)
+	is.Equal(next.Count, 0)
+	is.Equal(len(next.Rows), 0)
+	again, err := m.JournalExport(ctx, export.ExportID)
+	is.NoErr(err)
+	is.Equal(again.Count, export.Count)
+	is.Equal(again.Rows, export.Rows)
+	list, err := m.JournalExports(ctx)
+	is.NoErr(err)
+	is.Equal(len(list.Exports), 1)
+	is.Equal(list.Exports[0].Count, 6)
+
+	// Invalid params
+	_, err = m.JournalExportCreate(ctx, share.ParamsJournalPost{To: today}, "admin")
+	is.True(errors.Is(err, model.ErrInvalidParam("")))
+	_, err = m.JournalExportCreate(ctx, share.ParamsJournalPost{
+		From: today, To: "2000-01-01"}, "admin")
+	is.True(errors.Is(err, model.ErrInvalidParam("")))
+	_, err = m.JournalExport(ctx, "missing")
+	is.True(errors.Is(err, model.ErrNotFound("")))
+	exec(t, db, `update config set val = '' where term = 'journal_expense'`)
+	_, err = m.JournalExportCreate(ctx, share.ParamsJournalPost{
+		From: today, To: today}, "admin")
+	is.True(errors.Is(err, model.ErrConfig("")))
+}
@@ -0,0 +1,51 @@
+package model
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	"github.com/shopd/shopd/go/share"
+)
+
+// Config terms for sending email with SMTP
+const (
+	// TermSmtpAddr is the host:port of the SMTP server, e.g. smtp.host.com:587
+	TermSmtpAddr = "smtp_addr"
+	// TermSmtpUser is the username for plain auth, empty to skip auth
+	TermSmtpUser = "smtp_user"
+	TermSmtpPass = "smtp_pass"
+	// TermMailFrom is the sender address, the business_email term is used
+	// if it's not set
+	TermMailFrom = "mail_from"
+)
+
+// SMTPConfig returns the SMTP server settings for the domain
+func (m *Model) SMTPConfig(ctx context.Context) (conf share.SMTPConfig, err error) {
+	conf.Addr, err = configVal(ctx, m.q, TermSmtpAddr, "")
+	if err != nil {
+		return conf, err
+	}
+	if conf.Addr == "" {
+		return conf, errors.WithStack(ErrConfig(TermSmtpAddr))
+	}
+	conf.User, err = configVal(ctx, m.q, TermSmtpUser, "")
+	if err != nil {
+		return conf, err
+	}
+	conf.Pass, err = configVal(ctx, m.q, TermSmtpPass, "")
+	if err != nil {
+		return conf, err
+	}
+	business, err := configVal(ctx, m.q, TermBusinessEmail, "")
+	if err != nil {
+		return conf, err
+	}
+	conf.From, err = configVal(ctx, m.q, TermMailFrom, business)
+	if err != nil {
+		return conf, err
+	}
+	if conf.From == "" {
+		return conf, errors.WithStack(ErrConfig(TermMailFrom))
+	}
+	return conf, nil
+}
@@ -0,0 +1,155 @@
+package model
+
+import (
+	"context"
+	"database/sql"
+	"math"
+	"time"
+	// Embed the time zone database, the host might not have one
+	_ "time/tzdata"
+
+	"github.com/pkg/errors"
+	"github.com/segmentio/ksuid"
+	"github.com/shopd/shopd/go/db/sqlite"
+)
+
+// ModIDSystem is the mod_id for changes made by the system,
+// as opposed to changes made by a user
+const ModIDSystem = "s"
+
+// Model implements the business logic.
+// Queries are generated with sqlc, see go/db/sqlite
+type Model struct {
+	db *sql.DB
+	q  *sqlite.Queries
+}
+
+type ModelParams struct {
+	DB *sql.DB
+}
+
+func NewModel(params ModelParams) *Model {
+	return &Model{
+		db: params.DB,
+		q:  sqlite.New(params.DB),
+	}
+}
+
+// tx executes fn in a transaction,
+// changes are rolled back if fn returns an error
+func (m *Model) tx(
+	ctx context.Context, fn func(q *sqlite.Queries) error) (err error) {
+
+	tx, err := m.db.BeginTx(ctx, nil)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	err = fn(m.q.WithTx(tx))
+	if err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	return errors.WithStack(tx.Commit())
+}
+
+// NewID returns a new KSUID,
+// see comments at the top of scripts/db/schema.strict.sql.
+// Also used for mod cols
+func NewID() string {
+	return ksuid.New().String()
+}
+
+// modTime returns the time encoded in a KSUID mod col,
+// or the zero time if mod is not a valid KSUID
+func modTime(mod string) time.Time {
+	id, err := ksuid.Parse(mod)
+	if err != nil {
+		return time.Time{}
+	}
+	return id.Time()
+}
+
+// ksuidEpoch in unix seconds, KSUID timestamps are relative to this
+const ksuidEpoch = 1400000000
+
+// modBefore returns the smallest KSUID for t,
+// mod cols with a value less than this were modified before t.
+// Times outside the KSUID range are clamped
+func modBefore(t time.Time) string {
+	if t.Unix() < ksuidEpoch {
+		return ksuid.Nil.String()
+	}
+	if t.Unix() >= ksuidEpoch+math.MaxUint32 {
+		return ksuid.Max.String()
+	}
+	id, err := ksuid.FromParts(t, make([]byte, 16))
+	if err != nil {
+		// Only returns an error if the payload length is wrong
+		panic(err)
+	}
+	return id.String()
+}
+
+// DateTimeFormat for date time cols, in UTC
+const DateTimeFormat = time.DateTime
+
+// TermTimezone is the IANA time zone for the domain,
+// date times entered by admin users are in this time zone
+const TermTimezone = "timezone"
+
+// TimezoneDefault is used if the timezone term is not set
+const TimezoneDefault = "UTC"
+
+// location returns the time zone for the domain
+func location(
+	ctx context.Context, q *sqlite.Queries) (loc *time.Location, err error) {
+
+	name, err := configVal(ctx, q, TermTimezone, TimezoneDefault)
+	if err != nil {
+		return loc, err
+	}
+	loc, err = time.LoadLocation(name)
+	if err != nil {
+		return loc, errors.WithStack(err)
+	}
+	return loc, nil
+}
+
+// DateRange is a date time range, end is exclusive
+type DateRange struct {
+	Start time.Time
+	End   time.Time
+}
+
+// inRanges returns true if t is in one of the ranges,
+// or if ranges is empty
+func inRanges(ranges []DateRange, t time.Time) bool {
+	if len(ranges) == 0 {
+		return true
+	}
+	for _, r := range ranges {
+		if !t.Before(r.Start) && t.Before(r.End) {
+			return true
+		}
+	}
+	return false
+}
+
+// configVal returns the global setting for term,
+// or deflt if the term is not set
+func configVal(
+	ctx context.Context, q *sqlite.Queries, term, deflt string) (
+	val string, err error) {
+
+	c, err := q.ConfigByTerm(ctx, term)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return deflt, nil
+		}
+		return val, errors.WithStack(err)
+	}
+	if c.Val == "" {
+		return deflt, nil
+	}
+	return c.Val, nil
+}
@@ -0,0 +1,41 @@
+package model_test
+
+import (
+	"database/sql"
+	"os"
+	"testing"
+
+	"github.com/matryer/is"
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/shopd/shopd/go/model"
+)
+
+// newTestModel creates a model with an in-memory DB,
+// the schema and seed data are loaded from scripts/db
+func newTestModel(t *testing.T) (m *model.Model, db *sql.DB) {
+	is := is.New(t)
+	db, err := sql.Open("sqlite3", ":memory:")
+	is.NoErr(err)
+	// Each connection has its own in-memory DB
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { _ = db.Close() })
+	for _, f := range []string{
+		"../../scripts/db/schema.strict.sql",
+		"../../scripts/db/init.sql",
+	} {
+		b, err := os.ReadFile(f)
+		is.NoErr(err)
+		_, err = db.Exec(string(b))
+		is.NoErr(err)
+	}
+	return model.NewModel(model.ModelParams{DB: db}), db
+}
+
+// exec runs the statements, e.g. to insert fixtures
+func exec(t *testing.T, db *sql.DB, statements ...string) {
+	is := is.New(t)
+	for _, s := range statements {
+		_, err := db.Exec(s)
+		is.NoErr(err)
+	}
+}
@@ -0,0 +1,37 @@
+package model
+
+import (
+	"context"
+
+	"github.com/shopd/shopd/go/db/sqlite"
+	"github.com/shopd/shopd/go/money"
+)
+
+// Config terms for rounding exact amounts, e.g. tax
+const (
+	TermRounding      = "rounding"
+	TermRoundingScope = "rounding_scope"
+)
+
+// rounding returns the rounding mode and scope for the domain
+func rounding(ctx context.Context, q *sqlite.Queries) (
+	mode money.Rounding, scope money.Scope, err error) {
+
+	val, err := configVal(ctx, q, TermRounding, string(money.RoundHalfEven))
+	if err != nil {
+		return mode, scope, err
+	}
+	mode, err = money.ParseRounding(val)
+	if err != nil {
+		return mode, scope, err
+	}
+	val, err = configVal(ctx, q, TermRoundingScope, string(money.ScopeInvoice))
+	if err != nil {
+		return mode, scope, err
+	}
+	scope, err = money.ParseScope(val)
+	if err != nil {
+		return mode, scope, err
+	}
+	return mode, scope, nil
+}
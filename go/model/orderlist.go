@@ -0,0 +1,187 @@
+package model
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/shopd/shopd/go/db/sqlite"
+	"github.com/shopd/shopd/go/money"
+	"github.com/shopd/shopd/go/share"
+)
+
+// OrdersPageSize is the number of orders per page in the admin list
+const OrdersPageSize = 50
+
+// DateFormat for date params
+const DateFormat = "2006-01-02"
+
+// Orders lists a page of orders for admin users, most recent first.
+// Use list.Next as the filter cursor to fetch the next page
+func (m *Model) Orders(
+	ctx context.Context, filter share.OrderFilter) (
+	list share.OrderList, err error) {
+
+	list.Filter = filter
+	params := sqlite.OrderListParams{
+		State:  filter.State,
+		Paid:   -1,
+		Tag:    filter.Tag,
+		UserID: filter.UserID,
+		Search: filter.Search,
+		Cursor: filter.Cursor,
+		// Fetch one more to check if there is a next page
+		Limit: OrdersPageSize + 1,
+	}
+	switch filter.Paid {
+	case "":
+	case "0":
+		params.Paid = 0
+	case "1":
+		params.Paid = 1
+	default:
+		return list, errors.WithStack(ErrInvalidParam("Paid"))
+	}
+	params.ModFrom, params.ModTo, err = modRange(filter.From, filter.To)
+	if err != nil {
+		return list, err
+	}
+
+	rows, err := m.q.OrderList(ctx, params)
+	if err != nil {
+		return list, errors.WithStack(err)
+	}
+	if len(rows) > OrdersPageSize {
+		rows = rows[:OrdersPageSize]
+		list.Next = rows[len(rows)-1].Mod
+	}
+	currency, err := configVal(ctx, m.q, TermCurrency, CurrencyDefault)
+	if err != nil {
+		return list, err
+	}
+	list.Orders = make([]share.OrderSummary, 0, len(rows))
+	for _, row := range rows {
+		tags, err := m.q.OrderTagsByOrderID(ctx, row.OrderID)
+		if err != nil {
+			return list, errors.WithStack(err)
+		}
+		total, err := orderTotal(ctx, m.q, row.OrderID, currency)
+		if err != nil {
+			return list, err
+		}
+		list.Orders = append(list.Orders, share.OrderSummary{
+			OrderID:  row.OrderID,
+			OrderNo:  row.OrderNo,
+			State:    row.State,
+			UserID:   row.UserID,
+			Email:    row.Email,
+			Paid:     row.Paid == 1,
+			Total:    total,
+			Currency: currency,
+			Tags:     tags,
+			Date:     modTime(row.Mod),
+			Mod:      row.Mod,
+		})
+	}
+	return list, nil
+}
+
+// modRange converts the From and To date params to a range of mod values,
+// the range includes To. Empty dates are ignored
+func modRange(from, to string) (modFrom, modTo string, err error) {
+	if from != "" {
+		t, err := time.Parse(DateFormat, from)
+		if err != nil {
+			return modFrom, modTo, errors.WithStack(ErrInvalidParam("From"))
+		}
+		modFrom = modBefore(t)
+	}
+	if to != "" {
+		t, err := time.Parse(DateFormat, to)
+		if err != nil {
+			return modFrom, modTo, errors.WithStack(ErrInvalidParam("To"))
+		}
+		modTo = modBefore(t.AddDate(0, 0, 1))
+	}
+	return modFrom, modTo, nil
+}
+
+// OrderSummary fetches a single row for the admin list
+func (m *Model) OrderSummary(
+	ctx context.Context, orderID string) (
+	summary share.OrderSummary, err error) {
+
+	order, err := orderByID(ctx, m.q, orderID)
+	if err != nil {
+		return summary, err
+	}
+	summary = share.OrderSummary{
+		OrderID: order.OrderID,
+		OrderNo: order.OrderNo,
+		State:   order.State,
+		UserID:  order.UserID,
+		Paid:    order.Paid == 1,
+		Date:    modTime(order.Mod),
+		Mod:     order.Mod,
+	}
+	summary.Currency, err = configVal(ctx, m.q, TermCurrency, CurrencyDefault)
+	if err != nil {
+		return summary, err
+	}
+	user, err := m.q.UserByID(ctx, order.UserID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return summary, errors.WithStack(err)
+	}
+	summary.Email = user.Email
+	summary.Total, err = orderTotal(ctx, m.q, orderID, summary.Currency)
+	if err != nil {
+		return summary, err
+	}
+	summary.Tags, err = m.q.OrderTagsByOrderID(ctx, orderID)
+	if err != nil {
+		return summary, errors.WithStack(err)
+	}
+	return summary, nil
+}
+
+// orderTotal is the invoice total for the order, i.e. the lines less
+// discounts plus the tax saved when the order was priced.
+// Vouchers are listed with the payments on the invoice, and not included
+func orderTotal(
+	ctx context.Context, q *sqlite.Queries, orderID, currency string) (
+	total int64, err error) {
+
+	lines, err := q.OrderLinesByOrderID(ctx, orderID)
+	if err != nil {
+		return total, errors.WithStack(err)
+	}
+	amounts := make([]lineAmount, 0, len(lines))
+	subtotal := money.New(0, currency)
+	for _, line := range lines {
+		if line.SKU == SkuVoucher {
+			continue
+		}
+		amount := money.New(line.Price, currency).Multiply(line.Qty)
+		if !systemSku(line.SKU) {
+			amounts = append(amounts, lineAmount{line.OrderLineID, amount.Amount()})
+		}
+		subtotal, err = subtotal.Add(amount)
+		if err != nil {
+			return total, err
+		}
+	}
+	taxes, err := q.OrderTaxByOrderID(ctx, orderID)
+	if err != nil {
+		return total, errors.WithStack(err)
+	}
+	mode, scope, err := rounding(ctx, q)
+	if err != nil {
+		return total, err
+	}
+	parts := []int64{subtotal.Amount()}
+	for _, tax := range invoiceTaxes(taxes, amounts, mode, scope) {
+		parts = append(parts, tax.Tax)
+	}
+	return money.Sum(currency, parts...).Amount(), nil
+}
@@ -0,0 +1,40 @@
+package model_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/matryer/is"
+	"github.com/shopd/shopd/go/share"
+)
+
+func TestOrdersTotal(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+	m, db := newTestModel(t)
+	exec(t, db,
+		`insert into cat(sku, title, descr, state, mod, mod_id)
+		values ('a', 'Apple', '', 'stock', 'm', 's')`,
+		`insert into cat_price values ('a', 1000)`,
+		`insert into voucher values ('GIFT', 500, 500, 'm', 's')`,
+	)
+	cart, err := m.CartAdd(ctx, "", "", share.ParamsCartPost{Sku: "a", Qty: 2})
+	is.NoErr(err)
+	_, err = m.CartCode(ctx, cart.OrderID, "", "gift")
+	is.NoErr(err)
+	is.NoErr(m.SetOrderState(ctx, cart.OrderID, share.OrderStatePending, ""))
+
+	// The total includes tax, the voucher is a payment
+	inv, err := m.Receipt(ctx, cart.OrderID)
+	is.NoErr(err)
+	is.Equal(inv.Total, int64(2300))
+
+	list, err := m.Orders(ctx, share.OrderFilter{})
+	is.NoErr(err)
+	is.Equal(len(list.Orders), 1)
+	is.Equal(list.Orders[0].Total, inv.Total)
+
+	summary, err := m.OrderSummary(ctx, cart.OrderID)
+	is.NoErr(err)
+	is.Equal(summary.Total, inv.Total)
+}
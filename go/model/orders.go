@@ -0,0 +1,141 @@
+package model
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strconv"
+
+	"github.com/pkg/errors"
+	"github.com/shopd/shopd/go/db/sqlite"
+)
+
+// Config terms for order numbers
+const (
+	TermOrderNoPrefix = "order_no_prefix"
+	// TermOrderNoSeq is the last allocated order number
+	TermOrderNoSeq = "order_no_seq"
+)
+
+// orderByID fetches the order, or returns ErrNotFound
+func orderByID(
+	ctx context.Context, q *sqlite.Queries, orderID string) (
+	order sqlite.Orders, err error) {
+
+	order, err = q.OrderByID(ctx, orderID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return order, errors.WithStack(ErrNotFound(orderID))
+		}
+		return order, errors.WithStack(err)
+	}
+	return order, nil
+}
+
+// OrderUserID returns the user that placed the order,
+// empty string for guest orders
+func (m *Model) OrderUserID(
+	ctx context.Context, orderID string) (userID string, err error) {
+
+	order, err := orderByID(ctx, m.q, orderID)
+	if err != nil {
+		return userID, err
+	}
+	return order.UserID, nil
+}
+
+// orderNo allocates the next order number if the order doesn't have one.
+// Must be called in a transaction
+func orderNo(
+	ctx context.Context, q *sqlite.Queries, order sqlite.Orders) (
+	no string, err error) {
+
+	if order.OrderNo != "" {
+		return order.OrderNo, nil
+	}
+
+	no, err = nextNo(ctx, q, TermOrderNoPrefix, TermOrderNoSeq)
+	if err != nil {
+		return no, err
+	}
+	err = q.OrderUpdateOrderNo(ctx, sqlite.OrderUpdateOrderNoParams{
+		OrderNo: no,
+		OrderID: order.OrderID,
+	})
+	if err != nil {
+		return no, errors.WithStack(err)
+	}
+	return no, nil
+}
+
+// nextNo increments the sequence config term,
+// and returns the number with prefix. Must be called in a transaction
+func nextNo(
+	ctx context.Context, q *sqlite.Queries, prefixTerm, seqTerm string) (
+	no string, err error) {
+
+	seq, err := configVal(ctx, q, seqTerm, "0")
+	if err != nil {
+		return no, err
+	}
+	n, err := strconv.ParseInt(seq, 10, 64)
+	if err != nil {
+		return no, errors.WithStack(err)
+	}
+	n++
+	prefix, err := configVal(ctx, q, prefixTerm, "")
+	if err != nil {
+		return no, err
+	}
+	err = q.ConfigUpsert(ctx, sqlite.ConfigUpsertParams{
+		Term: seqTerm,
+		Val:  strconv.FormatInt(n, 10),
+		Mod:  NewID(),
+	})
+	if err != nil {
+		return no, errors.WithStack(err)
+	}
+	return fmt.Sprintf("%s%d", prefix, n), nil
+}
+
+// orderAct appends an order activity entry,
+// set admin if the entry must only be visible to admin users
+func orderAct(
+	ctx context.Context, q *sqlite.Queries, order sqlite.Orders,
+	userID string, admin bool, msg string) (err error) {
+
+	var adminFlag int64
+	if admin {
+		adminFlag = 1
+	}
+	err = q.OrderActInsert(ctx, sqlite.OrderActInsertParams{
+		OrderID: order.OrderID,
+		State:   order.State,
+		Msg:     msg,
+		UserID:  userID,
+		Admin:   adminFlag,
+		Mod:     NewID(),
+	})
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	return nil
+}
+
+// orderConfigVal returns the order config for term,
+// or empty string if the term is not set
+func orderConfigVal(
+	ctx context.Context, q *sqlite.Queries, orderID, term string) (
+	val string, err error) {
+
+	config, err := q.OrderConfigByOrderID(ctx, orderID)
+	if err != nil {
+		return val, errors.WithStack(err)
+	}
+	for _, c := range config {
+		if c.OrderLineID == "" && c.Term == term {
+			return c.Val, nil
+		}
+	}
+	return val, nil
+}
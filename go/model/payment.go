@@ -0,0 +1,557 @@
+package model
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/shopd/shopd/go/db/sqlite"
+	"github.com/shopd/shopd/go/share"
+)
+
+// Payments are recorded in the tran table, and linked to orders with
+// order_tran. Details are stored in tran_config, e.g. the method,
+// processor, and processor reference. The processor is called by
+// go/services, the model records the outcome.
+// Orders are paid when successful transactions cover the total.
+// Amounts are never negative, refunds are transactions with the
+// refund type, see credit.go
+
+// Config terms for payments
+const (
+	// TermPaymentProcessor is the processor name used for checkout
+	TermPaymentProcessor = "payment_processor"
+	// TermTranMethod is the tran_config term for the method, e.g. card
+	TermTranMethod = "method"
+	// TermTranProcessor is the tran_config term for the processor name
+	TermTranProcessor = "processor"
+	// TermProcessorRef is the tran_config term for the processor reference
+	TermProcessorRef = "processor_ref"
+	// TermTranRef is the tran_config term for the reference entered by admins
+	TermTranRef = "ref"
+	// TermRefunded is the tran_config term for the refunded amount
+	TermRefunded = "refunded"
+	// TermTranType is the tran_config term for the type, e.g. refund
+	TermTranType = "type"
+	// TermCreditNote is the tran_config term for the credit note of a refund
+	TermCreditNote = "credit_note"
+)
+
+// PaymentProcessor returns the processor name for the domain
+func (m *Model) PaymentProcessor(ctx context.Context) (name string, err error) {
+	name, err = configVal(ctx, m.q, TermPaymentProcessor, "")
+	if err != nil {
+		return name, err
+	}
+	if name == "" {
+		return name, errors.WithStack(ErrConfig(TermPaymentProcessor))
+	}
+	return name, nil
+}
+
+// PaymentCreate records a pending payment for the amount due on the order,
+// or returns the pending payment if there is one for the same amount.
+// The order must be checked out, i.e. pending
+func (m *Model) PaymentCreate(
+	ctx context.Context, orderID, processor, userID string) (
+	pay share.Payment, err error) {
+
+	err = m.tx(ctx, func(q *sqlite.Queries) error {
+		order, err := orderByID(ctx, q, orderID)
+		if err != nil {
+			return err
+		}
+		if order.Paid == 1 {
+			return errors.WithStack(ErrOrderPaid(orderID))
+		}
+		if order.State != share.OrderStatePending {
+			return errors.WithStack(ErrOrderState(orderID, order.State))
+		}
+		inv, err := invoice(ctx, q, order)
+		if err != nil {
+			return err
+		}
+		if inv.Due == 0 {
+			return errors.WithStack(ErrOrderPaid(orderID))
+		}
+		// Checkout may be retried, e.g. the buyer went back from
+		// the hosted page, the open payment for the amount due is reused
+		trans, err := q.TransByOrderID(ctx, orderID)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		for _, tran := range trans {
+			open, err := payment(ctx, q, tran.TranID)
+			if err != nil {
+				return err
+			}
+			if open.State == share.TranStatePending && open.Type == "" &&
+				open.Processor == processor && open.Amount == inv.Due {
+				pay = open
+				return nil
+			}
+		}
+
+		pay = share.Payment{
+			TranID:    NewID(),
+			OrderID:   orderID,
+			State:     share.TranStatePending,
+			Descr:     tranDescr[share.TranMethodCard],
+			Amount:    inv.Due,
+			Currency:  inv.Currency,
+			Method:    share.TranMethodCard,
+			Processor: processor,
+		}
+		return tranInsert(ctx, q, pay, userID)
+	})
+	if err != nil {
+		return pay, err
+	}
+	return pay, nil
+}
+
+// failStalePayments fails open checkout payments that are not for the
+// amount due, e.g. the order was priced again after applying a discount.
+// The processor intent is for the old amount, checkout creates a new one
+func failStalePayments(
+	ctx context.Context, q *sqlite.Queries, order sqlite.Orders,
+	userID string) (err error) {
+
+	inv, err := invoice(ctx, q, order)
+	if err != nil {
+		return err
+	}
+	trans, err := q.TransByOrderID(ctx, order.OrderID)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	for _, tran := range trans {
+		pay, err := payment(ctx, q, tran.TranID)
+		if err != nil {
+			return err
+		}
+		if pay.State != share.TranStatePending || pay.Type != "" ||
+			pay.Processor == "" || pay.Amount == inv.Due {
+			continue
+		}
+		err = q.TranUpdateState(ctx, sqlite.TranUpdateStateParams{
+			State:  share.TranStateFailed,
+			Mod:    NewID(),
+			TranID: pay.TranID,
+		})
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		err = orderAct(ctx, q, order, userID, false,
+			fmt.Sprintf("%s %s %s, the amount due changed",
+				pay.Descr, share.TranStateFailed, pay.TranID))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// tranDescr by method
+var tranDescr = map[string]string{
+	share.TranMethodCard:   "Card payment",
+	share.TranMethodEFT:    "EFT payment",
+	share.TranMethodCash:   "Cash payment",
+	share.TranMethodCredit: "Store credit",
+}
+
+// PaymentRecord records a payment received outside of checkout,
+// e.g. EFT or cash on collection. Payments may be partial,
+// the order is paid when the payments cover the total
+func (m *Model) PaymentRecord(
+	ctx context.Context, params share.ParamsOrdersPaymentPost, userID string) (
+	pay share.Payment, err error) {
+
+	// Store credit is applied with PaymentCredit
+	descr, ok := tranDescr[params.Method]
+	if !ok || params.Method == share.TranMethodCredit {
+		return pay, errors.WithStack(ErrInvalidParam("Method"))
+	}
+	err = m.tx(ctx, func(q *sqlite.Queries) error {
+		order, err := orderByID(ctx, q, params.OrderID)
+		if err != nil {
+			return err
+		}
+		if order.Paid == 1 {
+			return errors.WithStack(ErrOrderPaid(order.OrderID))
+		}
+		switch order.State {
+		case share.OrderStatePending, share.OrderStateConfirmed,
+			share.OrderStateComplete:
+		default:
+			return errors.WithStack(ErrOrderState(order.OrderID, order.State))
+		}
+		inv, err := invoice(ctx, q, order)
+		if err != nil {
+			return err
+		}
+		if params.Amount <= 0 || params.Amount > inv.Due {
+			return errors.WithStack(ErrInvalidParam("Amount"))
+		}
+
+		pay = share.Payment{
+			TranID:   NewID(),
+			OrderID:  order.OrderID,
+			State:    share.TranStateSuccess,
+			Descr:    descr,
+			Amount:   params.Amount,
+			Currency: inv.Currency,
+			Method:   params.Method,
+			Message:  strings.TrimSpace(params.Message),
+		}
+		err = tranInsert(ctx, q, pay, userID)
+		if err != nil {
+			return err
+		}
+		err = orderAct(ctx, q, order, userID, true,
+			fmt.Sprintf("%s received %s", descr, pay.TranID))
+		if err != nil {
+			return err
+		}
+		return orderPaid(ctx, q, order, userID)
+	})
+	if err != nil {
+		return pay, err
+	}
+	return pay, nil
+}
+
+// OrderPayments lists the transactions for the order, including refunds,
+// and the outstanding balance
+func (m *Model) OrderPayments(
+	ctx context.Context, orderID string) (list share.OrderPayments, err error) {
+
+	order, err := orderByID(ctx, m.q, orderID)
+	if err != nil {
+		return list, err
+	}
+	inv, err := invoice(ctx, m.q, order)
+	if err != nil {
+		return list, err
+	}
+	list = share.OrderPayments{
+		OrderID:  order.OrderID,
+		State:    order.State,
+		Paid:     order.Paid == 1,
+		Currency: inv.Currency,
+		Total:    inv.Total,
+		Received: inv.Paid,
+		Due:      inv.Due,
+	}
+	trans, err := m.q.TransByOrderID(ctx, orderID)
+	if err != nil {
+		return list, errors.WithStack(err)
+	}
+	list.Payments = make([]share.Payment, 0, len(trans))
+	for _, tran := range trans {
+		pay, err := payment(ctx, m.q, tran.TranID)
+		if err != nil {
+			return list, err
+		}
+		if pay.Type == share.TranTypeRefund && pay.State == share.TranStateSuccess {
+			list.Refunded += pay.Amount
+		}
+		list.Payments = append(list.Payments, pay)
+	}
+	return list, nil
+}
+
+// PaymentRef sets the processor reference for the payment
+func (m *Model) PaymentRef(
+	ctx context.Context, tranID, ref string) (err error) {
+
+	err = m.q.TranConfigUpsert(ctx, sqlite.TranConfigUpsertParams{
+		TranID: tranID,
+		Term:   TermProcessorRef,
+		Val:    ref,
+		UserID: ModIDSystem,
+	})
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	return nil
+}
+
+// Payment returns the transaction with config
+func (m *Model) Payment(
+	ctx context.Context, tranID string) (pay share.Payment, err error) {
+
+	return payment(ctx, m.q, tranID)
+}
+
+// PaymentByRef returns the transaction for the processor reference
+func (m *Model) PaymentByRef(
+	ctx context.Context, processor, ref string) (pay share.Payment, err error) {
+
+	tranID, err := m.q.TranIDByProcessorRef(ctx, sqlite.TranIDByProcessorRefParams{
+		Ref:       ref,
+		Processor: processor,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return pay, errors.WithStack(ErrNotFound(ref))
+		}
+		return pay, errors.WithStack(err)
+	}
+	return payment(ctx, m.q, tranID)
+}
+
+// PaymentUpdate sets the state of a pending payment,
+// the order is marked as paid if the payments cover the total
+func (m *Model) PaymentUpdate(
+	ctx context.Context, tranID, state, userID string) (
+	pay share.Payment, err error) {
+
+	err = m.tx(ctx, func(q *sqlite.Queries) error {
+		pay, err = payment(ctx, q, tranID)
+		if err != nil {
+			return err
+		}
+		if pay.State == state {
+			return nil
+		}
+		if pay.State != share.TranStatePending ||
+			(state != share.TranStateSuccess && state != share.TranStateFailed) {
+			return errors.WithStack(ErrStateTransition(pay.State, state))
+		}
+		err = q.TranUpdateState(ctx, sqlite.TranUpdateStateParams{
+			State:  state,
+			Mod:    NewID(),
+			TranID: tranID,
+		})
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		pay.State = state
+
+		order, err := orderByID(ctx, q, pay.OrderID)
+		if err != nil {
+			return err
+		}
+		err = orderAct(ctx, q, order, userID, false,
+			fmt.Sprintf("%s %s %s", pay.Descr, state, tranID))
+		if err != nil {
+			return err
+		}
+		if state != share.TranStateSuccess {
+			return nil
+		}
+		return orderPaid(ctx, q, order, userID)
+	})
+	if err != nil {
+		return pay, err
+	}
+	return pay, nil
+}
+
+// PaymentRefunded adds amount to the refunded total for the payment
+func (m *Model) PaymentRefunded(
+	ctx context.Context, tranID string, amount int64, userID string) (
+	pay share.Payment, err error) {
+
+	err = m.tx(ctx, func(q *sqlite.Queries) error {
+		pay, err = payment(ctx, q, tranID)
+		if err != nil {
+			return err
+		}
+		if pay.State != share.TranStateSuccess {
+			return errors.WithStack(ErrStateTransition(
+				pay.State, share.PaymentRefunded))
+		}
+		if amount <= 0 || pay.Refunded+amount > pay.Amount {
+			return errors.WithStack(ErrInvalidParam("Amount"))
+		}
+		pay.Refunded += amount
+		err = q.TranConfigUpsert(ctx, sqlite.TranConfigUpsertParams{
+			TranID: tranID,
+			Term:   TermRefunded,
+			Val:    strconv.FormatInt(pay.Refunded, 10),
+			UserID: modID(userID),
+		})
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		return nil
+	})
+	if err != nil {
+		return pay, err
+	}
+	return pay, nil
+}
+
+// tranInsert records the transaction and config, and links it to the order if set
+func tranInsert(
+	ctx context.Context, q *sqlite.Queries, pay share.Payment,
+	userID string) (err error) {
+
+	err = q.TranInsert(ctx, sqlite.TranInsertParams{
+		TranID:    pay.TranID,
+		AccountID: pay.AccountID,
+		State:     pay.State,
+		Descr:     pay.Descr,
+		Amount:    pay.Amount,
+		Currency:  pay.Currency,
+		UserID:    modID(userID),
+		Mod:       NewID(),
+	})
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	// Account adjustments are not linked to orders
+	if pay.OrderID != "" {
+		err = q.OrderTranInsert(ctx, sqlite.OrderTranInsertParams{
+			OrderID: pay.OrderID,
+			TranID:  pay.TranID,
+		})
+		if err != nil {
+			return errors.WithStack(err)
+		}
+	}
+	config := []struct{ term, val string }{
+		{TermTranMethod, pay.Method},
+		{TermTranProcessor, pay.Processor},
+		{TermProcessorRef, pay.Ref},
+		{TermTranRef, pay.Message},
+		{TermTranType, pay.Type},
+		{TermCreditNote, pay.CreditNoteID},
+	}
+	for _, c := range config {
+		if c.val == "" {
+			continue
+		}
+		err = q.TranConfigUpsert(ctx, sqlite.TranConfigUpsertParams{
+			TranID: pay.TranID,
+			Term:   c.term,
+			Val:    c.val,
+			UserID: modID(userID),
+		})
+		if err != nil {
+			return errors.WithStack(err)
+		}
+	}
+	return nil
+}
+
+// payment fetches the transaction, config, and linked order
+func payment(
+	ctx context.Context, q *sqlite.Queries, tranID string) (
+	pay share.Payment, err error) {
+
+	tran, err := q.TranByID(ctx, tranID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return pay, errors.WithStack(ErrNotFound(tranID))
+		}
+		return pay, errors.WithStack(err)
+	}
+	pay = share.Payment{
+		TranID:    tran.TranID,
+		State:     tran.State,
+		Descr:     tran.Descr,
+		Amount:    tran.Amount,
+		Currency:  tran.Currency,
+		AccountID: tran.AccountID,
+	}
+	link, err := q.OrderTranByTranID(ctx, tranID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return pay, errors.WithStack(err)
+	}
+	pay.OrderID = link.OrderID
+
+	config, err := q.TranConfigByTranID(ctx, tranID)
+	if err != nil {
+		return pay, errors.WithStack(err)
+	}
+	for _, c := range config {
+		switch c.Term {
+		case TermTranMethod:
+			pay.Method = c.Val
+		case TermTranProcessor:
+			pay.Processor = c.Val
+		case TermProcessorRef:
+			pay.Ref = c.Val
+		case TermTranRef:
+			pay.Message = c.Val
+		case TermTranType:
+			pay.Type = c.Val
+		case TermCreditNote:
+			pay.CreditNoteID = c.Val
+		case TermRefunded:
+			pay.Refunded, err = strconv.ParseInt(c.Val, 10, 64)
+			if err != nil {
+				return pay, errors.WithStack(err)
+			}
+		}
+	}
+	return pay, nil
+}
+
+// orderPaid sets the paid flag if successful payments cover the total,
+// pending orders are confirmed when paid.
+// Payments received after the order was reversed or expired are recorded,
+// but the order is not paid. The payment must be refunded, see ReconRefund
+func orderPaid(
+	ctx context.Context, q *sqlite.Queries, order sqlite.Orders,
+	userID string) (err error) {
+
+	if order.Paid == 1 {
+		return nil
+	}
+	if !orderPayable(order.State) {
+		return orderAct(ctx, q, order, userID, true, fmt.Sprintf(
+			"Payment received for %s order, refund required", order.State))
+	}
+	inv, err := invoice(ctx, q, order)
+	if err != nil {
+		return err
+	}
+	if inv.Due > 0 {
+		return nil
+	}
+	// Order numbers are allocated in the order that orders are paid
+	_, err = orderNo(ctx, q, order)
+	if err != nil {
+		return err
+	}
+	err = q.OrderUpdatePaid(ctx, sqlite.OrderUpdatePaidParams{
+		Paid:    1,
+		Mod:     NewID(),
+		ModID:   modID(userID),
+		OrderID: order.OrderID,
+	})
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	err = orderAct(ctx, q, order, userID, false, "Paid in full")
+	if err != nil {
+		return err
+	}
+	if order.State != share.OrderStatePending {
+		return nil
+	}
+	// The money is already taken, so the order is confirmed
+	// even if stock ran out after checkout
+	_, err = allocateOrder(ctx, q, order.OrderID, userID)
+	if errors.Is(err, ErrInsufficientStock("")) {
+		err = backorder(ctx, q, order, userID, err)
+	}
+	if err != nil {
+		return err
+	}
+	return updateOrderState(ctx, q, order, share.OrderStateConfirmed, userID)
+}
+
+// orderPayable returns true if payments may be applied to orders in the state
+func orderPayable(state string) bool {
+	return state == share.OrderStatePending ||
+		state == share.OrderStateConfirmed ||
+		state == share.OrderStateComplete
+}
@@ -0,0 +1,185 @@
+package model_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+	"github.com/pkg/errors"
+	"github.com/shopd/shopd/go/model"
+	"github.com/shopd/shopd/go/share"
+)
+
+func TestPaymentRetry(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+	m, db := newTestModel(t)
+	exec(t, db,
+		`insert into cat(sku, title, descr, state, mod, mod_id)
+		values ('a', 'Apple', '', 'stock', 'm', 's')`,
+		`insert into cat_price values ('a', 1000)`,
+	)
+	cart, err := m.CartAdd(ctx, "", "", share.ParamsCartPost{Sku: "a", Qty: 1})
+	is.NoErr(err)
+	orderID := cart.OrderID
+	_, err = m.PaymentCreate(ctx, orderID, "fake", "")
+	is.True(errors.Is(err, model.ErrOrderState("", "")))
+	is.NoErr(m.SetOrderState(ctx, orderID, share.OrderStatePending, ""))
+
+	// The pending payment is reused
+	first, err := m.PaymentCreate(ctx, orderID, "fake", "")
+	is.NoErr(err)
+	is.Equal(first.Amount, int64(1150))
+	pay, err := m.PaymentCreate(ctx, orderID, "fake", "")
+	is.NoErr(err)
+	is.Equal(pay.TranID, first.TranID)
+
+	// The buyer pays again after a failed payment
+	_, err = m.PaymentUpdate(ctx, first.TranID, share.TranStateFailed, "")
+	is.NoErr(err)
+	pay, err = m.PaymentCreate(ctx, orderID, "fake", "")
+	is.NoErr(err)
+	is.True(pay.TranID != first.TranID)
+	is.Equal(pay.Amount, int64(1150))
+
+	_, err = m.PaymentUpdate(ctx, pay.TranID, share.TranStateSuccess, "")
+	is.NoErr(err)
+	summary, err := m.OrderSummary(ctx, orderID)
+	is.NoErr(err)
+	is.True(summary.Paid)
+	is.Equal(summary.State, share.OrderStateConfirmed)
+	_, err = m.PaymentCreate(ctx, orderID, "fake", "")
+	is.True(errors.Is(err, model.ErrOrderPaid("")))
+}
+
+func TestLatePayment(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+	m, db := newTestModel(t)
+	exec(t, db,
+		`insert into cat(sku, title, descr, state, mod, mod_id)
+		values ('a', 'Apple', '', 'stock', 'm', 's')`,
+		`insert into cat_price values ('a', 1000)`,
+	)
+	cart, err := m.CartAdd(ctx, "", "", share.ParamsCartPost{Sku: "a", Qty: 1})
+	is.NoErr(err)
+	orderID := cart.OrderID
+	is.NoErr(m.SetOrderState(ctx, orderID, share.OrderStatePending, ""))
+	pay, err := m.PaymentCreate(ctx, orderID, "fake", "")
+	is.NoErr(err)
+	n, err := m.ExpireOrders(ctx, time.Now().Add(8*24*time.Hour))
+	is.NoErr(err)
+	is.Equal(n, 1)
+
+	// The processor settles the payment after the order expired
+	pay, err = m.PaymentUpdate(ctx, pay.TranID, share.TranStateSuccess, "")
+	is.NoErr(err)
+	is.Equal(pay.State, share.TranStateSuccess)
+	summary, err := m.OrderSummary(ctx, orderID)
+	is.NoErr(err)
+	is.True(!summary.Paid)
+	is.Equal(summary.State, share.OrderStateExpired)
+	var acts int
+	is.NoErr(db.QueryRow(`select count(*) from order_act
+		where order_id = ? and admin = 1 and msg like '%refund required%'`,
+		orderID).Scan(&acts))
+	is.Equal(acts, 1)
+
+	recon, err := m.Reconciliation(ctx, share.ReconFilter{})
+	is.NoErr(err)
+	is.Equal(len(recon.Issues), 1)
+	is.Equal(recon.Issues[0].Type, share.ReconRefund)
+	is.Equal(recon.Issues[0].TranID, pay.TranID)
+}
+
+func TestPaymentEventSave(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+	m, _ := newTestModel(t)
+	event := share.PaymentEvent{
+		Processor: "fake",
+		EventID:   "e1",
+		Ref:       "r1",
+		State:     share.TranStateSuccess,
+		Amount:    1150,
+	}
+	saved, err := m.PaymentEventSave(ctx, event, []byte("{}"))
+	is.NoErr(err)
+	is.Equal(saved.Handled, share.PaymentEventPending)
+
+	// Failed events are retried
+	_, err = m.PaymentEventHandled(ctx, "fake", "e1", errors.New("failed"), "")
+	is.NoErr(err)
+	saved, err = m.PaymentEventSave(ctx, event, []byte("{}"))
+	is.NoErr(err)
+	is.Equal(saved.Handled, share.PaymentEventFailed)
+	events, err := m.PaymentEvents(ctx, share.PaymentEventFailed)
+	is.NoErr(err)
+	is.Equal(len(events.Events), 1)
+
+	// Events that were handled are not stored again
+	_, err = m.PaymentEventHandled(ctx, "fake", "e1", nil, "admin")
+	is.NoErr(err)
+	event.State = share.TranStateFailed
+	saved, err = m.PaymentEventSave(ctx, event, []byte("{}"))
+	is.NoErr(err)
+	is.Equal(saved.Handled, share.PaymentEventDone)
+	is.Equal(saved.State, share.TranStateSuccess)
+}
+
+func TestPaymentRecord(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+	m, db := newTestModel(t)
+	exec(t, db,
+		`insert into cat(sku, title, descr, state, mod, mod_id)
+		values ('a', 'Apple', '', 'stock', 'm', 's')`,
+		`insert into cat_price values ('a', 1000)`,
+	)
+	cart, err := m.CartAdd(ctx, "", "", share.ParamsCartPost{Sku: "a", Qty: 1})
+	is.NoErr(err)
+	orderID := cart.OrderID
+	params := share.ParamsOrdersPaymentPost{
+		OrderID: orderID, Method: share.TranMethodEFT, Amount: 500,
+	}
+	_, err = m.PaymentRecord(ctx, params, "admin")
+	is.True(errors.Is(err, model.ErrOrderState("", "")))
+	is.NoErr(m.SetOrderState(ctx, orderID, share.OrderStatePending, ""))
+
+	for _, p := range []share.ParamsOrdersPaymentPost{
+		{OrderID: orderID, Method: "cheque", Amount: 500},
+		{OrderID: orderID, Method: share.TranMethodCredit, Amount: 500},
+		{OrderID: orderID, Method: share.TranMethodEFT, Amount: 0},
+		{OrderID: orderID, Method: share.TranMethodEFT, Amount: 1151},
+	} {
+		_, err = m.PaymentRecord(ctx, p, "admin")
+		is.True(errors.Is(err, model.ErrInvalidParam("")))
+	}
+
+	// Partial payments don't confirm the order
+	params.Message = " EFT ref 123 "
+	pay, err := m.PaymentRecord(ctx, params, "admin")
+	is.NoErr(err)
+	is.Equal(pay.Message, "EFT ref 123")
+	list, err := m.OrderPayments(ctx, orderID)
+	is.NoErr(err)
+	is.True(!list.Paid)
+	is.Equal(list.State, share.OrderStatePending)
+	is.Equal(list.Received, int64(500))
+	is.Equal(list.Due, int64(650))
+
+	params.Method = share.TranMethodCash
+	params.Amount = 650
+	_, err = m.PaymentRecord(ctx, params, "admin")
+	is.NoErr(err)
+	list, err = m.OrderPayments(ctx, orderID)
+	is.NoErr(err)
+	is.True(list.Paid)
+	is.Equal(list.State, share.OrderStateConfirmed)
+	is.Equal(list.Due, int64(0))
+	is.Equal(len(list.Payments), 2)
+
+	_, err = m.PaymentRecord(ctx, params, "admin")
+	is.True(errors.Is(err, model.ErrOrderPaid("")))
+}
@@ -0,0 +1,648 @@
+package model
+
+import (
+	"context"
+	"database/sql"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/shopd/shopd/go/db/sqlite"
+	"github.com/shopd/shopd/go/share"
+)
+
+// Price lists override cat_price for buyers.
+// A price list applies to the buyer if the user, one of the user tags,
+// or one of the accounts linked to the user is listed.
+// Lists without user, tag, or account restrictions apply to all buyers.
+//
+// The most specific price is used, user lists are more specific than
+// account lists, and account lists more specific than tag lists.
+// If more than one list with the same specificity applies,
+// the lowest price is used.
+//
+// Quantity breaks in cat_price_tier apply to all buyers,
+// the tier price is used if it's lower than the price for the buyer.
+// Lines for skus with tiers are priced again when the qty changes
+
+// Specificity of price list restrictions
+const (
+	priceAll = iota
+	priceTag
+	priceAccount
+	priceUser
+)
+
+// Buyer for price lists, UserID is empty for guests
+type Buyer struct {
+	UserID   string
+	Tags     []string
+	Accounts []string
+}
+
+// PriceList is a price for a sku, from a price list with restrictions.
+// Empty restrictions are not applicable
+type PriceList struct {
+	PriceListID string
+	Price       int64
+	Users       []string
+	Tags        []string
+	Accounts    []string
+	Ranges      []DateRange
+}
+
+// specificity of the price list for the buyer,
+// or -1 if the list doesn't apply
+func (p PriceList) specificity(buyer Buyer, now time.Time) int {
+	if !inRanges(p.Ranges, now) {
+		return -1
+	}
+	if len(p.Users) == 0 && len(p.Tags) == 0 && len(p.Accounts) == 0 {
+		return priceAll
+	}
+	if buyer.UserID == "" {
+		return -1
+	}
+	if slices.Contains(p.Users, buyer.UserID) {
+		return priceUser
+	}
+	for _, account := range buyer.Accounts {
+		if slices.Contains(p.Accounts, account) {
+			return priceAccount
+		}
+	}
+	for _, tag := range buyer.Tags {
+		if slices.Contains(p.Tags, tag) {
+			return priceTag
+		}
+	}
+	return -1
+}
+
+// PriceParams for the Price func
+type PriceParams struct {
+	Now   time.Time
+	Buyer Buyer
+	// Qty on the order line
+	Qty int64
+	// Base is the cat_price
+	Base  int64
+	Lists []PriceList
+	// Tiers must be sorted by qty
+	Tiers []sqlite.CatPriceTier
+}
+
+// Price returns the unit price for the buyer and qty,
+// priceListID is empty if a price list is not used
+func Price(params PriceParams) (price int64, priceListID string) {
+	best := -1
+	price = params.Base
+	for _, list := range params.Lists {
+		s := list.specificity(params.Buyer, params.Now)
+		if s < 0 || s < best {
+			continue
+		}
+		if s > best || list.Price < price {
+			best, price, priceListID = s, list.Price, list.PriceListID
+		}
+	}
+	tier := TierPrice(params.Tiers, params.Qty, params.Base)
+	if tier < price {
+		return tier, ""
+	}
+	return price, priceListID
+}
+
+// TierPrice returns the price for the largest tier qty not more than qty,
+// or the base price if qty is below the first tier.
+// Tiers must be sorted by qty
+func TierPrice(tiers []sqlite.CatPriceTier, qty, base int64) int64 {
+	price := base
+	for _, tier := range tiers {
+		if tier.Qty > qty {
+			break
+		}
+		price = tier.Price
+	}
+	return price
+}
+
+// SkuPrice returns the price of the sku for the user,
+// use an empty userID for guests.
+// Tiers lists the price for each quantity break, starting at qty one.
+// Prices include tax for the country of the order if the price_display
+// term is inclusive, use an empty orderID for the domain country
+func (m *Model) SkuPrice(
+	ctx context.Context, sku, userID, orderID string) (
+	price share.SkuPrice, err error) {
+
+	price.Sku = sku
+	price.Currency, err = configVal(ctx, m.q, TermCurrency, CurrencyDefault)
+	if err != nil {
+		return price, err
+	}
+	params, err := priceParams(ctx, m.q, sku, userID, time.Now())
+	if err != nil {
+		return price, err
+	}
+	params.Qty = 1
+	price.Price, _ = Price(params)
+	price.Tiers = []share.PriceTier{}
+	if len(params.Tiers) > 0 {
+		price.Tiers = append(price.Tiers, share.PriceTier{
+			Qty:   1,
+			Price: price.Price,
+		})
+	}
+	for _, tier := range params.Tiers {
+		params.Qty = tier.Qty
+		p, _ := Price(params)
+		price.Tiers = append(price.Tiers, share.PriceTier{
+			Qty:   tier.Qty,
+			Price: p,
+		})
+	}
+
+	price.TaxDisplay, err = priceDisplay(ctx, m.q)
+	if err != nil {
+		return price, err
+	}
+	if price.TaxDisplay == share.PriceDisplayExclusive {
+		return price, nil
+	}
+	// Tax for the country of the cart, if any
+	tax, err := taxParams(ctx, m.q, orderID)
+	if err != nil {
+		return price, err
+	}
+	price.Price, err = inclusivePrice(ctx, m.q, tax, sku, price.Price)
+	if err != nil {
+		return price, err
+	}
+	for i, tier := range price.Tiers {
+		price.Tiers[i].Price, err = inclusivePrice(ctx, m.q, tax, sku, tier.Price)
+		if err != nil {
+			return price, err
+		}
+	}
+	return price, nil
+}
+
+// skuPrice returns the unit price for the buyer and qty,
+// or ErrUnavailable if the sku doesn't have a cat_price
+func skuPrice(
+	ctx context.Context, q *sqlite.Queries, sku, userID string, qty int64,
+	now time.Time) (price int64, err error) {
+
+	params, err := priceParams(ctx, q, sku, userID, now)
+	if err != nil {
+		return price, err
+	}
+	params.Qty = qty
+	price, _ = Price(params)
+	return price, nil
+}
+
+// priceParams fetches the base price, price lists, and tiers for the sku
+func priceParams(
+	ctx context.Context, q *sqlite.Queries, sku, userID string, now time.Time) (
+	params PriceParams, err error) {
+
+	base, err := q.CatPriceBySKU(ctx, sku)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return params, errors.WithStack(ErrUnavailable(sku))
+		}
+		return params, errors.WithStack(err)
+	}
+	params.Now = now.UTC()
+	params.Base = base.Price
+	params.Tiers, err = q.CatPriceTiersBySKU(ctx, sku)
+	if err != nil {
+		return params, errors.WithStack(err)
+	}
+	params.Lists, err = priceLists(ctx, q, sku)
+	if err != nil {
+		return params, err
+	}
+	if len(params.Lists) == 0 {
+		return params, nil
+	}
+	params.Buyer, err = buyer(ctx, q, userID)
+	if err != nil {
+		return params, err
+	}
+	return params, nil
+}
+
+// buyer fetches the user tags and accounts
+func buyer(
+	ctx context.Context, q *sqlite.Queries, userID string) (b Buyer, err error) {
+
+	b.UserID = userID
+	if userID == "" {
+		return b, nil
+	}
+	b.Tags, err = q.UserTagsByUserID(ctx, userID)
+	if err != nil {
+		return b, errors.WithStack(err)
+	}
+	b.Accounts, err = q.AccountsByUserID(ctx, userID)
+	if err != nil {
+		return b, errors.WithStack(err)
+	}
+	return b, nil
+}
+
+// priceLists lists the prices for the sku with restrictions
+func priceLists(
+	ctx context.Context, q *sqlite.Queries, sku string) (
+	lists []PriceList, err error) {
+
+	prices, err := q.PriceListPricesBySKU(ctx, sku)
+	if err != nil {
+		return lists, errors.WithStack(err)
+	}
+	if len(prices) == 0 {
+		return lists, nil
+	}
+	index := make(map[string]int)
+	for _, p := range prices {
+		index[p.PriceListID] = len(lists)
+		lists = append(lists, PriceList{
+			PriceListID: p.PriceListID,
+			Price:       p.Price,
+		})
+	}
+
+	users, err := q.PriceListUsers(ctx)
+	if err != nil {
+		return lists, errors.WithStack(err)
+	}
+	for _, row := range users {
+		if i, ok := index[row.PriceListID]; ok {
+			lists[i].Users = append(lists[i].Users, row.UserID)
+		}
+	}
+	tags, err := q.PriceListTags(ctx)
+	if err != nil {
+		return lists, errors.WithStack(err)
+	}
+	for _, row := range tags {
+		if i, ok := index[row.PriceListID]; ok {
+			lists[i].Tags = append(lists[i].Tags, row.Tag)
+		}
+	}
+	accounts, err := q.PriceListAccounts(ctx)
+	if err != nil {
+		return lists, errors.WithStack(err)
+	}
+	for _, row := range accounts {
+		if i, ok := index[row.PriceListID]; ok {
+			lists[i].Accounts = append(lists[i].Accounts, row.AccountID)
+		}
+	}
+	ranges, err := q.PriceListRanges(ctx)
+	if err != nil {
+		return lists, errors.WithStack(err)
+	}
+	for _, row := range ranges {
+		i, ok := index[row.PriceListID]
+		if !ok {
+			continue
+		}
+		start, err := time.Parse(DateTimeFormat, row.Start)
+		if err != nil {
+			return lists, errors.WithStack(ErrInvalidParam(row.PriceListID))
+		}
+		end, err := time.Parse(DateTimeFormat, row.End)
+		if err != nil {
+			return lists, errors.WithStack(ErrInvalidParam(row.PriceListID))
+		}
+		lists[i].Ranges = append(lists[i].Ranges, DateRange{
+			Start: start, End: end,
+		})
+	}
+
+	return lists, nil
+}
+
+// PriceListImport replaces the prices and restrictions on the price list,
+// the price list is created if it doesn't exist.
+// The first row of the CSV is a header with columns sku and price,
+// prices are in the smallest unit, e.g. cents.
+// Lists without user, tag, or account restrictions apply to all buyers,
+// params.Global must be set to confirm that
+func (m *Model) PriceListImport(
+	ctx context.Context, params share.ParamsPriceListPost, r io.Reader,
+	userID string) (result share.PriceListImport, err error) {
+
+	result.PriceListID = strings.TrimSpace(params.PriceListID)
+	if result.PriceListID == "" {
+		return result, errors.WithStack(ErrInvalidParam("PriceListID"))
+	}
+	users := splitLines(params.Users)
+	tags := splitLines(params.Tags)
+	accounts := splitLines(params.Accounts)
+	restricted := len(users) > 0 || len(tags) > 0 || len(accounts) > 0
+	if restricted == params.Global {
+		return result, errors.WithStack(ErrInvalidParam("Global"))
+	}
+	prices, err := priceListCSV(r)
+	if err != nil {
+		return result, err
+	}
+
+	err = m.tx(ctx, func(q *sqlite.Queries) error {
+		err := q.PriceListUpsert(ctx, sqlite.PriceListUpsertParams{
+			PriceListID: result.PriceListID,
+			Descr:       params.Descr,
+			Mod:         NewID(),
+			ModID:       modID(userID),
+		})
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		err = priceListRestrict(ctx, q, result.PriceListID,
+			users, tags, accounts, params.Ranges)
+		if err != nil {
+			return err
+		}
+		err = q.PriceListPriceDelete(ctx, result.PriceListID)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		for _, p := range prices {
+			_, err = q.CatBySKU(ctx, p.SKU)
+			if err != nil {
+				if errors.Is(err, sql.ErrNoRows) {
+					return errors.WithStack(ErrNotFound(p.SKU))
+				}
+				return errors.WithStack(err)
+			}
+			err = q.PriceListPriceInsert(ctx, sqlite.PriceListPriceInsertParams{
+				PriceListID: result.PriceListID,
+				SKU:         p.SKU,
+				Price:       p.Price,
+			})
+			if err != nil {
+				return errors.WithStack(err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return result, err
+	}
+	result.Count = len(prices)
+	return result, nil
+}
+
+// priceListRestrict replaces the restrictions on the price list,
+// users and accounts must exist
+func priceListRestrict(
+	ctx context.Context, q *sqlite.Queries, priceListID string,
+	users, tags, accounts []string, ranges string) (err error) {
+
+	loc, err := location(ctx, q)
+	if err != nil {
+		return err
+	}
+	dateRanges, err := dateRangesCSV(strings.NewReader(ranges), loc)
+	if err != nil {
+		return err
+	}
+
+	err = q.PriceListUserDelete(ctx, priceListID)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	for _, u := range users {
+		_, err = q.UserByID(ctx, u)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return errors.WithStack(ErrNotFound(u))
+			}
+			return errors.WithStack(err)
+		}
+		err = q.PriceListUserInsert(ctx, sqlite.PriceListUserInsertParams{
+			PriceListID: priceListID,
+			UserID:      u,
+		})
+		if err != nil {
+			return errors.WithStack(err)
+		}
+	}
+	err = q.PriceListTagDelete(ctx, priceListID)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	for _, tag := range tags {
+		err = q.PriceListTagInsert(ctx, sqlite.PriceListTagInsertParams{
+			PriceListID: priceListID,
+			Tag:         tag,
+		})
+		if err != nil {
+			return errors.WithStack(err)
+		}
+	}
+	err = q.PriceListAccountDelete(ctx, priceListID)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	for _, account := range accounts {
+		_, err = q.AccountByID(ctx, account)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return errors.WithStack(ErrNotFound(account))
+			}
+			return errors.WithStack(err)
+		}
+		err = q.PriceListAccountInsert(ctx, sqlite.PriceListAccountInsertParams{
+			PriceListID: priceListID,
+			AccountID:   account,
+		})
+		if err != nil {
+			return errors.WithStack(err)
+		}
+	}
+	err = q.PriceListRangeDelete(ctx, priceListID)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	for _, r := range dateRanges {
+		err = q.PriceListRangeInsert(ctx, sqlite.PriceListRangeInsertParams{
+			PriceListID: priceListID,
+			Start:       r.Start.Format(DateTimeFormat),
+			End:         r.End.Format(DateTimeFormat),
+		})
+		if err != nil {
+			return errors.WithStack(err)
+		}
+	}
+	return nil
+}
+
+// splitLines returns the unique non-empty lines in s, trimmed
+func splitLines(s string) (list []string) {
+	for _, line := range strings.Split(s, "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" && !slices.Contains(list, line) {
+			list = append(list, line)
+		}
+	}
+	return list
+}
+
+// dateRangesCSV reads start and end columns, without a header,
+// in the domain time zone. End must be after start
+func dateRangesCSV(
+	r io.Reader, loc *time.Location) (ranges []DateRange, err error) {
+
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+	reader.FieldsPerRecord = 2
+	for line := 1; ; line++ {
+		row, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return ranges, errors.WithStack(
+				ErrInvalidParam(fmt.Sprintf("line %d", line)))
+		}
+		start, err := parseLocal(row[0], loc)
+		if err != nil {
+			return ranges, errors.WithStack(
+				ErrInvalidParam(fmt.Sprintf("line %d", line)))
+		}
+		end, err := parseLocal(row[1], loc)
+		if err != nil || !end.After(start) {
+			return ranges, errors.WithStack(
+				ErrInvalidParam(fmt.Sprintf("line %d", line)))
+		}
+		ranges = append(ranges, DateRange{Start: start, End: end})
+	}
+	return ranges, nil
+}
+
+// priceListCSV reads sku and price columns, in any order
+func priceListCSV(r io.Reader) (prices []sqlite.PriceListPrice, err error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+	header, err := reader.Read()
+	if err != nil {
+		return prices, errors.WithStack(ErrInvalidParam("header"))
+	}
+	skuCol, priceCol := -1, -1
+	for i, col := range header {
+		switch strings.ToLower(strings.TrimSpace(col)) {
+		case "sku":
+			skuCol = i
+		case "price":
+			priceCol = i
+		}
+	}
+	if skuCol < 0 || priceCol < 0 {
+		return prices, errors.WithStack(ErrInvalidParam("header"))
+	}
+
+	seen := make(map[string]bool)
+	for line := 2; ; line++ {
+		row, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return prices, errors.WithStack(
+				ErrInvalidParam(fmt.Sprintf("line %d", line)))
+		}
+		sku := strings.TrimSpace(row[skuCol])
+		price, err := strconv.ParseInt(strings.TrimSpace(row[priceCol]), 10, 64)
+		if sku == "" || err != nil || price < 0 || seen[sku] {
+			return prices, errors.WithStack(
+				ErrInvalidParam(fmt.Sprintf("line %d", line)))
+		}
+		seen[sku] = true
+		prices = append(prices, sqlite.PriceListPrice{SKU: sku, Price: price})
+	}
+	return prices, nil
+}
+
+// PriceTiersSet replaces the quantity breaks for the sku.
+// Tiers are CSV rows with qty and price columns, without a header,
+// an empty list removes the tiers. Returns the price for guests
+func (m *Model) PriceTiersSet(
+	ctx context.Context, params share.ParamsPriceTiersPost, userID string) (
+	price share.SkuPrice, err error) {
+
+	tiers, err := priceTiersCSV(strings.NewReader(params.Tiers))
+	if err != nil {
+		return price, err
+	}
+	err = m.tx(ctx, func(q *sqlite.Queries) error {
+		_, err := q.CatPriceBySKU(ctx, params.Sku)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return errors.WithStack(ErrNotFound(params.Sku))
+			}
+			return errors.WithStack(err)
+		}
+		err = q.CatPriceTierDelete(ctx, params.Sku)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		for _, tier := range tiers {
+			err = q.CatPriceTierInsert(ctx, sqlite.CatPriceTierInsertParams{
+				SKU:   params.Sku,
+				Qty:   tier.Qty,
+				Price: tier.Price,
+			})
+			if err != nil {
+				return errors.WithStack(err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return price, err
+	}
+	return m.SkuPrice(ctx, params.Sku, "", "")
+}
+
+// priceTiersCSV reads qty and price columns,
+// qty must be more than one and unique
+func priceTiersCSV(r io.Reader) (tiers []sqlite.CatPriceTier, err error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+	reader.FieldsPerRecord = 2
+	seen := make(map[int64]bool)
+	for line := 1; ; line++ {
+		row, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return tiers, errors.WithStack(
+				ErrInvalidParam(fmt.Sprintf("line %d", line)))
+		}
+		qty, err := strconv.ParseInt(strings.TrimSpace(row[0]), 10, 64)
+		if err != nil || qty <= 1 || seen[qty] {
+			return tiers, errors.WithStack(
+				ErrInvalidParam(fmt.Sprintf("line %d", line)))
+		}
+		price, err := strconv.ParseInt(strings.TrimSpace(row[1]), 10, 64)
+		if err != nil || price < 0 {
+			return tiers, errors.WithStack(
+				ErrInvalidParam(fmt.Sprintf("line %d", line)))
+		}
+		seen[qty] = true
+		tiers = append(tiers, sqlite.CatPriceTier{Qty: qty, Price: price})
+	}
+	return tiers, nil
+}
@@ -0,0 +1,234 @@
+package model_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+	"github.com/pkg/errors"
+	"github.com/shopd/shopd/go/db/sqlite"
+	"github.com/shopd/shopd/go/model"
+	"github.com/shopd/shopd/go/share"
+)
+
+func TestPrice(t *testing.T) {
+	is := is.New(t)
+	now := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	params := model.PriceParams{
+		Now: now,
+		Buyer: model.Buyer{
+			UserID:   "u1",
+			Tags:     []string{"trade"},
+			Accounts: []string{"a1"},
+		},
+		Qty:  1,
+		Base: 1000,
+		Lists: []model.PriceList{
+			{PriceListID: "all", Price: 700},
+			{PriceListID: "tag", Price: 850, Tags: []string{"trade"}},
+			{PriceListID: "tag2", Price: 800, Tags: []string{"trade"}},
+			{PriceListID: "other", Price: 100, Users: []string{"u2"}},
+		},
+	}
+
+	// Tag lists are more specific than lists for all buyers,
+	// the lowest price of the same specificity is used
+	price, id := model.Price(params)
+	is.Equal(price, int64(800))
+	is.Equal(id, "tag2")
+
+	params.Lists = append(params.Lists,
+		model.PriceList{PriceListID: "account", Price: 900, Accounts: []string{"a1"}})
+	price, id = model.Price(params)
+	is.Equal(price, int64(900))
+	is.Equal(id, "account")
+
+	params.Lists = append(params.Lists, model.PriceList{
+		PriceListID: "user", Price: 950, Users: []string{"u1"},
+		Ranges: []model.DateRange{{
+			Start: now.Add(time.Hour), End: now.Add(2 * time.Hour),
+		}},
+	})
+	price, id = model.Price(params)
+	is.Equal(price, int64(900)) // the user list is not in range yet
+	params.Now = now.Add(time.Hour)
+	price, id = model.Price(params)
+	is.Equal(price, int64(950))
+	is.Equal(id, "user")
+
+	// Tiers apply if lower than the buyer's price
+	params.Tiers = []sqlite.CatPriceTier{{Qty: 10, Price: 960}, {Qty: 20, Price: 600}}
+	params.Qty = 10
+	price, id = model.Price(params)
+	is.Equal(price, int64(950))
+	is.Equal(id, "user")
+	params.Qty = 25
+	price, id = model.Price(params)
+	is.Equal(price, int64(600))
+	is.Equal(id, "")
+
+	// Guests only get lists for all buyers
+	params.Buyer = model.Buyer{}
+	params.Qty = 1
+	price, id = model.Price(params)
+	is.Equal(price, int64(700))
+	is.Equal(id, "all")
+}
+
+func TestPriceListImport(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+	m, db := newTestModel(t)
+	exec(t, db,
+		`insert into cat(sku, title, descr, state, mod, mod_id)
+		values ('a', 'Apple', '', 'stock', 'm', 's')`,
+		`insert into cat_price values ('a', 1000)`,
+		`insert into user(user_id, email, descr, mod)
+		values ('u1', 'u1@example.com', '', 'm'), ('u2', 'u2@example.com', '', 'm')`,
+		`insert into user_tag values ('u2', 'trade')`,
+		`update config set val = 'exclusive' where term = 'price_display'`,
+	)
+	csv := "sku,price\na,800\n"
+
+	// Lists for all buyers must be confirmed
+	_, err := m.PriceListImport(ctx, share.ParamsPriceListPost{
+		PriceListID: "all",
+	}, strings.NewReader(csv), "")
+	is.True(errors.Is(err, model.ErrInvalidParam("")))
+	_, err = m.PriceListImport(ctx, share.ParamsPriceListPost{
+		PriceListID: "vip", Users: "u1", Global: true,
+	}, strings.NewReader(csv), "")
+	is.True(errors.Is(err, model.ErrInvalidParam("")))
+	_, err = m.PriceListImport(ctx, share.ParamsPriceListPost{
+		PriceListID: "vip", Users: "nobody",
+	}, strings.NewReader(csv), "")
+	is.True(errors.Is(err, model.ErrNotFound("")))
+
+	result, err := m.PriceListImport(ctx, share.ParamsPriceListPost{
+		PriceListID: "vip", Users: "u1\n", Tags: " trade \n\n",
+		Ranges: "2020-01-01 00:00,2099-01-01 00:00",
+	}, strings.NewReader(csv), "")
+	is.NoErr(err)
+	is.Equal(result.Count, 1)
+
+	price, err := m.SkuPrice(ctx, "a", "u1", "")
+	is.NoErr(err)
+	is.Equal(price.Price, int64(800))
+	price, err = m.SkuPrice(ctx, "a", "u2", "")
+	is.NoErr(err)
+	is.Equal(price.Price, int64(800))
+	price, err = m.SkuPrice(ctx, "a", "", "")
+	is.NoErr(err)
+	is.Equal(price.Price, int64(1000))
+
+	// Restrictions are replaced
+	_, err = m.PriceListImport(ctx, share.ParamsPriceListPost{
+		PriceListID: "vip", Users: "u1",
+	}, strings.NewReader(csv), "")
+	is.NoErr(err)
+	price, err = m.SkuPrice(ctx, "a", "u2", "")
+	is.NoErr(err)
+	is.Equal(price.Price, int64(1000))
+}
+
+func TestCartMergeReprice(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+	m, db := newTestModel(t)
+	exec(t, db,
+		`insert into cat(sku, title, descr, state, mod, mod_id)
+		values ('a', 'Apple', '', 'stock', 'm', 's')`,
+		`insert into cat_price values ('a', 1000)`,
+		`insert into user(user_id, email, descr, mod)
+		values ('u1', 'u1@example.com', '', 'm')`,
+		`update config set val = 'exclusive' where term = 'price_display'`,
+	)
+	_, err := m.PriceListImport(ctx, share.ParamsPriceListPost{
+		PriceListID: "vip", Users: "u1",
+	}, strings.NewReader("sku,price\na,800\n"), "")
+	is.NoErr(err)
+	guest, err := m.CartAdd(ctx, "", "", share.ParamsCartPost{Sku: "a", Qty: 1})
+	is.NoErr(err)
+	is.Equal(guest.Lines[0].Price, int64(1000))
+
+	// The guest cart is converted and priced for the user
+	cart, err := m.CartMerge(ctx, guest.OrderID, "u1")
+	is.NoErr(err)
+	is.Equal(cart.OrderID, guest.OrderID)
+	is.Equal(cart.Lines[0].Price, int64(800))
+}
+
+func TestPriceTiersSet(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+	m, db := newTestModel(t)
+	exec(t, db,
+		`insert into cat(sku, title, descr, state, mod, mod_id)
+		values ('a', 'Apple', '', 'stock', 'm', 's')`,
+		`insert into cat_price values ('a', 1000)`,
+		`update config set val = 'exclusive' where term = 'price_display'`,
+	)
+	for _, tiers := range []string{"1,900", "10,900\n10,800", "10,-1", "10"} {
+		_, err := m.PriceTiersSet(ctx, share.ParamsPriceTiersPost{
+			Sku: "a", Tiers: tiers,
+		}, "")
+		is.True(errors.Is(err, model.ErrInvalidParam("")))
+	}
+	_, err := m.PriceTiersSet(ctx, share.ParamsPriceTiersPost{
+		Sku: "b", Tiers: "10,900",
+	}, "")
+	is.True(errors.Is(err, model.ErrNotFound("")))
+
+	price, err := m.PriceTiersSet(ctx, share.ParamsPriceTiersPost{
+		Sku: "a", Tiers: "50,700\n10,900",
+	}, "")
+	is.NoErr(err)
+	is.Equal(price.Price, int64(1000))
+	is.Equal(price.Tiers, []share.PriceTier{
+		{Qty: 1, Price: 1000},
+		{Qty: 10, Price: 900},
+		{Qty: 50, Price: 700},
+	})
+
+	// An empty list removes the tiers
+	price, err = m.PriceTiersSet(ctx, share.ParamsPriceTiersPost{Sku: "a"}, "")
+	is.NoErr(err)
+	is.Equal(len(price.Tiers), 0)
+}
+
+func TestCartTierPrice(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+	m, db := newTestModel(t)
+	exec(t, db,
+		`insert into cat(sku, title, descr, state, mod, mod_id)
+		values ('a', 'Apple', '', 'stock', 'm', 's')`,
+		`insert into cat_price values ('a', 1000)`,
+		`insert into cat_price_tier values ('a', 10, 900)`,
+		`insert into discount values ('d1', 1000, 0, 'Sale', 'm', 's')`,
+		`update config set val = 'exclusive' where term = 'price_display'`,
+	)
+	cart, err := m.CartAdd(ctx, "", "", share.ParamsCartPost{Sku: "a", Qty: 5})
+	is.NoErr(err)
+	line := cart.Lines[0]
+	is.Equal(line.Price, int64(1000))
+
+	// The line is priced again when the qty changes
+	cart, err = m.CartUpdate(ctx, cart.OrderID, "", share.ParamsCartPatch{
+		OrderLineID: line.OrderLineID, Qty: 10,
+	})
+	is.NoErr(err)
+	for _, l := range cart.Lines {
+		if l.Sku == "a" {
+			is.Equal(l.Price, int64(900))
+		}
+	}
+
+	// Discount and tax use the tier price
+	is.NoErr(m.SetOrderState(ctx, cart.OrderID, share.OrderStatePending, ""))
+	inv, err := m.Receipt(ctx, cart.OrderID)
+	is.NoErr(err)
+	is.Equal(inv.Total, int64((9000-900)*115/100))
+}
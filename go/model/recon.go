@@ -0,0 +1,305 @@
+package model
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/shopd/shopd/go/db/sqlite"
+	"github.com/shopd/shopd/go/share"
+)
+
+// Reconciliation lists transactions matching the filter.
+// Issues are checked for all processors and states in the date range,
+// paid orders are checked by the date they were last modified.
+// Orders paid with vouchers only don't have transactions
+func (m *Model) Reconciliation(
+	ctx context.Context, filter share.ReconFilter) (
+	recon share.Reconciliation, err error) {
+
+	recon.Filter = filter
+	recon.Currency, err = configVal(ctx, m.q, TermCurrency, CurrencyDefault)
+	if err != nil {
+		return recon, err
+	}
+	modFrom, modTo, err := modRange(filter.From, filter.To)
+	if err != nil {
+		return recon, err
+	}
+	recon.Trans, err = reconTrans(ctx, m.q, sqlite.TranReportParams{
+		Processor: filter.Processor,
+		State:     filter.State,
+		ModFrom:   modFrom,
+		ModTo:     modTo,
+	})
+	if err != nil {
+		return recon, err
+	}
+	for _, tran := range recon.Trans {
+		if tran.State != share.TranStateSuccess {
+			continue
+		}
+		switch tran.Type {
+		case "":
+			recon.Received += tran.Amount
+		case share.TranTypeRefund:
+			recon.Refunded += tran.Amount
+		}
+	}
+
+	recon.Issues, err = reconIssues(ctx, m.q, recon.Currency, modFrom, modTo)
+	if err != nil {
+		return recon, err
+	}
+	return recon, nil
+}
+
+// ReconSettlement compares a processor settlement CSV to successful
+// payments in the date range, the range should cover the settlement.
+// The CSV has ref and amount columns, and optionally currency,
+// amounts are in the smallest unit of the currency
+func (m *Model) ReconSettlement(
+	ctx context.Context, filter share.ReconFilter, r io.Reader) (
+	diff share.ReconSettlement, err error) {
+
+	diff.Filter = filter
+	diff.Currency, err = configVal(ctx, m.q, TermCurrency, CurrencyDefault)
+	if err != nil {
+		return diff, err
+	}
+	lines, err := settlementCSV(r, diff.Currency)
+	if err != nil {
+		return diff, err
+	}
+	modFrom, modTo, err := modRange(filter.From, filter.To)
+	if err != nil {
+		return diff, err
+	}
+	trans, err := reconTrans(ctx, m.q, sqlite.TranReportParams{
+		Processor: filter.Processor,
+		State:     share.TranStateSuccess,
+		ModFrom:   modFrom,
+		ModTo:     modTo,
+	})
+	if err != nil {
+		return diff, err
+	}
+
+	// Refunds don't have a processor reference
+	byRef := make(map[string]share.ReconTran)
+	for _, tran := range trans {
+		if tran.Ref != "" && tran.Type == "" {
+			byRef[tran.Ref] = tran
+		}
+	}
+	settled := make(map[string]bool)
+	for _, line := range lines {
+		tran, ok := byRef[line.Ref]
+		if !ok {
+			diff.Unmatched = append(diff.Unmatched, line)
+			continue
+		}
+		settled[line.Ref] = true
+		match := share.SettlementMatch{SettlementLine: line, Tran: tran}
+		if line.Amount != tran.Amount || line.Currency != tran.Currency {
+			diff.Mismatched = append(diff.Mismatched, match)
+			continue
+		}
+		diff.Matched = append(diff.Matched, match)
+	}
+	for _, tran := range trans {
+		if tran.Ref != "" && tran.Type == "" && !settled[tran.Ref] {
+			diff.Unsettled = append(diff.Unsettled, tran)
+		}
+	}
+	return diff, nil
+}
+
+func reconTrans(
+	ctx context.Context, q *sqlite.Queries, params sqlite.TranReportParams) (
+	trans []share.ReconTran, err error) {
+
+	rows, err := q.TranReport(ctx, params)
+	if err != nil {
+		return trans, errors.WithStack(err)
+	}
+	trans = make([]share.ReconTran, 0, len(rows))
+	for _, row := range rows {
+		trans = append(trans, share.ReconTran{
+			Payment: share.Payment{
+				TranID:    row.TranID,
+				OrderID:   row.OrderID,
+				State:     row.State,
+				Descr:     row.Descr,
+				Amount:    row.Amount,
+				Currency:  row.Currency,
+				Method:    row.Method,
+				Processor: row.Processor,
+				Ref:       row.Ref,
+				Type:      row.Type,
+			},
+			OrderNo: row.OrderNo,
+			Date:    modTime(row.Mod),
+		})
+	}
+	return trans, nil
+}
+
+// reconIssues flags successful payments that are not linked to an order,
+// not in the default currency, or received for reversed or expired orders,
+// and paid orders where the payments don't match the total.
+// Account adjustments are not linked to orders
+func reconIssues(
+	ctx context.Context, q *sqlite.Queries, currency, modFrom, modTo string) (
+	issues []share.ReconIssue, err error) {
+
+	trans, err := reconTrans(ctx, q, sqlite.TranReportParams{
+		State:   share.TranStateSuccess,
+		ModFrom: modFrom,
+		ModTo:   modTo,
+	})
+	if err != nil {
+		return issues, err
+	}
+	for _, tran := range trans {
+		issue := share.ReconIssue{
+			OrderID:  tran.OrderID,
+			OrderNo:  tran.OrderNo,
+			TranID:   tran.TranID,
+			Currency: tran.Currency,
+			Actual:   tran.Amount,
+		}
+		if tran.OrderID == "" && tran.Type != share.TranTypeCredit &&
+			tran.Type != share.TranTypeDebit {
+			issue.Type = share.ReconUnlinked
+			issues = append(issues, issue)
+		}
+		if tran.Currency != currency {
+			issue.Type = share.ReconCurrency
+			issues = append(issues, issue)
+		}
+		if tran.OrderID != "" && tran.Type == "" {
+			refund, err := reconRefund(ctx, q, tran.Payment)
+			if err != nil {
+				return issues, err
+			}
+			if refund {
+				issue.Type = share.ReconRefund
+				issues = append(issues, issue)
+			}
+		}
+	}
+
+	orders, err := q.OrdersPaidByMod(ctx, sqlite.OrdersPaidByModParams{
+		ModFrom: modFrom,
+		ModTo:   modTo,
+	})
+	if err != nil {
+		return issues, errors.WithStack(err)
+	}
+	for _, order := range orders {
+		inv, err := invoice(ctx, q, order)
+		if err != nil {
+			return issues, err
+		}
+		issue := share.ReconIssue{
+			OrderID:  order.OrderID,
+			OrderNo:  order.OrderNo,
+			Currency: inv.Currency,
+			Expected: inv.Total,
+			Actual:   inv.Paid,
+		}
+		// Payments include vouchers, listed without a TranID
+		if len(inv.Payments) == 0 {
+			issue.Type = share.ReconNoTran
+			issues = append(issues, issue)
+			continue
+		}
+		if inv.Paid != inv.Total {
+			issue.Type = share.ReconAmount
+			issues = append(issues, issue)
+		}
+	}
+	return issues, nil
+}
+
+// reconRefund returns true if the payment was received for an order
+// that was reversed or expired before it was paid, and not refunded
+func reconRefund(
+	ctx context.Context, q *sqlite.Queries, tran share.Payment) (
+	refund bool, err error) {
+
+	order, err := orderByID(ctx, q, tran.OrderID)
+	if err != nil {
+		return refund, err
+	}
+	if order.Paid == 1 || orderPayable(order.State) {
+		return false, nil
+	}
+	pay, err := payment(ctx, q, tran.TranID)
+	if err != nil {
+		return refund, err
+	}
+	return pay.Refunded < pay.Amount, nil
+}
+
+// settlementCSV reads ref, amount, and currency columns, in any order.
+// The currency column is optional, refs must be unique
+func settlementCSV(
+	r io.Reader, currency string) (lines []share.SettlementLine, err error) {
+
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+	header, err := reader.Read()
+	if err != nil {
+		return lines, errors.WithStack(ErrInvalidParam("header"))
+	}
+	refCol, amountCol, currencyCol := -1, -1, -1
+	for i, col := range header {
+		switch strings.ToLower(strings.TrimSpace(col)) {
+		case "ref":
+			refCol = i
+		case "amount":
+			amountCol = i
+		case "currency":
+			currencyCol = i
+		}
+	}
+	if refCol < 0 || amountCol < 0 {
+		return lines, errors.WithStack(ErrInvalidParam("header"))
+	}
+
+	seen := make(map[string]bool)
+	for line := 2; ; line++ {
+		row, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return lines, errors.WithStack(
+				ErrInvalidParam(fmt.Sprintf("line %d", line)))
+		}
+		ref := strings.TrimSpace(row[refCol])
+		amount, err := strconv.ParseInt(strings.TrimSpace(row[amountCol]), 10, 64)
+		if ref == "" || err != nil || seen[ref] {
+			return lines, errors.WithStack(
+				ErrInvalidParam(fmt.Sprintf("line %d", line)))
+		}
+		seen[ref] = true
+		settlement := share.SettlementLine{
+			Line:     line,
+			Ref:      ref,
+			Amount:   amount,
+			Currency: currency,
+		}
+		if currencyCol >= 0 && strings.TrimSpace(row[currencyCol]) != "" {
+			settlement.Currency = strings.ToUpper(strings.TrimSpace(row[currencyCol]))
+		}
+		lines = append(lines, settlement)
+	}
+	return lines, nil
+}
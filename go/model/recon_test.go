@@ -0,0 +1,172 @@
+package model_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+	"github.com/pkg/errors"
+	"github.com/shopd/shopd/go/model"
+	"github.com/shopd/shopd/go/share"
+)
+
+func TestReconciliation(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+	m, db := newTestModel(t)
+	exec(t, db,
+		`insert into cat(sku, title, descr, state, mod, mod_id)
+		values ('a', 'Apple', '', 'stock', 'm', 's')`,
+		`insert into cat_price values ('a', 1000)`,
+	)
+	order := func() string {
+		cart, err := m.CartAdd(ctx, "", "", share.ParamsCartPost{Sku: "a", Qty: 1})
+		is.NoErr(err)
+		is.NoErr(m.SetOrderState(ctx, cart.OrderID, share.OrderStatePending, ""))
+		return cart.OrderID
+	}
+
+	// Paid with the processor
+	card := order()
+	pay, err := m.PaymentCreate(ctx, card, "fake", "")
+	is.NoErr(err)
+	is.NoErr(m.PaymentRef(ctx, pay.TranID, "ref1"))
+	_, err = m.PaymentUpdate(ctx, pay.TranID, share.TranStateSuccess, "")
+	is.NoErr(err)
+	// Failed payments are listed, but not summed
+	failed, err := m.PaymentCreate(ctx, order(), "fake", "")
+	is.NoErr(err)
+	_, err = m.PaymentUpdate(ctx, failed.TranID, share.TranStateFailed, "")
+	is.NoErr(err)
+	// Paid by EFT, and partly refunded
+	eft := order()
+	_, err = m.PaymentRecord(ctx, share.ParamsOrdersPaymentPost{
+		OrderID: eft,
+		Method:  share.TranMethodEFT,
+		Amount:  1150,
+	}, "admin")
+	is.NoErr(err)
+	_, err = m.CreditNoteCreate(ctx, share.ParamsOrdersRefundPost{
+		OrderID: eft, Amount: 150, Method: share.TranMethodEFT}, "admin")
+	is.NoErr(err)
+
+	recon, err := m.Reconciliation(ctx, share.ReconFilter{})
+	is.NoErr(err)
+	is.Equal(recon.Currency, "ZAR")
+	is.Equal(len(recon.Trans), 4)
+	is.Equal(recon.Received, int64(2300))
+	is.Equal(recon.Refunded, int64(150))
+	is.Equal(len(recon.Issues), 0)
+
+	recon, err = m.Reconciliation(ctx, share.ReconFilter{Processor: "fake"})
+	is.NoErr(err)
+	is.Equal(len(recon.Trans), 2)
+	is.Equal(recon.Received, int64(1150))
+	recon, err = m.Reconciliation(ctx, share.ReconFilter{
+		Processor: "fake", State: share.TranStateSuccess})
+	is.NoErr(err)
+	is.Equal(len(recon.Trans), 1)
+	is.Equal(recon.Trans[0].TranID, pay.TranID)
+	is.Equal(recon.Trans[0].Ref, "ref1")
+	is.True(recon.Trans[0].OrderNo != "")
+
+	// The range includes To
+	today := time.Now().Format(model.DateFormat)
+	tomorrow := time.Now().AddDate(0, 0, 1).Format(model.DateFormat)
+	recon, err = m.Reconciliation(ctx, share.ReconFilter{From: today, To: today})
+	is.NoErr(err)
+	is.Equal(len(recon.Trans), 4)
+	recon, err = m.Reconciliation(ctx, share.ReconFilter{From: tomorrow})
+	is.NoErr(err)
+	is.Equal(len(recon.Trans), 0)
+	_, err = m.Reconciliation(ctx, share.ReconFilter{From: "today"})
+	is.True(errors.Is(err, model.ErrInvalidParam("")))
+
+	// Issues
+	exec(t, db,
+		`update orders set paid = 1 where order_id = '`+order()+`'`,
+		`insert into tran(tran_id, account_id, state, descr, amount, currency,
+		user_id, mod) values ('t1', '', 'success', 'Unlinked', 100, 'USD',
+		'admin', '`+model.NewID()+`')`,
+	)
+	short := order()
+	_, err = m.PaymentRecord(ctx, share.ParamsOrdersPaymentPost{
+		OrderID: short,
+		Method:  share.TranMethodCash,
+		Amount:  1000,
+	}, "admin")
+	is.NoErr(err)
+	exec(t, db, `update orders set paid = 1 where order_id = '`+short+`'`)
+
+	recon, err = m.Reconciliation(ctx, share.ReconFilter{})
+	is.NoErr(err)
+	types := make(map[string]share.ReconIssue)
+	for _, issue := range recon.Issues {
+		types[issue.Type] = issue
+	}
+	is.Equal(len(recon.Issues), 4)
+	is.Equal(types[share.ReconUnlinked].TranID, "t1")
+	is.Equal(types[share.ReconCurrency].TranID, "t1")
+	is.Equal(types[share.ReconCurrency].Currency, "USD")
+	is.Equal(types[share.ReconNoTran].Expected, int64(1150))
+	is.Equal(types[share.ReconAmount].OrderID, short)
+	is.Equal(types[share.ReconAmount].Expected, int64(1150))
+	is.Equal(types[share.ReconAmount].Actual, int64(1000))
+}
+
+func TestReconSettlement(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+	m, db := newTestModel(t)
+	exec(t, db,
+		`insert into cat(sku, title, descr, state, mod, mod_id)
+		values ('a', 'Apple', '', 'stock', 'm', 's')`,
+		`insert into cat_price values ('a', 1000)`,
+	)
+	paid := func(ref string) string {
+		cart, err := m.CartAdd(ctx, "", "", share.ParamsCartPost{Sku: "a", Qty: 1})
+		is.NoErr(err)
+		is.NoErr(m.SetOrderState(ctx, cart.OrderID, share.OrderStatePending, ""))
+		pay, err := m.PaymentCreate(ctx, cart.OrderID, "fake", "")
+		is.NoErr(err)
+		is.NoErr(m.PaymentRef(ctx, pay.TranID, ref))
+		_, err = m.PaymentUpdate(ctx, pay.TranID, share.TranStateSuccess, "")
+		is.NoErr(err)
+		return pay.TranID
+	}
+	matched := paid("ref1")
+	mismatched := paid("ref2")
+	unsettled := paid("ref3")
+
+	// Columns in any order, currency is optional
+	diff, err := m.ReconSettlement(ctx, share.ReconFilter{Processor: "fake"},
+		strings.NewReader("Amount, Ref, Currency\n1150, ref1,\n1000, ref2, zar\n500, ref9, ZAR\n"))
+	is.NoErr(err)
+	is.Equal(len(diff.Matched), 1)
+	is.Equal(diff.Matched[0].Tran.TranID, matched)
+	is.Equal(diff.Matched[0].Currency, "ZAR")
+	is.Equal(len(diff.Mismatched), 1)
+	is.Equal(diff.Mismatched[0].Tran.TranID, mismatched)
+	is.Equal(diff.Mismatched[0].Amount, int64(1000))
+	is.Equal(len(diff.Unmatched), 1)
+	is.Equal(diff.Unmatched[0].Ref, "ref9")
+	is.Equal(diff.Unmatched[0].Line, 4)
+	is.Equal(len(diff.Unsettled), 1)
+	is.Equal(diff.Unsettled[0].TranID, unsettled)
+
+	// Invalid settlements
+	for _, csv := range []string{
+		"",
+		"ref,total\nref1,1150\n",
+		"ref,amount\nref1,11.50\n",
+		"ref,amount\n,1150\n",
+		"ref,amount\nref1,1150\nref1,1150\n",
+		"ref,amount\nref1\n",
+	} {
+		_, err = m.ReconSettlement(ctx, share.ReconFilter{Processor: "fake"},
+			strings.NewReader(csv))
+		is.True(errors.Is(err, model.ErrInvalidParam("")))
+	}
+}
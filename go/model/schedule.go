@@ -0,0 +1,416 @@
+package model
+
+import (
+	"context"
+	"database/sql"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/shopd/shopd/go/db/sqlite"
+	"github.com/shopd/shopd/go/share"
+)
+
+// Changes to cat_price may be scheduled, e.g. a sale that starts on Friday
+// and reverts afterwards. Start and end are entered in the domain time zone,
+// and stored in UTC. ApplyPriceSchedule is called by a background job,
+// it sets cat_price when a change starts, and restores the prev price when
+// it ends. Every change to cat_price is recorded in cat_price_history.
+// Changes with an end may not overlap other changes for the same sku
+
+// LocalDateTimeFormat for date times entered in the domain time zone,
+// matches the value of datetime-local inputs
+const LocalDateTimeFormat = "2006-01-02T15:04"
+
+// SchedulePrice schedules a change to the catalog price for the sku
+func (m *Model) SchedulePrice(
+	ctx context.Context, params share.ParamsPriceSchedulePost, userID string) (
+	list share.PriceSchedules, err error) {
+
+	if params.Price < 0 {
+		return list, errors.WithStack(ErrInvalidParam("Price"))
+	}
+	err = m.tx(ctx, func(q *sqlite.Queries) error {
+		_, err := q.CatBySKU(ctx, params.Sku)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return errors.WithStack(ErrNotFound(params.Sku))
+			}
+			return errors.WithStack(err)
+		}
+		// The price is restored at the end,
+		// skus without a catalog price can't be reverted
+		_, err = q.CatPriceBySKU(ctx, params.Sku)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return errors.WithStack(ErrUnavailable(params.Sku))
+			}
+			return errors.WithStack(err)
+		}
+		loc, err := location(ctx, q)
+		if err != nil {
+			return err
+		}
+		start, err := parseLocal(params.Start, loc)
+		if err != nil {
+			return errors.WithStack(ErrInvalidParam("Start"))
+		}
+		end := ""
+		if strings.TrimSpace(params.End) != "" {
+			t, err := parseLocal(params.End, loc)
+			if err != nil || !t.After(start) {
+				return errors.WithStack(ErrInvalidParam("End"))
+			}
+			end = t.Format(DateTimeFormat)
+		}
+		schedule := sqlite.CatPriceSchedule{
+			SKU:   params.Sku,
+			Start: start.Format(DateTimeFormat),
+			End:   end,
+		}
+
+		schedules, err := q.CatPriceSchedulesBySKU(ctx, params.Sku)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		for _, s := range schedules {
+			if s.State != share.PriceSchedulePending &&
+				s.State != share.PriceScheduleActive {
+				continue
+			}
+			if scheduleOverlap(s, schedule) {
+				return errors.WithStack(ErrInvalidParam("Start"))
+			}
+		}
+
+		err = q.CatPriceScheduleInsert(ctx, sqlite.CatPriceScheduleInsertParams{
+			CatPriceScheduleID: NewID(),
+			SKU:                params.Sku,
+			Price:              params.Price,
+			Start:              schedule.Start,
+			End:                schedule.End,
+			State:              share.PriceSchedulePending,
+			Mod:                NewID(),
+			ModID:              modID(userID),
+		})
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		return nil
+	})
+	if err != nil {
+		return list, err
+	}
+	return m.PriceSchedules(ctx, params.Sku)
+}
+
+// CancelPriceSchedule cancels a pending price change,
+// changes that started can't be cancelled
+func (m *Model) CancelPriceSchedule(
+	ctx context.Context, scheduleID, userID string) (
+	list share.PriceSchedules, err error) {
+
+	sku := ""
+	err = m.tx(ctx, func(q *sqlite.Queries) error {
+		schedule, err := q.CatPriceScheduleByID(ctx, scheduleID)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return errors.WithStack(ErrNotFound(scheduleID))
+			}
+			return errors.WithStack(err)
+		}
+		sku = schedule.SKU
+		if schedule.State != share.PriceSchedulePending {
+			return errors.WithStack(ErrStateTransition(
+				schedule.State, share.PriceScheduleCancelled))
+		}
+		err = q.CatPriceScheduleUpdate(ctx, sqlite.CatPriceScheduleUpdateParams{
+			State:              share.PriceScheduleCancelled,
+			Prev:               schedule.Prev,
+			Mod:                NewID(),
+			ModID:              modID(userID),
+			CatPriceScheduleID: scheduleID,
+		})
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		return nil
+	})
+	if err != nil {
+		return list, err
+	}
+	return m.PriceSchedules(ctx, sku)
+}
+
+// PriceSchedules lists scheduled changes and the price history for the sku
+func (m *Model) PriceSchedules(
+	ctx context.Context, sku string) (list share.PriceSchedules, err error) {
+
+	list.Sku = sku
+	list.Currency, err = configVal(ctx, m.q, TermCurrency, CurrencyDefault)
+	if err != nil {
+		return list, err
+	}
+	loc, err := location(ctx, m.q)
+	if err != nil {
+		return list, err
+	}
+	list.Timezone = loc.String()
+	price, err := m.q.CatPriceBySKU(ctx, sku)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return list, errors.WithStack(err)
+	}
+	list.Price = price.Price
+
+	schedules, err := m.q.CatPriceSchedulesBySKU(ctx, sku)
+	if err != nil {
+		return list, errors.WithStack(err)
+	}
+	list.Schedules = make([]share.PriceSchedule, 0, len(schedules))
+	for _, s := range schedules {
+		list.Schedules = append(list.Schedules, share.PriceSchedule{
+			PriceScheduleID: s.CatPriceScheduleID,
+			Price:           s.Price,
+			Start:           formatLocal(s.Start, loc),
+			End:             formatLocal(s.End, loc),
+			Prev:            s.Prev,
+			State:           s.State,
+		})
+	}
+
+	history, err := m.q.CatPriceHistoryBySKU(ctx, sku)
+	if err != nil {
+		return list, errors.WithStack(err)
+	}
+	list.History = make([]share.PriceHistory, 0, len(history))
+	for _, h := range history {
+		list.History = append(list.History, share.PriceHistory{
+			Price:           h.Price,
+			Changed:         formatLocal(h.Changed, loc),
+			ModID:           h.ModID,
+			PriceScheduleID: h.CatPriceScheduleID,
+		})
+	}
+	return list, nil
+}
+
+// ApplyPriceSchedule starts and ends scheduled price changes due at now,
+// in the order they are due. Returns the skus with changed prices
+func (m *Model) ApplyPriceSchedule(
+	ctx context.Context, now time.Time) (skus []string, err error) {
+
+	due := now.UTC().Format(DateTimeFormat)
+	start, err := m.q.CatPriceSchedulesStart(ctx, due)
+	if err != nil {
+		return skus, errors.WithStack(err)
+	}
+	end, err := m.q.CatPriceSchedulesEnd(ctx, due)
+	if err != nil {
+		return skus, errors.WithStack(err)
+	}
+
+	// Changes that started and ended since the last run are included
+	events := make([]scheduleEvent, 0, len(start)+len(end))
+	for _, s := range start {
+		events = append(events,
+			scheduleEvent{at: s.Start, id: s.CatPriceScheduleID})
+		if s.End != "" && s.End <= due {
+			events = append(events,
+				scheduleEvent{at: s.End, id: s.CatPriceScheduleID, end: true})
+		}
+	}
+	for _, s := range end {
+		events = append(events,
+			scheduleEvent{at: s.End, id: s.CatPriceScheduleID, end: true})
+	}
+	// Date times in DateTimeFormat sort lexically,
+	// the end is exclusive so changes that end are applied first
+	slices.SortStableFunc(events, func(a, b scheduleEvent) int {
+		if c := strings.Compare(a.at, b.at); c != 0 {
+			return c
+		}
+		if a.end && !b.end {
+			return -1
+		}
+		if b.end && !a.end {
+			return 1
+		}
+		return 0
+	})
+
+	changed := make(map[string]bool)
+	for _, event := range events {
+		err = m.tx(ctx, func(q *sqlite.Queries) error {
+			sku, err := scheduleApply(ctx, q, event)
+			if err != nil {
+				return err
+			}
+			if sku != "" {
+				changed[sku] = true
+			}
+			return nil
+		})
+		if err != nil {
+			return skus, err
+		}
+	}
+	for sku := range changed {
+		skus = append(skus, sku)
+	}
+	slices.Sort(skus)
+	return skus, nil
+}
+
+// scheduleEvent is the start or end of a scheduled price change
+type scheduleEvent struct {
+	at  string
+	id  string
+	end bool
+}
+
+// scheduleApply starts or ends the scheduled price change,
+// must be called in a transaction. Returns the sku if the price changed
+func scheduleApply(
+	ctx context.Context, q *sqlite.Queries, event scheduleEvent) (
+	sku string, err error) {
+
+	s, err := q.CatPriceScheduleByID(ctx, event.id)
+	if err != nil {
+		return sku, errors.WithStack(err)
+	}
+	sku = s.SKU
+	if event.end {
+		if s.State != share.PriceScheduleActive {
+			// Cancelled when it started
+			return "", nil
+		}
+		err = q.CatPriceScheduleUpdate(ctx, sqlite.CatPriceScheduleUpdateParams{
+			State:              share.PriceScheduleDone,
+			Prev:               s.Prev,
+			Mod:                NewID(),
+			ModID:              ModIDSystem,
+			CatPriceScheduleID: s.CatPriceScheduleID,
+		})
+		if err != nil {
+			return sku, errors.WithStack(err)
+		}
+		return sku, catPriceSet(ctx, q, catPriceChange{
+			sku:        s.SKU,
+			price:      s.Prev,
+			changed:    s.End,
+			scheduleID: s.CatPriceScheduleID,
+		}, ModIDSystem)
+	}
+
+	prev, err := q.CatPriceBySKU(ctx, s.SKU)
+	if errors.Is(err, sql.ErrNoRows) {
+		// The price can't be restored at the end
+		err = q.CatPriceScheduleUpdate(ctx, sqlite.CatPriceScheduleUpdateParams{
+			State:              share.PriceScheduleCancelled,
+			Prev:               s.Prev,
+			Mod:                NewID(),
+			ModID:              ModIDSystem,
+			CatPriceScheduleID: s.CatPriceScheduleID,
+		})
+		if err != nil {
+			return "", errors.WithStack(err)
+		}
+		return "", nil
+	}
+	if err != nil {
+		return sku, errors.WithStack(err)
+	}
+	state := share.PriceScheduleDone
+	if s.End != "" {
+		state = share.PriceScheduleActive
+	}
+	err = q.CatPriceScheduleUpdate(ctx, sqlite.CatPriceScheduleUpdateParams{
+		State:              state,
+		Prev:               prev.Price,
+		Mod:                NewID(),
+		ModID:              ModIDSystem,
+		CatPriceScheduleID: s.CatPriceScheduleID,
+	})
+	if err != nil {
+		return sku, errors.WithStack(err)
+	}
+	return sku, catPriceSet(ctx, q, catPriceChange{
+		sku:        s.SKU,
+		price:      s.Price,
+		changed:    s.Start,
+		scheduleID: s.CatPriceScheduleID,
+	}, ModIDSystem)
+}
+
+// catPriceChange is a change to the catalog price,
+// changed is the date time in UTC when the price took effect,
+// scheduleID is empty if the change was not scheduled
+type catPriceChange struct {
+	sku        string
+	price      int64
+	changed    string
+	scheduleID string
+}
+
+// catPriceSet sets the catalog price and records the change
+func catPriceSet(
+	ctx context.Context, q *sqlite.Queries, change catPriceChange,
+	userID string) (err error) {
+
+	err = q.CatPriceUpsert(ctx, sqlite.CatPriceUpsertParams{
+		SKU:   change.sku,
+		Price: change.price,
+	})
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	err = q.CatPriceHistoryInsert(ctx, sqlite.CatPriceHistoryInsertParams{
+		SKU:                change.sku,
+		Price:              change.price,
+		Changed:            change.changed,
+		Mod:                NewID(),
+		ModID:              modID(userID),
+		CatPriceScheduleID: change.scheduleID,
+	})
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	return nil
+}
+
+// scheduleOverlap returns true if either change has an end,
+// and the other change starts before it ends
+func scheduleOverlap(a, b sqlite.CatPriceSchedule) bool {
+	if a.End == "" && b.End == "" {
+		return a.Start == b.Start
+	}
+	if a.End == "" {
+		return a.Start >= b.Start && a.Start < b.End
+	}
+	if b.End == "" {
+		return b.Start >= a.Start && b.Start < a.End
+	}
+	// Date times in DateTimeFormat sort lexically
+	return a.Start < b.End && b.Start < a.End
+}
+
+// parseLocal parses a date time in the domain time zone
+func parseLocal(val string, loc *time.Location) (t time.Time, err error) {
+	val = strings.Replace(strings.TrimSpace(val), " ", "T", 1)
+	t, err = time.ParseInLocation(LocalDateTimeFormat, val, loc)
+	if err != nil {
+		return t, errors.WithStack(err)
+	}
+	return t.UTC(), nil
+}
+
+// formatLocal formats a UTC date time col in the domain time zone,
+// empty values are returned as is
+func formatLocal(val string, loc *time.Location) string {
+	t, err := time.Parse(DateTimeFormat, val)
+	if err != nil {
+		return val
+	}
+	return t.In(loc).Format(LocalDateTimeFormat)
+}
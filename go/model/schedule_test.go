@@ -0,0 +1,122 @@
+package model_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+	"github.com/pkg/errors"
+	"github.com/shopd/shopd/go/model"
+	"github.com/shopd/shopd/go/share"
+)
+
+func TestSchedulePriceUnpriced(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+	m, db := newTestModel(t)
+	exec(t, db,
+		`insert into cat(sku, title, descr, state, mod, mod_id)
+		values ('a', 'Apple', '', 'stock', 'm', 's')`,
+	)
+	_, err := m.SchedulePrice(ctx, share.ParamsPriceSchedulePost{
+		Sku: "a", Price: 800, Start: "2030-01-01T10:00", End: "2030-01-02T10:00",
+	}, "")
+	is.True(errors.Is(err, model.ErrUnavailable("")))
+}
+
+func TestApplyPriceSchedule(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+	m, db := newTestModel(t)
+	exec(t, db,
+		`insert into cat(sku, title, descr, state, mod, mod_id)
+		values ('a', 'Apple', '', 'stock', 'm', 's')`,
+		`insert into cat_price values ('a', 1000)`,
+		`update config set val = 'Africa/Johannesburg' where term = 'timezone'`,
+	)
+	// Date times are in the domain time zone, two hours ahead of UTC
+	_, err := m.SchedulePrice(ctx, share.ParamsPriceSchedulePost{
+		Sku: "a", Price: 800, Start: "2030-01-01T10:00", End: "2030-01-01T12:00",
+	}, "")
+	is.NoErr(err)
+	_, err = m.SchedulePrice(ctx, share.ParamsPriceSchedulePost{
+		Sku: "a", Price: 700, Start: "2030-01-01T11:00",
+	}, "")
+	is.True(errors.Is(err, model.ErrInvalidParam(""))) // overlaps
+	_, err = m.SchedulePrice(ctx, share.ParamsPriceSchedulePost{
+		Sku: "a", Price: 700, Start: "2030-01-01T12:00",
+	}, "")
+	is.NoErr(err)
+
+	price := func() int64 {
+		var p int64
+		is.NoErr(db.QueryRow(`select price from cat_price where sku = 'a'`).Scan(&p))
+		return p
+	}
+	utc := func(hour, min int) time.Time {
+		return time.Date(2030, 1, 1, hour, min, 0, 0, time.UTC)
+	}
+
+	skus, err := m.ApplyPriceSchedule(ctx, utc(7, 59))
+	is.NoErr(err)
+	is.Equal(len(skus), 0)
+	is.Equal(price(), int64(1000))
+
+	skus, err = m.ApplyPriceSchedule(ctx, utc(8, 0))
+	is.NoErr(err)
+	is.Equal(skus, []string{"a"})
+	is.Equal(price(), int64(800))
+
+	skus, err = m.ApplyPriceSchedule(ctx, utc(8, 30))
+	is.NoErr(err)
+	is.Equal(len(skus), 0)
+
+	// The first change ends before the next one starts
+	skus, err = m.ApplyPriceSchedule(ctx, utc(10, 0))
+	is.NoErr(err)
+	is.Equal(skus, []string{"a"})
+	is.Equal(price(), int64(700))
+
+	list, err := m.PriceSchedules(ctx, "a")
+	is.NoErr(err)
+	is.Equal(len(list.Schedules), 2)
+	for _, s := range list.Schedules {
+		is.Equal(s.State, share.PriceScheduleDone)
+		is.Equal(s.Prev, int64(1000))
+	}
+	history := []int64{}
+	for _, h := range list.History {
+		history = append(history, h.Price)
+	}
+	is.Equal(len(history), 3)
+}
+
+func TestApplyPriceScheduleOneRun(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+	m, db := newTestModel(t)
+	exec(t, db,
+		`insert into cat(sku, title, descr, state, mod, mod_id)
+		values ('a', 'Apple', '', 'stock', 'm', 's')`,
+		`insert into cat_price values ('a', 1000)`,
+	)
+	_, err := m.SchedulePrice(ctx, share.ParamsPriceSchedulePost{
+		Sku: "a", Price: 800, Start: "2030-01-01T10:00", End: "2030-01-01T12:00",
+	}, "")
+	is.NoErr(err)
+	_, err = m.SchedulePrice(ctx, share.ParamsPriceSchedulePost{
+		Sku: "a", Price: 700, Start: "2030-01-01T12:00", End: "2030-01-01T14:00",
+	}, "")
+	is.NoErr(err)
+
+	// Changes that started and ended since the last run are applied in order,
+	// the price is restored at the end
+	skus, err := m.ApplyPriceSchedule(ctx,
+		time.Date(2030, 1, 2, 0, 0, 0, 0, time.UTC))
+	is.NoErr(err)
+	is.Equal(skus, []string{"a"})
+	var p int64
+	is.NoErr(db.QueryRow(`select price from cat_price where sku = 'a'`).Scan(&p))
+	is.Equal(p, int64(1000))
+}
@@ -0,0 +1,89 @@
+package model
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/shopd/shopd/go/db/sqlite"
+	"github.com/shopd/shopd/go/share"
+)
+
+// SessionMaxAge is how long a session token is valid
+const SessionMaxAge = 30 * 24 * time.Hour
+
+// SessionVerify is the email verification step of the login flow,
+// the otp from the login link can only be used once.
+// The user is marked as verified the first time a session is verified
+func (m *Model) SessionVerify(
+	ctx context.Context, userID, otp string) (err error) {
+
+	return m.tx(ctx, func(q *sqlite.Queries) error {
+		now := time.Now().Unix()
+		n, err := q.SessionVerify(ctx, sqlite.SessionVerifyParams{
+			Verified: now,
+			Mod:      NewID(),
+			UserID:   userID,
+			Otp:      otp,
+		})
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		if n == 0 {
+			return errors.WithStack(ErrSessionVerify(userID))
+		}
+		err = q.UserVerify(ctx, sqlite.UserVerifyParams{
+			Verified: now,
+			Mod:      NewID(),
+			UserID:   userID,
+		})
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		return nil
+	})
+}
+
+// SessionToken returns a signed token for the verified session,
+// the token expires after SessionMaxAge
+func (m *Model) SessionToken(
+	ctx context.Context, userID string) (token string, err error) {
+
+	expires := time.Now().Add(SessionMaxAge).Unix()
+	return m.Sign(ctx, fmt.Sprintf("%s|%d", userID, expires))
+}
+
+// SessionUser returns the user for the token. The token is not valid if
+// it's expired, the session is not verified, or the user is disabled
+func (m *Model) SessionUser(
+	ctx context.Context, token string) (
+	user share.SessionUser, ok bool, err error) {
+
+	val, ok, err := m.Verify(ctx, token)
+	if err != nil || !ok {
+		return user, false, err
+	}
+	userID, expires, found := strings.Cut(val, "|")
+	if !found {
+		return user, false, nil
+	}
+	unix, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil || time.Now().Unix() > unix {
+		return user, false, nil
+	}
+	session, err := m.q.SessionByUserID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return user, false, nil
+		}
+		return user, false, errors.WithStack(err)
+	}
+	if session.Verified == 0 || session.Disabled != 0 {
+		return user, false, nil
+	}
+	return share.SessionUser{UserID: userID, Role: session.Role}, true, nil
+}
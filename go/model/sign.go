@@ -0,0 +1,82 @@
+package model
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/shopd/shopd/go/db/sqlite"
+)
+
+// TermSecret is the config term for the key used to sign values,
+// e.g. cookies. It's generated on first use
+const TermSecret = "secret"
+
+// Sign returns val with a signature appended,
+// use Verify to check the signature
+func (m *Model) Sign(ctx context.Context, val string) (signed string, err error) {
+	key, err := m.secret(ctx)
+	if err != nil {
+		return signed, err
+	}
+	return val + "." + signature(key, val), nil
+}
+
+// Verify returns the value if the signature is valid
+func (m *Model) Verify(
+	ctx context.Context, signed string) (val string, ok bool, err error) {
+
+	i := strings.LastIndex(signed, ".")
+	if i < 0 {
+		return val, false, nil
+	}
+	key, err := m.secret(ctx)
+	if err != nil {
+		return val, false, err
+	}
+	val = signed[:i]
+	if !hmac.Equal([]byte(signed[i+1:]), []byte(signature(key, val))) {
+		return "", false, nil
+	}
+	return val, true, nil
+}
+
+func signature(key []byte, val string) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(val))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// secret returns the signing key, a new key is generated if not set
+func (m *Model) secret(ctx context.Context) (key []byte, err error) {
+	err = m.tx(ctx, func(q *sqlite.Queries) error {
+		val, err := configVal(ctx, q, TermSecret, "")
+		if err != nil {
+			return err
+		}
+		if val == "" {
+			b := make([]byte, 32)
+			_, err = rand.Read(b)
+			if err != nil {
+				return errors.WithStack(err)
+			}
+			val = hex.EncodeToString(b)
+			err = q.ConfigUpsert(ctx, sqlite.ConfigUpsertParams{
+				Term: TermSecret,
+				Val:  val,
+				Mod:  NewID(),
+			})
+			if err != nil {
+				return errors.WithStack(err)
+			}
+		}
+		key, err = hex.DecodeString(val)
+		return errors.WithStack(err)
+	})
+	return key, err
+}
@@ -0,0 +1,139 @@
+package model
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/pkg/errors"
+	"github.com/shopd/shopd/go/db/sqlite"
+	"github.com/shopd/shopd/go/share"
+)
+
+// OrderTransitions is the order state machine,
+// it lists the states an order may transition to from each state.
+// Idle carts and unpaid pending orders are expired by the system,
+// see ExpireCarts and ExpireOrders
+var OrderTransitions = map[string][]string{
+	share.OrderStateCart: {
+		share.OrderStatePending,
+	},
+	share.OrderStatePending: {
+		share.OrderStateConfirmed,
+		share.OrderStateReversed,
+	},
+	share.OrderStateConfirmed: {
+		share.OrderStateComplete,
+		share.OrderStateReversed,
+	},
+	// Complete orders are reversed by credit notes
+	share.OrderStateComplete: {
+		share.OrderStateReversed,
+	},
+}
+
+// SetOrderState transitions the order through the state machine.
+// Tax is calculated when the cart is checked out,
+// orders covered by vouchers are paid and confirmed at checkout,
+// stock is allocated when the order is confirmed,
+// and released when the order is reversed, along with voucher amounts.
+// Stock for complete orders was shipped, credit notes may restock it.
+// Orders with payments must be reversed with a credit note, see CreditNoteCreate
+func (m *Model) SetOrderState(
+	ctx context.Context, orderID, state, userID string) (err error) {
+
+	return m.tx(ctx, func(q *sqlite.Queries) error {
+		order, err := orderByID(ctx, q, orderID)
+		if err != nil {
+			return err
+		}
+		if state == share.OrderStateReversed {
+			// Payments are refunded with credit notes,
+			// a full credit note reverses the order
+			paid, err := orderHasPayments(ctx, q, orderID)
+			if err != nil {
+				return err
+			}
+			if paid {
+				return errors.WithStack(ErrOrderPayments(orderID))
+			}
+		}
+		return setOrderState(ctx, q, order, state, userID)
+	})
+}
+
+// orderHasPayments returns true if the order has successful payments,
+// vouchers are not payments, they are refunded when the order is reversed
+func orderHasPayments(
+	ctx context.Context, q *sqlite.Queries, orderID string) (
+	paid bool, err error) {
+
+	trans, err := q.TransByOrderID(ctx, orderID)
+	if err != nil {
+		return paid, errors.WithStack(err)
+	}
+	for _, tran := range trans {
+		if tran.State == share.TranStateSuccess {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// setOrderState must be called in a transaction
+func setOrderState(
+	ctx context.Context, q *sqlite.Queries, order sqlite.Orders,
+	state, userID string) (err error) {
+
+	orderID := order.OrderID
+	if !slices.Contains(OrderTransitions[order.State], state) {
+		return errors.WithStack(ErrStateTransition(order.State, state))
+	}
+
+	switch state {
+	case share.OrderStatePending:
+		err = priceOrder(ctx, q, order)
+	case share.OrderStateConfirmed:
+		_, err = allocateOrder(ctx, q, orderID, userID)
+	case share.OrderStateReversed:
+		if order.State != share.OrderStateComplete {
+			err = releaseOrder(ctx, q, orderID, userID)
+		}
+		if err == nil {
+			err = voucherRefund(ctx, q, orderID, userID)
+		}
+	}
+	if err != nil {
+		return err
+	}
+	err = updateOrderState(ctx, q, order, state, userID)
+	if err != nil {
+		return err
+	}
+	if state == share.OrderStatePending {
+		// Vouchers may cover the order, nothing is due then
+		order.State = state
+		return orderPaid(ctx, q, order, userID)
+	}
+	return nil
+}
+
+// updateOrderState records the state without side effects,
+// callers must check the transition
+func updateOrderState(
+	ctx context.Context, q *sqlite.Queries, order sqlite.Orders,
+	state, userID string) (err error) {
+
+	err = q.OrderUpdateState(ctx, sqlite.OrderUpdateStateParams{
+		State:   state,
+		Mod:     NewID(),
+		ModID:   modID(userID),
+		OrderID: order.OrderID,
+	})
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	msg := fmt.Sprintf("State changed from %s to %s", order.State, state)
+	order.State = state
+	return orderAct(ctx, q, order, userID, false, msg)
+}
@@ -0,0 +1,464 @@
+package model
+
+import (
+	"context"
+	"database/sql"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/shopd/shopd/go/db/sqlite"
+	"github.com/shopd/shopd/go/money"
+	"github.com/shopd/shopd/go/share"
+)
+
+// Tax methods, see comments for the order_tax table
+const (
+	// TaxMethodLine writes a tax line for each order line
+	TaxMethodLine = "line"
+	// TaxMethodBasket writes one tax line per pct rate,
+	// calculated on the sum of the order lines with that rate
+	TaxMethodBasket = "basket"
+)
+
+// Config terms for tax
+const (
+	TermTaxMethod = "tax_method"
+	// TermCountry is the ISO 3166-1 alpha-3 country code of the domain,
+	// used if the order does not have an address
+	TermCountry = "country"
+)
+
+// CountryDefault is used if the country term is not set
+const CountryDefault = "ZAF"
+
+// addrCountries maps address taxonomies to country codes
+var addrCountries = map[string]string{
+	"address_it": "ITA",
+	"address_za": "ZAF",
+}
+
+// TaxRule is a row from the tax table for the order country
+type TaxRule struct {
+	Tag string
+	Sku string
+	// Vat is set if the rule overrides the default vat rate,
+	// otherwise the rule adds a tax line
+	Vat bool
+	// Pct in basis points, e.g. 1500 for 15%
+	Pct int64
+	// Tax is a fixed amount per unit, used instead of Pct if non-zero
+	Tax int64
+	// Descr for the invoice, empty for the default description
+	Descr string
+}
+
+// specificity of the rule for the line, or -1 if the rule doesn't match.
+// Rules for a sku are more specific than rules for a tag,
+// and rules for a tag are more specific than rules for the country
+func (r TaxRule) specificity(line TaxLine) int {
+	if r.Tag != "" && !slices.Contains(line.Tags, r.Tag) {
+		return -1
+	}
+	if r.Sku != "" {
+		if r.Sku != line.Sku {
+			return -1
+		}
+		return 2
+	}
+	if r.Tag != "" {
+		return 1
+	}
+	return 0
+}
+
+// TaxLine is an order line to calculate tax on
+type TaxLine struct {
+	OrderLineID string
+	Sku         string
+	// Tags for the sku from cat_tag
+	Tags []string
+	Qty  int64
+	// Amount is the exclusive price times qty, less discounts
+	Amount int64
+}
+
+// TaxParams for the Tax func
+type TaxParams struct {
+	Method string
+	// VatPct is the default vat rate for the country
+	VatPct int64
+	Rules  []TaxRule
+	Lines  []TaxLine
+}
+
+// TaxRow is an order_tax row, tax is calculated before rounding
+type TaxRow struct {
+	// OrderLineID is empty for the basket method
+	OrderLineID string
+	Fixed       bool
+	Pct         int64
+	Tax         money.Exact
+	// Vat and Descr are copied from the rule
+	Vat   bool
+	Descr string
+}
+
+// Tax calculates the tax rows for the order lines.
+//
+// Each line is taxed at the default vat rate, unless a rule overrides it.
+// Rules match on country, then tag, and then sku. Rules with Vat set
+// replace the vat rate, the most specific matching rule is used.
+// Other rules add a tax line, additional taxes at different
+// specificities stack, e.g. a levy for the tag and a duty for the sku.
+// A fixed tax amount wins over pct.
+// Of matching rules with the same specificity the first one is used
+func Tax(params TaxParams) (rows []TaxRow, err error) {
+	if params.Method != TaxMethodLine && params.Method != TaxMethodBasket {
+		return rows, errors.WithStack(ErrConfig(TermTaxMethod))
+	}
+
+	for _, line := range params.Lines {
+		vat := TaxRule{Vat: true, Pct: params.VatPct}
+		if rule, ok := vatRule(params.Rules, line); ok {
+			vat = rule
+		}
+		rows = append(rows, taxRow(vat, line))
+		for _, rule := range additionalTaxRules(params.Rules, line) {
+			rows = append(rows, taxRow(rule, line))
+		}
+	}
+
+	if params.Method == TaxMethodBasket {
+		rows = taxBasket(rows)
+	}
+	return rows, nil
+}
+
+// vatRule returns the most specific rule with Vat set matching the line
+func vatRule(rules []TaxRule, line TaxLine) (rule TaxRule, ok bool) {
+	best := -1
+	for _, r := range rules {
+		if !r.Vat {
+			continue
+		}
+		if s := r.specificity(line); s > best {
+			rule, best = r, s
+		}
+	}
+	return rule, best >= 0
+}
+
+// additionalTaxRules returns the first matching rule without Vat set
+// for each specificity, from the least to the most specific
+func additionalTaxRules(rules []TaxRule, line TaxLine) (matched []TaxRule) {
+	seen := make(map[int]bool)
+	for _, r := range rules {
+		s := r.specificity(line)
+		if r.Vat || s < 0 || seen[s] {
+			continue
+		}
+		seen[s] = true
+		matched = append(matched, r)
+	}
+	sort.SliceStable(matched, func(i, j int) bool {
+		return matched[i].specificity(line) < matched[j].specificity(line)
+	})
+	return matched
+}
+
+func taxRow(rule TaxRule, line TaxLine) TaxRow {
+	if rule.Tax > 0 {
+		return TaxRow{
+			OrderLineID: line.OrderLineID,
+			Fixed:       true,
+			Tax:         money.NewExact(rule.Tax * line.Qty),
+			Vat:         rule.Vat,
+			Descr:       rule.Descr,
+		}
+	}
+	return TaxRow{
+		OrderLineID: line.OrderLineID,
+		Pct:         rule.Pct,
+		Tax:         money.Pct(line.Amount, rule.Pct),
+		Vat:         rule.Vat,
+		Descr:       rule.Descr,
+	}
+}
+
+// taxBasket sums the pct tax rows by tax and rate, i.e. VAT and
+// additional taxes with the same rate are summed separately.
+// Fixed and zero rated rows are kept per line, they don't need rounding,
+// and the taxable amount can't be derived from the tax
+func taxBasket(rows []TaxRow) (basket []TaxRow) {
+	type key struct {
+		vat   bool
+		descr string
+		pct   int64
+	}
+	sums := make(map[key]money.Exact)
+	keys := []key{}
+	for _, row := range rows {
+		if row.Fixed || row.Pct == 0 {
+			continue
+		}
+		k := key{vat: row.Vat, descr: row.Descr, pct: row.Pct}
+		if _, ok := sums[k]; !ok {
+			keys = append(keys, k)
+		}
+		sums[k] += row.Tax
+	}
+	sort.SliceStable(keys, func(i, j int) bool {
+		if keys[i].vat != keys[j].vat {
+			return keys[i].vat
+		}
+		return keys[i].pct > keys[j].pct
+	})
+	for _, k := range keys {
+		basket = append(basket, TaxRow{
+			Pct: k.pct, Tax: sums[k], Vat: k.vat, Descr: k.descr})
+	}
+	for _, row := range rows {
+		if row.Fixed || row.Pct == 0 {
+			basket = append(basket, row)
+		}
+	}
+	return basket
+}
+
+// PriceOrder applies discounts and calculates tax for the order,
+// replacing the discount lines and order_tax rows.
+// The coupon use is recorded, and the amount due is drawn from the voucher.
+// Open checkout payments for another amount are failed.
+// Orders that are paid can't be priced again
+func (m *Model) PriceOrder(ctx context.Context, orderID string) (err error) {
+
+	return m.tx(ctx, func(q *sqlite.Queries) error {
+		order, err := orderByID(ctx, q, orderID)
+		if err != nil {
+			return err
+		}
+		return priceOrder(ctx, q, order)
+	})
+}
+
+// priceOrder must be called in a transaction
+func priceOrder(
+	ctx context.Context, q *sqlite.Queries, order sqlite.Orders) (err error) {
+
+	if order.Paid == 1 {
+		return errors.WithStack(ErrOrderPaid(order.OrderID))
+	}
+	err = couponOrder(ctx, q, order)
+	if err != nil {
+		return err
+	}
+	discounts, err := discountOrder(ctx, q, order, time.Now())
+	if err != nil {
+		return err
+	}
+
+	params, err := taxParams(ctx, q, order.OrderID)
+	if err != nil {
+		return err
+	}
+	lines, err := taxLines(ctx, q, order.OrderID)
+	if err != nil {
+		return err
+	}
+	params.Lines = discountedLines(lines, discounts)
+
+	rows, err := Tax(params)
+	if err != nil {
+		return err
+	}
+	err = saveTax(ctx, q, order.OrderID, rows)
+	if err != nil {
+		return err
+	}
+
+	lines = discountedLines(lines, nil)
+	tax, err := taxTotal(ctx, q, rows, lines)
+	if err != nil {
+		return err
+	}
+	currency, err := configVal(ctx, q, TermCurrency, CurrencyDefault)
+	if err != nil {
+		return err
+	}
+	amounts := []int64{tax}
+	for _, line := range params.Lines {
+		amounts = append(amounts, line.Amount)
+	}
+	total := money.Sum(currency, amounts...).Amount()
+	err = voucherOrder(ctx, q, order, total, ModIDSystem)
+	if err != nil {
+		return err
+	}
+	return failStalePayments(ctx, q, order, ModIDSystem)
+}
+
+// taxTotal rounds the tax rows as per the invoice,
+// lines are the amounts before discounts, excluding system lines
+func taxTotal(
+	ctx context.Context, q *sqlite.Queries, rows []TaxRow, lines []TaxLine) (
+	total int64, err error) {
+
+	amounts := make([]lineAmount, 0, len(lines))
+	for _, line := range lines {
+		amounts = append(amounts, lineAmount{line.OrderLineID, line.Amount})
+	}
+	taxes := make([]sqlite.OrderTax, 0, len(rows))
+	for _, row := range rows {
+		taxes = append(taxes, sqlite.OrderTax{
+			OrderLineID: row.OrderLineID,
+			Fixed:       flag(row.Fixed),
+			Tax:         row.Tax.Float(),
+			Pct:         row.Pct,
+			Vat:         flag(row.Vat),
+			Descr:       row.Descr,
+		})
+	}
+	mode, scope, err := rounding(ctx, q)
+	if err != nil {
+		return total, err
+	}
+	for _, tax := range invoiceTaxes(taxes, amounts, mode, scope) {
+		total += tax.Tax
+	}
+	return total, nil
+}
+
+// taxParams for the order, without lines
+func taxParams(
+	ctx context.Context, q *sqlite.Queries, orderID string) (
+	params TaxParams, err error) {
+
+	params.Method, err = configVal(ctx, q, TermTaxMethod, TaxMethodLine)
+	if err != nil {
+		return params, err
+	}
+	country, err := orderCountry(ctx, q, orderID)
+	if err != nil {
+		return params, err
+	}
+	// Zero rated countries must have a vat row with zero pct
+	vat, err := q.VatByCountry(ctx, country)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return params, errors.WithStack(ErrConfig("vat " + country))
+		}
+		return params, errors.WithStack(err)
+	}
+	params.VatPct = vat.Pct
+	rules, err := q.TaxByCountry(ctx, country)
+	if err != nil {
+		return params, errors.WithStack(err)
+	}
+	for _, rule := range rules {
+		params.Rules = append(params.Rules, TaxRule{
+			Tag:   rule.Tag,
+			Sku:   rule.SKU,
+			Vat:   rule.Vat == 1,
+			Pct:   rule.Pct,
+			Tax:   rule.Tax,
+			Descr: rule.Descr,
+		})
+	}
+	return params, nil
+}
+
+// taxLines lists the order lines with catalog tags
+func taxLines(
+	ctx context.Context, q *sqlite.Queries, orderID string) (
+	taxLines []TaxLine, err error) {
+
+	tags, err := q.CatTagsByOrderID(ctx, orderID)
+	if err != nil {
+		return taxLines, errors.WithStack(err)
+	}
+	skuTags := make(map[string][]string)
+	for _, tag := range tags {
+		skuTags[tag.SKU] = append(skuTags[tag.SKU], tag.Tag)
+	}
+	currency, err := configVal(ctx, q, TermCurrency, CurrencyDefault)
+	if err != nil {
+		return taxLines, err
+	}
+	lines, err := q.OrderLinesByOrderID(ctx, orderID)
+	if err != nil {
+		return taxLines, errors.WithStack(err)
+	}
+	for _, line := range lines {
+		taxLines = append(taxLines, TaxLine{
+			OrderLineID: line.OrderLineID,
+			Sku:         line.SKU,
+			Tags:        skuTags[line.SKU],
+			Qty:         line.Qty,
+			Amount:      money.New(line.Price, currency).Multiply(line.Qty).Amount(),
+		})
+	}
+	return taxLines, nil
+}
+
+// saveTax replaces the order_tax rows,
+// tax lines can't be edited, only deleted and inserted
+func saveTax(
+	ctx context.Context, q *sqlite.Queries, orderID string, rows []TaxRow) (
+	err error) {
+
+	err = q.OrderTaxDelete(ctx, orderID)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	for _, row := range rows {
+		err = q.OrderTaxInsert(ctx, sqlite.OrderTaxInsertParams{
+			OrderID:     orderID,
+			OrderLineID: row.OrderLineID,
+			Fixed:       flag(row.Fixed),
+			Tax:         row.Tax.Float(),
+			Pct:         row.Pct,
+			Vat:         flag(row.Vat),
+			Descr:       row.Descr,
+			// Each row needs a unique mod, it's part of the primary key
+			Mod: NewID(),
+		})
+		if err != nil {
+			return errors.WithStack(err)
+		}
+	}
+	return nil
+}
+
+// flag converts a bool to an integer col with a (0, 1) check
+func flag(b bool) int64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// orderCountry returns the country for the delivery address,
+// or the billing address, or the country for the domain
+func orderCountry(
+	ctx context.Context, q *sqlite.Queries, orderID string) (
+	country string, err error) {
+
+	for _, addrType := range []string{share.AddrTypeDelivery, share.AddrTypeBilling} {
+		addr, err := q.OrderAddrByType(ctx, sqlite.OrderAddrByTypeParams{
+			OrderID: orderID,
+			Type:    addrType,
+		})
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				continue
+			}
+			return country, errors.WithStack(err)
+		}
+		if country, ok := addrCountries[addr.Taxonomy]; ok {
+			return country, nil
+		}
+	}
+	return configVal(ctx, q, TermCountry, CountryDefault)
+}
@@ -0,0 +1,232 @@
+package model_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/matryer/is"
+	"github.com/pkg/errors"
+	"github.com/shopd/shopd/go/model"
+	"github.com/shopd/shopd/go/money"
+	"github.com/shopd/shopd/go/share"
+)
+
+func TestTax(t *testing.T) {
+	bread := model.TaxLine{
+		OrderLineID: "l1", Sku: "bread", Tags: []string{"food"}, Qty: 1, Amount: 1000}
+	wine := model.TaxLine{
+		OrderLineID: "l2", Sku: "wine", Tags: []string{"alcohol"}, Qty: 2, Amount: 2000}
+	vat := func(line model.TaxLine, pct int64) model.TaxRow {
+		return model.TaxRow{
+			OrderLineID: line.OrderLineID, Pct: pct,
+			Tax: money.Pct(line.Amount, pct), Vat: true}
+	}
+	tax := func(line model.TaxLine, pct int64) model.TaxRow {
+		row := vat(line, pct)
+		row.Vat = false
+		return row
+	}
+
+	for _, tc := range []struct {
+		name   string
+		method string
+		rules  []model.TaxRule
+		lines  []model.TaxLine
+		want   []model.TaxRow
+	}{
+		{
+			name:  "default vat",
+			lines: []model.TaxLine{bread},
+			want:  []model.TaxRow{vat(bread, 1500)},
+		},
+		{
+			name: "override default vat for the country",
+			rules: []model.TaxRule{
+				{Vat: true, Pct: 1400},
+			},
+			lines: []model.TaxLine{bread},
+			want:  []model.TaxRow{vat(bread, 1400)},
+		},
+		{
+			name: "zero rated tag",
+			rules: []model.TaxRule{
+				{Tag: "food", Vat: true, Pct: 0},
+			},
+			lines: []model.TaxLine{bread, wine},
+			want:  []model.TaxRow{vat(bread, 0), vat(wine, 1500)},
+		},
+		{
+			name: "sku is more specific than tag",
+			rules: []model.TaxRule{
+				{Sku: "bread", Vat: true, Pct: 1000},
+				{Tag: "food", Vat: true, Pct: 0},
+				{Vat: true, Pct: 1400},
+			},
+			lines: []model.TaxLine{bread},
+			want:  []model.TaxRow{vat(bread, 1000)},
+		},
+		{
+			name: "tag rule for a sku without the tag",
+			rules: []model.TaxRule{
+				{Tag: "food", Sku: "wine", Vat: true, Pct: 0},
+			},
+			lines: []model.TaxLine{wine},
+			want:  []model.TaxRow{vat(wine, 1500)},
+		},
+		{
+			name: "additional taxes stack",
+			rules: []model.TaxRule{
+				{Sku: "wine", Tax: 200},
+				{Tag: "alcohol", Pct: 500},
+				{Pct: 100},
+			},
+			lines: []model.TaxLine{wine},
+			want: []model.TaxRow{
+				vat(wine, 1500),
+				tax(wine, 100),
+				tax(wine, 500),
+				{OrderLineID: "l2", Fixed: true, Tax: money.NewExact(400)},
+			},
+		},
+		{
+			name: "first additional tax with the same specificity",
+			rules: []model.TaxRule{
+				{Tag: "alcohol", Pct: 500},
+				{Tag: "alcohol", Pct: 700},
+			},
+			lines: []model.TaxLine{wine, bread},
+			want: []model.TaxRow{
+				vat(wine, 1500), tax(wine, 500), vat(bread, 1500)},
+		},
+		{
+			name:   "basket sums by tax and pct",
+			method: model.TaxMethodBasket,
+			rules: []model.TaxRule{
+				{Sku: "wine", Tax: 200},
+				{Tag: "food", Vat: true, Pct: 0},
+				{Tag: "alcohol", Pct: 1500, Descr: "Levy"},
+			},
+			lines: []model.TaxLine{bread, wine, {
+				OrderLineID: "l3", Sku: "cheese", Qty: 1, Amount: 500}},
+			want: []model.TaxRow{
+				{Pct: 1500, Tax: money.Pct(2500, 1500), Vat: true},
+				{Pct: 1500, Tax: money.Pct(2000, 1500), Descr: "Levy"},
+				vat(bread, 0),
+				{OrderLineID: "l2", Fixed: true, Tax: money.NewExact(400)},
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			is := is.New(t)
+			if tc.method == "" {
+				tc.method = model.TaxMethodLine
+			}
+			rows, err := model.Tax(model.TaxParams{
+				Method: tc.method,
+				VatPct: 1500,
+				Rules:  tc.rules,
+				Lines:  tc.lines,
+			})
+			is.NoErr(err)
+			is.Equal(rows, tc.want)
+		})
+	}
+
+	t.Run("invalid method", func(t *testing.T) {
+		is := is.New(t)
+		_, err := model.Tax(model.TaxParams{Method: "order"})
+		is.True(err != nil)
+	})
+}
+
+func TestTaxRounding(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+	m, db := newTestModel(t)
+	// Tax on each line is 15.45 cents
+	exec(t, db,
+		`insert into cat(sku, title, descr, state, mod, mod_id) values
+		('a', 'A', '', 'stock', 'm', 's'),
+		('b', 'B', '', 'stock', 'm', 's'),
+		('c', 'C', '', 'stock', 'm', 's')`,
+		`insert into cat_price values ('a', 103), ('b', 103), ('c', 103)`,
+	)
+	orderID := ""
+	for _, sku := range []string{"a", "b", "c"} {
+		cart, err := m.CartAdd(ctx, orderID, "", share.ParamsCartPost{Sku: sku, Qty: 1})
+		is.NoErr(err)
+		orderID = cart.OrderID
+	}
+	is.NoErr(m.SetOrderState(ctx, orderID, share.OrderStatePending, ""))
+
+	for _, tc := range []struct {
+		mode  money.Rounding
+		scope money.Scope
+		want  int64
+	}{
+		// Total of 46.35 cents
+		{money.RoundHalfEven, money.ScopeInvoice, 46},
+		{money.RoundUp, money.ScopeInvoice, 47},
+		{money.RoundDown, money.ScopeInvoice, 46},
+		// Lines are rounded first
+		{money.RoundHalfEven, money.ScopeLine, 45},
+		{money.RoundUp, money.ScopeLine, 48},
+		{money.RoundDown, money.ScopeLine, 45},
+	} {
+		_, err := db.Exec(`update config set val = ? where term = ?`,
+			string(tc.mode), model.TermRounding)
+		is.NoErr(err)
+		_, err = db.Exec(`update config set val = ? where term = ?`,
+			string(tc.scope), model.TermRoundingScope)
+		is.NoErr(err)
+		inv, err := m.Receipt(ctx, orderID)
+		is.NoErr(err)
+		is.Equal(inv.Tax, tc.want) // mode, scope
+		is.Equal(inv.Total, 309+tc.want)
+	}
+}
+
+func TestInvoiceTaxes(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+	m, db := newTestModel(t)
+	exec(t, db,
+		`insert into cat(sku, title, descr, state, mod, mod_id) values
+		('wine', 'Wine', '', 'stock', 'm', 's'),
+		('bread', 'Bread', '', 'stock', 'm', 's')`,
+		`insert into cat_price values ('wine', 1000), ('bread', 1000)`,
+		`insert into cat_tag values ('wine', 'alcohol')`,
+		`insert into tax(country, tag, sku, vat, pct, tax, descr, mod, mod_id)
+		values ('ZAF', 'alcohol', '', 0, 500, 0, 'Levy', 'm', 's'),
+		('ZAF', '', 'bread', 0, 0, 20, '', 'm', 's')`,
+	)
+	orderID := ""
+	for _, sku := range []string{"wine", "bread"} {
+		cart, err := m.CartAdd(ctx, orderID, "", share.ParamsCartPost{Sku: sku, Qty: 1})
+		is.NoErr(err)
+		orderID = cart.OrderID
+	}
+	is.NoErr(m.SetOrderState(ctx, orderID, share.OrderStatePending, ""))
+
+	inv, err := m.Receipt(ctx, orderID)
+	is.NoErr(err)
+	is.Equal(inv.Taxes, []share.InvoiceTax{
+		{Descr: "VAT 15%", Pct: 1500, Vat: true, Taxable: 2000, Tax: 300},
+		{Descr: "Levy 5%", Pct: 500, Taxable: 1000, Tax: 50},
+		{Descr: "Tax", Fixed: true, Taxable: 1000, Tax: 20},
+	})
+}
+
+func TestTaxWithoutVat(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+	m, db := newTestModel(t)
+	exec(t, db,
+		`insert into cat(sku, title, descr, state, mod, mod_id)
+		values ('a', 'A', '', 'stock', 'm', 's')`,
+		`insert into cat_price values ('a', 100)`,
+		`update config set val = 'ITA' where term = 'country'`,
+	)
+	_, err := m.CartAdd(ctx, "", "", share.ParamsCartPost{Sku: "a", Qty: 1})
+	is.True(errors.Is(err, model.ErrConfig("")))
+}
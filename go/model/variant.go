@@ -0,0 +1,355 @@
+package model
+
+import (
+	"context"
+	"database/sql"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/shopd/shopd/go/db/sqlite"
+	"github.com/shopd/shopd/go/share"
+)
+
+// VariantsMax is the max number of variants generated per group
+const VariantsMax = 1000
+
+// VariantGroup lists the variants in the group of the sku,
+// with attribute selectors for the sku.
+// Items that are not in a group don't have variants
+func (m *Model) VariantGroup(
+	ctx context.Context, sku string) (group share.VariantGroup, err error) {
+
+	return variantGroup(ctx, m.q, sku)
+}
+
+// GenerateVariants creates an item for each combination of attribute values
+// that doesn't exist, and sets the attributes for all items in the matrix.
+// Skus are the group ID and the values, e.g. running-shoe-42-grey.
+// Existing items keep the title and price, and are moved to the group
+func (m *Model) GenerateVariants(
+	ctx context.Context, params share.ParamsVariantsPost, userID string) (
+	result share.VariantsGenerate, err error) {
+
+	params.GroupID = strings.TrimSpace(params.GroupID)
+	params.Title = strings.TrimSpace(params.Title)
+	if params.GroupID == "" {
+		return result, errors.WithStack(ErrInvalidParam("GroupID"))
+	}
+	if params.Title == "" {
+		return result, errors.WithStack(ErrInvalidParam("Title"))
+	}
+	if params.Price < 0 {
+		return result, errors.WithStack(ErrInvalidParam("Price"))
+	}
+	attrs, vals, err := variantMatrix(params.Attrs)
+	if err != nil {
+		return result, err
+	}
+	combos := variantCombos(vals)
+
+	err = m.tx(ctx, func(q *sqlite.Queries) error {
+		changed := time.Now().UTC().Format(DateTimeFormat)
+		skus := make([]string, 0, len(combos))
+		for idx, combo := range combos {
+			sku := variantSku(params.GroupID, combo)
+			if systemSku(sku) {
+				return errors.WithStack(ErrInvalidParam(sku))
+			}
+			// Values for different attributes may join to the same sku,
+			// e.g. "a-b" and "c" or "a" and "b-c"
+			if slices.Contains(skus, sku) {
+				return errors.WithStack(ErrInvalidParam("Attrs"))
+			}
+			item, err := q.CatBySKU(ctx, sku)
+			if err != nil && !errors.Is(err, sql.ErrNoRows) {
+				return errors.WithStack(err)
+			}
+			if err == nil && item.State == share.CatStateSystem {
+				return errors.WithStack(ErrInvalidParam(sku))
+			}
+			if errors.Is(err, sql.ErrNoRows) {
+				err = q.CatUpsert(ctx, sqlite.CatUpsertParams{
+					SKU:   sku,
+					Title: params.Title + " " + strings.Join(combo, " "),
+					State: share.CatStateStock,
+					Mod:   NewID(),
+					ModID: modID(userID),
+				})
+				if err != nil {
+					return errors.WithStack(err)
+				}
+				err = catPriceSet(ctx, q, catPriceChange{
+					sku:     sku,
+					price:   params.Price,
+					changed: changed,
+				}, userID)
+				if err != nil {
+					return err
+				}
+				result.Created = append(result.Created, sku)
+			}
+
+			err = variantAttrsSet(ctx, q, sku, attrs, combo)
+			if err != nil {
+				return err
+			}
+			err = q.VariantDeleteBySKU(ctx, sku)
+			if err != nil {
+				return errors.WithStack(err)
+			}
+			err = q.VariantInsert(ctx, sqlite.VariantInsertParams{
+				GroupID: params.GroupID,
+				SKU:     sku,
+				Idx:     int64(idx),
+			})
+			if err != nil {
+				return errors.WithStack(err)
+			}
+			skus = append(skus, sku)
+		}
+
+		result.VariantGroup, err = variantGroup(ctx, q, skus[0])
+		return err
+	})
+	if err != nil {
+		return result, err
+	}
+	return result, nil
+}
+
+// variantMatrix reads one attribute per line, e.g. "size: 40, 41, 42".
+// Names are lowercase, values must be unique for the attribute
+// as used in the sku, and the number of combinations is limited
+func variantMatrix(s string) (attrs []string, vals [][]string, err error) {
+	combos := 1
+	for _, line := range strings.Split(s, "\n") {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		name, list, ok := strings.Cut(line, ":")
+		name = strings.ToLower(strings.TrimSpace(name))
+		if !ok || name == "" || slices.Contains(attrs, name) {
+			return attrs, vals, errors.WithStack(ErrInvalidParam("Attrs"))
+		}
+		attrVals := []string{}
+		parts := []string{}
+		for _, val := range strings.Split(list, ",") {
+			val = strings.TrimSpace(val)
+			part := variantSkuPart(val)
+			if val == "" || slices.Contains(parts, part) {
+				return attrs, vals, errors.WithStack(ErrInvalidParam("Attrs"))
+			}
+			attrVals = append(attrVals, val)
+			parts = append(parts, part)
+		}
+		combos *= len(attrVals)
+		if combos > VariantsMax {
+			return attrs, vals, errors.WithStack(ErrInvalidParam("Attrs"))
+		}
+		attrs = append(attrs, name)
+		vals = append(vals, attrVals)
+	}
+	if len(attrs) == 0 || len(attrs) > share.VariantAttrMax {
+		return attrs, vals, errors.WithStack(ErrInvalidParam("Attrs"))
+	}
+	return attrs, vals, nil
+}
+
+// variantCombos lists combinations of values,
+// the values of the first attribute change slowest
+func variantCombos(vals [][]string) (combos [][]string) {
+	combos = [][]string{{}}
+	for _, attrVals := range vals {
+		next := make([][]string, 0, len(combos)*len(attrVals))
+		for _, combo := range combos {
+			for _, val := range attrVals {
+				next = append(next, append(slices.Clone(combo), val))
+			}
+		}
+		combos = next
+	}
+	return combos
+}
+
+// variantSku appends the values to the group ID, see variantSkuPart
+func variantSku(groupID string, combo []string) string {
+	parts := []string{groupID}
+	for _, val := range combo {
+		parts = append(parts, variantSkuPart(val))
+	}
+	return strings.Join(parts, "-")
+}
+
+// variantSkuPart is the value in the sku,
+// lowercase and with spaces replaced by dashes
+func variantSkuPart(val string) string {
+	return strings.Join(strings.Fields(strings.ToLower(val)), "-")
+}
+
+// variantAttrsSet sets the attribute config for the sku,
+// and removes config for attributes that are not used
+func variantAttrsSet(
+	ctx context.Context, q *sqlite.Queries, sku string,
+	attrs []string, vals []string) (err error) {
+
+	for i := 1; i <= share.VariantAttrMax; i++ {
+		terms := map[string]string{
+			share.VariantAttrTerm(i): "",
+			share.VariantValTerm(i):  "",
+		}
+		if i <= len(attrs) {
+			terms[share.VariantAttrTerm(i)] = attrs[i-1]
+			terms[share.VariantValTerm(i)] = vals[i-1]
+		}
+		for term, val := range terms {
+			if val == "" {
+				err = q.CatConfigDelete(ctx, sqlite.CatConfigDeleteParams{
+					SKU:  sku,
+					Term: term,
+				})
+			} else {
+				err = q.CatConfigUpsert(ctx, sqlite.CatConfigUpsertParams{
+					SKU:  sku,
+					Term: term,
+					Val:  val,
+				})
+			}
+			if err != nil {
+				return errors.WithStack(err)
+			}
+		}
+	}
+	return nil
+}
+
+// variantGroup reads attributes from cat_config. Attribute names are taken
+// from the sku, and values for other variants are matched by name.
+// Hidden items are not listed, unless it's the sku
+func variantGroup(
+	ctx context.Context, q *sqlite.Queries, sku string) (
+	group share.VariantGroup, err error) {
+
+	group.Sku = sku
+	_, err = q.CatBySKU(ctx, sku)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return group, errors.WithStack(ErrNotFound(sku))
+		}
+		return group, errors.WithStack(err)
+	}
+	groups, err := q.VariantsBySKU(ctx, sku)
+	if err != nil {
+		return group, errors.WithStack(err)
+	}
+	if len(groups) == 0 {
+		return group, nil
+	}
+	group.GroupID = groups[0].GroupID
+
+	items, err := q.VariantItemsByGroupID(ctx, group.GroupID)
+	if err != nil {
+		return group, errors.WithStack(err)
+	}
+	rows, err := q.CatConfigByGroupID(ctx, group.GroupID)
+	if err != nil {
+		return group, errors.WithStack(err)
+	}
+	config := make(map[string]map[string]string)
+	for _, row := range rows {
+		if config[row.SKU] == nil {
+			config[row.SKU] = make(map[string]string)
+		}
+		config[row.SKU][row.Term] = row.Val
+	}
+	// Attribute values by name for each sku
+	attrVals := make(map[string]map[string]string)
+	for s, terms := range config {
+		attrVals[s] = make(map[string]string)
+		for i := 1; i <= share.VariantAttrMax; i++ {
+			attr := terms[share.VariantAttrTerm(i)]
+			if attr != "" {
+				attrVals[s][attr] = terms[share.VariantValTerm(i)]
+			}
+		}
+	}
+	attrs := []string{}
+	for i := 1; i <= share.VariantAttrMax; i++ {
+		attr := config[sku][share.VariantAttrTerm(i)]
+		if attr != "" {
+			attrs = append(attrs, attr)
+		}
+	}
+
+	selected := -1
+	for _, item := range items {
+		if item.SKU != sku && (item.State == share.CatStateHidden ||
+			item.State == share.CatStateSystem) {
+			continue
+		}
+		variant := share.Variant{
+			Sku:       item.SKU,
+			Title:     item.Title,
+			Vals:      make([]string, len(attrs)),
+			Available: item.State == share.CatStateStock && item.Qty > 0,
+		}
+		for j, attr := range attrs {
+			variant.Vals[j] = attrVals[item.SKU][attr]
+		}
+		if item.SKU == sku {
+			selected = len(group.Variants)
+		}
+		group.Variants = append(group.Variants, variant)
+	}
+	if selected < 0 {
+		return group, nil
+	}
+
+	for j, attr := range attrs {
+		selector := share.VariantAttr{Attr: attr}
+		for _, variant := range group.Variants {
+			val := variant.Vals[j]
+			if val == "" || slices.ContainsFunc(selector.Vals,
+				func(v share.VariantVal) bool { return v.Val == val }) {
+				continue
+			}
+			match := variantMatch(group.Variants, group.Variants[selected].Vals, j, val)
+			selector.Vals = append(selector.Vals, share.VariantVal{
+				Val:       val,
+				Sku:       match.Sku,
+				Selected:  val == group.Variants[selected].Vals[j],
+				Available: match.Available,
+			})
+		}
+		group.Attrs = append(group.Attrs, selector)
+	}
+	return group, nil
+}
+
+// variantMatch is the variant with val for attribute j,
+// and the selected values for the other attributes.
+// Otherwise the first available variant with val, or the first with val
+func variantMatch(
+	variants []share.Variant, selected []string, j int, val string) (
+	match share.Variant) {
+
+	want := slices.Clone(selected)
+	want[j] = val
+	i := slices.IndexFunc(variants, func(v share.Variant) bool {
+		return slices.Equal(v.Vals, want)
+	})
+	if i >= 0 {
+		return variants[i]
+	}
+	i = slices.IndexFunc(variants, func(v share.Variant) bool {
+		return v.Vals[j] == val && v.Available
+	})
+	if i >= 0 {
+		return variants[i]
+	}
+	i = slices.IndexFunc(variants, func(v share.Variant) bool {
+		return v.Vals[j] == val
+	})
+	return variants[i]
+}
@@ -0,0 +1,130 @@
+package model_test
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/matryer/is"
+	"github.com/pkg/errors"
+	"github.com/shopd/shopd/go/model"
+	"github.com/shopd/shopd/go/share"
+)
+
+func TestGenerateVariants(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+	m, db := newTestModel(t)
+	exec(t, db,
+		`insert into depot(depot, descr, province, idx, mod, mod_id)
+		values ('jhb', 'Johannesburg', 'Gauteng', 0, 'm', 's')`,
+		`insert into cat(sku, title, descr, state, mod, mod_id)
+		values ('shoe-41-grey', 'Special', '', 'stock', 'm', 's')`,
+		`insert into cat_price values ('shoe-41-grey', 900)`,
+	)
+
+	result, err := m.GenerateVariants(ctx, share.ParamsVariantsPost{
+		GroupID: "shoe",
+		Title:   "Shoe",
+		Price:   1000,
+		Attrs:   "Size: 40, 41\nColour: Grey, Light  Blue",
+	}, "admin")
+	is.NoErr(err)
+	// Existing items keep the title and price
+	is.Equal(result.Created, []string{
+		"shoe-40-grey", "shoe-40-light-blue", "shoe-41-light-blue"})
+	is.Equal(result.GroupID, "shoe")
+	is.Equal(len(result.Variants), 4)
+	is.Equal(result.Variants[2].Title, "Special")
+	is.Equal(result.Variants[2].Vals, []string{"41", "Grey"})
+	var price int64
+	is.NoErr(db.QueryRow(
+		`select price from cat_price where sku = 'shoe-41-grey'`).Scan(&price))
+	is.Equal(price, int64(900))
+	is.NoErr(db.QueryRow(
+		`select price from cat_price where sku = 'shoe-40-grey'`).Scan(&price))
+	is.Equal(price, int64(1000))
+
+	// Selectors for the sku, hidden items are not listed
+	exec(t, db,
+		`insert into cat_qty values ('shoe-40-grey', 'jhb', 1),
+		('shoe-41-grey', 'jhb', 1), ('shoe-41-light-blue', 'jhb', 1)`,
+		`update cat set state = 'hidden' where sku = 'shoe-41-light-blue'`,
+	)
+	group, err := m.VariantGroup(ctx, "shoe-40-grey")
+	is.NoErr(err)
+	is.Equal(len(group.Variants), 3)
+	is.Equal(group.Attrs, []share.VariantAttr{
+		{Attr: "size", Vals: []share.VariantVal{
+			{Val: "40", Sku: "shoe-40-grey", Selected: true, Available: true},
+			{Val: "41", Sku: "shoe-41-grey", Available: true},
+		}},
+		{Attr: "colour", Vals: []share.VariantVal{
+			{Val: "Grey", Sku: "shoe-40-grey", Selected: true, Available: true},
+			{Val: "Light  Blue", Sku: "shoe-40-light-blue"},
+		}},
+	})
+	group, err = m.VariantGroup(ctx, "shoe-41-grey")
+	is.NoErr(err)
+	// No exact match, the first variant with the value
+	is.Equal(group.Attrs[1].Vals[1].Sku, "shoe-40-light-blue")
+
+	// Items that are not in a group don't have variants
+	exec(t, db, `insert into cat(sku, title, descr, state, mod, mod_id)
+		values ('sock', 'Sock', '', 'stock', 'm', 's')`)
+	group, err = m.VariantGroup(ctx, "sock")
+	is.NoErr(err)
+	is.Equal(group.GroupID, "")
+	is.Equal(len(group.Variants), 0)
+	_, err = m.VariantGroup(ctx, "boot")
+	is.True(errors.Is(err, model.ErrNotFound("")))
+}
+
+func TestGenerateVariantsInvalid(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+	m, db := newTestModel(t)
+	items := func() (n int) {
+		is.NoErr(db.QueryRow(`select count(*) from cat`).Scan(&n))
+		return n
+	}
+	before := items()
+	vals := func(n int) string {
+		list := make([]string, n)
+		for i := range list {
+			list[i] = fmt.Sprint(i)
+		}
+		return strings.Join(list, ", ")
+	}
+
+	for _, params := range []share.ParamsVariantsPost{
+		{Title: "Shoe", Attrs: "size: 40"},
+		{GroupID: "shoe", Attrs: "size: 40"},
+		{GroupID: "shoe", Title: "Shoe", Price: -1, Attrs: "size: 40"},
+		{GroupID: "shoe", Title: "Shoe", Attrs: ""},
+		{GroupID: "shoe", Title: "Shoe", Attrs: "size 40"},
+		{GroupID: "shoe", Title: "Shoe", Attrs: "size: 40, "},
+		{GroupID: "shoe", Title: "Shoe", Attrs: "size: 40\nSize: 41"},
+		{GroupID: "shoe", Title: "Shoe", Attrs: "a: 1\nb: 1\nc: 1\nd: 1"},
+		// Values must be unique as used in the sku
+		{GroupID: "shoe", Title: "Shoe", Attrs: "colour: Grey, grey"},
+		{GroupID: "shoe", Title: "Shoe", Attrs: "colour: light blue, Light-Blue"},
+		{GroupID: "shoe", Title: "Shoe", Attrs: "a: x, x-y\nb: y-z, z"},
+		// The number of combinations is limited
+		{GroupID: "shoe", Title: "Shoe",
+			Attrs: "a: " + vals(10) + "\nb: " + vals(10) + "\nc: " + vals(11)},
+	} {
+		_, err := m.GenerateVariants(ctx, params, "admin")
+		is.True(errors.Is(err, model.ErrInvalidParam("")))
+	}
+	is.Equal(items(), before)
+
+	// The limit is inclusive
+	result, err := m.GenerateVariants(ctx, share.ParamsVariantsPost{
+		GroupID: "shoe", Title: "Shoe",
+		Attrs: "a: " + vals(10) + "\nb: " + vals(10) + "\nc: " + vals(10),
+	}, "admin")
+	is.NoErr(err)
+	is.Equal(len(result.Created), model.VariantsMax)
+}
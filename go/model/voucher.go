@@ -0,0 +1,187 @@
+package model
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/pkg/errors"
+	"github.com/shopd/shopd/go/db/sqlite"
+	"github.com/shopd/shopd/go/share"
+)
+
+// Vouchers are a monetary substitute, the balance is drawn down when the
+// order is priced. The amount drawn is added to the order as a line for the
+// SkuVoucher system sku, with a negative price and qty of one.
+// Vouchers are not discounts, tax is calculated before the voucher is used.
+// The amount is returned to the balance if the order is priced again,
+// or reversed
+
+// SkuVoucher is the system sku for voucher lines
+const SkuVoucher = "voucher"
+
+// TermVoucher is the order config term for the voucher code,
+// it's set on the order, and on the voucher line
+const TermVoucher = "voucher"
+
+// GenerateVouchers creates a batch of vouchers for the amount
+func (m *Model) GenerateVouchers(
+	ctx context.Context, params share.ParamsVouchersPost, userID string) (
+	codes []string, err error) {
+
+	if params.Count <= 0 || params.Count > CodesMax {
+		return codes, errors.WithStack(ErrInvalidParam("Count"))
+	}
+	if params.Amount <= 0 {
+		return codes, errors.WithStack(ErrInvalidParam("Amount"))
+	}
+	err = m.tx(ctx, func(q *sqlite.Queries) error {
+		for range params.Count {
+			code, err := newCode(params.Prefix)
+			if err != nil {
+				return err
+			}
+			err = q.VoucherInsert(ctx, sqlite.VoucherInsertParams{
+				Code:    code,
+				Amount:  params.Amount,
+				Balance: params.Amount,
+				Mod:     NewID(),
+				ModID:   modID(userID),
+			})
+			if err != nil {
+				return errors.WithStack(err)
+			}
+			codes = append(codes, code)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// voucherOrder draws the amount due from the voucher on the order,
+// must be called in a transaction. Total is the amount due including tax
+func voucherOrder(
+	ctx context.Context, q *sqlite.Queries, order sqlite.Orders,
+	total int64, userID string) (err error) {
+
+	err = voucherRefund(ctx, q, order.OrderID, userID)
+	if err != nil {
+		return err
+	}
+	err = voucherDelete(ctx, q, order.OrderID)
+	if err != nil {
+		return err
+	}
+
+	code, err := orderConfigVal(ctx, q, order.OrderID, TermVoucher)
+	if err != nil || code == "" {
+		return err
+	}
+	voucher, err := q.VoucherByCode(ctx, code)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return errors.WithStack(ErrInvalidCode(code))
+		}
+		return errors.WithStack(err)
+	}
+	amount := min(voucher.Balance, total)
+	if amount <= 0 {
+		return nil
+	}
+
+	err = q.VoucherAdd(ctx, sqlite.VoucherAddParams{
+		Balance: -amount,
+		Mod:     NewID(),
+		ModID:   modID(userID),
+		Code:    code,
+	})
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	err = q.VoucherRedeemInsert(ctx, sqlite.VoucherRedeemInsertParams{
+		Code:    code,
+		OrderID: order.OrderID,
+		Amount:  amount,
+		Mod:     NewID(),
+	})
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	orderLineID := NewID()
+	err = q.OrderLineInsert(ctx, sqlite.OrderLineInsertParams{
+		OrderLineID: orderLineID,
+		OrderID:     order.OrderID,
+		SKU:         SkuVoucher,
+		Price:       -amount,
+		Qty:         1,
+	})
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	err = q.OrderConfigUpsert(ctx, sqlite.OrderConfigUpsertParams{
+		OrderID:     order.OrderID,
+		OrderLineID: orderLineID,
+		Term:        TermVoucher,
+		Val:         code,
+	})
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	return nil
+}
+
+// voucherRefund returns the amounts drawn by the order to the vouchers
+func voucherRefund(
+	ctx context.Context, q *sqlite.Queries, orderID, userID string) (err error) {
+
+	redeemed, err := q.VoucherRedeemByOrderID(ctx, orderID)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	for _, r := range redeemed {
+		err = q.VoucherAdd(ctx, sqlite.VoucherAddParams{
+			Balance: r.Amount,
+			Mod:     NewID(),
+			ModID:   modID(userID),
+			Code:    r.Code,
+		})
+		if err != nil {
+			return errors.WithStack(err)
+		}
+	}
+	err = q.VoucherRedeemDelete(ctx, orderID)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	return nil
+}
+
+// voucherDelete removes the voucher lines from the order
+func voucherDelete(
+	ctx context.Context, q *sqlite.Queries, orderID string) (err error) {
+
+	lines, err := q.OrderLinesByOrderID(ctx, orderID)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	for _, line := range lines {
+		if line.SKU != SkuVoucher {
+			continue
+		}
+		err = q.OrderConfigDelete(ctx, sqlite.OrderConfigDeleteParams{
+			OrderID:     orderID,
+			OrderLineID: line.OrderLineID,
+			Term:        TermVoucher,
+		})
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		err = q.OrderLineDelete(ctx, line.OrderLineID)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,67 @@
+package model_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/matryer/is"
+	"github.com/pkg/errors"
+	"github.com/shopd/shopd/go/model"
+	"github.com/shopd/shopd/go/share"
+)
+
+func TestVoucherCoversOrder(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+	m, db := newTestModel(t)
+	exec(t, db,
+		`insert into cat(sku, title, descr, state, mod, mod_id)
+		values ('a', 'Apple', '', 'stock', 'm', 's')`,
+		`insert into cat_price values ('a', 1000)`,
+		`insert into voucher values ('GIFT', 5000, 5000, 'm', 's')`,
+	)
+	cart, err := m.CartAdd(ctx, "", "", share.ParamsCartPost{Sku: "a", Qty: 2})
+	is.NoErr(err)
+	_, err = m.CartCode(ctx, cart.OrderID, "", "gift")
+	is.NoErr(err)
+	is.NoErr(m.SetOrderState(ctx, cart.OrderID, share.OrderStatePending, ""))
+
+	// Nothing is due, so the order is paid and confirmed at checkout
+	summary, err := m.OrderSummary(ctx, cart.OrderID)
+	is.NoErr(err)
+	is.True(summary.Paid)
+	is.Equal(summary.State, share.OrderStateConfirmed)
+	var balance int64
+	is.NoErr(db.QueryRow(`select balance from voucher where code = 'GIFT'`).
+		Scan(&balance))
+	is.Equal(balance, int64(5000-2300))
+
+	_, err = m.PaymentCreate(ctx, cart.OrderID, "fake", "")
+	is.True(errors.Is(err, model.ErrOrderPaid("")))
+}
+
+func TestVoucherPartial(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+	m, db := newTestModel(t)
+	exec(t, db,
+		`insert into cat(sku, title, descr, state, mod, mod_id)
+		values ('a', 'Apple', '', 'stock', 'm', 's')`,
+		`insert into cat_price values ('a', 1000)`,
+		`insert into voucher values ('GIFT', 500, 500, 'm', 's')`,
+	)
+	cart, err := m.CartAdd(ctx, "", "", share.ParamsCartPost{Sku: "a", Qty: 2})
+	is.NoErr(err)
+	_, err = m.CartCode(ctx, cart.OrderID, "", "gift")
+	is.NoErr(err)
+	is.NoErr(m.SetOrderState(ctx, cart.OrderID, share.OrderStatePending, ""))
+
+	summary, err := m.OrderSummary(ctx, cart.OrderID)
+	is.NoErr(err)
+	is.True(!summary.Paid)
+	is.Equal(summary.State, share.OrderStatePending)
+
+	pay, err := m.PaymentCreate(ctx, cart.OrderID, "fake", "")
+	is.NoErr(err)
+	is.Equal(pay.Amount, int64(2300-500))
+}
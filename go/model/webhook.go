@@ -0,0 +1,147 @@
+package model
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/pkg/errors"
+	"github.com/shopd/shopd/go/db/sqlite"
+	"github.com/shopd/shopd/go/share"
+)
+
+// Webhook events are stored in the payment_event inbox before they are
+// handled, see go/services. Events that were handled before are ignored,
+// processors may send the same event more than once.
+// Failed events are listed for admin users to retry
+
+// PaymentEventsLimit is the max number of events listed
+const PaymentEventsLimit = 100
+
+// PaymentEventSave stores the event if it's new,
+// otherwise the stored event is returned
+func (m *Model) PaymentEventSave(
+	ctx context.Context, event share.PaymentEvent, body []byte) (
+	saved share.PaymentEvent, err error) {
+
+	err = m.tx(ctx, func(q *sqlite.Queries) error {
+		row, err := q.PaymentEventByID(ctx, sqlite.PaymentEventByIDParams{
+			Processor: event.Processor,
+			EventID:   event.EventID,
+		})
+		if err == nil {
+			saved = paymentEvent(row)
+			return nil
+		}
+		if !errors.Is(err, sql.ErrNoRows) {
+			return errors.WithStack(err)
+		}
+		row = sqlite.PaymentEvent{
+			Processor: event.Processor,
+			EventID:   event.EventID,
+			Ref:       event.Ref,
+			State:     event.State,
+			Amount:    event.Amount,
+			Body:      string(body),
+			Handled:   share.PaymentEventPending,
+			Mod:       NewID(),
+			ModID:     ModIDSystem,
+		}
+		err = q.PaymentEventInsert(ctx, sqlite.PaymentEventInsertParams(row))
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		saved = paymentEvent(row)
+		return nil
+	})
+	if err != nil {
+		return saved, err
+	}
+	return saved, nil
+}
+
+// PaymentEvent returns the stored event
+func (m *Model) PaymentEvent(
+	ctx context.Context, processor, eventID string) (
+	event share.PaymentEvent, err error) {
+
+	row, err := m.q.PaymentEventByID(ctx, sqlite.PaymentEventByIDParams{
+		Processor: processor,
+		EventID:   eventID,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return event, errors.WithStack(ErrNotFound(eventID))
+		}
+		return event, errors.WithStack(err)
+	}
+	return paymentEvent(row), nil
+}
+
+// PaymentEventHandled records the outcome of handling the event,
+// the event failed if handleErr is not nil
+func (m *Model) PaymentEventHandled(
+	ctx context.Context, processor, eventID string, handleErr error,
+	userID string) (event share.PaymentEvent, err error) {
+
+	params := sqlite.PaymentEventUpdateParams{
+		Handled:   share.PaymentEventDone,
+		Mod:       NewID(),
+		ModID:     modID(userID),
+		Processor: processor,
+		EventID:   eventID,
+	}
+	if handleErr != nil {
+		params.Handled = share.PaymentEventFailed
+		params.Error = handleErr.Error()
+	}
+	err = m.q.PaymentEventUpdate(ctx, params)
+	if err != nil {
+		return event, errors.WithStack(err)
+	}
+	return m.PaymentEvent(ctx, processor, eventID)
+}
+
+// PaymentEvents lists events by handled state, failed events by default
+func (m *Model) PaymentEvents(
+	ctx context.Context, handled string) (list share.PaymentEvents, err error) {
+
+	if handled == "" {
+		handled = share.PaymentEventFailed
+	}
+	if handled != share.PaymentEventPending &&
+		handled != share.PaymentEventDone &&
+		handled != share.PaymentEventFailed {
+		return list, errors.WithStack(ErrInvalidParam("Handled"))
+	}
+	list.Handled = handled
+	list.Currency, err = configVal(ctx, m.q, TermCurrency, CurrencyDefault)
+	if err != nil {
+		return list, err
+	}
+	rows, err := m.q.PaymentEventsByHandled(ctx, sqlite.PaymentEventsByHandledParams{
+		Handled: handled,
+		Limit:   PaymentEventsLimit,
+	})
+	if err != nil {
+		return list, errors.WithStack(err)
+	}
+	list.Events = make([]share.PaymentEvent, 0, len(rows))
+	for _, row := range rows {
+		list.Events = append(list.Events, paymentEvent(row))
+	}
+	return list, nil
+}
+
+func paymentEvent(row sqlite.PaymentEvent) share.PaymentEvent {
+	return share.PaymentEvent{
+		Processor: row.Processor,
+		EventID:   row.EventID,
+		Ref:       row.Ref,
+		State:     row.State,
+		Amount:    row.Amount,
+		Handled:   row.Handled,
+		Error:     row.Error,
+		Attempts:  row.Attempts,
+		Updated:   modTime(row.Mod),
+	}
+}
@@ -0,0 +1,66 @@
+package money
+
+import (
+	"math/big"
+	"sort"
+)
+
+// Allocate splits amount by the weights, the parts always sum to amount.
+// Each part is rounded down, and the remainder is distributed one unit
+// at a time to the parts with the largest fractions, ties go to the
+// first part. Negative weights are treated as zero.
+// If all weights are zero the amount is split evenly
+func Allocate(amount int64, weights ...int64) []int64 {
+	parts := make([]int64, len(weights))
+	if len(weights) == 0 {
+		return parts
+	}
+
+	var sum int64
+	w := make([]int64, len(weights))
+	for i, weight := range weights {
+		w[i] = max(weight, 0)
+		sum += w[i]
+	}
+	if sum == 0 {
+		for i := range w {
+			w[i] = 1
+		}
+		sum = int64(len(w))
+	}
+
+	sign := int64(1)
+	if amount < 0 {
+		sign, amount = -1, -amount
+	}
+
+	// Use big ints, amount times weight may overflow
+	a := big.NewInt(amount)
+	s := big.NewInt(sum)
+	fractions := make([]*big.Int, len(w))
+	var allocated int64
+	for i := range w {
+		q, r := new(big.Int).QuoRem(
+			new(big.Int).Mul(a, big.NewInt(w[i])), s, new(big.Int))
+		parts[i] = q.Int64()
+		fractions[i] = r
+		allocated += parts[i]
+	}
+
+	order := make([]int, len(w))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		return fractions[order[i]].Cmp(fractions[order[j]]) > 0
+	})
+	for i := 0; allocated < amount; i++ {
+		parts[order[i%len(order)]]++
+		allocated++
+	}
+
+	for i := range parts {
+		parts[i] *= sign
+	}
+	return parts
+}
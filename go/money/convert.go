@@ -0,0 +1,147 @@
+package money
+
+import (
+	"math/big"
+	"strconv"
+	"strings"
+
+	gomoney "github.com/Rhymond/go-money"
+	"github.com/pkg/errors"
+)
+
+// RateScale of exchange rates, a rate is the number of major units of the
+// target currency for one major unit of the source currency, times RateScale.
+// E.g. 55000 converts 1 ZAR to 0.055 USD
+const RateScale = 1000000
+
+// rateDecimals is the number of decimals in RateScale
+const rateDecimals = 6
+
+// Converter converts amounts between currencies for display
+type Converter struct {
+	From string
+	To   string
+	Rate int64
+	Mode Rounding
+}
+
+// Converted returns true if amounts are converted to another currency
+func (c Converter) Converted() bool {
+	return c.To != "" && c.To != c.From && c.Rate > 0
+}
+
+// Currency that amounts are converted to
+func (c Converter) Currency() string {
+	if c.Converted() {
+		return c.To
+	}
+	return c.From
+}
+
+// Convert the amount in minor units of the From currency,
+// to minor units of the To currency, rounded with Mode.
+// The fraction digits of the currencies may differ, e.g. ZAR to JPY
+func (c Converter) Convert(amount int64) int64 {
+	if !c.Converted() {
+		return amount
+	}
+	num := big.NewInt(amount)
+	num.Mul(num, big.NewInt(c.Rate))
+	num.Mul(num, pow10(fraction(c.To)))
+	den := big.NewInt(RateScale)
+	den.Mul(den, pow10(fraction(c.From)))
+	return roundRat(num, den, c.Mode)
+}
+
+// ValidCurrency returns true for ISO 4217 currency codes
+func ValidCurrency(code string) bool {
+	return code != "" && gomoney.GetCurrency(code) != nil
+}
+
+// ParseRate parses a decimal exchange rate, e.g. "0.055",
+// with at most six decimals
+func ParseRate(s string) (rate int64, err error) {
+	s = strings.TrimSpace(s)
+	whole, frac, _ := strings.Cut(s, ".")
+	if whole == "" {
+		whole = "0"
+	}
+	// Signs are not allowed, strconv accepts them
+	if len(frac) > rateDecimals || !digits(whole) || !digits(frac) {
+		return rate, errors.WithStack(ErrRate(s))
+	}
+	frac += strings.Repeat("0", rateDecimals-len(frac))
+	w, err := strconv.ParseInt(whole, 10, 64)
+	if err != nil {
+		return rate, errors.WithStack(ErrRate(s))
+	}
+	f, err := strconv.ParseInt(frac, 10, 64)
+	if err != nil {
+		return rate, errors.WithStack(ErrRate(s))
+	}
+	rate = w*RateScale + f
+	if rate <= 0 || rate/RateScale != w {
+		return rate, errors.WithStack(ErrRate(s))
+	}
+	return rate, nil
+}
+
+// digits returns true if s only contains the digits 0 to 9
+func digits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// FormatRate formats the exchange rate without trailing zeros
+func FormatRate(rate int64) string {
+	s := strconv.FormatInt(rate/RateScale, 10)
+	frac := strconv.FormatInt(rate%RateScale+RateScale, 10)[1:]
+	frac = strings.TrimRight(frac, "0")
+	if frac == "" {
+		return s
+	}
+	return s + "." + frac
+}
+
+// fraction digits of the currency, two if the currency is not known
+func fraction(code string) int {
+	c := gomoney.GetCurrency(code)
+	if c == nil {
+		return 2
+	}
+	return c.Fraction
+}
+
+func pow10(n int) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(n)), nil)
+}
+
+// roundRat rounds num divided by den to an integer,
+// see Exact.Round for the rounding modes. Den must be positive
+func roundRat(num, den *big.Int, mode Rounding) int64 {
+	q, r := new(big.Int).QuoRem(num, den, new(big.Int))
+	if r.Sign() == 0 {
+		return q.Int64()
+	}
+	sign := int64(r.Sign())
+	r.Abs(r)
+	// Compare twice the remainder with den to find halves
+	cmp := new(big.Int).Mul(r, big.NewInt(2)).Cmp(den)
+	switch mode {
+	case RoundUp:
+		q.Add(q, big.NewInt(sign))
+	case RoundHalfUp:
+		if cmp >= 0 {
+			q.Add(q, big.NewInt(sign))
+		}
+	case RoundHalfEven:
+		if cmp > 0 || (cmp == 0 && q.Bit(0) == 1) {
+			q.Add(q, big.NewInt(sign))
+		}
+	}
+	return q.Int64()
+}
@@ -0,0 +1,23 @@
+package money
+
+import (
+	"github.com/mozey/errors"
+)
+
+var ErrMoney = errors.NewCause("money")
+
+var ErrCurrencyMismatch = func(a, b string) error {
+	return errors.NewWithCausef(ErrMoney, "currency mismatch %s %s", a, b)
+}
+
+var ErrRounding = func(mode string) error {
+	return errors.NewWithCausef(ErrMoney, "invalid rounding %s", mode)
+}
+
+var ErrScope = func(scope string) error {
+	return errors.NewWithCausef(ErrMoney, "invalid rounding scope %s", scope)
+}
+
+var ErrRate = func(rate string) error {
+	return errors.NewWithCausef(ErrMoney, "invalid exchange rate %s", rate)
+}
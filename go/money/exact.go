@@ -0,0 +1,115 @@
+package money
+
+import (
+	"math"
+
+	"github.com/pkg/errors"
+)
+
+// Scale of exact amounts, one minor unit is Scale exact units.
+// The same as the basis points denominator,
+// that means percentages of whole minor units are exact
+const Scale = 10000
+
+// Exact is an amount in 1/Scale of the smallest unit of the currency,
+// e.g. the tax calculated on a line before rounding
+type Exact int64
+
+// NewExact converts an amount in minor units
+func NewExact(amount int64) Exact {
+	return Exact(amount * Scale)
+}
+
+// Pct calculates bps basis points of amount in minor units
+func Pct(amount, bps int64) Exact {
+	return Exact(amount * bps)
+}
+
+// ExactFromFloat converts a float amount in minor units,
+// e.g. the order_tax.tax col
+func ExactFromFloat(f float64) Exact {
+	return Exact(math.Round(f * Scale))
+}
+
+// Float amount in minor units
+func (e Exact) Float() float64 {
+	return float64(e) / Scale
+}
+
+// Rounding mode for converting exact amounts to minor units
+type Rounding string
+
+const (
+	// RoundHalfEven rounds half to the nearest even unit, i.e. banker's rounding
+	RoundHalfEven Rounding = "half_even"
+	// RoundHalfUp rounds half away from zero
+	RoundHalfUp Rounding = "half_up"
+	// RoundDown truncates towards zero
+	RoundDown Rounding = "down"
+	// RoundUp rounds away from zero
+	RoundUp Rounding = "up"
+)
+
+func ParseRounding(s string) (Rounding, error) {
+	mode := Rounding(s)
+	switch mode {
+	case RoundHalfEven, RoundHalfUp, RoundDown, RoundUp:
+		return mode, nil
+	}
+	return mode, errors.WithStack(ErrRounding(s))
+}
+
+// Scope decides when exact amounts are rounded
+type Scope string
+
+const (
+	// ScopeLine rounds each line, the total is the sum of rounded lines
+	ScopeLine Scope = "line"
+	// ScopeInvoice rounds the total,
+	// the rounded total is allocated to the lines
+	ScopeInvoice Scope = "invoice"
+)
+
+func ParseScope(s string) (Scope, error) {
+	scope := Scope(s)
+	switch scope {
+	case ScopeLine, ScopeInvoice:
+		return scope, nil
+	}
+	return scope, errors.WithStack(ErrScope(s))
+}
+
+// Round to minor units
+func (e Exact) Round(mode Rounding) int64 {
+	q, r := int64(e)/Scale, int64(e)%Scale
+	if r == 0 {
+		return q
+	}
+	sign := int64(1)
+	if r < 0 {
+		sign, r = -1, -r
+	}
+	switch mode {
+	case RoundUp:
+		q += sign
+	case RoundHalfUp:
+		if r*2 >= Scale {
+			q += sign
+		}
+	case RoundHalfEven:
+		if r*2 > Scale || (r*2 == Scale && q%2 != 0) {
+			q += sign
+		}
+	}
+	return q
+}
+
+// Allocate splits the exact amount by the weights, see the Allocate func
+func (e Exact) Allocate(weights ...int64) []Exact {
+	parts := Allocate(int64(e), weights...)
+	es := make([]Exact, len(parts))
+	for i, part := range parts {
+		es[i] = Exact(part)
+	}
+	return es
+}
@@ -0,0 +1,56 @@
+package money
+
+import (
+	"strings"
+
+	gomoney "github.com/Rhymond/go-money"
+)
+
+// Locale overrides the separators of the currency
+type Locale struct {
+	Decimal  string
+	Thousand string
+}
+
+// Locales by language tag, the currency defaults are used if not listed
+var Locales = map[string]Locale{
+	"en-ZA": {Decimal: ".", Thousand: ","},
+	"af-ZA": {Decimal: ",", Thousand: " "},
+	"en-US": {Decimal: ".", Thousand: ","},
+	"en-GB": {Decimal: ".", Thousand: ","},
+	"de-DE": {Decimal: ",", Thousand: "."},
+	"fr-FR": {Decimal: ",", Thousand: " "},
+}
+
+func (m Money) formatter(locale string) *gomoney.Formatter {
+	f := *m.m.Currency().Formatter()
+	if l, ok := Locales[locale]; ok {
+		f.Decimal = l.Decimal
+		f.Thousand = l.Thousand
+	}
+	return &f
+}
+
+// Format with the currency symbol, e.g. R1,234.56
+func (m Money) Format(locale string) string {
+	return m.formatter(locale).Format(m.Amount())
+}
+
+// Number without the currency symbol, e.g. 1,234.56
+func (m Money) Number(locale string) string {
+	f := m.formatter(locale)
+	f.Grapheme = ""
+	f.Template = "1"
+	return strings.TrimSpace(f.Format(m.Amount()))
+}
+
+// Decimal without the currency symbol or thousand separators,
+// e.g. 1234.56 for file exports
+func (m Money) Decimal() string {
+	f := *m.m.Currency().Formatter()
+	f.Grapheme = ""
+	f.Template = "1"
+	f.Decimal = "."
+	f.Thousand = ""
+	return strings.TrimSpace(f.Format(m.Amount()))
+}
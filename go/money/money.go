@@ -0,0 +1,72 @@
+package money
+
+import (
+	gomoney "github.com/Rhymond/go-money"
+	"github.com/pkg/errors"
+)
+
+// Money is an amount in the smallest unit of the currency
+type Money struct {
+	m *gomoney.Money
+}
+
+func New(amount int64, currency string) Money {
+	return Money{m: gomoney.New(amount, currency)}
+}
+
+// Amount in the smallest unit
+func (m Money) Amount() int64 {
+	return m.m.Amount()
+}
+
+// Currency code, e.g. ZAR
+func (m Money) Currency() string {
+	return m.m.Currency().Code
+}
+
+func (m Money) Add(om Money) (Money, error) {
+	r, err := m.m.Add(om.m)
+	if err != nil {
+		return m, errors.WithStack(ErrCurrencyMismatch(m.Currency(), om.Currency()))
+	}
+	return Money{m: r}, nil
+}
+
+func (m Money) Subtract(om Money) (Money, error) {
+	r, err := m.m.Subtract(om.m)
+	if err != nil {
+		return m, errors.WithStack(ErrCurrencyMismatch(m.Currency(), om.Currency()))
+	}
+	return Money{m: r}, nil
+}
+
+// Multiply by qty, e.g. price times qty for a line amount
+func (m Money) Multiply(qty int64) Money {
+	return Money{m: m.m.Multiply(qty)}
+}
+
+// Pct calculates bps basis points of the amount,
+// the result is exact and must be rounded
+func (m Money) Pct(bps int64) Exact {
+	return Pct(m.Amount(), bps)
+}
+
+// Allocate splits the amount by the weights without losing cents,
+// see the Allocate func
+func (m Money) Allocate(weights ...int64) []Money {
+	parts := Allocate(m.Amount(), weights...)
+	ms := make([]Money, len(parts))
+	for i, part := range parts {
+		ms[i] = New(part, m.Currency())
+	}
+	return ms
+}
+
+// Sum of amounts in the same currency
+func Sum(currency string, amounts ...int64) Money {
+	var sum int64
+	for _, amount := range amounts {
+		sum += amount
+	}
+	return New(sum, currency)
+}
@@ -0,0 +1,159 @@
+package money_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/matryer/is"
+	"github.com/shopd/shopd/go/money"
+)
+
+func TestExactRound(t *testing.T) {
+	is := is.New(t)
+	modes := []money.Rounding{
+		money.RoundHalfEven, money.RoundHalfUp, money.RoundDown, money.RoundUp}
+	for _, tc := range []struct {
+		exact money.Exact
+		// want by mode, half_even, half_up, down, up
+		want [4]int64
+	}{
+		{money.NewExact(3), [4]int64{3, 3, 3, 3}},
+		{15000, [4]int64{2, 2, 1, 2}},
+		{25000, [4]int64{2, 3, 2, 3}},
+		{20001, [4]int64{2, 2, 2, 3}},
+		{26000, [4]int64{3, 3, 2, 3}},
+		{-15000, [4]int64{-2, -2, -1, -2}},
+		{-25000, [4]int64{-2, -3, -2, -3}},
+		{money.Pct(1000, 1500), [4]int64{150, 150, 150, 150}},
+		// 149.85, 151.5, and 154.5 minor units
+		{money.Pct(999, 1500), [4]int64{150, 150, 149, 150}},
+		{money.Pct(1010, 1500), [4]int64{152, 152, 151, 152}},
+		{money.Pct(1030, 1500), [4]int64{154, 155, 154, 155}},
+	} {
+		for i, mode := range modes {
+			is.Equal(tc.exact.Round(mode), tc.want[i]) // exact, mode
+		}
+	}
+}
+
+func TestParseRounding(t *testing.T) {
+	is := is.New(t)
+	mode, err := money.ParseRounding("half_even")
+	is.NoErr(err)
+	is.Equal(mode, money.RoundHalfEven)
+	_, err = money.ParseRounding("nearest")
+	is.True(err != nil)
+	_, err = money.ParseScope("order")
+	is.True(err != nil)
+}
+
+func TestAllocate(t *testing.T) {
+	is := is.New(t)
+	half := int64(math.MaxInt64 / 2)
+	for _, tc := range []struct {
+		amount  int64
+		weights []int64
+		want    []int64
+	}{
+		// Ties go to the first part
+		{100, []int64{1, 1, 1}, []int64{34, 33, 33}},
+		// Largest fraction gets the remainder
+		{100, []int64{1, 2}, []int64{33, 67}},
+		{7, []int64{1, 1, 1, 1}, []int64{2, 2, 2, 1}},
+		{-100, []int64{1, 1, 1}, []int64{-34, -33, -33}},
+		// Split evenly if all weights are zero
+		{10, []int64{0, 0}, []int64{5, 5}},
+		// Negative weights are zero
+		{10, []int64{-1, 1}, []int64{0, 10}},
+		{0, []int64{1, 2}, []int64{0, 0}},
+		{5, []int64{}, []int64{}},
+		// Amount times weight overflows int64
+		{math.MaxInt64, []int64{3, 3}, []int64{half + 1, half}},
+	} {
+		parts := money.Allocate(tc.amount, tc.weights...)
+		is.Equal(parts, tc.want) // amount, weights
+		var sum int64
+		for _, part := range parts {
+			sum += part
+		}
+		if len(parts) > 0 {
+			is.Equal(sum, tc.amount) // parts sum to amount
+		}
+	}
+}
+
+func TestExactAllocate(t *testing.T) {
+	is := is.New(t)
+	parts := money.NewExact(1).Allocate(1, 1, 1)
+	is.Equal(parts, []money.Exact{3334, 3333, 3333})
+
+	m := money.New(1000, "ZAR").Allocate(1, 2)
+	is.Equal(m[0].Amount(), int64(333))
+	is.Equal(m[1].Amount(), int64(667))
+	is.Equal(m[1].Currency(), "ZAR")
+}
+
+func TestConverter(t *testing.T) {
+	is := is.New(t)
+	usd := money.Converter{From: "ZAR", To: "USD", Rate: 55000}
+	for _, tc := range []struct {
+		amount int64
+		mode   money.Rounding
+		want   int64
+	}{
+		{10000, money.RoundHalfUp, 550},
+		// 55.55 cents
+		{1010, money.RoundHalfUp, 56},
+		{1010, money.RoundDown, 55},
+		// 5.5 and 16.5 cents
+		{100, money.RoundHalfEven, 6},
+		{300, money.RoundHalfEven, 16},
+		{300, money.RoundHalfUp, 17},
+		{-300, money.RoundHalfEven, -16},
+		{-300, money.RoundHalfUp, -17},
+		// 0.55 cents
+		{10, money.RoundUp, 1},
+		{10, money.RoundDown, 0},
+	} {
+		usd.Mode = tc.mode
+		is.Equal(usd.Convert(tc.amount), tc.want) // amount, mode
+	}
+	is.True(usd.Converted())
+	is.Equal(usd.Currency(), "USD")
+
+	// JPY doesn't have minor units, 123.45 ZAR is 987.6 JPY
+	jpy := money.Converter{
+		From: "ZAR", To: "JPY", Rate: 8 * money.RateScale, Mode: money.RoundHalfUp}
+	is.Equal(jpy.Convert(12345), int64(988))
+
+	for _, c := range []money.Converter{
+		{From: "ZAR"},
+		{From: "ZAR", To: "ZAR", Rate: 2 * money.RateScale},
+		{From: "ZAR", To: "USD"},
+	} {
+		is.True(!c.Converted())
+		is.Equal(c.Convert(1234), int64(1234))
+		is.Equal(c.Currency(), "ZAR")
+	}
+}
+
+func TestParseRate(t *testing.T) {
+	is := is.New(t)
+	for s, want := range map[string]int64{
+		"0.055": 55000,
+		"8":     8 * money.RateScale,
+		".5":    500000,
+		" 1.25": 1250000,
+	} {
+		rate, err := money.ParseRate(s)
+		is.NoErr(err)
+		is.Equal(rate, want) // rate
+	}
+	for _, s := range []string{"", "0", "-1", "abc", "0.0000001", "1.-5", "1.+5", "+1"} {
+		_, err := money.ParseRate(s)
+		is.True(err != nil) // invalid rate
+	}
+	is.Equal(money.FormatRate(55000), "0.055")
+	is.Equal(money.FormatRate(8*money.RateScale), "8")
+	is.Equal(money.FormatRate(1250000), "1.25")
+}
@@ -0,0 +1,102 @@
+package router
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shopd/shopd/go/share"
+	"github.com/shopd/shopd/www/api/account"
+	"github.com/shopd/shopd/www/api/admin/accounts"
+	"github.com/shopd/shopd/www/api/admin/accounts/statement"
+	content "github.com/shopd/shopd/www/content/account"
+	admin "github.com/shopd/shopd/www/content/admin/accounts"
+	"github.com/shopd/shopd/www/view"
+)
+
+func (h *RouteHandler) GetAccount(c *gin.Context) {
+	c.Render(http.StatusOK, h.Content(c.Request, content.Index))
+}
+
+// ApiGetAccount lists statements for the accounts of the session user
+func (h *RouteHandler) ApiGetAccount(c *gin.Context) {
+	statements, err := h.s.Model.UserStatements(
+		c.Request.Context(), sessionUserID(c))
+	if err != nil {
+		abort(c, err)
+		return
+	}
+	model := view.AccountGet{}
+	for _, s := range statements {
+		model.Statements = append(model.Statements, view.AccountStatement{
+			AccountStatement: s,
+		})
+	}
+	c.Render(http.StatusOK, h.Template(c.Request, account.Get(model)))
+}
+
+func (h *RouteHandler) GetAccounts(c *gin.Context) {
+	c.Render(http.StatusOK, h.Content(c.Request, admin.Index))
+}
+
+func (h *RouteHandler) ApiGetAccounts(c *gin.Context) {
+	list, err := h.s.Model.Accounts(c.Request.Context())
+	if err != nil {
+		abort(c, err)
+		return
+	}
+	c.Render(http.StatusOK, h.Template(c.Request, accounts.Get(view.AccountsGet{
+		Accounts: list,
+	})))
+}
+
+func (h *RouteHandler) ApiGetAccountsStatement(c *gin.Context) {
+	accountID := share.Query(c.Request.URL.Query(), share.ParamAccountID)
+	s, err := h.s.Model.AccountStatement(c.Request.Context(), accountID)
+	if err != nil {
+		abort(c, err)
+		return
+	}
+	h.renderStatement(c, s)
+}
+
+// ApiPostAccountsStatement credits or debits the account
+func (h *RouteHandler) ApiPostAccountsStatement(c *gin.Context) {
+	params := share.ParamsAccountCreditPost{}
+	err := c.ShouldBind(&params)
+	if err != nil {
+		_ = c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+	s, err := h.s.Model.AccountAdjust(
+		c.Request.Context(), params, sessionUserID(c))
+	if err != nil {
+		abort(c, err)
+		return
+	}
+	h.renderStatement(c, s)
+}
+
+// ApiPostAccountsUsers links a user to the account, or unlinks the user
+func (h *RouteHandler) ApiPostAccountsUsers(c *gin.Context) {
+	params := share.ParamsAccountUserPost{}
+	err := c.ShouldBind(&params)
+	if err != nil {
+		_ = c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+	s, err := h.s.Model.AccountUser(c.Request.Context(), params)
+	if err != nil {
+		abort(c, err)
+		return
+	}
+	h.renderStatement(c, s)
+}
+
+func (h *RouteHandler) renderStatement(
+	c *gin.Context, s share.AccountStatement) {
+
+	c.Render(http.StatusOK, h.Template(c.Request, statement.Get(
+		view.AccountsStatementGet{
+			AccountStatement: view.AccountStatement{AccountStatement: s},
+		})))
+}
@@ -0,0 +1,199 @@
+package router
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+	"github.com/shopd/shopd/go/model"
+	"github.com/shopd/shopd/go/share"
+	"github.com/shopd/shopd/www/api/cart"
+	content "github.com/shopd/shopd/www/content/cart"
+	"github.com/shopd/shopd/www/view"
+)
+
+// CookieCart is the name of the cookie with the signed cart order ID
+const CookieCart = "cart"
+
+// cookieCartMaxAge in seconds, idle carts are expired separately
+const cookieCartMaxAge = 30 * 24 * 60 * 60
+
+// GetCart doesn't require a session, guest carts are identified by cookie.
+// The cart param restores the cookie, e.g. from a cart reminder link
+func (h *RouteHandler) GetCart(c *gin.Context) {
+	signed := share.Query(c.Request.URL.Query(), share.ParamCart)
+	if signed != "" {
+		orderID, ok, err := h.s.Model.Verify(c.Request.Context(), signed)
+		if err != nil {
+			abort(c, err)
+			return
+		}
+		if ok {
+			err = h.setCartID(c, orderID)
+			if err != nil {
+				abort(c, err)
+				return
+			}
+		}
+	}
+	c.Render(http.StatusOK, h.Content(c.Request, content.Index))
+}
+
+func (h *RouteHandler) ApiGetCart(c *gin.Context) {
+	ctx := c.Request.Context()
+	orderID, err := h.cartID(c)
+	if err != nil {
+		abort(c, err)
+		return
+	}
+	data := share.Cart{}
+	if orderID != "" {
+		data, err = h.s.Model.Cart(ctx, orderID, sessionUserID(c))
+		if err != nil && !errors.Is(err, model.ErrNotFound("")) {
+			abort(c, err)
+			return
+		}
+	}
+	h.renderCart(c, data)
+}
+
+func (h *RouteHandler) ApiPostCart(c *gin.Context) {
+	ctx := c.Request.Context()
+	params := share.ParamsCartPost{}
+	err := c.ShouldBind(&params)
+	if err != nil {
+		_ = c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+	orderID, err := h.cartID(c)
+	if err != nil {
+		abort(c, err)
+		return
+	}
+	data, err := h.s.Model.CartAdd(ctx, orderID, sessionUserID(c), params)
+	if err != nil {
+		abort(c, err)
+		return
+	}
+	if data.OrderID != orderID {
+		err = h.setCartID(c, data.OrderID)
+		if err != nil {
+			abort(c, err)
+			return
+		}
+	}
+	h.renderCart(c, data)
+}
+
+func (h *RouteHandler) ApiPatchCart(c *gin.Context) {
+	ctx := c.Request.Context()
+	params := share.ParamsCartPatch{}
+	err := c.ShouldBind(&params)
+	if err != nil {
+		_ = c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+	orderID, err := h.cartID(c)
+	if err != nil {
+		abort(c, err)
+		return
+	}
+	data, err := h.s.Model.CartUpdate(ctx, orderID, sessionUserID(c), params)
+	if err != nil {
+		abort(c, err)
+		return
+	}
+	h.renderCart(c, data)
+}
+
+// ApiPostCartCode applies a coupon or voucher code to the cart
+func (h *RouteHandler) ApiPostCartCode(c *gin.Context) {
+	ctx := c.Request.Context()
+	params := share.ParamsCartCodePost{}
+	err := c.ShouldBind(&params)
+	if err != nil {
+		_ = c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+	orderID, err := h.cartID(c)
+	if err != nil {
+		abort(c, err)
+		return
+	}
+	data, err := h.s.Model.CartCode(ctx, orderID, sessionUserID(c), params.Code)
+	if err != nil {
+		abort(c, err)
+		return
+	}
+	h.renderCart(c, data)
+}
+
+// ApiDeleteCartCode removes a coupon or voucher code from the cart
+func (h *RouteHandler) ApiDeleteCartCode(c *gin.Context) {
+	ctx := c.Request.Context()
+	orderID, err := h.cartID(c)
+	if err != nil {
+		abort(c, err)
+		return
+	}
+	code := share.Query(c.Request.URL.Query(), share.ParamCode)
+	data, err := h.s.Model.CartCodeRemove(ctx, orderID, sessionUserID(c), code)
+	if err != nil {
+		abort(c, err)
+		return
+	}
+	h.renderCart(c, data)
+}
+
+// renderCart with display prices in the currency selected by the buyer
+func (h *RouteHandler) renderCart(c *gin.Context, data share.Cart) {
+	display, err := h.converter(c)
+	if err != nil {
+		abort(c, err)
+		return
+	}
+	model := view.CartGet{
+		Cart:    data,
+		Display: display,
+	}
+	if userID := sessionUserID(c); userID != "" {
+		model.Accounts, err = h.s.Model.UserStatements(c.Request.Context(), userID)
+		if err != nil {
+			abort(c, err)
+			return
+		}
+	}
+	c.Render(http.StatusOK, h.Template(c.Request, cart.Get(model)))
+}
+
+// cartID returns the order ID from the cart cookie,
+// or empty string if the cookie is not set or the signature is invalid
+func (h *RouteHandler) cartID(c *gin.Context) (orderID string, err error) {
+	signed, err := c.Cookie(CookieCart)
+	if err != nil {
+		return "", nil
+	}
+	orderID, ok, err := h.s.Model.Verify(c.Request.Context(), signed)
+	if err != nil {
+		return "", err
+	}
+	if !ok {
+		return "", nil
+	}
+	return orderID, nil
+}
+
+// setCartID sets the cart cookie, the cookie is removed if orderID is empty
+func (h *RouteHandler) setCartID(c *gin.Context, orderID string) (err error) {
+	c.SetSameSite(http.SameSiteLaxMode)
+	if orderID == "" {
+		c.SetCookie(CookieCart, "", -1, "/", "", true, true)
+		return nil
+	}
+	signed, err := h.s.Model.Sign(c.Request.Context(), orderID)
+	if err != nil {
+		return err
+	}
+	c.SetCookie(CookieCart, signed, cookieCartMaxAge, "/", "", true, true)
+	return nil
+}
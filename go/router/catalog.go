@@ -0,0 +1,56 @@
+package router
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shopd/shopd/go/share"
+	"github.com/shopd/shopd/www/api/admin/catalog"
+	content "github.com/shopd/shopd/www/content/admin/catalog"
+	"github.com/shopd/shopd/www/view"
+)
+
+func (h *RouteHandler) GetCatalog(c *gin.Context) {
+	c.Render(http.StatusOK, h.Content(c.Request, content.Index))
+}
+
+// ApiPostCatalog imports the uploaded catalog CSV,
+// or validates the file for a dry run
+func (h *RouteHandler) ApiPostCatalog(c *gin.Context) {
+	params := share.ParamsCatalogPost{}
+	err := c.ShouldBind(&params)
+	if err != nil {
+		_ = c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+	header, err := c.FormFile("File")
+	if err != nil {
+		_ = c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+	file, err := header.Open()
+	if err != nil {
+		_ = c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+	defer file.Close()
+	result, err := h.s.CatalogImport(
+		c.Request.Context(), file, params.DryRun, sessionUserID(c))
+	if err != nil {
+		abort(c, err)
+		return
+	}
+	c.Render(http.StatusOK, h.Template(c.Request, catalog.Post(view.CatalogPost{
+		CatalogImport: result,
+	})))
+}
+
+// ApiGetCatalogExport downloads the catalog CSV
+func (h *RouteHandler) ApiGetCatalogExport(c *gin.Context) {
+	c.Header("Content-Type", "text/csv")
+	c.Header("Content-Disposition", `attachment; filename="catalog.csv"`)
+	err := h.s.Model.CatalogExport(c.Request.Context(), c.Writer)
+	if err != nil {
+		_ = c.Error(err)
+	}
+}
@@ -0,0 +1,160 @@
+package router
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+	"github.com/shopd/shopd/go/model"
+	"github.com/shopd/shopd/go/services"
+	"github.com/shopd/shopd/go/share"
+	"github.com/shopd/shopd/www/components"
+	"github.com/shopd/shopd/www/view"
+)
+
+// ApiPostCheckout places the order for the cart, applies store credit
+// if requested, and redirects the buyer to the hosted payment page.
+// Orders covered by vouchers go straight to the receipt
+func (h *RouteHandler) ApiPostCheckout(c *gin.Context) {
+	ctx := c.Request.Context()
+	params := share.ParamsCheckoutPost{}
+	err := c.ShouldBind(&params)
+	if err != nil {
+		_ = c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+	orderID, err := h.cartID(c)
+	if err != nil {
+		abort(c, err)
+		return
+	}
+	if orderID == "" {
+		abort(c, model.ErrNotFound(CookieCart))
+		return
+	}
+	userID := sessionUserID(c)
+	// Guests can't check out carts that belong to a user
+	_, err = h.s.Model.Cart(ctx, orderID, userID)
+	if err != nil {
+		abort(c, err)
+		return
+	}
+	paid, err := h.s.Checkout(ctx, orderID, userID)
+	if err != nil {
+		abort(c, err)
+		return
+	}
+	err = h.setCartID(c, "")
+	if err != nil {
+		abort(c, err)
+		return
+	}
+	if paid {
+		// Vouchers covered the order
+		h.receiptRedirect(c, orderID)
+		return
+	}
+	if params.UseCredit {
+		pay, err := h.s.PaymentCredit(ctx, orderID, params.AccountID, userID)
+		if err != nil {
+			abort(c, err)
+			return
+		}
+		order, err := h.s.Model.OrderSummary(ctx, orderID)
+		if err != nil {
+			abort(c, err)
+			return
+		}
+		if pay.Amount > 0 && order.Paid {
+			h.receiptRedirect(c, orderID)
+			return
+		}
+	}
+	redirectURL, err := h.s.PaymentStart(ctx, orderID, userID)
+	if err != nil {
+		// The cart cookie is removed, the buyer pays again from the receipt
+		log.Error().Stack().Err(err).Str("order", orderID).Msg("payment start")
+		h.receiptRedirect(c, orderID)
+		return
+	}
+	c.Header("HX-Redirect", redirectURL)
+	c.Status(http.StatusNoContent)
+}
+
+// receiptRedirect sends the buyer to the receipt for the paid order
+func (h *RouteHandler) receiptRedirect(c *gin.Context, orderID string) {
+	u, err := h.receiptURL(c, orderID)
+	if err != nil {
+		abort(c, err)
+		return
+	}
+	c.Header("HX-Redirect", u)
+	c.Status(http.StatusNoContent)
+}
+
+// GetCheckoutReturn is where the processor sends the buyer after paying,
+// the payment status is checked with the processor
+func (h *RouteHandler) GetCheckoutReturn(c *gin.Context) {
+	tranID := share.Query(c.Request.URL.Query(), share.ParamTranID)
+	pay, err := h.s.PaymentSync(c.Request.Context(), tranID)
+	if err != nil {
+		abort(c, err)
+		return
+	}
+	u, err := h.receiptURL(c, pay.OrderID)
+	if err != nil {
+		abort(c, err)
+		return
+	}
+	c.Redirect(http.StatusSeeOther, u)
+}
+
+// GetFakePay renders the hosted page for the fake processor
+func (h *RouteHandler) GetFakePay(c *gin.Context) {
+	p, ok := h.s.Processors[services.ProcessorFake].(*services.FakeProcessor)
+	if !ok {
+		abort(c, model.ErrNotFound(services.ProcessorFake))
+		return
+	}
+	intent, err := p.Intent(c.Param("ref"))
+	if err != nil {
+		abort(c, err)
+		return
+	}
+	c.Render(http.StatusOK, h.Template(c.Request, components.FakePay(view.FakePay{
+		FakeIntent: intent,
+		Action:     services.FakePayPath + intent.Ref,
+	})))
+}
+
+// PostFakePay pays or declines on the fake hosted page,
+// and returns the buyer to the shop
+func (h *RouteHandler) PostFakePay(c *gin.Context) {
+	p, ok := h.s.Processors[services.ProcessorFake].(*services.FakeProcessor)
+	if !ok {
+		abort(c, model.ErrNotFound(services.ProcessorFake))
+		return
+	}
+	params := share.ParamsFakePayPost{}
+	err := c.ShouldBind(&params)
+	if err != nil {
+		_ = c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+	intent, err := p.Authorize(c.Param("ref"), params.Action == share.FakePayActionPay)
+	if err != nil {
+		abort(c, err)
+		return
+	}
+	// Processors notify the shop asynchronously,
+	// the payment is also checked when the buyer returns
+	header, body, err := p.Webhook(intent.Ref)
+	if err == nil {
+		err = h.s.PaymentWebhook(
+			c.Request.Context(), services.ProcessorFake, header, body)
+	}
+	if err != nil {
+		log.Error().Stack().Err(err).Msg("")
+	}
+	c.Redirect(http.StatusSeeOther, intent.ReturnURL)
+}
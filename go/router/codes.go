@@ -0,0 +1,54 @@
+package router
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shopd/shopd/go/share"
+	"github.com/shopd/shopd/www/api/admin/coupons"
+	"github.com/shopd/shopd/www/api/admin/vouchers"
+	content "github.com/shopd/shopd/www/content/admin/codes"
+	"github.com/shopd/shopd/www/view"
+)
+
+func (h *RouteHandler) GetCodes(c *gin.Context) {
+	c.Render(http.StatusOK, h.Content(c.Request, content.Index))
+}
+
+// ApiPostCoupons generates a batch of coupon codes
+func (h *RouteHandler) ApiPostCoupons(c *gin.Context) {
+	params := share.ParamsCouponsPost{}
+	err := c.ShouldBind(&params)
+	if err != nil {
+		_ = c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+	codes, err := h.s.Model.GenerateCoupons(
+		c.Request.Context(), params, sessionUserID(c))
+	if err != nil {
+		abort(c, err)
+		return
+	}
+	c.Render(http.StatusOK, h.Template(c.Request, coupons.Post(view.CodesPost{
+		Codes: codes,
+	})))
+}
+
+// ApiPostVouchers generates a batch of vouchers
+func (h *RouteHandler) ApiPostVouchers(c *gin.Context) {
+	params := share.ParamsVouchersPost{}
+	err := c.ShouldBind(&params)
+	if err != nil {
+		_ = c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+	codes, err := h.s.Model.GenerateVouchers(
+		c.Request.Context(), params, sessionUserID(c))
+	if err != nil {
+		abort(c, err)
+		return
+	}
+	c.Render(http.StatusOK, h.Template(c.Request, vouchers.Post(view.CodesPost{
+		Codes: codes,
+	})))
+}
@@ -0,0 +1,99 @@
+package router
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shopd/shopd/go/money"
+	"github.com/shopd/shopd/go/share"
+	"github.com/shopd/shopd/www/api/admin/currencies"
+	"github.com/shopd/shopd/www/api/currency"
+	content "github.com/shopd/shopd/www/content/admin/currencies"
+	"github.com/shopd/shopd/www/view"
+)
+
+// CookieCurrency is the name of the cookie with the display currency
+const CookieCurrency = "currency"
+
+// cookieCurrencyMaxAge in seconds
+const cookieCurrencyMaxAge = 365 * 24 * 60 * 60
+
+// ApiGetCurrency renders the currency switcher
+func (h *RouteHandler) ApiGetCurrency(c *gin.Context) {
+	data, err := h.s.Model.ExchangeRates(c.Request.Context())
+	if err != nil {
+		abort(c, err)
+		return
+	}
+	display, err := h.converter(c)
+	if err != nil {
+		abort(c, err)
+		return
+	}
+	c.Render(http.StatusOK, h.Template(c.Request, currency.Get(view.CurrencyGet{
+		ExchangeRates: data,
+		Selected:      display.Currency(),
+	})))
+}
+
+// ApiPostCurrency sets the display currency cookie,
+// the page is refreshed to show the converted prices
+func (h *RouteHandler) ApiPostCurrency(c *gin.Context) {
+	params := share.ParamsCurrencyPost{}
+	err := c.ShouldBind(&params)
+	if err != nil {
+		_ = c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+	c.SetSameSite(http.SameSiteLaxMode)
+	if params.Currency == "" {
+		c.SetCookie(CookieCurrency, "", -1, "/", "", true, true)
+	} else {
+		c.SetCookie(CookieCurrency, params.Currency, cookieCurrencyMaxAge,
+			"/", "", true, true)
+	}
+	c.Header("HX-Refresh", "true")
+	c.Status(http.StatusNoContent)
+}
+
+// converter for display prices in the currency selected by the buyer
+func (h *RouteHandler) converter(c *gin.Context) (money.Converter, error) {
+	selected, err := c.Cookie(CookieCurrency)
+	if err != nil {
+		selected = ""
+	}
+	return h.s.Model.Converter(c.Request.Context(), selected)
+}
+
+func (h *RouteHandler) GetCurrencies(c *gin.Context) {
+	c.Render(http.StatusOK, h.Content(c.Request, content.Index))
+}
+
+// ApiGetCurrencies lists the exchange rates
+func (h *RouteHandler) ApiGetCurrencies(c *gin.Context) {
+	data, err := h.s.Model.ExchangeRates(c.Request.Context())
+	if err != nil {
+		abort(c, err)
+		return
+	}
+	c.Render(http.StatusOK, h.Template(c.Request, currencies.Get(
+		view.CurrenciesGet{ExchangeRates: data})))
+}
+
+// ApiPostCurrencies sets the exchange rate for a currency
+func (h *RouteHandler) ApiPostCurrencies(c *gin.Context) {
+	params := share.ParamsExchangeRatePost{}
+	err := c.ShouldBind(&params)
+	if err != nil {
+		_ = c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+	data, err := h.s.Model.ExchangeRateSet(
+		c.Request.Context(), params, sessionUserID(c))
+	if err != nil {
+		abort(c, err)
+		return
+	}
+	c.Render(http.StatusOK, h.Template(c.Request, currencies.Get(
+		view.CurrenciesGet{ExchangeRates: data})))
+}
@@ -0,0 +1,124 @@
+package router
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+	"github.com/shopd/shopd/go/services"
+	"github.com/shopd/shopd/go/share"
+	"github.com/shopd/shopd/www/api/admin/catalog/img"
+	content "github.com/shopd/shopd/www/content/admin/catalog/img"
+	"github.com/shopd/shopd/www/view"
+)
+
+// ImgCacheControl for image files, file names include the hash
+// of the original so the content for a URL never changes
+const ImgCacheControl = "public, max-age=31536000, immutable"
+
+// imgUploadMaxBytes limits the request body for uploads,
+// allowing for the multipart form around the image
+const imgUploadMaxBytes = services.ImgMaxSize + 1<<20
+
+// GetImg serves original and resized images
+func (h *RouteHandler) GetImg(c *gin.Context) {
+	p, ok := h.s.ImgPath(c.Param("file"))
+	if !ok {
+		c.AbortWithStatus(http.StatusNotFound)
+		return
+	}
+	c.Header("Cache-Control", ImgCacheControl)
+	c.File(p)
+}
+
+func (h *RouteHandler) GetCatalogImg(c *gin.Context) {
+	c.Render(http.StatusOK, h.Content(c.Request, content.Index))
+}
+
+// ApiGetCatalogImg lists the images for the sku
+func (h *RouteHandler) ApiGetCatalogImg(c *gin.Context) {
+	params := share.ParamsCatImgGet{}
+	err := c.ShouldBind(&params)
+	if err != nil {
+		_ = c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+	data, err := h.s.Model.CatImgs(c.Request.Context(), params.Sku)
+	if err != nil {
+		abort(c, err)
+		return
+	}
+	h.renderCatalogImg(c, data)
+}
+
+// ApiPostCatalogImg links the uploaded image to the sku
+func (h *RouteHandler) ApiPostCatalogImg(c *gin.Context) {
+	c.Request.Body = http.MaxBytesReader(
+		c.Writer, c.Request.Body, imgUploadMaxBytes)
+	params := share.ParamsCatImgPost{}
+	err := c.ShouldBind(&params)
+	if err != nil {
+		maxErr := &http.MaxBytesError{}
+		if errors.As(err, &maxErr) {
+			_ = c.AbortWithError(http.StatusRequestEntityTooLarge, err)
+			return
+		}
+		_ = c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+	header, err := c.FormFile("File")
+	if err != nil {
+		_ = c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+	file, err := header.Open()
+	if err != nil {
+		_ = c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+	defer file.Close()
+	data, err := h.s.CatImgUpload(c.Request.Context(), file, params)
+	if err != nil {
+		abort(c, err)
+		return
+	}
+	h.renderCatalogImg(c, data)
+}
+
+// ApiPostCatalogImgOrder sorts the images for the sku
+func (h *RouteHandler) ApiPostCatalogImgOrder(c *gin.Context) {
+	params := share.ParamsCatImgOrderPost{}
+	err := c.ShouldBind(&params)
+	if err != nil {
+		_ = c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+	data, err := h.s.CatImgOrder(c.Request.Context(), params)
+	if err != nil {
+		abort(c, err)
+		return
+	}
+	h.renderCatalogImg(c, data)
+}
+
+// ApiDeleteCatalogImg unlinks the image from the sku
+func (h *RouteHandler) ApiDeleteCatalogImg(c *gin.Context) {
+	params := share.ParamsCatImgDelete{}
+	err := c.ShouldBind(&params)
+	if err != nil {
+		_ = c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+	data, err := h.s.CatImgDelete(c.Request.Context(), params)
+	if err != nil {
+		abort(c, err)
+		return
+	}
+	h.renderCatalogImg(c, data)
+}
+
+func (h *RouteHandler) renderCatalogImg(c *gin.Context, data share.CatImgs) {
+	c.Render(http.StatusOK, h.Template(c.Request, img.Get(view.CatalogImgGet{
+		CatImgs: data,
+	})))
+}
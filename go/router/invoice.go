@@ -0,0 +1,145 @@
+package router
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shopd/shopd/go/share"
+	"github.com/shopd/shopd/www/components"
+	"github.com/shopd/shopd/www/view"
+)
+
+// orderKeyPrefix distinguishes the order key from other signed order IDs,
+// e.g. the cart cookie
+const orderKeyPrefix = "order|"
+
+func (h *RouteHandler) GetInvoice(c *gin.Context) {
+	if !h.orderAccess(c, c.Param("id")) {
+		return
+	}
+	inv, err := h.s.Model.Invoice(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		abort(c, err)
+		return
+	}
+	h.renderInvoice(c, view.Invoice{Invoice: inv})
+}
+
+func (h *RouteHandler) GetReceipt(c *gin.Context) {
+	if !h.orderAccess(c, c.Param("id")) {
+		return
+	}
+	inv, err := h.s.Model.Receipt(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		abort(c, err)
+		return
+	}
+	model := view.Invoice{Invoice: inv, Receipt: true}
+	if inv.Due > 0 {
+		model.PayURL, err = h.orderURL(c, inv.OrderID, "pay")
+		if err != nil {
+			abort(c, err)
+			return
+		}
+	}
+	h.renderInvoice(c, model)
+}
+
+// PostOrderPay starts a payment for the amount due on the pending order,
+// buyers pay again from the receipt if checkout didn't complete
+func (h *RouteHandler) PostOrderPay(c *gin.Context) {
+	orderID := c.Param("id")
+	if !h.orderAccess(c, orderID) {
+		return
+	}
+	redirectURL, err := h.s.PaymentStart(
+		c.Request.Context(), orderID, sessionUserID(c))
+	if err != nil {
+		abort(c, err)
+		return
+	}
+	c.Redirect(http.StatusSeeOther, redirectURL)
+}
+
+// GetCreditNote renders a credit note for the order
+func (h *RouteHandler) GetCreditNote(c *gin.Context) {
+	if !h.orderAccess(c, c.Param("id")) {
+		return
+	}
+	doc, err := h.s.Model.CreditNote(
+		c.Request.Context(), c.Param("id"), c.Param("credit"))
+	if err != nil {
+		abort(c, err)
+		return
+	}
+	h.renderInvoice(c, view.Invoice{Invoice: doc})
+}
+
+// renderInvoice as HTML, or plain text if the format param is set
+func (h *RouteHandler) renderInvoice(c *gin.Context, model view.Invoice) {
+	if share.Query(c.Request.URL.Query(), share.ParamFormat) == share.FormatText {
+		c.String(http.StatusOK, model.Text())
+		return
+	}
+	c.Render(http.StatusOK, h.Template(c.Request, components.Invoice(model)))
+}
+
+// orderAccess aborts the request unless the session user is an admin,
+// or placed the order. Guests use the key from the checkout redirect
+func (h *RouteHandler) orderAccess(c *gin.Context, orderID string) (ok bool) {
+	ctx := c.Request.Context()
+	user := sessionUser(c)
+	if user.Role == share.RoleAdmin {
+		return true
+	}
+	userID, err := h.s.Model.OrderUserID(ctx, orderID)
+	if err != nil {
+		abort(c, err)
+		return false
+	}
+	if user.UserID != "" && user.UserID == userID {
+		return true
+	}
+	key := share.Query(c.Request.URL.Query(), share.ParamKey)
+	if userID == "" && key != "" {
+		val, ok, err := h.s.Model.Verify(ctx, key)
+		if err != nil {
+			abort(c, err)
+			return false
+		}
+		if ok && val == orderKeyPrefix+orderID {
+			return true
+		}
+	}
+	if user.UserID == "" {
+		c.AbortWithStatus(http.StatusUnauthorized)
+		return false
+	}
+	c.AbortWithStatus(http.StatusForbidden)
+	return false
+}
+
+// receiptURL for the buyer, guest orders include the signed order key
+func (h *RouteHandler) receiptURL(
+	c *gin.Context, orderID string) (u string, err error) {
+
+	return h.orderURL(c, orderID, "receipt")
+}
+
+// orderURL for the page of the order,
+// guest orders include the signed order key
+func (h *RouteHandler) orderURL(
+	c *gin.Context, orderID, page string) (u string, err error) {
+
+	u = fmt.Sprintf("/orders/%s/%s", orderID, page)
+	if sessionUserID(c) != "" {
+		return u, nil
+	}
+	key, err := h.s.Model.Sign(c.Request.Context(), orderKeyPrefix+orderID)
+	if err != nil {
+		return u, err
+	}
+	return fmt.Sprintf("%s?%s=%s", u, share.ParamKey, url.QueryEscape(key)), nil
+}
@@ -0,0 +1,81 @@
+package router
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+	"github.com/shopd/shopd/go/model"
+	"github.com/shopd/shopd/go/share"
+	"github.com/shopd/shopd/www/api/admin/journal"
+	content "github.com/shopd/shopd/www/content/admin/journal"
+	"github.com/shopd/shopd/www/view"
+)
+
+func (h *RouteHandler) GetJournal(c *gin.Context) {
+	c.Render(http.StatusOK, h.Content(c.Request, content.Index))
+}
+
+// ApiGetJournal lists previous journal exports
+func (h *RouteHandler) ApiGetJournal(c *gin.Context) {
+	h.renderJournal(c, "")
+}
+
+// ApiPostJournal exports documents for the period that were not exported before
+func (h *RouteHandler) ApiPostJournal(c *gin.Context) {
+	params := share.ParamsJournalPost{}
+	err := c.ShouldBind(&params)
+	if err != nil {
+		_ = c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+	export, err := h.s.Model.JournalExportCreate(
+		c.Request.Context(), params, sessionUserID(c))
+	if err != nil {
+		abort(c, err)
+		return
+	}
+	message := "No new documents for the period"
+	if export.Count > 0 {
+		message = fmt.Sprintf("Exported %d documents", export.Count)
+	}
+	h.renderJournal(c, message)
+}
+
+// ApiGetJournalExport downloads a previous export in the format
+func (h *RouteHandler) ApiGetJournalExport(c *gin.Context) {
+	query := c.Request.URL.Query()
+	format := share.Query(query, share.ParamFormat)
+	if !slices.Contains(share.JournalFormats, format) {
+		abort(c, errors.WithStack(model.ErrInvalidParam(share.ParamFormat)))
+		return
+	}
+	export, err := h.s.Model.JournalExport(
+		c.Request.Context(), share.Query(query, share.ParamExportID))
+	if err != nil {
+		abort(c, err)
+		return
+	}
+	file := view.JournalFile{JournalExport: export, Format: format}
+	c.Header("Content-Type", "text/csv")
+	c.Header("Content-Disposition",
+		fmt.Sprintf(`attachment; filename="%s"`, file.Filename()))
+	err = file.CSV(c.Writer)
+	if err != nil {
+		_ = c.Error(err)
+	}
+}
+
+func (h *RouteHandler) renderJournal(c *gin.Context, message string) {
+	list, err := h.s.Model.JournalExports(c.Request.Context())
+	if err != nil {
+		abort(c, err)
+		return
+	}
+	c.Render(http.StatusOK, h.Template(c.Request, journal.Get(view.JournalGet{
+		JournalExports: list,
+		Message:        message,
+	})))
+}
@@ -0,0 +1,226 @@
+package router
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shopd/shopd/go/share"
+	"github.com/shopd/shopd/www/api/admin/orders"
+	"github.com/shopd/shopd/www/api/admin/orders/discount"
+	"github.com/shopd/shopd/www/api/admin/orders/payments"
+	"github.com/shopd/shopd/www/api/admin/orders/refunds"
+	"github.com/shopd/shopd/www/api/admin/orders/state"
+	content "github.com/shopd/shopd/www/content/admin/orders"
+	"github.com/shopd/shopd/www/view"
+)
+
+func (h *RouteHandler) GetOrders(c *gin.Context) {
+	c.Render(http.StatusOK, h.Content(c.Request, content.Index))
+}
+
+func (h *RouteHandler) ApiGetOrders(c *gin.Context) {
+	query := c.Request.URL.Query()
+	list, err := h.s.Model.Orders(c.Request.Context(), share.OrderFilter{
+		State:  share.Query(query, share.ParamState),
+		Paid:   share.Query(query, share.ParamPaid),
+		Tag:    share.Query(query, share.ParamTag),
+		From:   share.Query(query, share.ParamFrom),
+		To:     share.Query(query, share.ParamTo),
+		UserID: share.Query(query, share.ParamUserID),
+		Search: share.Query(query, share.ParamSearch),
+		Cursor: share.Query(query, share.ParamCursor),
+	})
+	if err != nil {
+		abort(c, err)
+		return
+	}
+	c.Render(http.StatusOK, h.Template(c.Request, orders.Get(view.OrdersGet{
+		OrderList: list,
+	})))
+}
+
+// ApiPostOrdersState changes the state of the selected orders,
+// errors are listed per order
+func (h *RouteHandler) ApiPostOrdersState(c *gin.Context) {
+	ctx := c.Request.Context()
+	params := share.ParamsOrdersStatePost{}
+	err := c.ShouldBind(&params)
+	if err != nil {
+		_ = c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+
+	model := view.OrdersStatePost{}
+	for _, orderID := range params.OrderID {
+		result := share.OrderStateResult{}
+		err = h.s.Model.SetOrderState(ctx, orderID, params.State, sessionUserID(c))
+		if err != nil {
+			result.Error = err.Error()
+		}
+		result.Order, err = h.s.Model.OrderSummary(ctx, orderID)
+		if err != nil {
+			abort(c, err)
+			return
+		}
+		model.Results = append(model.Results, result)
+	}
+	c.Render(http.StatusOK, h.Template(c.Request, state.Post(model)))
+}
+
+// ApiGetOrdersDiscount lists the optional discounts for an order
+func (h *RouteHandler) ApiGetOrdersDiscount(c *gin.Context) {
+	orderID := share.Query(c.Request.URL.Query(), share.ParamOrderID)
+	list, err := h.s.Model.OrderDiscounts(c.Request.Context(), orderID)
+	if err != nil {
+		abort(c, err)
+		return
+	}
+	c.Render(http.StatusOK, h.Template(c.Request, discount.Get(view.OrdersDiscountGet{
+		OrderDiscounts: list,
+	})))
+}
+
+// ApiPostOrdersDiscount applies an optional discount to a pending order
+func (h *RouteHandler) ApiPostOrdersDiscount(c *gin.Context) {
+	ctx := c.Request.Context()
+	params := share.ParamsOrdersDiscountPost{}
+	err := c.ShouldBind(&params)
+	if err != nil {
+		_ = c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+	err = h.s.Model.ApplyDiscount(
+		ctx, params.OrderID, params.DiscountID, sessionUserID(c))
+	if err != nil {
+		abort(c, err)
+		return
+	}
+
+	model := view.OrdersDiscountPost{}
+	model.OrderDiscounts, err = h.s.Model.OrderDiscounts(ctx, params.OrderID)
+	if err != nil {
+		abort(c, err)
+		return
+	}
+	model.Order, err = h.s.Model.OrderSummary(ctx, params.OrderID)
+	if err != nil {
+		abort(c, err)
+		return
+	}
+	c.Render(http.StatusOK, h.Template(c.Request, discount.Post(model)))
+}
+
+// ApiGetOrdersPayments lists payments and the outstanding balance for an order
+func (h *RouteHandler) ApiGetOrdersPayments(c *gin.Context) {
+	orderID := share.Query(c.Request.URL.Query(), share.ParamOrderID)
+	list, err := h.s.Model.OrderPayments(c.Request.Context(), orderID)
+	if err != nil {
+		abort(c, err)
+		return
+	}
+	c.Render(http.StatusOK, h.Template(c.Request, payments.Get(view.OrdersPaymentsGet{
+		OrderPayments: list,
+	})))
+}
+
+// ApiPostOrdersPayments records a payment received by an admin,
+// e.g. EFT or cash on collection
+func (h *RouteHandler) ApiPostOrdersPayments(c *gin.Context) {
+	ctx := c.Request.Context()
+	params := share.ParamsOrdersPaymentPost{}
+	err := c.ShouldBind(&params)
+	if err != nil {
+		_ = c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+	_, err = h.s.PaymentRecord(ctx, params, sessionUserID(c))
+	if err != nil {
+		abort(c, err)
+		return
+	}
+
+	model := view.OrdersPaymentsPost{}
+	model.OrderPayments, err = h.s.Model.OrderPayments(ctx, params.OrderID)
+	if err != nil {
+		abort(c, err)
+		return
+	}
+	model.Order, err = h.s.Model.OrderSummary(ctx, params.OrderID)
+	if err != nil {
+		abort(c, err)
+		return
+	}
+	c.Render(http.StatusOK, h.Template(c.Request, payments.Post(model)))
+}
+
+// ApiGetOrdersRefunds lists credit notes for an order
+func (h *RouteHandler) ApiGetOrdersRefunds(c *gin.Context) {
+	orderID := share.Query(c.Request.URL.Query(), share.ParamOrderID)
+	list, err := h.s.Model.OrderRefunds(c.Request.Context(), orderID)
+	if err != nil {
+		abort(c, err)
+		return
+	}
+	c.Render(http.StatusOK, h.Template(c.Request, refunds.Get(view.OrdersRefundsGet{
+		OrderRefunds: list,
+	})))
+}
+
+// ApiPostOrdersRefunds issues a credit note, and refunds the money
+// with the selected method
+func (h *RouteHandler) ApiPostOrdersRefunds(c *gin.Context) {
+	ctx := c.Request.Context()
+	params := share.ParamsOrdersRefundPost{}
+	err := c.ShouldBind(&params)
+	if err != nil {
+		_ = c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+	_, err = h.s.Refund(ctx, params, sessionUserID(c))
+	if err != nil {
+		abort(c, err)
+		return
+	}
+
+	model := view.OrdersRefundsPost{}
+	model.OrderRefunds, err = h.s.Model.OrderRefunds(ctx, params.OrderID)
+	if err != nil {
+		abort(c, err)
+		return
+	}
+	model.Order, err = h.s.Model.OrderSummary(ctx, params.OrderID)
+	if err != nil {
+		abort(c, err)
+		return
+	}
+	c.Render(http.StatusOK, h.Template(c.Request, refunds.Post(model)))
+}
+
+// ApiPostOrdersRefundsRetry retries the processor refund for a credit note
+func (h *RouteHandler) ApiPostOrdersRefundsRetry(c *gin.Context) {
+	ctx := c.Request.Context()
+	params := share.ParamsOrdersRefundRetryPost{}
+	err := c.ShouldBind(&params)
+	if err != nil {
+		_ = c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+	err = h.s.RefundRetry(ctx, params, sessionUserID(c))
+	if err != nil {
+		abort(c, err)
+		return
+	}
+
+	model := view.OrdersRefundsPost{}
+	model.OrderRefunds, err = h.s.Model.OrderRefunds(ctx, params.OrderID)
+	if err != nil {
+		abort(c, err)
+		return
+	}
+	model.Order, err = h.s.Model.OrderSummary(ctx, params.OrderID)
+	if err != nil {
+		abort(c, err)
+		return
+	}
+	c.Render(http.StatusOK, h.Template(c.Request, refunds.Post(model)))
+}
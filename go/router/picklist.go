@@ -0,0 +1,40 @@
+package router
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shopd/shopd/go/share"
+	"github.com/shopd/shopd/www/api/admin/picklist"
+	content "github.com/shopd/shopd/www/content/admin/picklist"
+	"github.com/shopd/shopd/www/view"
+)
+
+func (h *RouteHandler) GetPicklist(c *gin.Context) {
+	c.Render(http.StatusOK, h.Content(c.Request, content.Index))
+}
+
+func (h *RouteHandler) ApiGetPicklist(c *gin.Context) {
+	ctx := c.Request.Context()
+	depots, err := h.s.Model.Depots(ctx)
+	if err != nil {
+		abort(c, err)
+		return
+	}
+
+	// Default to the preferred depot
+	depot := share.Query(c.Request.URL.Query(), share.ParamDepot)
+	if depot == "" && len(depots) > 0 {
+		depot = depots[0].Depot
+	}
+	list, err := h.s.Model.PickList(ctx, depot)
+	if err != nil {
+		abort(c, err)
+		return
+	}
+
+	c.Render(http.StatusOK, h.Template(c.Request, picklist.Get(view.PicklistGet{
+		Depots:   depots,
+		PickList: list,
+	})))
+}
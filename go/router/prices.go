@@ -0,0 +1,150 @@
+package router
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shopd/shopd/go/share"
+	"github.com/shopd/shopd/www/api/admin/prices"
+	"github.com/shopd/shopd/www/api/admin/prices/schedule"
+	"github.com/shopd/shopd/www/api/price"
+	content "github.com/shopd/shopd/www/content/admin/prices"
+	"github.com/shopd/shopd/www/view"
+)
+
+// ApiGetPrice renders the price of the sku for the session user,
+// tax inclusive prices are for the country of the cart
+func (h *RouteHandler) ApiGetPrice(c *gin.Context) {
+	params := share.ParamsPriceGet{}
+	err := c.ShouldBind(&params)
+	if err != nil {
+		_ = c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+	orderID, err := h.cartID(c)
+	if err != nil {
+		abort(c, err)
+		return
+	}
+	data, err := h.s.Model.SkuPrice(
+		c.Request.Context(), params.Sku, sessionUserID(c), orderID)
+	if err != nil {
+		abort(c, err)
+		return
+	}
+	display, err := h.converter(c)
+	if err != nil {
+		abort(c, err)
+		return
+	}
+	c.Render(http.StatusOK, h.Template(c.Request, price.Get(view.PriceGet{
+		SkuPrice: data,
+		Display:  display,
+	})))
+}
+
+func (h *RouteHandler) GetPrices(c *gin.Context) {
+	c.Render(http.StatusOK, h.Content(c.Request, content.Index))
+}
+
+// ApiPostPrices replaces the prices on a price list from the uploaded CSV
+func (h *RouteHandler) ApiPostPrices(c *gin.Context) {
+	params := share.ParamsPriceListPost{}
+	err := c.ShouldBind(&params)
+	if err != nil {
+		_ = c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+	header, err := c.FormFile("File")
+	if err != nil {
+		_ = c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+	file, err := header.Open()
+	if err != nil {
+		_ = c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+	defer file.Close()
+	data, err := h.s.Model.PriceListImport(
+		c.Request.Context(), params, file, sessionUserID(c))
+	if err != nil {
+		abort(c, err)
+		return
+	}
+	c.Render(http.StatusOK, h.Template(c.Request, prices.Post(view.PricesPost{
+		PriceListImport: data,
+	})))
+}
+
+// ApiPostPricesTiers replaces the quantity breaks for a sku
+func (h *RouteHandler) ApiPostPricesTiers(c *gin.Context) {
+	params := share.ParamsPriceTiersPost{}
+	err := c.ShouldBind(&params)
+	if err != nil {
+		_ = c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+	data, err := h.s.Model.PriceTiersSet(
+		c.Request.Context(), params, sessionUserID(c))
+	if err != nil {
+		abort(c, err)
+		return
+	}
+	c.Render(http.StatusOK, h.Template(c.Request, price.Get(view.PriceGet{
+		SkuPrice: data,
+	})))
+}
+
+// ApiGetPricesSchedule lists scheduled changes and the price history
+func (h *RouteHandler) ApiGetPricesSchedule(c *gin.Context) {
+	params := share.ParamsPriceGet{}
+	err := c.ShouldBind(&params)
+	if err != nil {
+		_ = c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+	data, err := h.s.Model.PriceSchedules(c.Request.Context(), params.Sku)
+	if err != nil {
+		abort(c, err)
+		return
+	}
+	c.Render(http.StatusOK, h.Template(c.Request, schedule.Get(
+		view.PricesScheduleGet{PriceSchedules: data})))
+}
+
+// ApiPostPricesSchedule schedules a change to the catalog price
+func (h *RouteHandler) ApiPostPricesSchedule(c *gin.Context) {
+	params := share.ParamsPriceSchedulePost{}
+	err := c.ShouldBind(&params)
+	if err != nil {
+		_ = c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+	data, err := h.s.Model.SchedulePrice(
+		c.Request.Context(), params, sessionUserID(c))
+	if err != nil {
+		abort(c, err)
+		return
+	}
+	c.Render(http.StatusOK, h.Template(c.Request, schedule.Get(
+		view.PricesScheduleGet{PriceSchedules: data})))
+}
+
+// ApiDeletePricesSchedule cancels a pending price change
+func (h *RouteHandler) ApiDeletePricesSchedule(c *gin.Context) {
+	params := share.ParamsPriceScheduleDelete{}
+	err := c.ShouldBind(&params)
+	if err != nil {
+		_ = c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+	data, err := h.s.Model.CancelPriceSchedule(
+		c.Request.Context(), params.PriceScheduleID, sessionUserID(c))
+	if err != nil {
+		abort(c, err)
+		return
+	}
+	c.Render(http.StatusOK, h.Template(c.Request, schedule.Get(
+		view.PricesScheduleGet{PriceSchedules: data})))
+}
@@ -0,0 +1,72 @@
+package router
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shopd/shopd/go/share"
+	"github.com/shopd/shopd/www/api/admin/payments/recon"
+	content "github.com/shopd/shopd/www/content/admin/payments/recon"
+	"github.com/shopd/shopd/www/view"
+)
+
+func (h *RouteHandler) GetPaymentsRecon(c *gin.Context) {
+	c.Render(http.StatusOK, h.Content(c.Request, content.Index))
+}
+
+// ApiGetPaymentsRecon renders the reconciliation report,
+// or downloads the transactions if the format param is csv
+func (h *RouteHandler) ApiGetPaymentsRecon(c *gin.Context) {
+	query := c.Request.URL.Query()
+	filter := share.ReconFilter{
+		Processor: share.Query(query, share.ParamProcessor),
+		State:     share.Query(query, share.ParamState),
+		From:      share.Query(query, share.ParamFrom),
+		To:        share.Query(query, share.ParamTo),
+	}
+	data, err := h.s.Model.Reconciliation(c.Request.Context(), filter)
+	if err != nil {
+		abort(c, err)
+		return
+	}
+	model := view.PaymentsReconGet{Reconciliation: data}
+	if share.Query(query, share.ParamFormat) == share.FormatCSV {
+		c.Header("Content-Type", "text/csv")
+		c.Header("Content-Disposition", `attachment; filename="payments.csv"`)
+		err = model.CSV(c.Writer)
+		if err != nil {
+			_ = c.Error(err)
+		}
+		return
+	}
+	c.Render(http.StatusOK, h.Template(c.Request, recon.Get(model)))
+}
+
+// ApiPostPaymentsRecon compares the uploaded settlement CSV to payments
+func (h *RouteHandler) ApiPostPaymentsRecon(c *gin.Context) {
+	filter := share.ReconFilter{}
+	err := c.ShouldBind(&filter)
+	if err != nil {
+		_ = c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+	header, err := c.FormFile("File")
+	if err != nil {
+		_ = c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+	file, err := header.Open()
+	if err != nil {
+		_ = c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+	defer file.Close()
+	data, err := h.s.Model.ReconSettlement(c.Request.Context(), filter, file)
+	if err != nil {
+		abort(c, err)
+		return
+	}
+	c.Render(http.StatusOK, h.Template(c.Request, recon.Post(view.PaymentsReconPost{
+		ReconSettlement: data,
+	})))
+}
@@ -6,8 +6,12 @@ import (
 
 	"github.com/a-h/templ"
 	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
 	"github.com/shopd/shopd-proto/go/share"
 	"github.com/shopd/shopd/go/config"
+	"github.com/shopd/shopd/go/model"
+	"github.com/shopd/shopd/go/services"
 	"github.com/shopd/shopd/www/components"
 	"github.com/shopd/shopd/www/view"
 )
@@ -17,6 +21,7 @@ type ContentFunc func(model view.Content) templ.Component
 
 type RouteHandler struct {
 	model view.Content
+	s     *services.Services
 }
 
 // Template renders a templ component
@@ -31,9 +36,8 @@ func (h *RouteHandler) Content(
 	return NewRenderer(r.Context(), components.Layout(h.model, content(h.model)))
 }
 
-// TODO Pass in services
-func NewRouter(conf *config.Config) *gin.Engine {
-	h := RouteHandler{}
+func NewRouter(conf *config.Config, s *services.Services) *gin.Engine {
+	h := RouteHandler{s: s}
 	h.model = view.NewContent(view.ContentParams{
 		BaseURL:      "https://localhost:8443/",  // TODO Use config
 		DomainConfig: share.DomainConfigExport{}, // TODO Domain config
@@ -41,6 +45,7 @@ func NewRouter(conf *config.Config) *gin.Engine {
 
 	r := gin.Default()
 	r.Use(gin.Recovery())
+	r.Use(h.session)
 
 	// TODO Zerolog Integration with Gin
 	// https://g.co/gemini/share/70fd8e96abb5
@@ -55,15 +60,158 @@ func NewRouter(conf *config.Config) *gin.Engine {
 
 	// login
 	r.GET("/login", h.GetLogin)
+	r.GET("/api/login", h.GetLoginVerify)
 	r.POST("/api/login", h.PostLoginAttempt)
 
+	// cart
+	r.GET("/cart", h.GetCart)
+	r.GET("/api/cart", h.ApiGetCart)
+	r.POST("/api/cart", h.ApiPostCart)
+	r.PATCH("/api/cart", h.ApiPatchCart)
+	r.POST("/api/cart/code", h.ApiPostCartCode)
+	r.DELETE("/api/cart/code", h.ApiDeleteCartCode)
+
+	// checkout
+	r.POST("/api/checkout", h.ApiPostCheckout)
+	r.GET(services.CheckoutReturnPath, h.GetCheckoutReturn)
+	if _, ok := s.Processors[services.ProcessorFake]; ok {
+		// Hosted page for the fake processor, only registered with stubs
+		r.GET(services.FakePayPath+":ref", h.GetFakePay)
+		r.POST(services.FakePayPath+":ref", h.PostFakePay)
+	}
+
+	// webhooks
+	r.POST("/api/webhooks/payments/:processor", h.ApiPostWebhookPayments)
+
+	// price
+	r.GET("/api/price", h.ApiGetPrice)
+	r.GET("/api/currency", h.ApiGetCurrency)
+	r.POST("/api/currency", h.ApiPostCurrency)
+
+	// variant
+	r.GET("/api/variant", h.ApiGetVariant)
+
+	// account
+	r.GET("/account", h.GetAccount)
+	r.GET("/api/account", h.ApiGetAccount)
+
+	// orders
+	r.GET("/orders/:id/invoice", h.GetInvoice)
+	r.GET("/orders/:id/receipt", h.GetReceipt)
+	r.POST("/orders/:id/pay", h.PostOrderPay)
+	r.GET("/orders/:id/credits/:credit", h.GetCreditNote)
+
+	// ...........................................................................
+	// Admin routes require a session with the admin role
+	admin := r.Group("/admin", h.admin)
+	apiAdmin := r.Group("/api/admin", h.admin)
+
+	// orders
+	admin.GET("/orders", h.GetOrders)
+	apiAdmin.GET("/orders", h.ApiGetOrders)
+	apiAdmin.POST("/orders/state", h.ApiPostOrdersState)
+	apiAdmin.GET("/orders/discount", h.ApiGetOrdersDiscount)
+	apiAdmin.POST("/orders/discount", h.ApiPostOrdersDiscount)
+	apiAdmin.GET("/orders/payments", h.ApiGetOrdersPayments)
+	apiAdmin.POST("/orders/payments", h.ApiPostOrdersPayments)
+	apiAdmin.GET("/orders/refunds", h.ApiGetOrdersRefunds)
+	apiAdmin.POST("/orders/refunds", h.ApiPostOrdersRefunds)
+	apiAdmin.POST("/orders/refunds/retry", h.ApiPostOrdersRefundsRetry)
+
+	// codes
+	admin.GET("/codes", h.GetCodes)
+	apiAdmin.POST("/coupons", h.ApiPostCoupons)
+	apiAdmin.POST("/vouchers", h.ApiPostVouchers)
+
+	// prices
+	admin.GET("/prices", h.GetPrices)
+	apiAdmin.POST("/prices", h.ApiPostPrices)
+	apiAdmin.POST("/prices/tiers", h.ApiPostPricesTiers)
+	apiAdmin.GET("/prices/schedule", h.ApiGetPricesSchedule)
+	apiAdmin.POST("/prices/schedule", h.ApiPostPricesSchedule)
+	apiAdmin.DELETE("/prices/schedule", h.ApiDeletePricesSchedule)
+
+	// currencies
+	admin.GET("/currencies", h.GetCurrencies)
+	apiAdmin.GET("/currencies", h.ApiGetCurrencies)
+	apiAdmin.POST("/currencies", h.ApiPostCurrencies)
+
+	// accounts
+	admin.GET("/accounts", h.GetAccounts)
+	apiAdmin.GET("/accounts", h.ApiGetAccounts)
+	apiAdmin.GET("/accounts/statement", h.ApiGetAccountsStatement)
+	apiAdmin.POST("/accounts/statement", h.ApiPostAccountsStatement)
+	apiAdmin.POST("/accounts/users", h.ApiPostAccountsUsers)
+
+	// payments
+	admin.GET("/payments/events", h.GetPaymentEvents)
+	apiAdmin.GET("/payments/events", h.ApiGetPaymentEvents)
+	apiAdmin.POST("/payments/events", h.ApiPostPaymentEvents)
+	admin.GET("/payments/recon", h.GetPaymentsRecon)
+	apiAdmin.GET("/payments/recon", h.ApiGetPaymentsRecon)
+	apiAdmin.POST("/payments/recon", h.ApiPostPaymentsRecon)
+
+	// journal
+	admin.GET("/journal", h.GetJournal)
+	apiAdmin.GET("/journal", h.ApiGetJournal)
+	apiAdmin.POST("/journal", h.ApiPostJournal)
+	apiAdmin.GET("/journal/export", h.ApiGetJournalExport)
+
+	// catalog
+	admin.GET("/catalog", h.GetCatalog)
+	apiAdmin.POST("/catalog", h.ApiPostCatalog)
+	apiAdmin.GET("/catalog/export", h.ApiGetCatalogExport)
+	admin.GET("/catalog/img", h.GetCatalogImg)
+	apiAdmin.GET("/catalog/img", h.ApiGetCatalogImg)
+	apiAdmin.POST("/catalog/img", h.ApiPostCatalogImg)
+	apiAdmin.POST("/catalog/img/order", h.ApiPostCatalogImgOrder)
+	apiAdmin.DELETE("/catalog/img", h.ApiDeleteCatalogImg)
+
+	// variants
+	admin.GET("/variants", h.GetVariants)
+	apiAdmin.POST("/variants", h.ApiPostVariants)
+
+	// picklist
+	admin.GET("/picklist", h.GetPicklist)
+	apiAdmin.GET("/picklist", h.ApiGetPicklist)
+
 	// static
 	staticRoot := filepath.Join(conf.Dir(), "www", "static")
 	r.Static("/s", staticRoot)
 
+	// images
+	r.GET(view.ImgPrefix+"/:file", h.GetImg)
+
 	return r
 }
 
+// abort the request with the status code for err
+func abort(c *gin.Context, err error) {
+	status := http.StatusInternalServerError
+	if errors.Is(err, model.ErrNotFound("")) {
+		status = http.StatusNotFound
+	} else if errors.Is(err, model.ErrOrderNotPaid("")) ||
+		errors.Is(err, model.ErrOrderPaid("")) ||
+		errors.Is(err, model.ErrOrderState("", "")) ||
+		errors.Is(err, model.ErrOrderPayments("")) ||
+		errors.Is(err, model.ErrCouponLimit("")) ||
+		errors.Is(err, model.ErrStateTransition("", "")) {
+		status = http.StatusConflict
+	} else if errors.Is(err, model.ErrInvalidQty(0)) ||
+		errors.Is(err, model.ErrInvalidParam("")) ||
+		errors.Is(err, model.ErrDiscountNotApplicable("")) ||
+		errors.Is(err, model.ErrInvalidCode("")) ||
+		errors.Is(err, model.ErrUnavailable("")) {
+		status = http.StatusBadRequest
+	} else if errors.Is(err, model.ErrSessionVerify("")) ||
+		errors.Is(err, model.ErrSignature("")) {
+		status = http.StatusUnauthorized
+	} else {
+		log.Error().Stack().Err(err).Msg("")
+	}
+	_ = c.AbortWithError(status, err)
+}
+
 // TODO Is this even useful? Rather don't match errors,
 // and remove go/middleware/errorhandler.go
 func ErrorMatcher(err error) (obj any, matched bool) {
@@ -4,11 +4,19 @@ import (
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+	"github.com/shopd/shopd/go/model"
+	"github.com/shopd/shopd/go/share"
 	"github.com/shopd/shopd/www/api/login"
 	content "github.com/shopd/shopd/www/content/login"
 	"github.com/shopd/shopd/www/view"
 )
 
+// CookieSession is the name of the cookie with the session token
+const CookieSession = "session"
+
+// ctxSessionUser is the gin context key for the session user
+const ctxSessionUser = "sessionUser"
+
 func (h *RouteHandler) GetLogin(c *gin.Context) {
 	c.Render(http.StatusOK, h.Content(c.Request, content.Index))
 }
@@ -16,3 +24,88 @@ func (h *RouteHandler) GetLogin(c *gin.Context) {
 func (h *RouteHandler) PostLoginAttempt(c *gin.Context) {
 	c.Render(http.StatusOK, h.Template(c.Request, login.Post(view.LoginPost{})))
 }
+
+// GetLoginVerify is the email verification step, i.e. the login link.
+// The guest cart is converted to the user's cart
+func (h *RouteHandler) GetLoginVerify(c *gin.Context) {
+	ctx := c.Request.Context()
+	query := c.Request.URL.Query()
+	userID := share.Query(query, share.ParamUserID)
+	err := h.s.Model.SessionVerify(ctx, userID, share.Query(query, share.ParamOtp))
+	if err != nil {
+		abort(c, err)
+		return
+	}
+	token, err := h.s.Model.SessionToken(ctx, userID)
+	if err != nil {
+		abort(c, err)
+		return
+	}
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(CookieSession, token,
+		int(model.SessionMaxAge.Seconds()), "/", "", true, true)
+	c.Set(ctxSessionUser, share.SessionUser{UserID: userID})
+
+	orderID, err := h.cartID(c)
+	if err != nil {
+		abort(c, err)
+		return
+	}
+	cart, err := h.s.Model.CartMerge(ctx, orderID, userID)
+	if err != nil {
+		abort(c, err)
+		return
+	}
+	err = h.setCartID(c, cart.OrderID)
+	if err != nil {
+		abort(c, err)
+		return
+	}
+
+	c.Render(http.StatusOK, h.Template(c.Request, login.Get(view.LoginGet{})))
+}
+
+// sessionUserID returns the user ID for the session,
+// or empty string for guests
+func sessionUserID(c *gin.Context) string {
+	return sessionUser(c).UserID
+}
+
+// sessionUser is set by the session middleware, empty for guests
+func sessionUser(c *gin.Context) share.SessionUser {
+	user, _ := c.Value(ctxSessionUser).(share.SessionUser)
+	return user
+}
+
+// session middleware reads the user from the session cookie,
+// invalid or expired tokens are ignored
+func (h *RouteHandler) session(c *gin.Context) {
+	token, err := c.Cookie(CookieSession)
+	if err != nil {
+		c.Next()
+		return
+	}
+	user, ok, err := h.s.Model.SessionUser(c.Request.Context(), token)
+	if err != nil {
+		abort(c, err)
+		return
+	}
+	if ok {
+		c.Set(ctxSessionUser, user)
+	}
+	c.Next()
+}
+
+// admin middleware requires a session with the admin role
+func (h *RouteHandler) admin(c *gin.Context) {
+	user := sessionUser(c)
+	if user.UserID == "" {
+		c.AbortWithStatus(http.StatusUnauthorized)
+		return
+	}
+	if user.Role != share.RoleAdmin {
+		c.AbortWithStatus(http.StatusForbidden)
+		return
+	}
+	c.Next()
+}
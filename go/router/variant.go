@@ -0,0 +1,53 @@
+package router
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shopd/shopd/go/share"
+	"github.com/shopd/shopd/www/api/admin/variants"
+	"github.com/shopd/shopd/www/api/variant"
+	content "github.com/shopd/shopd/www/content/admin/variants"
+	"github.com/shopd/shopd/www/view"
+)
+
+// ApiGetVariant renders attribute selectors for the variants of the sku
+func (h *RouteHandler) ApiGetVariant(c *gin.Context) {
+	params := share.ParamsVariantGet{}
+	err := c.ShouldBind(&params)
+	if err != nil {
+		_ = c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+	data, err := h.s.Model.VariantGroup(c.Request.Context(), params.Sku)
+	if err != nil {
+		abort(c, err)
+		return
+	}
+	c.Render(http.StatusOK, h.Template(c.Request, variant.Get(view.VariantGet{
+		VariantGroup: data,
+	})))
+}
+
+func (h *RouteHandler) GetVariants(c *gin.Context) {
+	c.Render(http.StatusOK, h.Content(c.Request, content.Index))
+}
+
+// ApiPostVariants generates variants from a matrix of attribute values
+func (h *RouteHandler) ApiPostVariants(c *gin.Context) {
+	params := share.ParamsVariantsPost{}
+	err := c.ShouldBind(&params)
+	if err != nil {
+		_ = c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+	data, err := h.s.GenerateVariants(
+		c.Request.Context(), params, sessionUserID(c))
+	if err != nil {
+		abort(c, err)
+		return
+	}
+	c.Render(http.StatusOK, h.Template(c.Request, variants.Post(view.VariantsPost{
+		VariantsGenerate: data,
+	})))
+}
@@ -0,0 +1,68 @@
+package router
+
+import (
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+	"github.com/shopd/shopd/go/share"
+	"github.com/shopd/shopd/www/api/admin/payments/events"
+	content "github.com/shopd/shopd/www/content/admin/payments/events"
+	"github.com/shopd/shopd/www/view"
+)
+
+// ApiPostWebhookPayments receives payment events from processors,
+// the signature is checked by the processor named in the path
+func (h *RouteHandler) ApiPostWebhookPayments(c *gin.Context) {
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		_ = c.AbortWithError(http.StatusBadRequest, errors.WithStack(err))
+		return
+	}
+	err = h.s.PaymentWebhook(
+		c.Request.Context(), c.Param("processor"), c.Request.Header, body)
+	if err != nil {
+		abort(c, err)
+		return
+	}
+	c.Status(http.StatusOK)
+}
+
+func (h *RouteHandler) GetPaymentEvents(c *gin.Context) {
+	c.Render(http.StatusOK, h.Content(c.Request, content.Index))
+}
+
+// ApiGetPaymentEvents lists webhook events, failed events by default
+func (h *RouteHandler) ApiGetPaymentEvents(c *gin.Context) {
+	handled := share.Query(c.Request.URL.Query(), share.ParamHandled)
+	h.renderPaymentEvents(c, handled)
+}
+
+// ApiPostPaymentEvents retries a failed event
+func (h *RouteHandler) ApiPostPaymentEvents(c *gin.Context) {
+	params := share.ParamsPaymentEventPost{}
+	err := c.ShouldBind(&params)
+	if err != nil {
+		_ = c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+	_, err = h.s.PaymentEventHandle(c.Request.Context(),
+		params.Processor, params.EventID, sessionUserID(c))
+	if err != nil {
+		abort(c, err)
+		return
+	}
+	h.renderPaymentEvents(c, share.PaymentEventFailed)
+}
+
+func (h *RouteHandler) renderPaymentEvents(c *gin.Context, handled string) {
+	list, err := h.s.Model.PaymentEvents(c.Request.Context(), handled)
+	if err != nil {
+		abort(c, err)
+		return
+	}
+	c.Render(http.StatusOK, h.Template(c.Request, events.Get(view.PaymentEventsGet{
+		PaymentEvents: list,
+	})))
+}
@@ -0,0 +1,27 @@
+package services
+
+import (
+	"context"
+	"io"
+
+	"github.com/rs/zerolog/log"
+	"github.com/shopd/shopd/go/share"
+)
+
+// CatalogImport imports the catalog CSV,
+// and rebuilds static pages for skus that changed
+func (s *Services) CatalogImport(
+	ctx context.Context, r io.Reader, dryRun bool, userID string) (
+	result share.CatalogImport, err error) {
+
+	result, err = s.Model.CatalogImport(ctx, r, dryRun, userID)
+	if err != nil {
+		return result, err
+	}
+	if result.DryRun || len(result.Errors) > 0 || len(result.Skus) == 0 {
+		return result, nil
+	}
+	log.Info().Int("created", result.Created).Int("updated", result.Updated).
+		Msg("catalog imported")
+	return result, s.Site.Rebuild(ctx, result.Skus)
+}
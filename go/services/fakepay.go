@@ -0,0 +1,218 @@
+package services
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"sync"
+
+	"github.com/pkg/errors"
+	"github.com/segmentio/ksuid"
+	"github.com/shopd/shopd/go/model"
+	"github.com/shopd/shopd/go/share"
+)
+
+// ProcessorFake is the name of the local fake processor
+const ProcessorFake = "fake"
+
+// FakeSignatureHeader is the webhook header with the hex encoded
+// HMAC-SHA256 of the request body
+const FakeSignatureHeader = "Fake-Signature"
+
+// FakePayPath is the route for the fake hosted page,
+// the processor reference is appended
+const FakePayPath = "/pay/fake/"
+
+// FakeProcessor keeps payments in memory, and renders a local hosted page
+// where the buyer pays or declines. Useful for dev and offline testing.
+// Webhook events are signed with a key generated on startup
+type FakeProcessor struct {
+	BaseURL string
+	mu      sync.Mutex
+	intents map[string]share.FakeIntent
+	key     []byte
+}
+
+// fakeEvent is the webhook request body
+type fakeEvent struct {
+	EventID string
+	Ref     string
+	State   string
+	Amount  int64
+}
+
+func NewFakeProcessor(baseURL string) *FakeProcessor {
+	key := make([]byte, 32)
+	_, _ = rand.Read(key)
+	return &FakeProcessor{
+		BaseURL: baseURL,
+		intents: make(map[string]share.FakeIntent),
+		key:     key,
+	}
+}
+
+func (p *FakeProcessor) CreateIntent(
+	ctx context.Context, pay share.Payment, returnURL string) (
+	ref string, err error) {
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	ref = "fake_" + ksuid.New().String()
+	p.intents[ref] = share.FakeIntent{
+		Ref:       ref,
+		TranID:    pay.TranID,
+		Amount:    pay.Amount,
+		Currency:  pay.Currency,
+		ReturnURL: returnURL,
+		State:     share.PaymentPending,
+	}
+	return ref, nil
+}
+
+func (p *FakeProcessor) RedirectURL(ref string) string {
+	return p.BaseURL + FakePayPath + ref
+}
+
+func (p *FakeProcessor) Capture(
+	ctx context.Context, ref string, amount int64) (state string, err error) {
+
+	return p.update(ref, func(intent *share.FakeIntent) error {
+		if intent.State != share.PaymentAuthorized || amount != intent.Amount {
+			return errors.WithStack(
+				model.ErrStateTransition(intent.State, share.PaymentCaptured))
+		}
+		intent.State = share.PaymentCaptured
+		return nil
+	})
+}
+
+func (p *FakeProcessor) Refund(
+	ctx context.Context, ref string, amount int64) (state string, err error) {
+
+	return p.update(ref, func(intent *share.FakeIntent) error {
+		if intent.State != share.PaymentCaptured &&
+			intent.State != share.PaymentRefunded {
+			return errors.WithStack(
+				model.ErrStateTransition(intent.State, share.PaymentRefunded))
+		}
+		if amount <= 0 || intent.Refunded+amount > intent.Amount {
+			return errors.WithStack(model.ErrInvalidParam("Amount"))
+		}
+		intent.Refunded += amount
+		intent.State = share.PaymentRefunded
+		return nil
+	})
+}
+
+func (p *FakeProcessor) Status(
+	ctx context.Context, ref string) (state string, err error) {
+
+	intent, err := p.Intent(ref)
+	if err != nil {
+		return state, err
+	}
+	return intent.State, nil
+}
+
+func (p *FakeProcessor) Event(
+	header http.Header, body []byte) (event share.PaymentEvent, err error) {
+
+	sig, err := hex.DecodeString(header.Get(FakeSignatureHeader))
+	if err != nil || !hmac.Equal(sig, p.sign(body)) {
+		return event, errors.WithStack(model.ErrSignature(ProcessorFake))
+	}
+	e := fakeEvent{}
+	err = json.Unmarshal(body, &e)
+	if err != nil {
+		return event, errors.WithStack(model.ErrInvalidParam("body"))
+	}
+	return share.PaymentEvent{
+		EventID: e.EventID,
+		Ref:     e.Ref,
+		State:   e.State,
+		Amount:  e.Amount,
+	}, nil
+}
+
+// Webhook returns a signed event with the current state of the payment,
+// as the processor would send it
+func (p *FakeProcessor) Webhook(ref string) (
+	header http.Header, body []byte, err error) {
+
+	intent, err := p.Intent(ref)
+	if err != nil {
+		return header, body, err
+	}
+	body, err = json.Marshal(fakeEvent{
+		EventID: "evt_" + ksuid.New().String(),
+		Ref:     intent.Ref,
+		State:   intent.State,
+		Amount:  intent.Amount,
+	})
+	if err != nil {
+		return header, body, errors.WithStack(err)
+	}
+	header = http.Header{}
+	header.Set(FakeSignatureHeader, hex.EncodeToString(p.sign(body)))
+	return header, body, nil
+}
+
+func (p *FakeProcessor) sign(body []byte) []byte {
+	mac := hmac.New(sha256.New, p.key)
+	mac.Write(body)
+	return mac.Sum(nil)
+}
+
+// Intent returns the payment for the hosted page
+func (p *FakeProcessor) Intent(ref string) (intent share.FakeIntent, err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	intent, ok := p.intents[ref]
+	if !ok {
+		return intent, errors.WithStack(model.ErrNotFound(ref))
+	}
+	return intent, nil
+}
+
+// Authorize is called when the buyer pays, or declines if ok is false
+func (p *FakeProcessor) Authorize(ref string, ok bool) (
+	intent share.FakeIntent, err error) {
+
+	_, err = p.update(ref, func(intent *share.FakeIntent) error {
+		if intent.State != share.PaymentPending {
+			return errors.WithStack(
+				model.ErrStateTransition(intent.State, share.PaymentAuthorized))
+		}
+		intent.State = share.PaymentFailed
+		if ok {
+			intent.State = share.PaymentAuthorized
+		}
+		return nil
+	})
+	if err != nil {
+		return intent, err
+	}
+	return p.Intent(ref)
+}
+
+// update the intent with fn, the state is returned
+func (p *FakeProcessor) update(
+	ref string, fn func(intent *share.FakeIntent) error) (state string, err error) {
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	intent, ok := p.intents[ref]
+	if !ok {
+		return state, errors.WithStack(model.ErrNotFound(ref))
+	}
+	err = fn(&intent)
+	if err != nil {
+		return state, err
+	}
+	p.intents[ref] = intent
+	return intent.State, nil
+}
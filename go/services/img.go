@@ -0,0 +1,173 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"path/filepath"
+	"regexp"
+
+	"github.com/pkg/errors"
+	"github.com/shopd/shopd/go/fileutil"
+	"github.com/shopd/shopd/go/model"
+	"github.com/shopd/shopd/go/share"
+	"golang.org/x/image/draw"
+)
+
+// ImgDir is the dir for uploaded images and resized variants
+const ImgDir = "img"
+
+// ImgMaxSize is the max size of an uploaded image in bytes
+const ImgMaxSize = 20 << 20
+
+// ImgMaxPixels is the max width times height of an uploaded image,
+// small files may decode to large images
+const ImgMaxPixels = 40_000_000
+
+// ImgQuality for resized JPEG variants
+const ImgQuality = 85
+
+// imgFileRegexp matches file names for originals and resized variants
+var imgFileRegexp = regexp.MustCompile(`^[0-9a-f]{64}(-[0-9]+)?\.(jpg|png)$`)
+
+// ImgPath is the path for the image file name,
+// ok is false if the name is invalid or the file doesn't exist
+func (s *Services) ImgPath(file string) (p string, ok bool) {
+	if !imgFileRegexp.MatchString(file) {
+		return p, false
+	}
+	p = filepath.Join(s.imgDir, file)
+	return p, fileutil.PathExists(p)
+}
+
+// CatImgUpload stores the uploaded JPEG or PNG image, links it to the sku,
+// and rebuilds static pages for the sku
+func (s *Services) CatImgUpload(
+	ctx context.Context, r io.Reader, params share.ParamsCatImgPost) (
+	imgs share.CatImgs, err error) {
+
+	img, err := s.imgStore(r)
+	if err != nil {
+		return imgs, err
+	}
+	img.Alt = params.Alt
+	img.Descr = params.Descr
+	imgs, err = s.Model.CatImgAdd(ctx, params.Sku, img)
+	if err != nil {
+		return imgs, err
+	}
+	return imgs, s.Site.Rebuild(ctx, []string{params.Sku})
+}
+
+// CatImgOrder sorts the images for the sku,
+// and rebuilds static pages for the sku
+func (s *Services) CatImgOrder(
+	ctx context.Context, params share.ParamsCatImgOrderPost) (
+	imgs share.CatImgs, err error) {
+
+	imgs, err = s.Model.CatImgOrder(ctx, params)
+	if err != nil {
+		return imgs, err
+	}
+	return imgs, s.Site.Rebuild(ctx, []string{params.Sku})
+}
+
+// CatImgDelete unlinks the image from the sku,
+// and rebuilds static pages for the sku
+func (s *Services) CatImgDelete(
+	ctx context.Context, params share.ParamsCatImgDelete) (
+	imgs share.CatImgs, err error) {
+
+	imgs, err = s.Model.CatImgDelete(ctx, params)
+	if err != nil {
+		return imgs, err
+	}
+	return imgs, s.Site.Rebuild(ctx, []string{params.Sku})
+}
+
+// imgStore writes the original image to the img dir, named by the hash
+// of the file, and resized variants for share.ImgWidths.
+// Files are not written again if the image was uploaded before
+func (s *Services) imgStore(r io.Reader) (img share.Img, err error) {
+	b, err := io.ReadAll(io.LimitReader(r, ImgMaxSize+1))
+	if err != nil {
+		return img, errors.WithStack(err)
+	}
+	if len(b) > ImgMaxSize {
+		return img, errors.WithStack(model.ErrInvalidParam("File"))
+	}
+	conf, _, err := image.DecodeConfig(bytes.NewReader(b))
+	if err != nil {
+		return img, errors.WithStack(model.ErrInvalidParam("File"))
+	}
+	if int64(conf.Width)*int64(conf.Height) > ImgMaxPixels {
+		return img, errors.WithStack(model.ErrInvalidParam("File"))
+	}
+	src, format, err := image.Decode(bytes.NewReader(b))
+	if err != nil {
+		return img, errors.WithStack(model.ErrInvalidParam("File"))
+	}
+	switch format {
+	case "jpeg":
+		img.Ext = share.ImgExtJPEG
+	case "png":
+		img.Ext = share.ImgExtPNG
+	default:
+		return img, errors.WithStack(model.ErrInvalidParam("File"))
+	}
+	sum := sha256.Sum256(b)
+	img.Hash = hex.EncodeToString(sum[:])
+
+	err = fileutil.MkdirAll(s.imgDir)
+	if err != nil {
+		return img, err
+	}
+	p := filepath.Join(s.imgDir, share.ImgFile(img.Hash, img.Ext, 0))
+	if !fileutil.PathExists(p) {
+		err = fileutil.WriteBytes(p, b)
+		if err != nil {
+			return img, err
+		}
+	}
+	for _, width := range share.ImgWidths {
+		p := filepath.Join(s.imgDir, share.ImgFile(img.Hash, img.Ext, width))
+		if fileutil.PathExists(p) {
+			continue
+		}
+		b, err := imgResize(src, img.Ext, width)
+		if err != nil {
+			return img, err
+		}
+		err = fileutil.WriteBytes(p, b)
+		if err != nil {
+			return img, err
+		}
+	}
+	return img, nil
+}
+
+// imgResize scales the image down to width, keeping the aspect ratio.
+// Images narrower than width are encoded at the original size
+func imgResize(src image.Image, ext string, width int) (b []byte, err error) {
+	bounds := src.Bounds()
+	width = min(width, bounds.Dx())
+	height := max(1, bounds.Dy()*width/bounds.Dx())
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), src, bounds, draw.Over, nil)
+
+	buf := bytes.Buffer{}
+	if ext == share.ImgExtPNG {
+		err = png.Encode(&buf, dst)
+	} else {
+		err = jpeg.Encode(&buf, dst, &jpeg.Options{Quality: ImgQuality})
+	}
+	if err != nil {
+		return b, errors.WithStack(err)
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,119 @@
+package services
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/matryer/is"
+	"github.com/pkg/errors"
+	"github.com/shopd/shopd/go/model"
+	"github.com/shopd/shopd/go/share"
+)
+
+// testPNG encodes a solid image of the size
+func testPNG(t *testing.T, width, height int) []byte {
+	is := is.New(t)
+	src := image.NewRGBA(image.Rect(0, 0, width, height))
+	for x := range width {
+		src.Set(x, 0, color.RGBA{R: 255, A: 255})
+	}
+	buf := bytes.Buffer{}
+	is.NoErr(png.Encode(&buf, src))
+	return buf.Bytes()
+}
+
+// pngSize sets the size in the PNG header, without changing the pixels
+func pngSize(b []byte, width, height int) []byte {
+	b = bytes.Clone(b)
+	// IHDR data follows the signature, chunk length, and type
+	binary.BigEndian.PutUint32(b[16:], uint32(width))
+	binary.BigEndian.PutUint32(b[20:], uint32(height))
+	binary.BigEndian.PutUint32(b[29:], crc32.ChecksumIEEE(b[12:29]))
+	return b
+}
+
+func TestImgStore(t *testing.T) {
+	is := is.New(t)
+	s := &Services{imgDir: filepath.Join(t.TempDir(), ImgDir)}
+	b := testPNG(t, 800, 400)
+
+	img, err := s.imgStore(bytes.NewReader(b))
+	is.NoErr(err)
+	is.Equal(img.Ext, share.ImgExtPNG)
+	is.Equal(len(img.Hash), 64)
+
+	// Variants are not wider than the original
+	for _, tc := range []struct {
+		width int
+		want  image.Point
+	}{
+		{0, image.Pt(800, 400)},
+		{320, image.Pt(320, 160)},
+		{640, image.Pt(640, 320)},
+		{1280, image.Pt(800, 400)},
+	} {
+		p, ok := s.ImgPath(share.ImgFile(img.Hash, img.Ext, tc.width))
+		is.True(ok)
+		f, err := os.Open(p)
+		is.NoErr(err)
+		conf, format, err := image.DecodeConfig(f)
+		_ = f.Close()
+		is.NoErr(err)
+		is.Equal(format, "png")
+		is.Equal(image.Pt(conf.Width, conf.Height), tc.want)
+	}
+
+	// Uploading the same file again doesn't write the files again
+	p, _ := s.ImgPath(share.ImgFile(img.Hash, img.Ext, 320))
+	is.NoErr(os.WriteFile(p, []byte("cached"), 0644))
+	again, err := s.imgStore(bytes.NewReader(b))
+	is.NoErr(err)
+	is.Equal(again.Hash, img.Hash)
+	cached, err := os.ReadFile(p)
+	is.NoErr(err)
+	is.Equal(string(cached), "cached")
+}
+
+func TestImgStoreInvalid(t *testing.T) {
+	is := is.New(t)
+	s := &Services{imgDir: filepath.Join(t.TempDir(), ImgDir)}
+	for _, r := range [][]byte{
+		[]byte("not an image"),
+		// Small files may decode to large images
+		pngSize(testPNG(t, 8, 5), 8000, 5001),
+		bytes.Repeat([]byte{0}, ImgMaxSize+1),
+	} {
+		_, err := s.imgStore(bytes.NewReader(r))
+		is.True(errors.Is(err, model.ErrInvalidParam("")))
+	}
+	_, err := os.Stat(s.imgDir)
+	is.True(os.IsNotExist(err))
+}
+
+func TestImgPath(t *testing.T) {
+	is := is.New(t)
+	s := &Services{imgDir: t.TempDir()}
+	hash := strings.Repeat("a", 64)
+	is.NoErr(os.WriteFile(filepath.Join(s.imgDir, hash+".jpg"), nil, 0644))
+	for _, tc := range []struct {
+		file string
+		ok   bool
+	}{
+		{hash + ".jpg", true},
+		{hash + "-320.jpg", false}, // not found
+		{hash + ".gif", false},
+		{"../" + hash + ".jpg", false},
+		{strings.Repeat("A", 64) + ".jpg", false},
+	} {
+		_, ok := s.ImgPath(tc.file)
+		is.Equal(ok, tc.ok)
+	}
+}
@@ -0,0 +1,73 @@
+package services
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+	"github.com/shopd/shopd/go/model"
+	"github.com/shopd/shopd/go/share"
+	"github.com/shopd/shopd/www/components"
+	"github.com/shopd/shopd/www/view"
+)
+
+// InvoiceEmail sends the tax invoice to the buyer of a paid order,
+// the HTML and plain text invoice are attached
+func (s *Services) InvoiceEmail(ctx context.Context, orderID string) (err error) {
+	inv, err := s.Model.Invoice(ctx, orderID)
+	if err != nil {
+		return err
+	}
+	if inv.Buyer.Email == "" {
+		return nil
+	}
+	doc := view.Invoice{Invoice: inv}
+	attachments, err := components.InvoiceAttachments(ctx, doc)
+	if err != nil {
+		return err
+	}
+	return s.Mailer.Send(ctx, share.Email{
+		To:          inv.Buyer.Email,
+		Subject:     fmt.Sprintf("%s %s", doc.Title(), inv.OrderNo),
+		Body:        doc.Text(),
+		Attachments: attachments,
+	})
+}
+
+// paidEmail sends the invoice if the payment settled the order.
+// The payment is already recorded, so errors are logged and not returned
+func (s *Services) paidEmail(ctx context.Context, pay share.Payment) {
+	if pay.State != share.TranStateSuccess || pay.Amount <= 0 {
+		return
+	}
+	s.invoiceEmailLog(ctx, pay.OrderID)
+}
+
+// invoiceEmailLog sends the invoice and logs errors,
+// orders that are not paid in full are skipped
+func (s *Services) invoiceEmailLog(ctx context.Context, orderID string) {
+	err := s.InvoiceEmail(ctx, orderID)
+	if err != nil && !errors.Is(err, model.ErrOrderNotPaid("")) {
+		log.Error().Stack().Err(err).Str("order", orderID).Msg("invoice email")
+	}
+}
+
+// Checkout places the pending order for the cart. Vouchers may cover the
+// total, the order is paid then and the invoice is sent
+func (s *Services) Checkout(
+	ctx context.Context, orderID, userID string) (paid bool, err error) {
+
+	err = s.Model.SetOrderState(ctx, orderID, share.OrderStatePending, userID)
+	if err != nil {
+		return paid, err
+	}
+	order, err := s.Model.OrderSummary(ctx, orderID)
+	if err != nil {
+		return paid, err
+	}
+	if order.Paid {
+		s.invoiceEmailLog(ctx, orderID)
+	}
+	return order.Paid, nil
+}
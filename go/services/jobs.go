@@ -0,0 +1,131 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/shopd/shopd/go/share"
+)
+
+// JobInterval is the time between background job runs
+const JobInterval = 15 * time.Minute
+
+// PriceJobInterval is the time between price job runs,
+// scheduled price changes are applied at most this long after they are due
+const PriceJobInterval = time.Minute
+
+// StartJobs runs background jobs until Cleanup is called
+func (s *Services) StartJobs() {
+	ctx, cancel := context.WithCancel(context.Background())
+	s.cancel = cancel
+	go s.runJob(ctx, JobInterval, s.CartJob)
+	go s.runJob(ctx, PriceJobInterval, s.PriceJob)
+}
+
+// runJob runs the job at interval until ctx is done
+func (s *Services) runJob(
+	ctx context.Context, interval time.Duration,
+	job func(ctx context.Context, now time.Time) error) {
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		err := job(ctx, time.Now())
+		if err != nil {
+			log.Error().Stack().Err(err).Msg("")
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// PriceJob applies scheduled price changes,
+// and rebuilds static pages for skus with changed prices
+func (s *Services) PriceJob(ctx context.Context, now time.Time) (err error) {
+	skus, err := s.Model.ApplyPriceSchedule(ctx, now)
+	if err != nil {
+		return err
+	}
+	if len(skus) == 0 {
+		return nil
+	}
+	log.Info().Strs("skus", skus).Msg("scheduled prices applied")
+	return s.Site.Rebuild(ctx, skus)
+}
+
+// CartJob emails reminders for idle carts, and expires abandoned carts
+// and unpaid pending orders.
+// Thresholds are configured per domain, see model.TermCartExpire
+// and model.TermOrderExpire
+func (s *Services) CartJob(ctx context.Context, now time.Time) (err error) {
+	reminders, err := s.Model.CartReminders(ctx, now)
+	if err != nil {
+		return err
+	}
+	for _, reminder := range reminders {
+		msg, err := s.cartReminderEmail(ctx, reminder)
+		if err != nil {
+			return err
+		}
+		err = s.Mailer.Send(ctx, msg)
+		if err != nil {
+			return err
+		}
+		err = s.Model.CartReminded(ctx, reminder.Cart.OrderID)
+		if err != nil {
+			return err
+		}
+	}
+	if len(reminders) > 0 {
+		log.Info().Int("count", len(reminders)).Msg("cart reminders sent")
+	}
+
+	n, err := s.Model.ExpireCarts(ctx, now)
+	if err != nil {
+		return err
+	}
+	if n > 0 {
+		log.Info().Int("count", n).Msg("carts expired")
+	}
+
+	n, err = s.Model.ExpireOrders(ctx, now)
+	if err != nil {
+		return err
+	}
+	if n > 0 {
+		log.Info().Int("count", n).Msg("orders expired")
+	}
+	return nil
+}
+
+// cartReminderEmail links back to the cart,
+// the link sets the cart cookie on another device
+func (s *Services) cartReminderEmail(
+	ctx context.Context, reminder share.CartReminder) (
+	msg share.Email, err error) {
+
+	signed, err := s.Model.Sign(ctx, reminder.Cart.OrderID)
+	if err != nil {
+		return msg, err
+	}
+	body := strings.Builder{}
+	fmt.Fprintln(&body, "You left these items in your cart")
+	fmt.Fprintln(&body)
+	for _, line := range reminder.Cart.Lines {
+		fmt.Fprintf(&body, "%d x %s\n", line.Qty, line.Title)
+	}
+	fmt.Fprintln(&body)
+	fmt.Fprintf(&body, "%s/cart?%s=%s\n", s.baseURL, share.ParamCart, signed)
+
+	return share.Email{
+		To:      reminder.Email,
+		Subject: "Your cart is waiting",
+		Body:    body.String(),
+	}, nil
+}
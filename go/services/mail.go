@@ -0,0 +1,132 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"mime/multipart"
+	"net"
+	"net/smtp"
+	"net/textproto"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+	"github.com/segmentio/ksuid"
+	"github.com/shopd/shopd/go/fileutil"
+	"github.com/shopd/shopd/go/model"
+	"github.com/shopd/shopd/go/share"
+)
+
+// EmailDir is the dir for dev emails, see magefiles/dev.go
+const EmailDir = "email"
+
+// emlLineLen is the max length of base64 encoded lines
+const emlLineLen = 76
+
+// Mailer sends email
+type Mailer interface {
+	Send(ctx context.Context, msg share.Email) error
+}
+
+// FileMailer writes emails to files instead of sending them,
+// useful for dev and testing
+type FileMailer struct {
+	Dir string
+}
+
+func (fm *FileMailer) Send(ctx context.Context, msg share.Email) (err error) {
+	err = fileutil.MkdirAll(fm.Dir)
+	if err != nil {
+		return err
+	}
+	b, err := emlMessage(msg)
+	if err != nil {
+		return err
+	}
+	p := filepath.Join(fm.Dir, ksuid.New().String()+".eml")
+	return fileutil.WriteBytes(p, b)
+}
+
+// SMTPMailer sends email with the SMTP server configured for the domain,
+// see model.TermSmtpAddr. Settings are read on every send,
+// changes don't require a restart
+type SMTPMailer struct {
+	Model *model.Model
+}
+
+func (sm *SMTPMailer) Send(ctx context.Context, msg share.Email) (err error) {
+	conf, err := sm.Model.SMTPConfig(ctx)
+	if err != nil {
+		return err
+	}
+	msg.From = conf.From
+	b, err := emlMessage(msg)
+	if err != nil {
+		return err
+	}
+	var auth smtp.Auth
+	if conf.User != "" {
+		host, _, err := net.SplitHostPort(conf.Addr)
+		if err != nil {
+			return errors.WithStack(model.ErrConfig(model.TermSmtpAddr))
+		}
+		auth = smtp.PlainAuth("", conf.User, conf.Pass, host)
+	}
+	err = smtp.SendMail(conf.Addr, auth, conf.From, []string{msg.To}, b)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	return nil
+}
+
+// emlMessage formats the email as RFC 822 message,
+// attachments are base64 encoded parts of a multipart message
+func emlMessage(msg share.Email) (b []byte, err error) {
+	buf := bytes.Buffer{}
+	if msg.From != "" {
+		fmt.Fprintf(&buf, "From: %s\r\n", msg.From)
+	}
+	fmt.Fprintf(&buf, "To: %s\r\nSubject: %s\r\n", msg.To, msg.Subject)
+	if len(msg.Attachments) == 0 {
+		fmt.Fprintf(&buf, "\r\n%s", msg.Body)
+		return buf.Bytes(), nil
+	}
+
+	w := multipart.NewWriter(&buf)
+	fmt.Fprintf(&buf, "MIME-Version: 1.0\r\n")
+	fmt.Fprintf(&buf,
+		"Content-Type: multipart/mixed; boundary=%s\r\n\r\n", w.Boundary())
+	header := textproto.MIMEHeader{}
+	header.Set("Content-Type", "text/plain; charset=utf-8")
+	part, err := w.CreatePart(header)
+	if err != nil {
+		return b, errors.WithStack(err)
+	}
+	_, err = part.Write([]byte(msg.Body))
+	if err != nil {
+		return b, errors.WithStack(err)
+	}
+	for _, a := range msg.Attachments {
+		header := textproto.MIMEHeader{}
+		header.Set("Content-Type", a.ContentType)
+		header.Set("Content-Disposition",
+			fmt.Sprintf("attachment; filename=%q", a.Filename))
+		header.Set("Content-Transfer-Encoding", "base64")
+		part, err := w.CreatePart(header)
+		if err != nil {
+			return b, errors.WithStack(err)
+		}
+		enc := base64.StdEncoding.EncodeToString(a.Body)
+		for len(enc) > emlLineLen {
+			fmt.Fprintf(part, "%s\r\n", enc[:emlLineLen])
+			enc = enc[emlLineLen:]
+		}
+		fmt.Fprintf(part, "%s\r\n", enc)
+	}
+	err = w.Close()
+	if err != nil {
+		return b, errors.WithStack(err)
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,295 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/pkg/errors"
+	"github.com/shopd/shopd/go/model"
+	"github.com/shopd/shopd/go/share"
+)
+
+// CheckoutReturnPath is where processors send the buyer after paying
+const CheckoutReturnPath = "/checkout/return"
+
+// PaymentProcessor takes payments on a hosted page,
+// states are share.PaymentPending, share.PaymentAuthorized, etc
+type PaymentProcessor interface {
+	// CreateIntent registers the payment with the processor,
+	// the buyer is sent to returnURL after paying
+	CreateIntent(ctx context.Context, pay share.Payment, returnURL string) (
+		ref string, err error)
+	// RedirectURL is the hosted page where the buyer pays
+	RedirectURL(ref string) string
+	// Capture an authorized payment
+	Capture(ctx context.Context, ref string, amount int64) (
+		state string, err error)
+	// Refund a captured payment, refunds may be partial
+	Refund(ctx context.Context, ref string, amount int64) (
+		state string, err error)
+	Status(ctx context.Context, ref string) (state string, err error)
+	// Event checks the signature of a webhook request, and parses the event
+	Event(header http.Header, body []byte) (event share.PaymentEvent, err error)
+}
+
+// Processors by name, the processor for the domain is configured with
+// model.TermPaymentProcessor
+type Processors map[string]PaymentProcessor
+
+// processor returns the processor registered with name
+func (s *Services) processor(name string) (p PaymentProcessor, err error) {
+	p, ok := s.Processors[name]
+	if !ok {
+		return p, errors.WithStack(model.ErrConfig(model.TermPaymentProcessor))
+	}
+	return p, nil
+}
+
+// checkoutProcessor is the processor configured for the domain,
+// the fake processor is used if it's registered and none is configured
+func (s *Services) checkoutProcessor(ctx context.Context) (
+	name string, p PaymentProcessor, err error) {
+
+	name, err = s.Model.PaymentProcessor(ctx)
+	if errors.Is(err, model.ErrConfig("")) {
+		if _, ok := s.Processors[ProcessorFake]; ok {
+			name, err = ProcessorFake, nil
+		}
+	}
+	if err != nil {
+		return name, p, err
+	}
+	p, err = s.processor(name)
+	return name, p, err
+}
+
+// PaymentStart creates a payment for the amount due on the order,
+// and returns the URL of the hosted page where the buyer pays
+func (s *Services) PaymentStart(
+	ctx context.Context, orderID, userID string) (redirectURL string, err error) {
+
+	name, p, err := s.checkoutProcessor(ctx)
+	if err != nil {
+		return redirectURL, err
+	}
+	pay, err := s.Model.PaymentCreate(ctx, orderID, name, userID)
+	if err != nil {
+		return redirectURL, err
+	}
+	if pay.Ref != "" {
+		// Payment was started before
+		return p.RedirectURL(pay.Ref), nil
+	}
+	returnURL := fmt.Sprintf("%s%s?%s=%s", s.baseURL, CheckoutReturnPath,
+		share.ParamTranID, url.QueryEscape(pay.TranID))
+	ref, err := p.CreateIntent(ctx, pay, returnURL)
+	if err != nil {
+		_, failErr := s.Model.PaymentUpdate(
+			ctx, pay.TranID, share.TranStateFailed, model.ModIDSystem)
+		if failErr != nil {
+			return redirectURL, failErr
+		}
+		return redirectURL, err
+	}
+	err = s.Model.PaymentRef(ctx, pay.TranID, ref)
+	if err != nil {
+		return redirectURL, err
+	}
+	return p.RedirectURL(ref), nil
+}
+
+// PaymentCredit pays for the order with store credit,
+// the invoice is sent if the credit covers the total
+func (s *Services) PaymentCredit(
+	ctx context.Context, orderID, accountID, userID string) (
+	pay share.Payment, err error) {
+
+	pay, err = s.Model.PaymentCredit(ctx, orderID, accountID, userID)
+	if err != nil {
+		return pay, err
+	}
+	s.paidEmail(ctx, pay)
+	return pay, nil
+}
+
+// PaymentRecord records a payment received outside of checkout,
+// the invoice is sent when the payments cover the total
+func (s *Services) PaymentRecord(
+	ctx context.Context, params share.ParamsOrdersPaymentPost, userID string) (
+	pay share.Payment, err error) {
+
+	pay, err = s.Model.PaymentRecord(ctx, params, userID)
+	if err != nil {
+		return pay, err
+	}
+	s.paidEmail(ctx, pay)
+	return pay, nil
+}
+
+// PaymentSync fetches the status of a pending payment from the processor,
+// authorized payments are captured
+func (s *Services) PaymentSync(
+	ctx context.Context, tranID string) (pay share.Payment, err error) {
+
+	pay, err = s.Model.Payment(ctx, tranID)
+	if err != nil {
+		return pay, err
+	}
+	if pay.State != share.TranStatePending || pay.Ref == "" {
+		return pay, nil
+	}
+	p, err := s.processor(pay.Processor)
+	if err != nil {
+		return pay, err
+	}
+	state, err := p.Status(ctx, pay.Ref)
+	if err != nil {
+		return pay, err
+	}
+	if state == share.PaymentAuthorized {
+		state, err = p.Capture(ctx, pay.Ref, pay.Amount)
+		if err != nil {
+			return pay, err
+		}
+	}
+	switch state {
+	case share.PaymentCaptured:
+		pay, err = s.Model.PaymentUpdate(
+			ctx, tranID, share.TranStateSuccess, model.ModIDSystem)
+		if err != nil {
+			return pay, err
+		}
+		s.paidEmail(ctx, pay)
+		return pay, nil
+	case share.PaymentFailed:
+		return s.Model.PaymentUpdate(
+			ctx, tranID, share.TranStateFailed, model.ModIDSystem)
+	}
+	return pay, nil
+}
+
+// PaymentRefund refunds amount of a successful payment with the processor
+func (s *Services) PaymentRefund(
+	ctx context.Context, tranID string, amount int64, userID string) (
+	pay share.Payment, err error) {
+
+	pay, err = s.Model.Payment(ctx, tranID)
+	if err != nil {
+		return pay, err
+	}
+	if pay.State != share.TranStateSuccess {
+		return pay, errors.WithStack(
+			model.ErrStateTransition(pay.State, share.PaymentRefunded))
+	}
+	if amount <= 0 || pay.Refunded+amount > pay.Amount {
+		return pay, errors.WithStack(model.ErrInvalidParam("Amount"))
+	}
+	p, err := s.processor(pay.Processor)
+	if err != nil {
+		return pay, err
+	}
+	state, err := p.Refund(ctx, pay.Ref, amount)
+	if err != nil {
+		return pay, err
+	}
+	if state != share.PaymentRefunded {
+		return pay, errors.WithStack(
+			model.ErrStateTransition(share.PaymentCaptured, state))
+	}
+	return s.Model.PaymentRefunded(ctx, tranID, amount, userID)
+}
+
+// PaymentWebhook stores the event sent by the processor, and handles it.
+// Events that were handled before are ignored
+func (s *Services) PaymentWebhook(
+	ctx context.Context, processor string, header http.Header, body []byte) (
+	err error) {
+
+	p, ok := s.Processors[processor]
+	if !ok {
+		return errors.WithStack(model.ErrNotFound(processor))
+	}
+	event, err := p.Event(header, body)
+	if err != nil {
+		return err
+	}
+	event.Processor = processor
+	event, err = s.Model.PaymentEventSave(ctx, event, body)
+	if err != nil {
+		return err
+	}
+	if event.Handled == share.PaymentEventDone {
+		return nil
+	}
+	event, err = s.PaymentEventHandle(ctx, processor, event.EventID, "")
+	if err != nil {
+		return err
+	}
+	if event.Handled == share.PaymentEventFailed {
+		// The processor retries the request
+		return errors.Errorf("payment event %s failed: %s",
+			event.EventID, event.Error)
+	}
+	return nil
+}
+
+// PaymentEventHandle updates the payment for a stored event,
+// the outcome is recorded on the event so failed events can be retried
+func (s *Services) PaymentEventHandle(
+	ctx context.Context, processor, eventID, userID string) (
+	event share.PaymentEvent, err error) {
+
+	event, err = s.Model.PaymentEvent(ctx, processor, eventID)
+	if err != nil {
+		return event, err
+	}
+	if event.Handled == share.PaymentEventDone {
+		return event, nil
+	}
+	handleErr := s.paymentEvent(ctx, event)
+	return s.Model.PaymentEventHandled(ctx, processor, eventID, handleErr, userID)
+}
+
+// paymentEvent maps the processor state to the tran state,
+// authorized payments are captured
+func (s *Services) paymentEvent(
+	ctx context.Context, event share.PaymentEvent) (err error) {
+
+	pay, err := s.Model.PaymentByRef(ctx, event.Processor, event.Ref)
+	if err != nil {
+		return err
+	}
+	if pay.State != share.TranStatePending {
+		// E.g. the payment was captured when the buyer returned
+		return nil
+	}
+	if event.Amount != pay.Amount {
+		return errors.WithStack(model.ErrInvalidParam("Amount"))
+	}
+	state := event.State
+	if state == share.PaymentAuthorized {
+		p, err := s.processor(event.Processor)
+		if err != nil {
+			return err
+		}
+		state, err = p.Capture(ctx, pay.Ref, pay.Amount)
+		if err != nil {
+			return err
+		}
+	}
+	switch state {
+	case share.PaymentCaptured:
+		pay, err = s.Model.PaymentUpdate(
+			ctx, pay.TranID, share.TranStateSuccess, model.ModIDSystem)
+		if err != nil {
+			return err
+		}
+		s.paidEmail(ctx, pay)
+	case share.PaymentFailed:
+		_, err = s.Model.PaymentUpdate(
+			ctx, pay.TranID, share.TranStateFailed, model.ModIDSystem)
+	}
+	return err
+}
@@ -0,0 +1,90 @@
+package services
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/pkg/errors"
+	"github.com/shopd/shopd/go/model"
+	"github.com/shopd/shopd/go/share"
+)
+
+// Refund issues a credit note for the order. For RefundMethodProcessor the
+// refund is split over the processor payments in the order they were made.
+// The credit note is kept if a processor refund fails,
+// the remaining amount may be refunded with RefundRetry
+func (s *Services) Refund(
+	ctx context.Context, params share.ParamsOrdersRefundPost, userID string) (
+	note share.CreditNote, err error) {
+
+	note, err = s.Model.CreditNoteCreate(ctx, params, userID)
+	if err != nil {
+		return note, err
+	}
+	if params.Method != share.RefundMethodProcessor || note.Refund == 0 {
+		return note, nil
+	}
+
+	err = s.refundProcessor(ctx, note.OrderID, note.CreditNoteID, note.Refund, userID)
+	if err != nil {
+		return note, err
+	}
+	return note, nil
+}
+
+// RefundRetry refunds the amount still due for the credit note,
+// after an earlier processor refund failed
+func (s *Services) RefundRetry(
+	ctx context.Context, params share.ParamsOrdersRefundRetryPost,
+	userID string) (err error) {
+
+	due, err := s.Model.CreditNoteRefundDue(ctx, params.OrderID, params.CreditNoteID)
+	if err != nil {
+		return err
+	}
+	if due == 0 {
+		return errors.WithStack(model.ErrInvalidParam("CreditNoteID"))
+	}
+	return s.refundProcessor(ctx, params.OrderID, params.CreditNoteID, due, userID)
+}
+
+// refundProcessor splits due over the processor payments for the order,
+// and records a refund for the credit note for each processor refund
+func (s *Services) refundProcessor(
+	ctx context.Context, orderID, creditNoteID string, due int64,
+	userID string) (err error) {
+
+	list, err := s.Model.OrderPayments(ctx, orderID)
+	if err != nil {
+		return err
+	}
+	remaining := due
+	for _, pay := range list.Payments {
+		if remaining == 0 {
+			break
+		}
+		if pay.Processor == "" || pay.Type != "" ||
+			pay.State != share.TranStateSuccess {
+			continue
+		}
+		amount := min(remaining, pay.Amount-pay.Refunded)
+		if amount <= 0 {
+			continue
+		}
+		_, err = s.PaymentRefund(ctx, pay.TranID, amount, userID)
+		if err != nil {
+			return err
+		}
+		_, err = s.Model.RefundRecord(ctx, creditNoteID, share.Payment{
+			Method:    pay.Method,
+			Processor: pay.Processor,
+			Message:   fmt.Sprintf("Refund of %s", pay.TranID),
+			Amount:    amount,
+		}, userID)
+		if err != nil {
+			return err
+		}
+		remaining -= amount
+	}
+	return nil
+}
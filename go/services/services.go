@@ -0,0 +1,84 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"path/filepath"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/pkg/errors"
+	"github.com/shopd/shopd/go/config"
+	"github.com/shopd/shopd/go/model"
+)
+
+// DBFile is the SQLite DB file in the domain dir
+const DBFile = "shopd.db"
+
+// Services are shared by the route handlers and commands
+type Services struct {
+	Model  *model.Model
+	Mailer Mailer
+	Site   Site
+	// Processors take payments, see model.TermPaymentProcessor
+	Processors Processors
+	db         *sql.DB
+	// baseURL for links in emails
+	baseURL string
+	// imgDir for uploaded images, see ImgDir
+	imgDir string
+	// cancel stops background jobs
+	cancel context.CancelFunc
+}
+
+// NewServices opens the domain DB and creates the services.
+// Remember to call Cleanup
+func NewServices(conf *config.Config) (s *Services, err error) {
+	dbPath := filepath.Join(conf.ExecTemplateDomainDir(), DBFile)
+	db, err := sql.Open("sqlite3", dbPath)
+	if err != nil {
+		return s, errors.WithStack(err)
+	}
+
+	baseURL := fmt.Sprintf("https://%s", conf.Domain())
+	m := model.NewModel(model.ModelParams{DB: db})
+	site := &MageSite{
+		Mage: filepath.Join(conf.Dir(), "magefiles", "mage"),
+		Dir:  conf.Dir(),
+	}
+	s = &Services{
+		Model:      m,
+		Mailer:     &SMTPMailer{Model: m},
+		Site:       site,
+		Processors: Processors{},
+		db:         db,
+		baseURL:    baseURL,
+		imgDir:     filepath.Join(conf.Dir(), ImgDir),
+		cancel:     func() {},
+	}
+	return s, nil
+}
+
+// UseFakeProcessor registers the fake processor, for dev with stubs.
+// Checkout uses it if the domain doesn't configure a processor
+func (s *Services) UseFakeProcessor() {
+	s.Processors[ProcessorFake] = NewFakeProcessor(s.baseURL)
+}
+
+// UseFileMailer writes emails to the dir instead of sending them,
+// for dev with stubs, see magefiles/dev.go
+func (s *Services) UseFileMailer(dir string) {
+	s.Mailer = &FileMailer{Dir: filepath.Join(dir, EmailDir)}
+}
+
+// UseFileSite writes rebuild requests to files instead of building pages,
+// for dev with stubs
+func (s *Services) UseFileSite(dir string) {
+	s.Site = &FileSite{Dir: filepath.Join(dir, RebuildDir)}
+}
+
+// Cleanup releases resources used by the services
+func (s *Services) Cleanup() error {
+	s.cancel()
+	return errors.WithStack(s.db.Close())
+}
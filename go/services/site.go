@@ -0,0 +1,62 @@
+package services
+
+import (
+	"context"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/segmentio/ksuid"
+	"github.com/shopd/shopd/go/fileutil"
+)
+
+// RebuildDir is the dir for dev rebuild requests
+const RebuildDir = "rebuild"
+
+// MageBuildSkus is the mage target that rebuilds pages for skus,
+// see magefiles/build.go
+const MageBuildSkus = "buildSkus"
+
+// Site regenerates static pages, e.g. when prices change
+type Site interface {
+	Rebuild(ctx context.Context, skus []string) error
+}
+
+// FileSite writes rebuild requests to files, one sku per line,
+// useful for dev and testing
+type FileSite struct {
+	Dir string
+}
+
+func (fs *FileSite) Rebuild(ctx context.Context, skus []string) (err error) {
+	err = fileutil.MkdirAll(fs.Dir)
+	if err != nil {
+		return err
+	}
+	b := strings.Join(skus, "\n") + "\n"
+	p := filepath.Join(fs.Dir, ksuid.New().String()+".txt")
+	return fileutil.WriteBytes(p, []byte(b))
+}
+
+// MageSite rebuilds static pages with the static mage binary,
+// see magefiles/static.go
+type MageSite struct {
+	// Mage is the path to the static mage binary
+	Mage string
+	// Dir is the app dir
+	Dir string
+}
+
+func (ms *MageSite) Rebuild(ctx context.Context, skus []string) (err error) {
+	if len(skus) == 0 {
+		return nil
+	}
+	cmd := exec.CommandContext(ctx, ms.Mage, MageBuildSkus, strings.Join(skus, ","))
+	cmd.Dir = ms.Dir
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return errors.Wrap(err, string(out))
+	}
+	return nil
+}
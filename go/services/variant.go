@@ -0,0 +1,24 @@
+package services
+
+import (
+	"context"
+
+	"github.com/shopd/shopd/go/share"
+)
+
+// GenerateVariants creates variants from the matrix of attribute values,
+// and rebuilds static pages for the group since the selectors changed
+func (s *Services) GenerateVariants(
+	ctx context.Context, params share.ParamsVariantsPost, userID string) (
+	result share.VariantsGenerate, err error) {
+
+	result, err = s.Model.GenerateVariants(ctx, params, userID)
+	if err != nil {
+		return result, err
+	}
+	skus := make([]string, 0, len(result.Variants))
+	for _, variant := range result.Variants {
+		skus = append(skus, variant.Sku)
+	}
+	return result, s.Site.Rebuild(ctx, skus)
+}
@@ -0,0 +1,68 @@
+package share
+
+import "time"
+
+// Account types for tran_config, besides TranTypeRefund.
+// Credits and refunds to an account increase the balance,
+// debits and payments from an account decrease it
+const (
+	TranTypeCredit = "credit"
+	TranTypeDebit  = "debit"
+)
+
+// Account is a customer ledger shared by the linked users,
+// Balance is store credit available in the default currency
+type Account struct {
+	AccountID string
+	Descr     string
+	Users     []string
+	Balance   int64
+}
+
+// Accounts lists accounts for the admin screen
+type Accounts struct {
+	Currency string
+	Accounts []Account
+}
+
+// AccountStatement lists the transactions for an account in order,
+// Balance is the running balance after each line
+type AccountStatement struct {
+	Account
+	Currency string
+	Lines    []AccountLine
+}
+
+type AccountLine struct {
+	TranID  string
+	OrderID string
+	Date    time.Time
+	Descr   string
+	Credit  int64
+	Debit   int64
+	Balance int64
+}
+
+// ParamsAccountCreditPost adjusts an account balance,
+// a negative Amount is a debit
+type ParamsAccountCreditPost struct {
+	AccountID string
+	Amount    int64
+	Message   string
+}
+
+// ParamsAccountUserPost links a user to an account,
+// or unlinks the user if Unlink is set
+type ParamsAccountUserPost struct {
+	AccountID string
+	UserID    string
+	Unlink    bool
+}
+
+// ParamsCheckoutPost for placing the order,
+// UseCredit pays with store credit first from AccountID,
+// the first account linked to the user is used if it's empty
+type ParamsCheckoutPost struct {
+	UseCredit bool
+	AccountID string
+}
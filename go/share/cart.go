@@ -0,0 +1,53 @@
+package share
+
+// Cart is an order in the cart state,
+// guest carts don't have a user ID
+type Cart struct {
+	OrderID  string
+	UserID   string
+	Currency string
+	// TaxDisplay is inclusive or exclusive,
+	// line prices and the subtotal include tax if inclusive
+	TaxDisplay string
+	Lines      []CartLine
+	Subtotal   int64
+	Tax        int64
+	Total      int64
+	// Coupon code for a discount, see Lines for the discount applied
+	Coupon string
+	// Voucher code, the balance is used when the order is placed
+	Voucher        string
+	VoucherBalance int64
+}
+
+// CartLine with the price snapshot taken when the sku was added
+type CartLine struct {
+	OrderLineID string
+	Sku         string
+	Title       string
+	Price       int64
+	Qty         int64
+	Amount      int64
+	// System lines can't be updated, e.g. discounts
+	System bool
+	// Descr explains system lines, e.g. the discount that was applied
+	Descr string
+}
+
+// ParamsCartPost adds qty of sku to the cart
+type ParamsCartPost struct {
+	Sku string
+	Qty int64
+}
+
+// ParamsCartPatch sets the qty for a cart line,
+// the line is removed if qty is zero
+type ParamsCartPatch struct {
+	OrderLineID string
+	Qty         int64
+}
+
+// ParamsCartCodePost applies a coupon or voucher code to the cart
+type ParamsCartCodePost struct {
+	Code string
+}
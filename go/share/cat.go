@@ -0,0 +1,37 @@
+package share
+
+// Catalog item states must be listed in the cat_state table,
+// see comments for the cat table in scripts/db/schema.strict.sql
+const (
+	CatStateStock        = "stock"
+	CatStateHidden       = "hidden"
+	CatStateDiscontinued = "discontinued"
+	CatStateSystem       = "system"
+)
+
+// CatalogImport is the result of a catalog upload. Nothing is imported if
+// any row has errors, or for a dry run. Created and Updated count the rows
+// that would change for a dry run, Skus lists the items that changed
+type CatalogImport struct {
+	DryRun  bool
+	Rows    int
+	Created int
+	Updated int
+	Skus    []string
+	Errors  []CatalogRowError
+}
+
+// CatalogRowError is a validation error for a row in the catalog CSV,
+// Line is the line number in the file and Col the header
+type CatalogRowError struct {
+	Line  int
+	Sku   string
+	Col   string
+	Error string
+}
+
+// ParamsCatalogPost imports the uploaded catalog CSV,
+// DryRun validates the file without making changes
+type ParamsCatalogPost struct {
+	DryRun bool
+}
@@ -0,0 +1,90 @@
+package share
+
+// TranTypeRefund is the type tran_config value for refunds,
+// transactions without a type are payments
+const TranTypeRefund = "refund"
+
+// Refund methods for credit notes, besides TranMethodEFT and TranMethodCash.
+// RefundMethodNone issues the credit note without refunding money,
+// RefundMethodProcessor refunds the processor payments for the order
+const (
+	RefundMethodNone      = ""
+	RefundMethodProcessor = "processor"
+)
+
+// CreditNote reverses all or part of the invoice for an order.
+// Amounts are in the smallest unit of Currency
+type CreditNote struct {
+	CreditNoteID string
+	OrderID      string
+	CreditNo     string
+	Reason       string
+	Currency     string
+	// Amount credited excluding tax
+	Amount int64
+	Tax    int64
+	Total  int64
+	// Refund is the money to be refunded,
+	// vouchers are restored when the order is reversed
+	Refund int64
+	// RefundDue is the part of Refund that was not refunded yet,
+	// e.g. the processor refund failed
+	RefundDue int64
+	Lines     []CreditNoteLine
+}
+
+// CreditNoteLine is the qty credited for an order line,
+// OrderLineID is empty for credits by amount
+type CreditNoteLine struct {
+	OrderLineID string
+	Sku         string
+	Qty         int64
+	Amount      int64
+	Tax         int64
+	Restock     int64
+}
+
+// OrderRefundLine is an order line that may be credited
+type OrderRefundLine struct {
+	OrderLineID string
+	Sku         string
+	Title       string
+	Qty         int64
+	// Credited qty on previous credit notes
+	Credited int64
+}
+
+// OrderRefunds lists the credit notes for an order,
+// and the lines and amount that may still be credited
+type OrderRefunds struct {
+	OrderID  string
+	State    string
+	Paid     bool
+	Currency string
+	Total    int64
+	Credited int64
+	// Refundable is money paid that was not refunded yet
+	Refundable  int64
+	Lines       []OrderRefundLine
+	CreditNotes []CreditNote
+}
+
+// ParamsOrdersRefundPost creates a credit note. Lines are credited by qty,
+// OrderLineID and Qty are parallel lists, Amount is credited in addition
+// to the lines. Method is one of the refund methods
+type ParamsOrdersRefundPost struct {
+	OrderID     string
+	OrderLineID []string
+	Qty         []int64
+	Amount      int64
+	Method      string
+	Message     string
+	Reason      string
+	Restock     bool
+}
+
+// ParamsOrdersRefundRetryPost retries the processor refund for a credit note
+type ParamsOrdersRefundRetryPost struct {
+	OrderID      string
+	CreditNoteID string
+}
@@ -0,0 +1,28 @@
+package share
+
+// ExchangeRates for display prices,
+// Currency is the default currency that orders are charged in
+type ExchangeRates struct {
+	Currency string
+	Rates    []ExchangeRate
+}
+
+// ExchangeRate is the decimal number of major units of the currency,
+// for one major unit of the default currency
+type ExchangeRate struct {
+	Currency string
+	Rate     string
+}
+
+// ParamsExchangeRatePost sets the rate for the currency,
+// an empty or zero rate removes the currency
+type ParamsExchangeRatePost struct {
+	Currency string
+	Rate     string
+}
+
+// ParamsCurrencyPost selects the display currency,
+// empty for the default currency
+type ParamsCurrencyPost struct {
+	Currency string
+}
@@ -0,0 +1,58 @@
+package share
+
+// OrderDiscounts lists the optional discounts for a pending order,
+// with a preview of the totals if each discount is applied
+type OrderDiscounts struct {
+	OrderID  string
+	State    string
+	Paid     bool
+	Currency string
+	// Applied is the optional discount ID applied to the order, if any
+	Applied   string
+	Totals    OrderTotals
+	Discounts []OrderDiscount
+}
+
+// OrderDiscount is an optional discount that applies to the order
+type OrderDiscount struct {
+	DiscountID string
+	Descr      string
+	// Amount of the discount
+	Amount int64
+	// Totals if the discount is applied,
+	// replacing the optional discount that was applied before
+	Totals OrderTotals
+}
+
+// OrderTotals in the smallest unit of the currency,
+// Subtotal is before discounts
+type OrderTotals struct {
+	Subtotal int64
+	Discount int64
+	Tax      int64
+	Total    int64
+}
+
+// ParamsOrdersDiscountPost applies the optional discount to the order
+type ParamsOrdersDiscountPost struct {
+	OrderID    string
+	DiscountID string
+}
+
+// ParamsCouponsPost generates a batch of coupon codes for the discount.
+// Max uses are not limited if zero
+type ParamsCouponsPost struct {
+	DiscountID  string
+	Prefix      string
+	Count       int64
+	MaxUses     int64
+	MaxUserUses int64
+}
+
+// ParamsVouchersPost generates a batch of vouchers,
+// Amount is in the smallest unit of the currency
+type ParamsVouchersPost struct {
+	Prefix string
+	Count  int64
+	Amount int64
+}
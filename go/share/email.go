@@ -0,0 +1,25 @@
+package share
+
+// Email message, the body is plain text
+type Email struct {
+	// From is set by the mailer
+	From        string
+	To          string
+	Subject     string
+	Body        string
+	Attachments []Attachment
+}
+
+// SMTPConfig for sending email, see model.TermSmtpAddr
+type SMTPConfig struct {
+	Addr string
+	User string
+	Pass string
+	From string
+}
+
+// CartReminder for a user with an idle cart
+type CartReminder struct {
+	Cart  Cart
+	Email string
+}
@@ -1,5 +1,10 @@
 package share
 
+import (
+	"net/url"
+	"strings"
+)
+
 const GET = "GET"
 const PATCH = "PATCH"
 const POST = "POST"
@@ -12,4 +17,49 @@ const DELETE = "DELETE"
 // fields must be public and therefore start with uppercase.
 // Go templating expects public fields.
 
+const ParamAccountID = "AccountID"
+
+// ParamCart is the signed cart order ID, used in cart reminder links
+const ParamCart = "Cart"
+const ParamCode = "Code"
+const ParamCursor = "Cursor"
+const ParamDepot = "Depot"
 const ParamEnv = "Env"
+const ParamExportID = "ExportID"
+const ParamFormat = "Format"
+const ParamFrom = "From"
+const ParamHandled = "Handled"
+
+// ParamKey is the signed order ID, used by guests to view order documents
+const ParamKey = "Key"
+const ParamOrderID = "OrderID"
+const ParamOtp = "Otp"
+const ParamPaid = "Paid"
+const ParamProcessor = "Processor"
+const ParamSearch = "Search"
+const ParamState = "State"
+const ParamTag = "Tag"
+const ParamTo = "To"
+const ParamTranID = "TranID"
+const ParamUserID = "UserID"
+
+// FormatText is the ParamFormat value for plain text responses
+const FormatText = "text"
+
+// FormatCSV is the ParamFormat value for CSV downloads
+const FormatCSV = "csv"
+
+// Query returns the first value for param,
+// keys are matched case-insensitive, e.g. ?depot=x or ?Depot=x
+func Query(values url.Values, param string) string {
+	v := values.Get(param)
+	if v != "" {
+		return v
+	}
+	for key := range values {
+		if strings.EqualFold(key, param) {
+			return values.Get(key)
+		}
+	}
+	return ""
+}
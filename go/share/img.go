@@ -0,0 +1,64 @@
+package share
+
+import "fmt"
+
+// Image formats, the ext for a registered image format
+const (
+	ImgExtJPEG = "jpg"
+	ImgExtPNG  = "png"
+)
+
+// ImgWidths for resized variants of uploaded images,
+// variants are not wider than the original
+var ImgWidths = []int{320, 640, 1280}
+
+// Img is an image linked to a sku, Idx 0 is the default image.
+// Hash is computed on the original image
+type Img struct {
+	Hash  string
+	Ext   string
+	Alt   string
+	Descr string
+	Idx   int64
+}
+
+// CatImgs lists the images for the sku in display order
+type CatImgs struct {
+	Sku  string
+	Imgs []Img
+}
+
+// ParamsCatImgGet for the images linked to the sku
+type ParamsCatImgGet struct {
+	Sku string
+}
+
+// ParamsCatImgPost links the uploaded image to the sku,
+// the image file is uploaded with the form
+type ParamsCatImgPost struct {
+	Sku   string
+	Alt   string
+	Descr string
+}
+
+// ParamsCatImgOrderPost sorts the images for the sku,
+// Hash lists all linked images and the first is the default image
+type ParamsCatImgOrderPost struct {
+	Sku  string
+	Hash []string
+}
+
+// ParamsCatImgDelete unlinks the image from the sku
+type ParamsCatImgDelete struct {
+	Sku  string
+	Hash string
+}
+
+// ImgFile is the file name for the original image if width is 0,
+// otherwise the file name for the resized variant
+func ImgFile(hash, ext string, width int) string {
+	if width == 0 {
+		return fmt.Sprintf("%s.%s", hash, ext)
+	}
+	return fmt.Sprintf("%s-%d.%s", hash, width, ext)
+}
@@ -0,0 +1,79 @@
+package share
+
+import "time"
+
+// Invoice is a tax invoice for a paid order, or a credit note.
+// Amounts are in the smallest unit of Currency, e.g. cents
+type Invoice struct {
+	OrderID  string
+	OrderNo  string
+	Date     time.Time
+	Currency string
+	Seller   InvoiceParty
+	Buyer    InvoiceParty
+	Lines    []InvoiceLine
+	Taxes    []InvoiceTax
+	Payments []InvoicePayment
+	// TaxDisplay is inclusive or exclusive,
+	// line amounts and the subtotal include tax if inclusive
+	TaxDisplay string
+	// Subtotal excludes tax, unless TaxDisplay is inclusive
+	Subtotal int64
+	Tax      int64
+	Total    int64
+	Paid     int64
+	// Due is the outstanding amount, if any
+	Due int64
+	// CreditNo is set for credit notes, payments are refunds
+	CreditNo string
+	// Reason for the credit note
+	Reason string
+}
+
+// InvoiceParty is the seller or buyer on an invoice
+type InvoiceParty struct {
+	Name  string
+	Addr  string
+	VatNo string
+	RegNo string
+	Email string
+	Phone string
+}
+
+type InvoiceLine struct {
+	Sku   string
+	Title string
+	Qty   int64
+	// Price per unit excluding tax
+	Price int64
+	// Amount is price times qty
+	Amount int64
+}
+
+// InvoiceTax is the tax breakdown by pct,
+// fixed tax amounts are listed separately
+type InvoiceTax struct {
+	Descr string
+	// Pct e.g. 1500 for 15%
+	Pct   int64
+	Fixed bool
+	// Vat is not set for additional taxes, e.g. a levy
+	Vat bool
+	// Taxable is the amount the tax was calculated on
+	Taxable int64
+	Tax     int64
+}
+
+type InvoicePayment struct {
+	TranID string
+	Descr  string
+	Date   time.Time
+	Amount int64
+}
+
+// Attachment for email messages
+type Attachment struct {
+	Filename    string
+	ContentType string
+	Body        []byte
+}
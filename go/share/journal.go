@@ -0,0 +1,66 @@
+package share
+
+import "time"
+
+// Journal sources are the documents booked by journal exports
+const (
+	JournalSourceInvoice = "invoice"
+	JournalSourceTran    = "tran"
+	JournalSourceCredit  = "credit_note"
+)
+
+// Journal export formats, JournalFormatCSV is the generic layout.
+// The other formats are the manual journal import layouts
+// of the accounting software
+const (
+	JournalFormatCSV        = "csv"
+	JournalFormatXero       = "xero"
+	JournalFormatQuickBooks = "quickbooks"
+)
+
+// JournalFormats lists the export formats
+var JournalFormats = []string{
+	JournalFormatCSV,
+	JournalFormatXero,
+	JournalFormatQuickBooks,
+}
+
+// JournalRow is a debit or credit to an account,
+// the rows for a document balance. Journal names the entry for the
+// document, e.g. Invoice 1001, Ref is the document number
+type JournalRow struct {
+	Date     time.Time
+	Journal  string
+	Ref      string
+	Source   string
+	SourceID string
+	Account  string
+	Descr    string
+	Debit    int64
+	Credit   int64
+}
+
+// JournalExport is a batch of journal rows for the period,
+// From and To are dates, e.g. 2006-01-02, and the period includes To
+type JournalExport struct {
+	ExportID string
+	From     string
+	To       string
+	Date     time.Time
+	Currency string
+	// Count is the number of documents
+	Count int
+	Rows  []JournalRow
+}
+
+// JournalExports lists previous exports, without rows
+type JournalExports struct {
+	Exports []JournalExport
+}
+
+// ParamsJournalPost exports documents for the period
+// that were not exported before
+type ParamsJournalPost struct {
+	From string
+	To   string
+}
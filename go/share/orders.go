@@ -0,0 +1,96 @@
+package share
+
+import "time"
+
+// Order states must be listed in the order_state table,
+// see scripts/db/init.sql
+const (
+	OrderStateCart      = "cart"
+	OrderStatePending   = "pending"
+	OrderStateConfirmed = "confirmed"
+	OrderStateReversed  = "reversed"
+	OrderStateComplete  = "complete"
+	// OrderStateExpired is set on idle carts
+	OrderStateExpired = "expired"
+)
+
+// Address types must be listed in the addrtype table
+const (
+	AddrTypeBilling  = "billing"
+	AddrTypeDelivery = "delivery"
+)
+
+// Depot is a physical stock location
+type Depot struct {
+	Depot    string
+	Descr    string
+	Province string
+}
+
+// PickList of items to collect at a depot
+type PickList struct {
+	Depot string
+	Items []PickItem
+}
+
+// PickItem is the total qty to collect for a sku,
+// and the order numbers it was allocated to
+type PickItem struct {
+	Sku     string
+	Title   string
+	Qty     int64
+	OrderNo []string
+}
+
+// OrderFilter for the admin order list, empty fields are ignored.
+// From and To are dates, e.g. 2006-01-02, and the range includes To.
+// Paid is "1" for paid, "0" for not paid
+type OrderFilter struct {
+	State  string
+	Paid   string
+	Tag    string
+	From   string
+	To     string
+	UserID string
+	Search string
+	// Cursor is the mod of the last order on the previous page
+	Cursor string
+}
+
+// OrderList is a page of orders matching the filter,
+// Next is the cursor for the next page, empty on the last page
+type OrderList struct {
+	Filter OrderFilter
+	Orders []OrderSummary
+	Next   string
+}
+
+// OrderSummary is a row in the admin order list
+type OrderSummary struct {
+	OrderID string
+	OrderNo string
+	State   string
+	UserID  string
+	Email   string
+	Paid    bool
+	// Total is the invoice total including tax, in the smallest unit of Currency
+	Total    int64
+	Currency string
+	Tags     []string
+	Date     time.Time
+	// Mod is used as cursor for pagination
+	Mod string
+}
+
+// ParamsOrdersStatePost transitions the orders to State
+type ParamsOrdersStatePost struct {
+	OrderID []string
+	State   string
+}
+
+// OrderStateResult for one of the orders in a bulk state change,
+// Error is set if the transition failed
+type OrderStateResult struct {
+	Order OrderSummary
+	Error string
+}
@@ -0,0 +1,112 @@
+package share
+
+// Scheduled price change states,
+// see comments for the cat_price_schedule table
+const (
+	PriceSchedulePending   = "pending"
+	PriceScheduleActive    = "active"
+	PriceScheduleDone      = "done"
+	PriceScheduleCancelled = "cancelled"
+)
+
+// Price display modes, see the price_display config term
+const (
+	PriceDisplayInclusive = "inclusive"
+	PriceDisplayExclusive = "exclusive"
+)
+
+// SkuPrice is the price of the sku for the buyer,
+// price lists override cat_price.
+// Prices include tax if TaxDisplay is inclusive
+type SkuPrice struct {
+	Sku        string
+	Currency   string
+	TaxDisplay string
+	Price      int64
+	// Tiers lists quantity break prices, empty if the sku doesn't have tiers
+	Tiers []PriceTier
+}
+
+// PriceTier is the unit price from Qty up to the next tier
+type PriceTier struct {
+	Qty   int64
+	Price int64
+}
+
+// ParamsPriceGet for the price of the sku
+type ParamsPriceGet struct {
+	Sku string
+}
+
+// ParamsPriceListPost replaces the prices and restrictions on a price list,
+// the CSV file is uploaded with the form
+type ParamsPriceListPost struct {
+	PriceListID string
+	Descr       string
+	// Users, Tags, and Accounts restrict the list, one per line
+	Users    string
+	Tags     string
+	Accounts string
+	// Ranges are rows with start and end columns in the domain time zone
+	Ranges string
+	// Global must be set for lists without user, tag, or account restrictions
+	Global bool
+}
+
+// PriceListImport is the result of a price list upload
+type PriceListImport struct {
+	PriceListID string
+	Count       int
+}
+
+// ParamsPriceTiersPost replaces the quantity breaks for the sku,
+// Tiers are CSV rows with qty and price columns
+type ParamsPriceTiersPost struct {
+	Sku   string
+	Tiers string
+}
+
+// PriceSchedules lists scheduled changes and the price history for a sku,
+// date times are in the domain time zone
+type PriceSchedules struct {
+	Sku       string
+	Currency  string
+	Timezone  string
+	Price     int64
+	Schedules []PriceSchedule
+	History   []PriceHistory
+}
+
+// PriceSchedule is a scheduled change to the catalog price.
+// End is empty if the change is permanent,
+// Prev is the price restored at the end
+type PriceSchedule struct {
+	PriceScheduleID string
+	Price           int64
+	Start           string
+	End             string
+	Prev            int64
+	State           string
+}
+
+// PriceHistory is a change to the catalog price
+type PriceHistory struct {
+	Price           int64
+	Changed         string
+	ModID           string
+	PriceScheduleID string
+}
+
+// ParamsPriceSchedulePost schedules a change to the catalog price,
+// date times are in the domain time zone, End may be empty
+type ParamsPriceSchedulePost struct {
+	Sku   string
+	Price int64
+	Start string
+	End   string
+}
+
+// ParamsPriceScheduleDelete cancels a pending price change
+type ParamsPriceScheduleDelete struct {
+	PriceScheduleID string
+}
@@ -0,0 +1,86 @@
+package share
+
+import "time"
+
+// Reconciliation issue types
+const (
+	// ReconNoTran is a paid order without a successful transaction
+	ReconNoTran = "no_tran"
+	// ReconUnlinked is a successful payment not linked to an order
+	ReconUnlinked = "unlinked"
+	// ReconAmount is a paid order where payments don't match the total
+	ReconAmount = "amount"
+	// ReconCurrency is a payment in a currency other than the order currency
+	ReconCurrency = "currency"
+	// ReconRefund is a payment received after the order was reversed
+	// or expired, that was not refunded
+	ReconRefund = "refund"
+)
+
+// ReconFilter for the payment reconciliation report, empty fields are ignored.
+// From and To are dates, e.g. 2006-01-02, and the range includes To
+type ReconFilter struct {
+	Processor string
+	State     string
+	From      string
+	To        string
+}
+
+// ReconTran is a transaction listed on the reconciliation report
+type ReconTran struct {
+	Payment
+	OrderNo string
+	Date    time.Time
+}
+
+// ReconIssue flags an order or transaction that doesn't reconcile.
+// Expected is the order total, Actual is the amount received
+type ReconIssue struct {
+	Type     string
+	OrderID  string
+	OrderNo  string
+	TranID   string
+	Currency string
+	Expected int64
+	Actual   int64
+}
+
+// Reconciliation lists transactions matching the filter,
+// and issues for orders and transactions in the date range.
+// Received and Refunded are the sums of successful transactions
+type Reconciliation struct {
+	Filter   ReconFilter
+	Currency string
+	Trans    []ReconTran
+	Issues   []ReconIssue
+	Received int64
+	Refunded int64
+}
+
+// SettlementLine is a row from a processor settlement CSV,
+// Line is the line number in the file
+type SettlementLine struct {
+	Line     int
+	Ref      string
+	Amount   int64
+	Currency string
+}
+
+// SettlementMatch is a settlement line with the transaction for the ref
+type SettlementMatch struct {
+	SettlementLine
+	Tran ReconTran
+}
+
+// ReconSettlement is the diff between a processor settlement
+// and successful payments for the processor.
+// Mismatched lines have a different amount or currency,
+// Unsettled lists payments not in the settlement
+type ReconSettlement struct {
+	Filter     ReconFilter
+	Currency   string
+	Matched    []SettlementMatch
+	Mismatched []SettlementMatch
+	Unmatched  []SettlementLine
+	Unsettled  []ReconTran
+}
@@ -0,0 +1,14 @@
+package share
+
+// User roles must be listed in the role table
+const (
+	RoleAdmin    = "admin"
+	RoleCustomer = "customer"
+	RoleWebhook  = "webhook"
+)
+
+// SessionUser is the user for a verified session token
+type SessionUser struct {
+	UserID string
+	Role   string
+}
@@ -0,0 +1,132 @@
+package share
+
+import "time"
+
+// Transaction states, see comments for the tran table
+const (
+	TranStatePending = "pending"
+	TranStateSuccess = "success"
+	TranStateFailed  = "failed"
+)
+
+// Transaction methods, see the method tran_config term
+const (
+	TranMethodCard = "card"
+	TranMethodEFT  = "eft"
+	TranMethodCash = "cash"
+	// TranMethodCredit pays with, or refunds to, store credit
+	TranMethodCredit = "credit"
+)
+
+// Payment states reported by processors,
+// captured payments are recorded as successful transactions
+const (
+	PaymentPending    = "pending"
+	PaymentAuthorized = "authorized"
+	PaymentCaptured   = "captured"
+	PaymentFailed     = "failed"
+	PaymentRefunded   = "refunded"
+)
+
+// Payment is a transaction linked to an order
+type Payment struct {
+	TranID   string
+	OrderID  string
+	State    string
+	Descr    string
+	Amount   int64
+	Currency string
+	Method   string
+	// Processor is empty for payments captured by admins
+	Processor string
+	// Ref is the processor reference for the payment
+	Ref string
+	// Message is the reference entered by admins, e.g. for EFT payments
+	Message string
+	// Refunded amount, refunds may be partial
+	Refunded int64
+	// Type is TranTypeRefund for refunds, otherwise empty
+	Type string
+	// CreditNoteID the refund was made for
+	CreditNoteID string
+	// AccountID is set for store credit
+	AccountID string
+}
+
+// FakeIntent is a payment registered with the fake processor
+type FakeIntent struct {
+	Ref       string
+	TranID    string
+	Amount    int64
+	Currency  string
+	ReturnURL string
+	State     string
+	Refunded  int64
+}
+
+// Actions on the fake hosted payment page
+const (
+	FakePayActionPay     = "pay"
+	FakePayActionDecline = "decline"
+)
+
+type ParamsFakePayPost struct {
+	Action string
+}
+
+// OrderPayments lists the transactions for an order,
+// Due is the outstanding balance
+type OrderPayments struct {
+	OrderID  string
+	State    string
+	Paid     bool
+	Currency string
+	Total    int64
+	Received int64
+	Due      int64
+	// Refunded is the sum of refund transactions
+	Refunded int64
+	Payments []Payment
+}
+
+// ParamsOrdersPaymentPost records a payment received by an admin,
+// Amount is in the smallest unit of the currency
+type ParamsOrdersPaymentPost struct {
+	OrderID string
+	Method  string
+	Message string
+	Amount  int64
+}
+
+// Handled states for payment webhook events
+const (
+	PaymentEventPending = "pending"
+	PaymentEventDone    = "done"
+	PaymentEventFailed  = "failed"
+)
+
+// PaymentEvent is a webhook event sent by a processor,
+// State is the payment state, e.g. PaymentCaptured
+type PaymentEvent struct {
+	Processor string
+	EventID   string
+	Ref       string
+	State     string
+	Amount    int64
+	Handled   string
+	Error     string
+	Attempts  int64
+	Updated   time.Time
+}
+
+// PaymentEvents lists webhook events for the admin screen
+type PaymentEvents struct {
+	Handled  string
+	Currency string
+	Events   []PaymentEvent
+}
+
+type ParamsPaymentEventPost struct {
+	Processor string
+	EventID   string
+}
@@ -1,9 +1,19 @@
 package share
 
-import "time"
+import (
+	"strconv"
+	"time"
+)
 
 const VersionFormatSeconds = "2006-01-02-15-04-05"
 
 func NowVersion() string {
 	return time.Now().UTC().Format(VersionFormatSeconds)
 }
+
+// FormatPct formats a percentage stored times 100, e.g. 1500 is "15%",
+// and 1250 is "12.5%"
+func FormatPct(pct int64) string {
+	s := strconv.FormatFloat(float64(pct)/100, 'f', -1, 64)
+	return s + "%"
+}
@@ -0,0 +1,76 @@
+package share
+
+import "fmt"
+
+// VariantAttrMax is the number of attributes for variants,
+// see VariantAttrTerm
+const VariantAttrMax = 3
+
+// VariantAttrTerm is the cat_config term for attribute i, starting from 1.
+// The attribute name is stored in variant_attr_i, e.g. size,
+// and the value in variant_val_i, e.g. 42
+func VariantAttrTerm(i int) string {
+	return fmt.Sprintf("variant_attr_%d", i)
+}
+
+// VariantValTerm is the cat_config term for the value of attribute i
+func VariantValTerm(i int) string {
+	return fmt.Sprintf("variant_val_%d", i)
+}
+
+// Variant is a sku in a variant group,
+// Vals are in the order of the group attributes.
+// Available is false if the item is not in stock
+type Variant struct {
+	Sku       string
+	Title     string
+	Vals      []string
+	Available bool
+}
+
+// VariantVal is an option for an attribute selector.
+// Sku is the variant with the value and the other selected values,
+// or the first variant with the value if there is no exact match
+type VariantVal struct {
+	Val       string
+	Sku       string
+	Selected  bool
+	Available bool
+}
+
+// VariantAttr is a selector with values in display order
+type VariantAttr struct {
+	Attr string
+	Vals []VariantVal
+}
+
+// VariantGroup lists the variants in the group of Sku,
+// with attribute selectors for the selected Sku
+type VariantGroup struct {
+	GroupID  string
+	Sku      string
+	Attrs    []VariantAttr
+	Variants []Variant
+}
+
+// ParamsVariantGet for the variant group of the sku
+type ParamsVariantGet struct {
+	Sku string
+}
+
+// ParamsVariantsPost generates variants for each combination of attribute
+// values. Attrs has one attribute per line, the name and comma separated
+// values, e.g. "size: 40, 41, 42". Title and Price are for new items
+type ParamsVariantsPost struct {
+	GroupID string
+	Title   string
+	Price   int64
+	Attrs   string
+}
+
+// VariantsGenerate is the result of generating variants,
+// Created lists the new skus
+type VariantsGenerate struct {
+	VariantGroup
+	Created []string
+}
@@ -68,7 +68,7 @@ func BuildSite(
 		return err
 	}
 
-	err = buildSite(env, envMap)
+	err = buildSite(env, envMap, nil)
 	if err != nil {
 		return errors.WithStack(err)
 	}
@@ -76,8 +76,29 @@ func BuildSite(
 	return nil
 }
 
+// BuildSkus rebuilds the static pages for comma separated skus,
+// the server runs it when prices change, see services.MageSite
+func BuildSkus(
+	ctx context.Context, skus string) (err error) {
+
+	envMap, err := versionEnv()
+	if err != nil {
+		return err
+	}
+
+	err = buildSite(EnvProd, envMap, strings.Split(skus, ","))
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	return nil
+}
+
+// buildSite builds the pages for skus, or all pages if skus is empty
 func buildSite(
-	env string, envMap []string) (err error) {
+	env string, envMap []string, skus []string) (err error) {
+
+	log.Info().Str("env", env).Strs("skus", skus).Msg("Building site")
 
 	// TODO Nothing to do here?
 
@@ -130,7 +151,7 @@ func BuildDev() (err error) {
 	}
 
 	// Static site
-	err = buildSite(EnvDev, envMap)
+	err = buildSite(EnvDev, envMap, nil)
 	if err != nil {
 		return errors.WithStack(err)
 	}
@@ -188,7 +188,7 @@ func shopdWatcherCmd() string {
 	mainPath := filepath.Join(conf.Dir(), "cmd", "shopd", "main.go")
 	return fmt.Sprintf(`%s \
 	--build.cmd "go build -o %s %s" \
-	--build.bin "%s run" \
+	--build.bin "%s run --stubs" \
 	--build.delay "100" \
 	--build.exclude_dir "node_modules" \
 	--build.exclude_dir "magefiles" \
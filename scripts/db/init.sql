@@ -9,7 +9,9 @@ insert into taxonomy(taxonomy, descr, mod) values
 -- See comments for Address table, and formats on Wikipedia
 -- https://en.wikipedia.org/wiki/Address#Format_by_country_and_area
 ("address_it", "Address format for Italy", "000pt58M8fYM8MzqlOmoPyu0lbE"),
-("address_za", "Address format for South Africa", "000pt58M8fYM8MzqlOmoPyu0lbE");
+("address_za", "Address format for South Africa", "000pt58M8fYM8MzqlOmoPyu0lbE"),
+-- See comments for variant table
+("variants", "Variant attributes", "000pt58M8fYM8MzqlOmoPyu0lbE");
 
 insert into eltag(eltag) values
 -- Empty string is the default for term.eltag
@@ -35,7 +37,14 @@ insert into term(term, descr, mod) values
 ("address_it_street", "Street name and number", "000pt58M8fYM8MzqlOmoPyu0lbE"),
 ("address_it_building", "Building, floor, and/or apartment number", "000pt58M8fYM8MzqlOmoPyu0lbE"),
 ("address_it_postbox", "Post office box number", "000pt58M8fYM8MzqlOmoPyu0lbE"),
-("address_it_postcode", "Postcode, town and province abbreviation", "000pt58M8fYM8MzqlOmoPyu0lbE");
+("address_it_postcode", "Postcode, town and province abbreviation", "000pt58M8fYM8MzqlOmoPyu0lbE"),
+
+("variant_attr_1", "Variant attribute, e.g. size", "000pt58M8fYM8MzqlOmoPyu0lbE"),
+("variant_val_1", "Variant attribute value, e.g. 42", "000pt58M8fYM8MzqlOmoPyu0lbE"),
+("variant_attr_2", "Variant attribute, e.g. colour", "000pt58M8fYM8MzqlOmoPyu0lbE"),
+("variant_val_2", "Variant attribute value, e.g. grey", "000pt58M8fYM8MzqlOmoPyu0lbE"),
+("variant_attr_3", "Variant attribute", "000pt58M8fYM8MzqlOmoPyu0lbE"),
+("variant_val_3", "Variant attribute value", "000pt58M8fYM8MzqlOmoPyu0lbE");
 
 insert into taxonomy_x_term(taxonomy, term) values
 ("address_za", "address_za_street"),
@@ -47,7 +56,14 @@ insert into taxonomy_x_term(taxonomy, term) values
 ("address_it", "address_it_street"),
 ("address_it", "address_it_building"),
 ("address_it", "address_it_postbox"),
-("address_it", "address_it_postcode");
+("address_it", "address_it_postcode"),
+
+("variants", "variant_attr_1"),
+("variants", "variant_val_1"),
+("variants", "variant_attr_2"),
+("variants", "variant_val_2"),
+("variants", "variant_attr_3"),
+("variants", "variant_val_3");
 
 insert into field(term, deflt, eltag, eltype, elreq, idx, mod, mod_id) values
 ("address_za_street", "", "input", "text", 1, 0, "000pt58M8fYM8MzqlOmoPyu0lbE", "s"),
@@ -74,7 +90,8 @@ insert into order_state(state) values
 ("pending"),
 ("confirmed"),
 ("reversed"),
-("complete");
+("complete"),
+("expired");
 
 insert into addrtype(type) values
 ("billing"),
@@ -87,3 +104,132 @@ insert into role(role) values
 
 insert into vat(country, pct, mod, mod_id) values
 ("ZAF", "1500", "000pt58M8fYM8MzqlOmoPyu0lbE", "s");
+
+insert into term(term, descr, mod) values
+("alloc_strategy", "Depot allocation strategy, prefer, fewest, or nearest", "000pt58M8fYM8MzqlOmoPyu0lbE"),
+("alloc_depot", "Preferred depot for allocating order lines", "000pt58M8fYM8MzqlOmoPyu0lbE"),
+("depot", "Depot that an order line is allocated to", "000pt58M8fYM8MzqlOmoPyu0lbE"),
+("backorder", "Set on paid orders that could not be allocated", "000pt58M8fYM8MzqlOmoPyu0lbE");
+
+insert into config(term, val, mod) values
+("alloc_strategy", "prefer", "000pt58M8fYM8MzqlOmoPyu0lbE");
+
+insert into term(term, descr, mod) values
+("business_name", "Registered business name, printed on invoices", "000pt58M8fYM8MzqlOmoPyu0lbE"),
+("business_addr", "Business address lines, printed on invoices", "000pt58M8fYM8MzqlOmoPyu0lbE"),
+("business_vat_no", "Business VAT registration number", "000pt58M8fYM8MzqlOmoPyu0lbE"),
+("business_reg_no", "Business registration number", "000pt58M8fYM8MzqlOmoPyu0lbE"),
+("business_email", "Business contact email", "000pt58M8fYM8MzqlOmoPyu0lbE"),
+("business_phone", "Business contact phone number", "000pt58M8fYM8MzqlOmoPyu0lbE"),
+("currency", "Default currency code for prices in the DB", "000pt58M8fYM8MzqlOmoPyu0lbE"),
+("order_no_prefix", "Prefix for order numbers", "000pt58M8fYM8MzqlOmoPyu0lbE"),
+("order_no_seq", "Last allocated order number", "000pt58M8fYM8MzqlOmoPyu0lbE"),
+("vat_no", "Customer VAT registration number for an order", "000pt58M8fYM8MzqlOmoPyu0lbE");
+
+insert into config(term, val, mod) values
+("currency", "ZAR", "000pt58M8fYM8MzqlOmoPyu0lbE"),
+("order_no_seq", "1000", "000pt58M8fYM8MzqlOmoPyu0lbE");
+
+insert into term(term, descr, mod) values
+("secret", "Key for signing cookies, generated on first use", "000pt58M8fYM8MzqlOmoPyu0lbE");
+
+insert into term(term, descr, mod) values
+("cart_expire", "Idle period after which carts are expired, e.g. 720h", "000pt58M8fYM8MzqlOmoPyu0lbE"),
+("cart_remind", "Idle period after which a cart reminder is emailed, empty to disable", "000pt58M8fYM8MzqlOmoPyu0lbE"),
+("cart_reminded", "Set when a cart reminder was emailed for an order", "000pt58M8fYM8MzqlOmoPyu0lbE");
+
+insert into config(term, val, mod) values
+("cart_expire", "720h", "000pt58M8fYM8MzqlOmoPyu0lbE");
+
+insert into term(term, descr, mod) values
+("order_expire", "Idle period after which unpaid pending orders are expired, e.g. 168h", "000pt58M8fYM8MzqlOmoPyu0lbE");
+
+insert into term(term, descr, mod) values
+("rounding", "Rounding mode for tax and discounts, half_even, half_up, down, or up", "000pt58M8fYM8MzqlOmoPyu0lbE"),
+("rounding_scope", "Round exact amounts per line, or per invoice", "000pt58M8fYM8MzqlOmoPyu0lbE");
+
+insert into config(term, val, mod) values
+("rounding", "half_even", "000pt58M8fYM8MzqlOmoPyu0lbE"),
+("rounding_scope", "invoice", "000pt58M8fYM8MzqlOmoPyu0lbE");
+
+insert into term(term, descr, mod) values
+("tax_method", "Tax calculation method, line or basket", "000pt58M8fYM8MzqlOmoPyu0lbE"),
+("country", "ISO 3166-1 alpha-3 country code for orders without an address", "000pt58M8fYM8MzqlOmoPyu0lbE");
+
+insert into config(term, val, mod) values
+("tax_method", "line", "000pt58M8fYM8MzqlOmoPyu0lbE"),
+("country", "ZAF", "000pt58M8fYM8MzqlOmoPyu0lbE");
+
+insert into term(term, descr, mod) values
+("discount", "Discount ID applied by a discount order line", "000pt58M8fYM8MzqlOmoPyu0lbE");
+
+-- discount is the system sku for discount order lines
+insert into cat(sku, title, descr, state, mod, mod_id) values
+("discount", "Discount", "Discount applied to the order", "system", "000pt58M8fYM8MzqlOmoPyu0lbE", "s");
+
+insert into term(term, descr, mod) values
+("discount_opt", "Optional discount ID applied to an order by an admin user", "000pt58M8fYM8MzqlOmoPyu0lbE");
+
+insert into term(term, descr, mod) values
+("coupon", "Coupon code applied to an order", "000pt58M8fYM8MzqlOmoPyu0lbE"),
+("voucher", "Voucher code applied to an order, or used by a voucher order line", "000pt58M8fYM8MzqlOmoPyu0lbE");
+
+-- voucher is the system sku for amounts drawn from vouchers
+insert into cat(sku, title, descr, state, mod, mod_id) values
+("voucher", "Voucher", "Amount paid with a voucher", "system", "000pt58M8fYM8MzqlOmoPyu0lbE", "s");
+
+insert into term(term, descr, mod) values
+("timezone", "IANA time zone for the domain, e.g. Africa/Johannesburg", "000pt58M8fYM8MzqlOmoPyu0lbE");
+
+insert into config(term, val, mod) values
+("timezone", "Africa/Johannesburg", "000pt58M8fYM8MzqlOmoPyu0lbE");
+
+insert into term(term, descr, mod) values
+("price_display", "Show prices to buyers inclusive or exclusive of tax", "000pt58M8fYM8MzqlOmoPyu0lbE");
+
+insert into config(term, val, mod) values
+("price_display", "inclusive", "000pt58M8fYM8MzqlOmoPyu0lbE");
+
+insert into term(term, descr, mod) values
+("payment_processor", "Payment processor for checkout, the fake processor is used with stubs if not set", "000pt58M8fYM8MzqlOmoPyu0lbE"),
+("method", "Transaction method, card, eft, or cash", "000pt58M8fYM8MzqlOmoPyu0lbE"),
+("processor", "Payment processor that handled a transaction", "000pt58M8fYM8MzqlOmoPyu0lbE"),
+("processor_ref", "Processor reference for a transaction", "000pt58M8fYM8MzqlOmoPyu0lbE"),
+("refunded", "Amount refunded by the processor for a transaction", "000pt58M8fYM8MzqlOmoPyu0lbE");
+
+insert into term(term, descr, mod) values
+("ref", "Reference entered by an admin for a transaction, e.g. for EFT payments", "000pt58M8fYM8MzqlOmoPyu0lbE");
+
+insert into term(term, descr, mod) values
+("credit_no_prefix", "Prefix for credit note numbers", "000pt58M8fYM8MzqlOmoPyu0lbE"),
+("credit_no_seq", "Last allocated credit note number", "000pt58M8fYM8MzqlOmoPyu0lbE"),
+("type", "Transaction type, refund, or empty for payments", "000pt58M8fYM8MzqlOmoPyu0lbE"),
+("credit_note", "Credit note that a refund transaction was made for", "000pt58M8fYM8MzqlOmoPyu0lbE");
+
+insert into config(term, val, mod) values
+("credit_no_prefix", "CN", "000pt58M8fYM8MzqlOmoPyu0lbE"),
+("credit_no_seq", "1000", "000pt58M8fYM8MzqlOmoPyu0lbE");
+
+insert into term(term, descr, mod) values
+("journal_sales", "Account code for sales in journal exports", "000pt58M8fYM8MzqlOmoPyu0lbE"),
+("journal_vat", "Account code for VAT output in journal exports", "000pt58M8fYM8MzqlOmoPyu0lbE"),
+("journal_receivable", "Account code for receivables in journal exports", "000pt58M8fYM8MzqlOmoPyu0lbE"),
+("journal_bank", "Account code for the bank in journal exports", "000pt58M8fYM8MzqlOmoPyu0lbE"),
+("journal_credit", "Account code for store credit and vouchers in journal exports", "000pt58M8fYM8MzqlOmoPyu0lbE"),
+("journal_tax", "Account code for taxes other than VAT in journal exports", "000pt58M8fYM8MzqlOmoPyu0lbE"),
+("journal_expense", "Account code for store credit adjustments in journal exports", "000pt58M8fYM8MzqlOmoPyu0lbE");
+
+insert into config(term, val, mod) values
+("journal_sales", "4000", "000pt58M8fYM8MzqlOmoPyu0lbE"),
+("journal_vat", "2200", "000pt58M8fYM8MzqlOmoPyu0lbE"),
+("journal_receivable", "1200", "000pt58M8fYM8MzqlOmoPyu0lbE"),
+("journal_bank", "1000", "000pt58M8fYM8MzqlOmoPyu0lbE"),
+("journal_credit", "2300", "000pt58M8fYM8MzqlOmoPyu0lbE"),
+("journal_tax", "2210", "000pt58M8fYM8MzqlOmoPyu0lbE"),
+("journal_expense", "6100", "000pt58M8fYM8MzqlOmoPyu0lbE");
+
+insert into term(term, descr, mod) values
+("smtp_addr", "SMTP server host:port for sending email", "000pt58M8fYM8MzqlOmoPyu0lbE"),
+("smtp_user", "SMTP username, empty to send without auth", "000pt58M8fYM8MzqlOmoPyu0lbE"),
+("smtp_pass", "SMTP password", "000pt58M8fYM8MzqlOmoPyu0lbE"),
+("mail_from", "Sender address for emails, defaults to business_email", "000pt58M8fYM8MzqlOmoPyu0lbE");
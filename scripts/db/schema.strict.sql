@@ -323,6 +323,75 @@ create table cat_price (
 	foreign key (sku) references cat(sku)
 ) strict;
 
+-- cat_price_tier lists quantity break prices,
+-- the price for the largest qty not more than the line qty is used.
+-- The cat_price applies below the first tier, see go/model/pricelist.go
+create table cat_price_tier (
+	sku text not null,
+	-- qty is the minimum line qty for the tier
+	qty integer not null check (qty > 1),
+	-- price in the smallest possible unit, see comments for cat_price
+	price integer not null check (price >= 0) default 0,
+	primary key (sku, qty),
+	foreign key (sku) references cat_price(sku)
+) strict;
+
+-- cat_price_schedule lists future changes to cat_price,
+-- applied by a background job, see go/model/schedule.go
+create table cat_price_schedule (
+	cat_price_schedule_id text primary key,
+	sku text not null,
+	-- price in the smallest possible unit, see comments for cat_price
+	price integer not null check (price >= 0) default 0,
+	-- start date time in UTC, e.g. 2024-12-06 00:00:00
+	start text not null,
+	-- end date time in UTC, empty if the change is permanent.
+	-- The prev price is restored at the end
+	end text not null default '',
+	-- prev is the cat_price replaced when the change started
+	prev integer not null check (prev >= 0) default 0,
+	state text not null check (
+		state in ('pending', 'active', 'done', 'cancelled')
+	) default 'pending',
+	mod text not null check (mod <> ''),
+	mod_id text not null check (mod_id <> ''),
+	foreign key (sku) references cat(sku)
+) strict;
+
+-- cat_price_schedule_state_idx for the background job
+create index cat_price_schedule_state_idx on cat_price_schedule(state);
+
+-- cat_price_history lists every change to cat_price
+create table cat_price_history (
+	sku text not null,
+	price integer not null check (price >= 0) default 0,
+	-- changed date time in UTC when the price took effect,
+	-- for scheduled changes this is the start or end
+	changed text not null,
+	mod text not null check (mod <> ''),
+	mod_id text not null check (mod_id <> ''),
+	-- cat_price_schedule_id if the change was scheduled
+	cat_price_schedule_id text not null default '',
+	primary key (sku, mod),
+	foreign key (sku) references cat(sku)
+) strict;
+
+-- cat_price_history_changed_idx to list the history for a sku
+create index cat_price_history_changed_idx on cat_price_history(sku, changed);
+
+-- exchange_rate converts prices in the default currency for display,
+-- orders are charged in the default currency.
+-- Rates are maintained by admin users, see go/model/currency.go
+create table exchange_rate (
+	-- currency code, e.g. USD
+	currency text primary key check (length(currency) = 3),
+	-- rate is the number of major units of the currency for one major unit
+	-- of the default currency, times 1000000. See go/money/convert.go
+	rate integer not null check (rate > 0),
+	mod text not null check (mod <> ''),
+	mod_id text not null check (mod_id <> '')
+) strict;
+
 create table cat_qty (
 	sku text not null,
 	-- depot is an optional physical location
@@ -389,10 +458,11 @@ end;
 -- group_id="running-shoe"
 -- sku="running-shoe-42-grey"
 --
--- TODO Taxonomy "variants" with terms "variant_attr_x" and "variant_val_x"?
--- E.g.
+-- Variant attributes are cat_config terms in the "variants" taxonomy,
+-- "variant_attr_x" and "variant_val_x" with x from 1 to 3. E.g.
 -- variant_attr_1="size", variant_val_1="42"
 -- variant_attr_2="colour", variant_val_2="grey"
+-- Variants in a group should set the same attributes
 create table variant (
 	-- group_id is the unique ID for a group of variants
 	group_id text not null default '',
@@ -423,6 +493,28 @@ create table cat_img (
 
 -- TODO Trigger on cat_img, hash must exist in img table
 
+
+-- .............................................................................
+--
+-- depot lists physical stock locations, see cat_qty.depot.
+-- Order lines are allocated to depots when the order is confirmed,
+-- the allocated depot is recorded with order_config.term="depot"
+create table depot (
+	depot text primary key,
+	-- descr in short, e.g. "Johannesburg warehouse"
+	descr text not null,
+	-- province is compared with the delivery address province,
+	-- when using the "nearest" allocation strategy
+	province text not null default '',
+	-- idx for sorting depots by preference.
+	-- Optional, fall back to sort on depot
+	idx integer not null default 0,
+	mod text not null check (mod <> ''),
+	mod_id text not null check (mod_id <> '')
+) strict;
+
+create index depot_mod_idx on depot(mod);
+
 -- .............................................................................
 --
 -- user table
@@ -618,7 +710,8 @@ create index orders_mod_idx on orders(mod);
 create table order_tax (
 	order_id text not null,
 	-- order_line_id if applicable, otherwise empty.
-	-- For example, the tax calculation method could be "basket"
+	-- For example, the tax calculation method could be "basket",
+	-- see the tax_method config term
 	-- https://github.com/shopd/shopd-issues/issues/70
 	order_line_id text not null,
 	-- fixed is set if tax was calculated as a fixed amount
@@ -633,6 +726,10 @@ create table order_tax (
 	-- pct is non-zero if a percentage tax was applied,
 	-- e.g. use 1500 for 15% VAT
 	pct integer not null check (pct >= 0) default 0,
+	-- vat is set if the tax is VAT, see the vat col on the tax table
+	vat integer not null check (vat in (0, 1)) default 0,
+	-- descr from the tax table, empty for the default description
+	descr text not null default '',
 	-- mod is the timestamp when this tax line was added.
 	-- Tax lines can't be edited, only add or delete is allowed
 	mod text not null check (mod <> ''),
@@ -666,7 +763,11 @@ create table order_line (
 	-- sku is the unique catalog item.
 	-- System processes and admin users can add "system" skus to an order,
 	-- they are useful for things like discounts, coupons, vouchers, etc.
-	-- TODO Consider discounts, coupons, vouchers, etc.
+	-- Discounts use the "discount" sku with a negative price,
+	-- the discount_id is set on order_config for the line.
+	-- Coupon codes add a discount, see the coupon table.
+	-- Vouchers use the "voucher" sku with a negative price,
+	-- the voucher code is set on order_config for the line.
 	-- "A coupon grants you a discount on your order.
 	-- A voucher, on the other hand, is considered a monetary substitute,
 	-- which is determined by the amount stated on the voucher"
@@ -770,6 +871,51 @@ create table order_tran (
 ) strict;
 
 
+-- credit_note reverses all or part of an invoice for a paid order.
+-- Credit notes are numbered separately from orders,
+-- see the credit_no_seq config term.
+-- Money refunded is recorded in the tran table,
+-- with the credit_note tran_config term
+create table credit_note (
+	credit_note_id text primary key,
+	order_id text not null,
+	credit_no text not null unique,
+	-- reason for the credit, printed on the credit note
+	reason text not null,
+	-- amount credited excluding tax, in the smallest unit
+	amount integer not null check (amount >= 0),
+	-- tax credited
+	tax integer not null check (tax >= 0),
+	-- refund is the money to be refunded for the credit note,
+	-- refund transactions link to the credit note with tran_config
+	refund integer not null check (refund >= 0) default 0,
+	mod text not null check (mod <> ''),
+	-- mod_id is the user_id that made the last change
+	mod_id text not null check (mod_id <> ''),
+	foreign key (order_id) references orders(order_id)
+) strict;
+
+-- credit_note_order_id_idx to list credit notes for an order
+create index credit_note_order_id_idx on credit_note(order_id);
+
+-- credit_note_line is the qty and amount credited for an order line
+create table credit_note_line (
+	credit_note_id text not null,
+	-- order_line_id is empty for credits by amount
+	order_line_id text not null,
+	sku text not null,
+	qty integer not null check (qty >= 0),
+	-- amount credited excluding tax
+	amount integer not null,
+	-- tax credited
+	tax integer not null,
+	-- restock is the qty returned to stock
+	restock integer not null check (restock >= 0) default 0,
+	primary key (credit_note_id, order_line_id),
+	foreign key (credit_note_id) references credit_note(credit_note_id)
+) strict;
+
+
 -- .............................................................................
 
 -- tran table for recording payments, credit notes, etc
@@ -795,6 +941,9 @@ create table tran (
 
 create index tran_mod_idx on tran(mod);
 
+-- tran_account_id_idx for account statements
+create index tran_account_id_idx on tran(account_id, mod);
+
 -- tran_config meta table, e.g.
 -- "method=cash", "method=card", "processor=stripe", "ref=message"
 create table tran_config (
@@ -821,15 +970,52 @@ create index tran_tag_tran_id_idx on tran_tag(tran_id);
 -- tran_tag_tag_idx to list all tran_ids for a tag
 create index tran_tag_tag_idx on tran_tag(tag);
 
--- TODO account might be overkill for now,
--- but create it now for future reference.
--- In the meantime tran table is good enough?
+-- tran_config_val_idx to find transactions by config value,
+-- e.g. the processor_ref sent with webhook events
+create index tran_config_val_idx on tran_config(term, val);
+
+-- payment_event is the inbox for payment processor webhooks.
+-- Events are stored before they are handled,
+-- events that were handled before are ignored
+create table payment_event (
+	-- processor name, see the payment_processor config term
+	processor text not null,
+	-- event_id assigned by the processor
+	event_id text not null,
+	-- ref is the processor reference for the payment,
+	-- see the processor_ref tran_config term
+	ref text not null,
+	-- state of the payment reported by the processor, e.g. captured
+	state text not null,
+	amount integer not null,
+	-- body is the raw request body
+	body text not null,
+	-- handled is pending, done, or failed.
+	-- Failed events may be retried by an admin
+	handled text not null check (handled in ('pending', 'done', 'failed')),
+	-- error message for failed events
+	error text not null default '',
+	attempts integer not null default 0,
+	mod text not null check (mod <> ''),
+	-- mod_id is the user_id that made the last change
+	mod_id text not null check (mod_id <> ''),
+	primary key (processor, event_id)
+) strict;
+
+-- payment_event_handled_idx to list events that need attention
+create index payment_event_handled_idx on payment_event(handled, mod);
+
+-- account is a customer ledger, e.g. for store credit.
+-- The balance is derived from successful tran rows with the account_id,
+-- the type tran_config term decides if a tran is a credit or debit.
 -- All accounts in here are for users with customer role
 create table account (
 	account_id text primary key,
 	descr text not null
 ) strict;
 
+-- account_x_user links users to accounts,
+-- the account balance is shared by all linked users
 create table account_x_user (
 	account_id text not null,
 	user_id text not null,
@@ -837,6 +1023,9 @@ create table account_x_user (
 	foreign key (account_id) references account(account_id)
 ) strict;
 
+-- account_x_user_user_id_idx to list accounts for a user
+create index account_x_user_user_id_idx on account_x_user(user_id);
+
 
 -- .............................................................................
 
@@ -889,11 +1078,70 @@ create table tax (
 
 -- .............................................................................
 
--- TODO price override tables have the same structure as discount tables?
+-- price_list overrides cat_price for buyers,
+-- restrictions have the same structure as the discount tables.
+-- Lists without user, tag, or account restrictions apply to all buyers.
+-- The most specific price is used, see go/model/pricelist.go
+create table price_list (
+	price_list_id text primary key,
+	-- descr to describe what this price list is for
+	descr text not null,
+	mod text not null check (mod <> ''),
+	mod_id text not null check (mod_id <> '')
+) strict;
+
+-- price_list_price lists the exclusive price per sku,
+-- see comments for cat_price
+create table price_list_price (
+	price_list_id text not null,
+	sku text not null,
+	-- price in the smallest possible unit, e.g. cent
+	price integer not null check (price >= 0) default 0,
+	primary key (price_list_id, sku),
+	foreign key (price_list_id) references price_list(price_list_id)
+) strict;
+
+-- price_list_user if the price list is for specified users
+create table price_list_user (
+	price_list_id text not null,
+	user_id text not null,
+	primary key (price_list_id, user_id),
+	foreign key (price_list_id) references price_list(price_list_id)
+) strict;
+
+-- price_list_tag if the price list is for users with specified tags in user_tag
+create table price_list_tag (
+	price_list_id text not null,
+	tag text not null,
+	primary key (price_list_id, tag),
+	foreign key (price_list_id) references price_list(price_list_id)
+) strict;
+
+-- price_list_account if the price list is for users linked to an account
+create table price_list_account (
+	price_list_id text not null,
+	account_id text not null,
+	primary key (price_list_id, account_id),
+	foreign key (price_list_id) references price_list(price_list_id),
+	foreign key (account_id) references account(account_id)
+) strict;
+
+-- price_list_range if the price list applies for a date time range
+create table price_list_range (
+	price_list_id text not null,
+	-- start date time in UTC, e.g. 2024-12-01 00:00:00
+	start text not null,
+	-- end date time in UTC, exclusive
+	end text not null,
+	primary key (price_list_id, start, end),
+	foreign key (price_list_id) references price_list(price_list_id)
+) strict;
 
 create table discount (
 	discount_id text primary key,
-	-- pct is the percentage discount to apply, or zero if not applicable
+	-- pct is the percentage discount to apply, or zero if not applicable,
+	-- e.g. use 1000 for 10%.
+	-- See go/model/discount.go for precedence and stacking rules
 	pct integer not null check (pct >= 0) default 0,
 	-- discount is a fixed discount, instead of percentage.
 	-- Use the value in this col instead of pct if non-zero
@@ -939,9 +1187,9 @@ create table discount_user (
 -- discount_range if the discount applies for a date time range
 create table discount_range (
 	discount_id text not null,
-	-- start date time
+	-- start date time in UTC, e.g. 2024-12-01 00:00:00
 	start text not null,
-	-- end date time
+	-- end date time in UTC, exclusive
 	end text not null,
 	primary key (discount_id, start, end),
 	foreign key (discount_id) references discount(discount_id)
@@ -953,3 +1201,80 @@ create table discount_opt (
 	discount_id text primary key,
 	foreign key (discount_id) references discount(discount_id)
 ) strict;
+
+-- coupon codes grant a discount.
+-- Discounts used by coupons are not applied automatically
+create table coupon (
+	-- code entered by the customer, upper case
+	code text primary key,
+	discount_id text not null,
+	-- max_uses is the number of orders that may use the code,
+	-- zero if not limited
+	max_uses integer not null check (max_uses >= 0) default 0,
+	-- max_user_uses is the number of orders per user that may use the code,
+	-- zero if not limited
+	max_user_uses integer not null check (max_user_uses >= 0) default 0,
+	mod text not null check (mod <> ''),
+	mod_id text not null check (mod_id <> ''),
+	foreign key (discount_id) references discount(discount_id)
+) strict;
+
+-- coupon_use records the orders that used a coupon code,
+-- uses by reversed or expired orders are not counted
+create table coupon_use (
+	code text not null,
+	order_id text not null,
+	user_id text not null,
+	primary key (code, order_id),
+	foreign key (code) references coupon(code),
+	foreign key (order_id) references orders(order_id)
+) strict;
+
+-- voucher is a monetary substitute, the balance is drawn down by orders
+create table voucher (
+	-- code entered by the customer, upper case
+	code text primary key,
+	-- amount the voucher was issued for, in the smallest unit
+	amount integer not null check (amount >= 0) default 0,
+	-- balance remaining, in the smallest unit
+	balance integer not null check (balance >= 0) default 0,
+	mod text not null check (mod <> ''),
+	mod_id text not null check (mod_id <> '')
+) strict;
+
+-- voucher_redeem records the amount drawn from a voucher by an order.
+-- The amount is returned to the balance if the order is reversed
+create table voucher_redeem (
+	code text not null,
+	order_id text not null,
+	amount integer not null check (amount > 0),
+	mod text primary key,
+	foreign key (code) references voucher(code),
+	foreign key (order_id) references orders(order_id)
+) strict;
+
+-- journal_export is a batch of accounting journal entries for a period,
+-- see the journal_* config terms for the account codes
+create table journal_export (
+	export_id text primary key,
+	-- period_from and period_to are dates, the period includes period_to
+	period_from text not null,
+	period_to text not null,
+	mod text not null check (mod <> ''),
+	mod_id text not null check (mod_id <> '')
+) strict;
+
+-- journal_entry records the documents included in an export,
+-- documents are only exported once so nothing is booked twice.
+-- source is invoice, tran, or credit_note,
+-- source_id is the order_id, tran_id, or credit_note_id
+create table journal_entry (
+	source text not null,
+	source_id text not null,
+	export_id text not null,
+	primary key (source, source_id),
+	foreign key (export_id) references journal_export(export_id)
+) strict;
+
+-- journal_entry_export_id_idx to list the documents for an export
+create index journal_entry_export_id_idx on journal_entry(export_id);
@@ -0,0 +1,36 @@
+package components
+
+import (
+	"bytes"
+	"context"
+
+	"github.com/pkg/errors"
+	"github.com/shopd/shopd/go/share"
+	"github.com/shopd/shopd/www/view"
+)
+
+// InvoiceAttachments renders the HTML and plain text invoice,
+// for attaching to email messages
+func InvoiceAttachments(
+	ctx context.Context, model view.Invoice) (
+	attachments []share.Attachment, err error) {
+
+	buf := bytes.Buffer{}
+	err = Invoice(model).Render(ctx, &buf)
+	if err != nil {
+		return attachments, errors.WithStack(err)
+	}
+
+	return []share.Attachment{
+		{
+			Filename:    model.Filename("html"),
+			ContentType: "text/html; charset=utf-8",
+			Body:        buf.Bytes(),
+		},
+		{
+			Filename:    model.Filename("txt"),
+			ContentType: "text/plain; charset=utf-8",
+			Body:        []byte(model.Text()),
+		},
+	}, nil
+}
@@ -0,0 +1,52 @@
+package view
+
+import (
+	"strings"
+
+	"github.com/shopd/shopd/go/share"
+)
+
+// AccountStatement renders the transactions for an account
+type AccountStatement struct {
+	share.AccountStatement
+}
+
+func (v AccountStatement) Amount(amount int64) string {
+	if amount == 0 {
+		return ""
+	}
+	return FormatAmount(amount, v.Currency)
+}
+
+func (v AccountStatement) Date(line share.AccountLine) string {
+	return line.Date.Format(DateFormat)
+}
+
+// Balance formats the balance, zero is shown
+func (v AccountStatement) Balance(balance int64) string {
+	return FormatAmount(balance, v.Currency)
+}
+
+// AccountGet lists statements for the accounts of the session user
+type AccountGet struct {
+	Statements []AccountStatement
+}
+
+// AccountsGet lists accounts for admin users
+type AccountsGet struct {
+	share.Accounts
+}
+
+func (v AccountsGet) Amount(amount int64) string {
+	return FormatAmount(amount, v.Currency)
+}
+
+func (v AccountsGet) Users(account share.Account) string {
+	return strings.Join(account.Users, ", ")
+}
+
+// AccountsStatementGet is the statement for admin users,
+// with the form to adjust the balance
+type AccountsStatementGet struct {
+	AccountStatement
+}
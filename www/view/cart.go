@@ -0,0 +1,57 @@
+package view
+
+import (
+	"strconv"
+
+	"github.com/shopd/shopd/go/money"
+	"github.com/shopd/shopd/go/share"
+)
+
+type CartGet struct {
+	share.Cart
+	// Display converts amounts to the currency selected by the buyer,
+	// the cart is charged in Currency
+	Display money.Converter
+	// Accounts with store credit for the session user,
+	// the buyer selects the account if there is more than one
+	Accounts []share.AccountStatement
+}
+
+// Amount formats an amount in the smallest unit
+func (v CartGet) Amount(amount int64) string {
+	return FormatAmount(amount, v.Currency)
+}
+
+// DisplayAmount formats an amount in the display currency
+func (v CartGet) DisplayAmount(amount int64) string {
+	return DisplayAmount(v.Display, amount, v.Currency)
+}
+
+// TaxLabel for line prices and the subtotal
+func (v CartGet) TaxLabel() string {
+	return TaxLabel(v.TaxDisplay)
+}
+
+// Inclusive returns true if line prices include tax
+func (v CartGet) Inclusive() bool {
+	return Inclusive(v.TaxDisplay)
+}
+
+func (v CartGet) Qty(qty int64) string {
+	return strconv.FormatInt(qty, 10)
+}
+
+// Codes applied to the cart
+func (v CartGet) Codes() (codes []string) {
+	for _, code := range []string{v.Coupon, v.Voucher} {
+		if code != "" {
+			codes = append(codes, code)
+		}
+	}
+	return codes
+}
+
+// AccountDescr labels the account with the balance
+func (v CartGet) AccountDescr(account share.AccountStatement) string {
+	return account.Descr + ", " + v.Amount(account.Balance)
+}
@@ -0,0 +1,20 @@
+package view
+
+import (
+	"strconv"
+
+	"github.com/shopd/shopd/go/share"
+)
+
+// CatalogPost is the result of a catalog upload
+type CatalogPost struct {
+	share.CatalogImport
+}
+
+func (v CatalogPost) Count(n int) string {
+	return strconv.Itoa(n)
+}
+
+func (v CatalogPost) Line(rowErr share.CatalogRowError) string {
+	return strconv.Itoa(rowErr.Line)
+}
@@ -0,0 +1,6 @@
+package view
+
+// CodesPost lists a batch of generated coupon or voucher codes
+type CodesPost struct {
+	Codes []string
+}
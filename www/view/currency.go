@@ -0,0 +1,27 @@
+package view
+
+import (
+	"github.com/shopd/shopd/go/money"
+	"github.com/shopd/shopd/go/share"
+)
+
+// CurrencyGet is the display currency switcher
+type CurrencyGet struct {
+	share.ExchangeRates
+	// Selected display currency
+	Selected string
+}
+
+// CurrenciesGet lists exchange rates for admin users
+type CurrenciesGet struct {
+	share.ExchangeRates
+}
+
+// DisplayAmount formats the amount in the display currency,
+// with the currency code, e.g. USD 12.34
+func DisplayAmount(display money.Converter, amount int64, currency string) string {
+	if !display.Converted() {
+		return currency + " " + FormatAmount(amount, currency)
+	}
+	return display.To + " " + FormatAmount(display.Convert(amount), display.To)
+}
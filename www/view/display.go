@@ -0,0 +1,27 @@
+package view
+
+import (
+	"github.com/shopd/shopd/go/money"
+	"github.com/shopd/shopd/go/share"
+)
+
+// Inclusive returns true if prices are displayed including tax
+func Inclusive(taxDisplay string) bool {
+	return taxDisplay == share.PriceDisplayInclusive
+}
+
+// TaxLabel for prices in the given tax display mode
+func TaxLabel(taxDisplay string) string {
+	if Inclusive(taxDisplay) {
+		return "incl. VAT"
+	}
+	return "excl. VAT"
+}
+
+// DisplayPrice formats the price in the display currency, with the tax
+// label, e.g. ZAR 115.00 incl. VAT. Prices shown to buyers use this helper
+func DisplayPrice(
+	display money.Converter, amount int64, currency, taxDisplay string) string {
+
+	return DisplayAmount(display, amount, currency) + " " + TaxLabel(taxDisplay)
+}
@@ -0,0 +1,61 @@
+package view
+
+import (
+	"fmt"
+	"net/url"
+	"path"
+	"strings"
+
+	"github.com/shopd/shopd/go/share"
+)
+
+// ImgPrefix is the path for image URLs, images are served with long cache
+// headers since file names include the hash of the original
+const ImgPrefix = "/img"
+
+// ImgURL for the resized variant of the image,
+// or the original if width is 0
+func ImgURL(img share.Img, width int) string {
+	return path.Join(ImgPrefix, share.ImgFile(img.Hash, img.Ext, width))
+}
+
+// ImgSrc is the default src for the image, the largest variant
+func ImgSrc(img share.Img) string {
+	return ImgURL(img, share.ImgWidths[len(share.ImgWidths)-1])
+}
+
+// ImgSrcset lists the resized variants for the srcset attribute,
+// e.g. /img/abc-320.jpg 320w, /img/abc-640.jpg 640w
+func ImgSrcset(img share.Img) string {
+	srcset := make([]string, 0, len(share.ImgWidths))
+	for _, width := range share.ImgWidths {
+		srcset = append(srcset, fmt.Sprintf("%s %dw", ImgURL(img, width), width))
+	}
+	return strings.Join(srcset, ", ")
+}
+
+// ImgAlt falls back to the descr for the sku
+func ImgAlt(img share.Img) string {
+	if img.Alt != "" {
+		return img.Alt
+	}
+	return img.Descr
+}
+
+// CatalogImgGet lists the images for a sku
+type CatalogImgGet struct {
+	share.CatImgs
+}
+
+// Thumb is the smallest variant
+func (v CatalogImgGet) Thumb(img share.Img) string {
+	return ImgURL(img, share.ImgWidths[0])
+}
+
+// DeleteURL unlinks the image from the sku
+func (v CatalogImgGet) DeleteURL(img share.Img) string {
+	query := url.Values{}
+	query.Set("Sku", v.Sku)
+	query.Set("Hash", img.Hash)
+	return "/api/admin/catalog/img?" + query.Encode()
+}
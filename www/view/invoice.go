@@ -0,0 +1,166 @@
+package view
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/shopd/shopd/go/money"
+	"github.com/shopd/shopd/go/share"
+)
+
+const DateFormat = "2006-01-02"
+
+// Invoice is the view model for invoice, receipt, and credit note documents,
+// the same model is used for the HTML and plain text versions
+type Invoice struct {
+	share.Invoice
+	// Receipt toggles the document type
+	Receipt bool
+	// PayURL is set on receipts for orders with an amount due,
+	// the buyer pays again if the payment failed
+	PayURL string
+}
+
+// Title of the document.
+// South African tax invoices must contain the words "Tax Invoice"
+func (v Invoice) Title() string {
+	if v.Receipt {
+		return "Receipt"
+	}
+	if v.Credit() {
+		return "Credit Note"
+	}
+	return "Tax Invoice"
+}
+
+// Credit returns true for credit notes
+func (v Invoice) Credit() bool {
+	return v.CreditNo != ""
+}
+
+// PaymentDescr labels payments, credit notes list refunds
+func (v Invoice) PaymentDescr() string {
+	if v.Credit() {
+		return "Refunded"
+	}
+	return "Paid"
+}
+
+// Filename for attachments with the given extension
+func (v Invoice) Filename(ext string) string {
+	no := v.OrderNo
+	if no == "" {
+		no = v.OrderID
+	}
+	if v.Credit() {
+		no = v.CreditNo
+	}
+	return fmt.Sprintf("%s-%s.%s",
+		strings.ReplaceAll(strings.ToLower(v.Title()), " ", "-"), no, ext)
+}
+
+// Amount formats an amount in the smallest unit
+func (v Invoice) Amount(amount int64) string {
+	return FormatAmount(amount, v.Currency)
+}
+
+// SubtotalDescr labels the subtotal, it includes tax if the display is inclusive
+func (v Invoice) SubtotalDescr() string {
+	if Inclusive(v.TaxDisplay) {
+		return "Subtotal " + TaxLabel(v.TaxDisplay)
+	}
+	return "Subtotal"
+}
+
+// TaxDescr labels the tax lines,
+// tax is included in the subtotal if the display is inclusive
+func (v Invoice) TaxDescr(tax share.InvoiceTax) string {
+	if Inclusive(v.TaxDisplay) {
+		return "Includes " + tax.Descr
+	}
+	return tax.Descr
+}
+
+func (v Invoice) Date(t time.Time) string {
+	return t.Format(DateFormat)
+}
+
+func (v Invoice) Qty(qty int64) string {
+	return strconv.FormatInt(qty, 10)
+}
+
+// Text renders the plain text version
+func (v Invoice) Text() string {
+	buf := bytes.Buffer{}
+	line := func(a ...any) {
+		fmt.Fprintln(&buf, a...)
+	}
+
+	line(v.Title())
+	line()
+	line(v.Seller.Name)
+	if v.Seller.Addr != "" {
+		line(v.Seller.Addr)
+	}
+	if v.Seller.VatNo != "" {
+		line("VAT No:", v.Seller.VatNo)
+	}
+	if v.Seller.RegNo != "" {
+		line("Reg No:", v.Seller.RegNo)
+	}
+	line()
+	if v.Credit() {
+		line("Credit Note No:", v.CreditNo)
+	}
+	line("Order No:", v.OrderNo)
+	line("Date:", v.Date(v.Invoice.Date))
+	if v.Reason != "" {
+		line("Reason:", v.Reason)
+	}
+	line()
+	line("To:", v.Buyer.Name)
+	if v.Buyer.Email != "" {
+		line(v.Buyer.Email)
+	}
+	if v.Buyer.Addr != "" {
+		line(v.Buyer.Addr)
+	}
+	if v.Buyer.VatNo != "" {
+		line("VAT No:", v.Buyer.VatNo)
+	}
+	line()
+
+	w := tabwriter.NewWriter(&buf, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(w, "SKU\tDescription\tQty\tPrice\tAmount\t")
+	for _, l := range v.Lines {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t\n",
+			l.Sku, l.Title, v.Qty(l.Qty), v.Amount(l.Price), v.Amount(l.Amount))
+	}
+	fmt.Fprintln(w, "\t\t\t\t\t")
+	fmt.Fprintf(w, "\t\t\t%s\t%s\t\n", v.SubtotalDescr(), v.Amount(v.Subtotal))
+	for _, tax := range v.Taxes {
+		fmt.Fprintf(w, "\t\t%s\t%s\t%s\t\n",
+			v.TaxDescr(tax), v.Amount(tax.Taxable), v.Amount(tax.Tax))
+	}
+	fmt.Fprintf(w, "\t\t\tTotal %s\t%s\t\n", v.Currency, v.Amount(v.Total))
+	for _, p := range v.Payments {
+		fmt.Fprintf(w, "\t\t%s\t%s %s\t%s\t\n",
+			p.Descr, v.PaymentDescr(), v.Date(p.Date), v.Amount(p.Amount))
+	}
+	if v.Due > 0 {
+		fmt.Fprintf(w, "\t\t\tDue %s\t%s\t\n", v.Currency, v.Amount(v.Due))
+	}
+	w.Flush()
+
+	return buf.String()
+}
+
+// FormatAmount formats an amount in the smallest unit of the currency,
+// e.g. 123456 is "1,234.56" for ZAR
+func FormatAmount(amount int64, currency string) string {
+	return money.New(amount, currency).Number("")
+}
@@ -0,0 +1,149 @@
+package view
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/shopd/shopd/go/money"
+	"github.com/shopd/shopd/go/share"
+)
+
+// JournalGet lists previous journal exports
+type JournalGet struct {
+	share.JournalExports
+	// Message about the export that was created, if any
+	Message string
+}
+
+func (v JournalGet) Formats() []string {
+	return share.JournalFormats
+}
+
+func (v JournalGet) Date(export share.JournalExport) string {
+	return export.Date.Format(time.DateTime)
+}
+
+func (v JournalGet) Count(export share.JournalExport) string {
+	return strconv.Itoa(export.Count)
+}
+
+// DownloadURL for the export in the format
+func (v JournalGet) DownloadURL(export share.JournalExport, format string) string {
+	query := url.Values{}
+	query.Set(share.ParamExportID, export.ExportID)
+	query.Set(share.ParamFormat, format)
+	return "/api/admin/journal/export?" + query.Encode()
+}
+
+// JournalFile is a journal export in one of share.JournalFormats
+type JournalFile struct {
+	share.JournalExport
+	Format string
+}
+
+// Filename for the download
+func (v JournalFile) Filename() string {
+	return fmt.Sprintf("journal-%s-%s-%s.csv", v.From, v.To, v.Format)
+}
+
+// amount formats the amount as a decimal without separators,
+// zero is empty
+func (v JournalFile) amount(amount int64) string {
+	if amount == 0 {
+		return ""
+	}
+	return money.New(amount, v.Currency).Decimal()
+}
+
+// CSV writes the rows in the layout for the format.
+// Xero and QuickBooks read dates in the regional format of the company,
+// e.g. dd/mm/yyyy for South Africa.
+// Xero doesn't allow manual journals to system accounts,
+// e.g. receivables and VAT, use clearing accounts for the journal codes
+func (v JournalFile) CSV(w io.Writer) error {
+	writer := csv.NewWriter(w)
+	var header []string
+	var record func(row share.JournalRow) []string
+	switch v.Format {
+	case share.JournalFormatCSV:
+		header = []string{
+			"date", "journal", "ref", "source", "source_id", "account",
+			"descr", "debit", "credit", "currency",
+		}
+		record = func(row share.JournalRow) []string {
+			return []string{
+				row.Date.Format(time.DateOnly),
+				row.Journal,
+				row.Ref,
+				row.Source,
+				row.SourceID,
+				row.Account,
+				row.Descr,
+				v.amount(row.Debit),
+				v.amount(row.Credit),
+				v.Currency,
+			}
+		}
+
+	case share.JournalFormatXero:
+		// Lines with the same narration and date are one journal,
+		// debits are positive and credits negative
+		header = []string{
+			"*Narration", "*Date", "Description", "*AccountCode", "*TaxRate", "*Amount",
+		}
+		record = func(row share.JournalRow) []string {
+			return []string{
+				row.Journal,
+				row.Date.Format("02/01/2006"),
+				row.Descr,
+				row.Account,
+				"No VAT",
+				v.amount(row.Debit - row.Credit),
+			}
+		}
+
+	case share.JournalFormatQuickBooks:
+		// Journal numbers are unique per document in the export
+		header = []string{
+			"Journal No", "Journal Date", "Account", "Debits", "Credits",
+			"Description", "Currency",
+		}
+		journals := make(map[string]int)
+		record = func(row share.JournalRow) []string {
+			key := row.Source + "/" + row.SourceID
+			if _, ok := journals[key]; !ok {
+				journals[key] = len(journals) + 1
+			}
+			return []string{
+				fmt.Sprintf("%s-%d",
+					v.Date.Format("20060102"), journals[key]),
+				row.Date.Format("02/01/2006"),
+				row.Account,
+				v.amount(row.Debit),
+				v.amount(row.Credit),
+				fmt.Sprintf("%s, %s", row.Journal, row.Descr),
+				v.Currency,
+			}
+		}
+
+	default:
+		return fmt.Errorf("invalid format %s", v.Format)
+	}
+
+	err := writer.Write(header)
+	if err != nil {
+		return err
+	}
+	for _, row := range v.Rows {
+		err = writer.Write(record(row))
+		if err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
@@ -0,0 +1,185 @@
+package view
+
+import (
+	"net/url"
+	"strconv"
+
+	"github.com/shopd/shopd/go/share"
+)
+
+// OrderStates listed in the admin filter
+var OrderStates = []string{
+	share.OrderStateCart,
+	share.OrderStatePending,
+	share.OrderStateConfirmed,
+	share.OrderStateReversed,
+	share.OrderStateComplete,
+	share.OrderStateExpired,
+}
+
+// OrdersGet is a page of the admin order list.
+// The first page renders the table, following pages only the rows
+type OrdersGet struct {
+	share.OrderList
+}
+
+// NextURL for the next page, with the same filter
+func (v OrdersGet) NextURL() string {
+	f := v.Filter
+	q := url.Values{}
+	for param, val := range map[string]string{
+		share.ParamState:  f.State,
+		share.ParamPaid:   f.Paid,
+		share.ParamTag:    f.Tag,
+		share.ParamFrom:   f.From,
+		share.ParamTo:     f.To,
+		share.ParamUserID: f.UserID,
+		share.ParamSearch: f.Search,
+	} {
+		if val != "" {
+			q.Set(param, val)
+		}
+	}
+	q.Set(share.ParamCursor, v.Next)
+	return "/api/admin/orders?" + q.Encode()
+}
+
+// OrdersStatePost lists the result of a bulk state change,
+// rows are swapped out of band
+type OrdersStatePost struct {
+	Results []share.OrderStateResult
+}
+
+// OrdersDiscountGet lists optional discounts for an order
+type OrdersDiscountGet struct {
+	share.OrderDiscounts
+	Error string
+}
+
+func (v OrdersDiscountGet) Amount(amount int64) string {
+	return FormatAmount(amount, v.Currency)
+}
+
+// OrdersDiscountPost is the result of applying an optional discount,
+// the order row is swapped out of band
+type OrdersDiscountPost struct {
+	OrdersDiscountGet
+	Order share.OrderSummary
+}
+
+// OrdersPaymentsGet lists payments and the balance for an order
+type OrdersPaymentsGet struct {
+	share.OrderPayments
+}
+
+func (v OrdersPaymentsGet) Amount(amount int64) string {
+	return FormatAmount(amount, v.Currency)
+}
+
+// Methods admins may record payments with
+func (v OrdersPaymentsGet) Methods() []string {
+	return []string{share.TranMethodEFT, share.TranMethodCash, share.TranMethodCard}
+}
+
+// Receivable returns true if payments may be recorded for the order
+func (v OrdersPaymentsGet) Receivable() bool {
+	if v.Paid || v.Due == 0 {
+		return false
+	}
+	return v.State == share.OrderStatePending ||
+		v.State == share.OrderStateConfirmed ||
+		v.State == share.OrderStateComplete
+}
+
+// OrdersPaymentsPost is the result of recording a payment,
+// the order row is swapped out of band
+type OrdersPaymentsPost struct {
+	OrdersPaymentsGet
+	Order share.OrderSummary
+}
+
+// OrdersRefundsGet lists credit notes for an order,
+// and the form to issue a credit note
+type OrdersRefundsGet struct {
+	share.OrderRefunds
+}
+
+func (v OrdersRefundsGet) Amount(amount int64) string {
+	return FormatAmount(amount, v.Currency)
+}
+
+func (v OrdersRefundsGet) Qty(qty int64) string {
+	return strconv.FormatInt(qty, 10)
+}
+
+// Remaining qty that may be credited for the line
+func (v OrdersRefundsGet) Remaining(line share.OrderRefundLine) string {
+	return strconv.FormatInt(line.Qty-line.Credited, 10)
+}
+
+// Methods for refunding money, the empty method issues a credit note only
+func (v OrdersRefundsGet) Methods() []string {
+	return []string{
+		share.RefundMethodNone,
+		share.RefundMethodProcessor,
+		share.TranMethodEFT,
+		share.TranMethodCash,
+		share.TranMethodCredit,
+	}
+}
+
+func (v OrdersRefundsGet) MethodDescr(method string) string {
+	switch method {
+	case share.RefundMethodNone:
+		return "credit note only"
+	case share.TranMethodCredit:
+		return "store credit"
+	}
+	return method
+}
+
+// Creditable returns true if a credit note may be issued for the order
+func (v OrdersRefundsGet) Creditable() bool {
+	if !v.Paid || v.Credited >= v.Total {
+		return false
+	}
+	return v.State == share.OrderStateConfirmed ||
+		v.State == share.OrderStateComplete
+}
+
+// CreditNoteURL for the credit note document
+func (v OrdersRefundsGet) CreditNoteURL(note share.CreditNote) string {
+	return "/orders/" + v.OrderID + "/credits/" + note.CreditNoteID
+}
+
+// OrdersRefundsPost is the result of issuing a credit note,
+// the order row is swapped out of band
+type OrdersRefundsPost struct {
+	OrdersRefundsGet
+	Order share.OrderSummary
+}
+
+// OrderRow in the admin order list
+type OrderRow struct {
+	share.OrderSummary
+	Error string
+	// OOB swaps the row by ID, see https://htmx.org/attributes/hx-swap-oob/
+	OOB bool
+}
+
+func (v OrderRow) ID() string {
+	return "order-" + v.OrderID
+}
+
+func (v OrderRow) Amount(amount int64) string {
+	return FormatAmount(amount, v.Currency)
+}
+
+// Pending orders that are not paid may have optional discounts applied
+func (v OrderRow) Discountable() bool {
+	return v.State == share.OrderStatePending && !v.Paid
+}
+
+func (v OrderRow) DateString() string {
+	return v.Date.Format(DateFormat)
+}
@@ -0,0 +1,155 @@
+package view
+
+import (
+	"encoding/csv"
+	"io"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/shopd/shopd/go/share"
+)
+
+// FakePay is the hosted page of the fake processor
+type FakePay struct {
+	share.FakeIntent
+	// Action URL for the pay and decline buttons
+	Action string
+}
+
+// Amount formats the amount with the currency code
+func (v FakePay) Amount() string {
+	return v.Currency + " " + FormatAmount(v.FakeIntent.Amount, v.Currency)
+}
+
+// Pending returns true if the buyer didn't pay or decline yet
+func (v FakePay) Pending() bool {
+	return v.State == share.PaymentPending
+}
+
+func (v FakePay) Pay() string {
+	return share.FakePayActionPay
+}
+
+func (v FakePay) Decline() string {
+	return share.FakePayActionDecline
+}
+
+// PaymentEventsGet lists webhook events for admin users
+type PaymentEventsGet struct {
+	share.PaymentEvents
+}
+
+// Amount formats an amount in the smallest unit
+func (v PaymentEventsGet) Amount(amount int64) string {
+	return FormatAmount(amount, v.Currency)
+}
+
+func (v PaymentEventsGet) Date(event share.PaymentEvent) string {
+	return event.Updated.Format(time.DateTime)
+}
+
+func (v PaymentEventsGet) Attempts(event share.PaymentEvent) string {
+	return strconv.FormatInt(event.Attempts, 10)
+}
+
+// Retry returns true if the event was not handled
+func (v PaymentEventsGet) Retry(event share.PaymentEvent) bool {
+	return event.Handled != share.PaymentEventDone
+}
+
+// PaymentsReconGet is the payment reconciliation report
+type PaymentsReconGet struct {
+	share.Reconciliation
+}
+
+// Amount formats an amount in the smallest unit
+func (v PaymentsReconGet) Amount(amount int64) string {
+	return FormatAmount(amount, v.Currency)
+}
+
+func (v PaymentsReconGet) Date(tran share.ReconTran) string {
+	return tran.Date.Format(time.DateTime)
+}
+
+// Issue describes the reconciliation issue
+func (v PaymentsReconGet) Issue(issue share.ReconIssue) string {
+	switch issue.Type {
+	case share.ReconNoTran:
+		return "Paid without a successful transaction"
+	case share.ReconUnlinked:
+		return "Payment not linked to an order"
+	case share.ReconAmount:
+		return "Payments don't match the order total"
+	case share.ReconCurrency:
+		return "Payment currency is " + issue.Currency
+	case share.ReconRefund:
+		return "Payment received for an unpaid reversed or expired order, refund it"
+	}
+	return issue.Type
+}
+
+// CSVURL downloads the transactions for the filter
+func (v PaymentsReconGet) CSVURL() string {
+	query := url.Values{}
+	query.Set(share.ParamFormat, share.FormatCSV)
+	params := []struct{ key, val string }{
+		{share.ParamProcessor, v.Filter.Processor},
+		{share.ParamState, v.Filter.State},
+		{share.ParamFrom, v.Filter.From},
+		{share.ParamTo, v.Filter.To},
+	}
+	for _, param := range params {
+		if param.val != "" {
+			query.Set(param.key, param.val)
+		}
+	}
+	return "/api/admin/payments/recon?" + query.Encode()
+}
+
+// CSV writes the transactions, amounts are in the smallest unit
+// so the file may be compared to processor settlements
+func (v PaymentsReconGet) CSV(w io.Writer) error {
+	writer := csv.NewWriter(w)
+	err := writer.Write([]string{
+		"date", "tran_id", "order_id", "order_no", "processor", "method",
+		"ref", "type", "state", "amount", "currency",
+	})
+	if err != nil {
+		return err
+	}
+	for _, tran := range v.Trans {
+		err = writer.Write([]string{
+			tran.Date.UTC().Format(time.RFC3339),
+			tran.TranID,
+			tran.OrderID,
+			tran.OrderNo,
+			tran.Processor,
+			tran.Method,
+			tran.Ref,
+			tran.Type,
+			tran.State,
+			strconv.FormatInt(tran.Amount, 10),
+			tran.Currency,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// PaymentsReconPost is the diff for an uploaded settlement
+type PaymentsReconPost struct {
+	share.ReconSettlement
+}
+
+// Amount formats an amount in the smallest unit
+func (v PaymentsReconPost) Amount(amount int64) string {
+	return FormatAmount(amount, v.Currency)
+}
+
+func (v PaymentsReconPost) Line(line share.SettlementLine) string {
+	return strconv.Itoa(line.Line)
+}
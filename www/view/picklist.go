@@ -0,0 +1,8 @@
+package view
+
+import "github.com/shopd/shopd/go/share"
+
+type PicklistGet struct {
+	Depots   []share.Depot
+	PickList share.PickList
+}
@@ -0,0 +1,53 @@
+package view
+
+import (
+	"fmt"
+
+	"github.com/shopd/shopd/go/money"
+	"github.com/shopd/shopd/go/share"
+)
+
+// PriceGet is the price of a sku for the logged in buyer
+type PriceGet struct {
+	share.SkuPrice
+	// Display converts prices to the currency selected by the buyer
+	Display money.Converter
+}
+
+// Amount formats the price in the display currency, with the tax label
+func (v PriceGet) Amount(price int64) string {
+	return DisplayPrice(v.Display, price, v.Currency, v.TaxDisplay)
+}
+
+// Charged formats the price in the currency that orders are charged in
+func (v PriceGet) Charged() string {
+	return v.Currency + " " + FormatAmount(v.Price, v.Currency)
+}
+
+// TierQty formats the qty range for the tier, e.g. 10–49 or 50+
+func (v PriceGet) TierQty(i int) string {
+	if i == len(v.Tiers)-1 {
+		return fmt.Sprintf("%d+", v.Tiers[i].Qty)
+	}
+	return fmt.Sprintf("%d–%d", v.Tiers[i].Qty, v.Tiers[i+1].Qty-1)
+}
+
+// PricesPost is the result of a price list upload
+type PricesPost struct {
+	share.PriceListImport
+}
+
+// PricesScheduleGet lists scheduled changes and the price history for a sku
+type PricesScheduleGet struct {
+	share.PriceSchedules
+}
+
+// Amount formats an amount in the smallest unit
+func (v PricesScheduleGet) Amount(amount int64) string {
+	return FormatAmount(amount, v.Currency)
+}
+
+// Cancellable returns true if the scheduled change didn't start yet
+func (v PricesScheduleGet) Cancellable(schedule share.PriceSchedule) bool {
+	return schedule.State == share.PriceSchedulePending
+}
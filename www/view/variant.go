@@ -0,0 +1,44 @@
+package view
+
+import (
+	"net/url"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/shopd/shopd/go/share"
+)
+
+// ProductPrefix is the path for static product pages,
+// each variant has its own page
+const ProductPrefix = "/p"
+
+// ProductURL for the static page of the sku
+func ProductURL(sku string) string {
+	return path.Join(ProductPrefix, url.PathEscape(sku))
+}
+
+// VariantGet renders attribute selectors for the sku,
+// values link to the page for the matching variant
+type VariantGet struct {
+	share.VariantGroup
+}
+
+// VariantsPost lists the variants generated from the matrix
+type VariantsPost struct {
+	share.VariantsGenerate
+}
+
+func (v VariantsPost) Count(n int) string {
+	return strconv.Itoa(n)
+}
+
+// IsNew is true if the variant was created
+func (v VariantsPost) IsNew(variant share.Variant) bool {
+	return slices.Contains(v.Created, variant.Sku)
+}
+
+func (v VariantsPost) Vals(variant share.Variant) string {
+	return strings.Join(variant.Vals, ", ")
+}